@@ -0,0 +1,114 @@
+package notifier
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"github.com/pkg/errors"
+	"homework10/internal/users"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// NewEmail sends through the SMTP server at addr, giving up on a message
+// after timeout.
+func NewEmail(addr string, from string, timeout time.Duration) *Email {
+	return &Email{addr: addr, from: from, timeout: timeout}
+}
+
+var InvalidAddress = errors.New("the email address contains line breaks")
+
+// Email sends notifications to the address of the user through an SMTP
+// server. In development it points to a local stand-in such as MailHog.
+type Email struct {
+	addr    string
+	from    string
+	timeout time.Duration
+}
+
+func (e *Email) Notify(ctx context.Context, user users.User, notification users.Notification) error {
+	return e.Mail(ctx, user, fmt.Sprintf("News about the ad #%d", notification.AdID), notification.Text)
+}
+
+// Mail sends a message of the account flows, e.g. a password reset token.
+// The whole exchange with the server ends with ctx or after the timeout, so
+// that a slow server does not hold the request.
+func (e *Email) Mail(ctx context.Context, user users.User, subject string, text string) error {
+	if user.Email == "" {
+		return nil
+	}
+	if strings.ContainsAny(user.Email, "\r\n") {
+		return InvalidAddress
+	}
+
+	msg := strings.Join([]string{
+		"From: " + e.from,
+		"To: " + user.Email,
+		"Subject: " + subject,
+		"",
+		text,
+	}, "\r\n")
+
+	ctx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+
+	return e.send(ctx, user.Email, []byte(msg))
+}
+
+// send does what smtp.SendMail does, over a connection bound to ctx.
+func (e *Email) send(ctx context.Context, to string, msg []byte) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", e.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return err
+		}
+	}
+	stop := context.AfterFunc(ctx, func() {
+		_ = conn.SetDeadline(time.Now())
+	})
+	defer stop()
+
+	host, _, err := net.SplitHostPort(e.addr)
+	if err != nil {
+		return err
+	}
+
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(e.from); err != nil {
+		return err
+	}
+	if err := c.Rcpt(to); err != nil {
+		return err
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return c.Quit()
+}
@@ -0,0 +1,110 @@
+package notifier
+
+import (
+	"context"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"homework10/internal/users"
+)
+
+// serveSMTP is a minimal SMTP stand-in accepting a single message.
+func serveSMTP(t *testing.T, lis net.Listener, messages chan<- string) {
+	conn, err := lis.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	tp := textproto.NewConn(conn)
+	_ = tp.PrintfLine("220 localhost ESMTP")
+
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+
+		switch cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0]); cmd {
+		case "EHLO", "HELO":
+			_ = tp.PrintfLine("250 localhost")
+		case "MAIL", "RCPT", "RSET", "NOOP":
+			_ = tp.PrintfLine("250 OK")
+		case "DATA":
+			_ = tp.PrintfLine("354 go ahead")
+			data, err := tp.ReadDotLines()
+			assert.NoError(t, err)
+			messages <- strings.Join(data, "\n")
+			_ = tp.PrintfLine("250 OK")
+		case "QUIT":
+			_ = tp.PrintfLine("221 bye")
+			return
+		default:
+			_ = tp.PrintfLine("502 not implemented")
+		}
+	}
+}
+
+func TestEmail_Notify(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		lis.Close()
+	})
+
+	messages := make(chan string, 1)
+	go serveSMTP(t, lis, messages)
+
+	email := NewEmail(lis.Addr().String(), "noreply@ads.local", time.Second)
+
+	err = email.Notify(context.Background(), users.User{ID: 1, Email: "test@testing.ru"}, users.Notification{AdID: 7, Text: "the ad is published"})
+	assert.NoError(t, err)
+
+	msg := <-messages
+	assert.Contains(t, msg, "To: test@testing.ru")
+	assert.Contains(t, msg, "the ad is published")
+}
+
+func TestEmail_InvalidAddress(t *testing.T) {
+	email := NewEmail("127.0.0.1:0", "noreply@ads.local", time.Second)
+
+	err := email.Notify(context.Background(), users.User{ID: 1, Email: "test@testing.ru\r\nBcc: spam@testing.ru"}, users.Notification{})
+	assert.ErrorIs(t, err, InvalidAddress)
+}
+
+func TestEmail_Timeout(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		lis.Close()
+	})
+
+	// a server that accepts the connection and never answers
+	go func() {
+		conn, err := lis.Accept()
+		if err != nil {
+			return
+		}
+		t.Cleanup(func() {
+			conn.Close()
+		})
+	}()
+
+	email := NewEmail(lis.Addr().String(), "noreply@ads.local", 100*time.Millisecond)
+
+	start := time.Now()
+	err = email.Notify(context.Background(), users.User{ID: 1, Email: "test@testing.ru"}, users.Notification{AdID: 7})
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	email = NewEmail(lis.Addr().String(), "noreply@ads.local", time.Minute)
+	err = email.Notify(ctx, users.User{ID: 1, Email: "test@testing.ru"}, users.Notification{AdID: 7})
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,178 @@
+package repo
+
+import (
+	"github.com/pkg/errors"
+	"homework10/internal/replication"
+	"strconv"
+	"time"
+)
+
+var (
+	ReadOnly         = errors.New("the repository is a read replica")
+	UnexpectedChange = errors.New("the change doesn't follow the position of the replica")
+)
+
+// feed numbers the changes of a primary repository and keeps the last ones
+// for the replicas following it. The feed of a replica holds the position
+// of the primary instead.
+type feed struct {
+	epoch   string
+	seq     int64
+	backlog []replication.Change
+	size    int
+	next    chan struct{}
+
+	// redact, if not nil, returns the entity as the replicas get it
+	redact func(e interface{}) interface{}
+
+	replica bool
+	// staging collects a dump until its end replaces the storage with it
+	staging *Repo
+}
+
+// Replicate numbers the following changes of the repository and keeps the
+// last backlog ones, so that a replica which was disconnected for a while
+// doesn't need a whole dump. The replicas get the entities passed through
+// redact, e.g. without their secrets, unless it is nil.
+func (a *Repo) Replicate(backlog int, redact func(e interface{}) interface{}) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.feed = &feed{epoch: strconv.FormatInt(time.Now().UnixNano(), 36), size: backlog, next: make(chan struct{}), redact: redact}
+}
+
+// NewReplica returns an empty repository changed only by Apply.
+func NewReplica() *Repo {
+	a := New().(*Repo)
+	a.feed = &feed{replica: true}
+	return a
+}
+
+// redacted returns rec as the replicas get it.
+func (f *feed) redacted(rec record) record {
+	if f.redact == nil || len(rec.Values) == 0 {
+		return rec
+	}
+
+	values := make([]interface{}, len(rec.Values))
+	for i, e := range rec.Values {
+		values[i] = f.redact(e)
+	}
+	rec.Values = values
+	return rec
+}
+
+func (f *feed) publish(payload []byte) {
+	f.seq++
+	f.backlog = append(f.backlog, replication.Change{Seq: f.seq, Data: payload})
+
+	// drops the changes out of the backlog only once in a while, so that
+	// appending stays cheap
+	if len(f.backlog) > 2*f.size {
+		f.backlog = f.backlog[:copy(f.backlog, f.backlog[len(f.backlog)-f.size:])]
+	}
+
+	close(f.next)
+	f.next = make(chan struct{})
+}
+
+func (a *Repo) Position() (string, int64) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.feed == nil {
+		return "", 0
+	}
+	return a.feed.epoch, a.feed.seq
+}
+
+func (a *Repo) Since(epoch string, seq int64) ([]replication.Change, <-chan struct{}, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	f := a.feed
+	if f == nil || f.replica || epoch != f.epoch || seq > f.seq {
+		return nil, nil, replication.Behind
+	}
+
+	first := f.seq - int64(len(f.backlog)) + 1
+	if seq+1 < first {
+		return nil, nil, replication.Behind
+	}
+
+	// a copy, the backlog is moved when it is trimmed
+	changes := append([]replication.Change(nil), f.backlog[seq+1-first:]...)
+	return changes, f.next, nil
+}
+
+func (a *Repo) Dump() ([]replication.Change, error) {
+	a.mu.RLock()
+	storage := make(map[int64]interface{}, len(a.storage))
+	for id, e := range a.storage {
+		storage[id] = e
+	}
+	nextNum := a.nextNum
+	f := a.feed
+	var seq int64
+	if f != nil {
+		seq = f.seq
+	}
+	a.mu.RUnlock()
+
+	var changes []replication.Change
+	err := dump(storage, nextNum, func(rec record) error {
+		if f != nil {
+			rec = f.redacted(rec)
+		}
+		payload, err := encode(rec)
+		if err != nil {
+			return err
+		}
+		changes = append(changes, replication.Change{Seq: seq, Data: payload})
+		return nil
+	})
+
+	return changes, err
+}
+
+// Apply applies a change of the primary to a replica. The changes of a dump
+// are collected aside until its end, so that reads never see a part of it.
+func (a *Repo) Apply(epoch string, c replication.Change) error {
+	rec, err := decode(c.Data)
+	if err != nil {
+		return errors.Wrap(UnexpectedChange, err.Error())
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	f := a.feed
+	if f == nil || !f.replica {
+		return errors.Wrap(UnexpectedChange, "the repository is not a replica")
+	}
+
+	switch {
+	case rec.Op == opHeader:
+		f.staging = New().(*Repo)
+		return f.staging.replay(rec)
+	case rec.Op == opEnd:
+		if f.staging == nil {
+			return errors.Wrap(UnexpectedChange, "a dump ends before it starts")
+		}
+		a.storage, a.nextNum = f.staging.storage, f.staging.nextNum
+		// the indexes are built again by the next Find
+		a.indexes = make(map[string]*index)
+		f.staging = nil
+	case f.staging != nil:
+		return f.staging.replay(rec)
+	case epoch != f.epoch || c.Seq != f.seq+1:
+		return errors.Wrapf(UnexpectedChange, "change %d of %q after %d of %q", c.Seq, epoch, f.seq, f.epoch)
+	default:
+		if err := a.replay(rec); err != nil {
+			return err
+		}
+	}
+
+	f.epoch, f.seq = epoch, c.Seq
+	return nil
+}
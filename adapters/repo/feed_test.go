@@ -0,0 +1,129 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"homework10/internal/replication"
+)
+
+func TestRepo_Since(t *testing.T) {
+	ctx := context.Background()
+
+	primary := New().(*Repo)
+	primary.Replicate(2, nil)
+	epoch, _ := primary.Position()
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, primary.Add(ctx, i))
+	}
+
+	type Test struct {
+		Name   string
+		Epoch  string
+		Seq    int64
+		Expect []int64
+		Err    error
+	}
+
+	// the backlog is trimmed to the last 2 changes once it holds more than 4
+	tests := [...]Test{
+		{"Up to date", epoch, 5, nil, nil},
+		{"In the backlog", epoch, 3, []int64{4, 5}, nil},
+		{"Trimmed", epoch, 2, nil, replication.Behind},
+		{"Other epoch", "other", 5, nil, replication.Behind},
+		{"Ahead", epoch, 6, nil, replication.Behind},
+	}
+
+	for _, test := range tests {
+		changes, _, err := primary.Since(test.Epoch, test.Seq)
+		if !errors.Is(err, test.Err) {
+			t.Fatalf(`test %q: expect %v got %v`, test.Name, test.Err, err)
+		}
+
+		var seqs []int64
+		for _, c := range changes {
+			seqs = append(seqs, c.Seq)
+		}
+		if !assert.ObjectsAreEqual(test.Expect, seqs) {
+			t.Fatalf(`test %q: expect %v got %v`, test.Name, test.Expect, seqs)
+		}
+	}
+}
+
+func TestRepo_Apply(t *testing.T) {
+	ctx := context.Background()
+
+	primary := New().(*Repo)
+	primary.Replicate(10, nil)
+	_, err := primary.AddBatch(ctx, []interface{}{1, 2}, nil)
+	assert.NoError(t, err)
+
+	replica := NewReplica()
+	assert.ErrorIs(t, replica.Add(ctx, 3), ReadOnly)
+
+	epoch, _ := primary.Position()
+	dump, err := primary.Dump()
+	assert.NoError(t, err)
+
+	_, next, err := primary.Since(epoch, 1)
+	assert.NoError(t, err)
+	assert.NoError(t, primary.Update(ctx, 0, 10))
+	<-next
+
+	changes, _, err := primary.Since(epoch, 1)
+	assert.NoError(t, err)
+
+	// a change before the dump doesn't follow the position of the replica
+	assert.ErrorIs(t, replica.Apply(epoch, changes[0]), UnexpectedChange)
+
+	// the dump is invisible until its end
+	for _, c := range dump[:len(dump)-1] {
+		assert.NoError(t, replica.Apply(epoch, c))
+	}
+	assert.Empty(t, replica.GetArray(ctx))
+	assert.NoError(t, replica.Apply(epoch, dump[len(dump)-1]))
+
+	found, err := replica.Find(ctx, valueIndex, 0, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{1, 2}, found)
+
+	assert.NoError(t, replica.Apply(epoch, changes[0]))
+	found, err = replica.Find(ctx, valueIndex, 0, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{2, 10}, found)
+	assert.Equal(t, int64(2), replica.GetNextId(ctx))
+
+	assert.ErrorIs(t, replica.Apply(epoch, changes[0]), UnexpectedChange)
+}
+
+func TestRepo_Redact(t *testing.T) {
+	ctx := context.Background()
+
+	primary := New().(*Repo)
+	primary.Replicate(10, func(e interface{}) interface{} { return -e.(int) })
+	assert.NoError(t, primary.Add(ctx, 1))
+	epoch, _ := primary.Position()
+
+	dump, err := primary.Dump()
+	assert.NoError(t, err)
+	assert.NoError(t, primary.Add(ctx, 2))
+	changes, _, err := primary.Since(epoch, 0)
+	assert.NoError(t, err)
+
+	replica := NewReplica()
+	for _, c := range append(dump, changes[1]) {
+		assert.NoError(t, replica.Apply(epoch, c))
+	}
+
+	found, err := replica.Find(ctx, valueIndex, -100, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{-2, -1}, found)
+
+	// the primary keeps the entities as they are
+	found, err = primary.Find(ctx, valueIndex, -100, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{1, 2}, found)
+}
@@ -0,0 +1,90 @@
+package repo
+
+import (
+	"homework10/internal/query"
+	"slices"
+	"sort"
+)
+
+// index keeps the IDs of the entities with each key in order. An ordered
+// index also keeps the keys in order to answer ranges. IDs and creation
+// times mostly grow, so insertions are mostly appends.
+type index struct {
+	def     query.Index
+	keys    []int64
+	buckets map[int64][]int64
+}
+
+func buildIndex(def query.Index, keys map[int64]int64) *index {
+	idx := &index{def: def, buckets: make(map[int64][]int64)}
+
+	for id, key := range keys {
+		idx.buckets[key] = append(idx.buckets[key], id)
+	}
+
+	for key, ids := range idx.buckets {
+		slices.Sort(ids)
+		if def.Ordered {
+			idx.keys = append(idx.keys, key)
+		}
+	}
+	slices.Sort(idx.keys)
+
+	return idx
+}
+
+func (idx *index) insert(key int64, id int64) {
+	ids, exists := idx.buckets[key]
+	if !exists && idx.def.Ordered {
+		idx.keys = insertSorted(idx.keys, key)
+	}
+	idx.buckets[key] = insertSorted(ids, id)
+}
+
+func (idx *index) remove(key int64, id int64) {
+	ids := removeSorted(idx.buckets[key], id)
+	if len(ids) > 0 {
+		idx.buckets[key] = ids
+		return
+	}
+
+	delete(idx.buckets, key)
+	if idx.def.Ordered {
+		idx.keys = removeSorted(idx.keys, key)
+	}
+}
+
+// find returns the IDs with a key from from to to. The IDs of an unordered
+// index are shared with it, they must be read under the lock of the Repo.
+func (idx *index) find(from int64, to int64) []int64 {
+	if !idx.def.Ordered {
+		return idx.buckets[from]
+	}
+
+	var ids []int64
+	for i := sort.Search(len(idx.keys), func(i int) bool { return idx.keys[i] >= from }); i < len(idx.keys) && idx.keys[i] <= to; i++ {
+		ids = append(ids, idx.buckets[idx.keys[i]]...)
+	}
+
+	return ids
+}
+
+func insertSorted(s []int64, v int64) []int64 {
+	if len(s) == 0 || s[len(s)-1] < v {
+		return append(s, v)
+	}
+
+	i, found := slices.BinarySearch(s, v)
+	if found {
+		return s
+	}
+	return slices.Insert(s, i, v)
+}
+
+func removeSorted(s []int64, v int64) []int64 {
+	i, found := slices.BinarySearch(s, v)
+	if !found {
+		return s
+	}
+	return slices.Delete(s, i, i+1)
+}
@@ -0,0 +1,441 @@
+package repo
+
+import (
+	"github.com/pkg/errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// SyncPolicy sets when the write-ahead log is flushed to the disk: after
+// every change, every SyncInterval, or whenever the OS decides. Only the
+// first one keeps every change acknowledged before a power loss.
+type SyncPolicy string
+
+const (
+	SyncAlways   SyncPolicy = "always"
+	SyncInterval SyncPolicy = "interval"
+	SyncNever    SyncPolicy = "never"
+)
+
+const (
+	logFile      = "wal"
+	oldLogFile   = "wal.old"
+	snapshotFile = "snapshot"
+	snapshotTmp  = "snapshot.tmp"
+
+	snapshotChunk = 1000
+)
+
+var UnknownSyncPolicy = errors.New("unknown sync policy")
+
+// Options of a repository persisted by Open. A snapshot is written after
+// SnapshotEvery changes, never if it is zero.
+type Options struct {
+	Sync          SyncPolicy
+	SyncInterval  time.Duration
+	SnapshotEvery int
+}
+
+// persistence is the state of a Repo stored in a directory: the last
+// snapshot and the write-ahead log of the changes made since.
+type persistence struct {
+	dir     string
+	opts    Options
+	log     *os.File
+	changes int
+
+	snapshots sync.WaitGroup
+	snapMu    sync.Mutex
+	stop      chan struct{}
+	stopped   sync.WaitGroup
+	// closed makes Close run once, closeErr is what it returned
+	closed   sync.Once
+	closeErr error
+
+	// err is the result of the last background snapshot or sync
+	err   error
+	errMu sync.Mutex
+}
+
+func (p *persistence) setErr(err error) {
+	p.errMu.Lock()
+	defer p.errMu.Unlock()
+
+	p.err = err
+}
+
+func (p *persistence) lastErr() error {
+	p.errMu.Lock()
+	defer p.errMu.Unlock()
+
+	return p.err
+}
+
+// Open loads the repository stored in dir, creating it if needed, and
+// persists every following change. A log cut short by a crash loses its
+// torn last record only, any other damage is an error.
+func Open(dir string, opts Options) (*Repo, error) {
+	switch opts.Sync {
+	case SyncAlways, SyncNever:
+	case SyncInterval:
+		if opts.SyncInterval <= 0 {
+			return nil, errors.Wrap(UnknownSyncPolicy, "the sync interval must be positive")
+		}
+	default:
+		return nil, errors.Wrapf(UnknownSyncPolicy, "%q", opts.Sync)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	a := New().(*Repo)
+
+	if err := a.loadSnapshot(filepath.Join(dir, snapshotFile)); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	// a crash during a snapshot leaves the log it replaces
+	_, err := readFrames(filepath.Join(dir, oldLogFile), a.replay)
+	oldLog := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	path := filepath.Join(dir, logFile)
+	size, err := readFrames(path, a.replay)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	log, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	// drops a torn last record, so that the next one follows a valid one
+	if err := log.Truncate(size); err != nil {
+		log.Close()
+		return nil, err
+	}
+	if _, err := log.Seek(size, 0); err != nil {
+		log.Close()
+		return nil, err
+	}
+
+	a.persist = &persistence{dir: dir, opts: opts, log: log, stop: make(chan struct{})}
+
+	if oldLog {
+		if err := a.Snapshot(); err != nil {
+			a.Close()
+			return nil, err
+		}
+	}
+
+	if opts.Sync == SyncInterval {
+		a.persist.stopped.Add(1)
+		go a.syncEvery(opts.SyncInterval)
+	}
+
+	return a, nil
+}
+
+func (a *Repo) loadSnapshot(path string) error {
+	complete := false
+	_, err := readFrames(path, func(rec record) error {
+		if rec.Op == opEnd {
+			complete = true
+			return nil
+		}
+		return a.replay(rec)
+	})
+
+	if errors.Is(err, CorruptLog) {
+		return errors.Wrap(CorruptSnapshot, err.Error())
+	} else if err != nil {
+		return err
+	}
+
+	if !complete {
+		return errors.Wrap(CorruptSnapshot, "it ends before the last record")
+	}
+
+	return nil
+}
+
+// replay applies a record of the log or the snapshot. Records are
+// idempotent, so a change found both in a snapshot and in the log replaced
+// by it is applied twice safely.
+func (a *Repo) replay(rec record) error {
+	switch rec.Op {
+	case opHeader:
+		a.nextNum = rec.NextNum
+	case opPut:
+		if len(rec.IDs) != len(rec.Values) {
+			return errors.Wrap(CorruptLog, "a record has more IDs than values")
+		}
+		for i, id := range rec.IDs {
+			a.put(id, rec.Values[i])
+			if id >= a.nextNum {
+				a.nextNum = id + 1
+			}
+		}
+	case opDelete:
+		for _, id := range rec.IDs {
+			a.remove(id)
+		}
+	default:
+		return errors.Wrapf(CorruptLog, "unknown operation %d", rec.Op)
+	}
+
+	return nil
+}
+
+// write records a change before it is applied: it appends it to the log
+// and numbers it for the replicas. It is called with the lock held.
+func (a *Repo) write(rec record) error {
+	if a.feed != nil && a.feed.replica {
+		return ReadOnly
+	}
+	if a.persist == nil && a.feed == nil {
+		return nil
+	}
+
+	payload, err := encode(rec)
+	if err != nil {
+		return err
+	}
+
+	published := payload
+	if a.feed != nil && a.feed.redact != nil {
+		if published, err = encode(a.feed.redacted(rec)); err != nil {
+			return err
+		}
+	}
+
+	if a.persist != nil {
+		if err := a.appendLog(payload); err != nil {
+			return err
+		}
+	}
+	if a.feed != nil {
+		a.feed.publish(published)
+	}
+
+	return nil
+}
+
+func (a *Repo) appendLog(payload []byte) error {
+	p := a.persist
+
+	if err := writeFrame(p.log, payload); err != nil {
+		return err
+	}
+
+	if p.opts.Sync == SyncAlways {
+		if err := p.log.Sync(); err != nil {
+			return err
+		}
+	}
+
+	p.changes++
+	if p.opts.SnapshotEvery > 0 && p.changes >= p.opts.SnapshotEvery {
+		p.changes = 0
+		p.snapshots.Add(1)
+		go func() {
+			defer p.snapshots.Done()
+			_ = a.Snapshot()
+		}()
+	}
+
+	return nil
+}
+
+func (a *Repo) syncEvery(interval time.Duration) {
+	defer a.persist.stopped.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			a.mu.RLock()
+			a.persist.setErr(a.persist.log.Sync())
+			a.mu.RUnlock()
+		case <-a.persist.stop:
+			return
+		}
+	}
+}
+
+// Snapshot writes the whole storage to a new snapshot and starts a new log.
+// The storage is locked only to copy it.
+func (a *Repo) Snapshot() error {
+	p := a.persist
+	if p == nil {
+		return nil
+	}
+
+	p.snapMu.Lock()
+	defer p.snapMu.Unlock()
+
+	err := a.snapshot()
+	p.setErr(err)
+	return err
+}
+
+func (a *Repo) snapshot() error {
+	p := a.persist
+
+	a.mu.Lock()
+	storage := make(map[int64]interface{}, len(a.storage))
+	for id, e := range a.storage {
+		storage[id] = e
+	}
+	nextNum := a.nextNum
+
+	// the log left by a failed snapshot has changes no snapshot has yet, it
+	// is replaced together with the current one
+	var err error
+	if _, statErr := os.Stat(filepath.Join(p.dir, oldLogFile)); os.IsNotExist(statErr) {
+		err = a.rotateLog()
+	}
+	a.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	tmp := filepath.Join(p.dir, snapshotTmp)
+	if err := writeSnapshot(tmp, storage, nextNum); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp, filepath.Join(p.dir, snapshotFile)); err != nil {
+		return err
+	}
+	if err := syncDir(p.dir); err != nil {
+		return err
+	}
+
+	return os.Remove(filepath.Join(p.dir, oldLogFile))
+}
+
+// rotateLog moves the log aside for the snapshot being written to replace.
+func (a *Repo) rotateLog() error {
+	p := a.persist
+
+	if err := p.log.Sync(); err != nil {
+		return err
+	}
+	if err := p.log.Close(); err != nil {
+		return err
+	}
+
+	path := filepath.Join(p.dir, logFile)
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	renameErr := os.Rename(path, filepath.Join(p.dir, oldLogFile))
+	if renameErr != nil {
+		// keeps appending to the current log
+		flags = os.O_WRONLY | os.O_APPEND
+	}
+
+	log, err := os.OpenFile(path, flags, 0o644)
+	if err != nil {
+		return err
+	}
+	if renameErr != nil {
+		p.log = log
+		return renameErr
+	}
+	p.log = log
+	p.changes = 0
+
+	return syncDir(p.dir)
+}
+
+func writeSnapshot(path string, storage map[int64]interface{}, nextNum int64) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := dump(storage, nextNum, func(rec record) error { return writeRecord(f, rec) }); err != nil {
+		return err
+	}
+
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// dump emits the records rebuilding the storage: a header, the entities by
+// chunks and an end.
+func dump(storage map[int64]interface{}, nextNum int64, emit func(record) error) error {
+	if err := emit(record{Op: opHeader, NextNum: nextNum}); err != nil {
+		return err
+	}
+
+	chunk := record{Op: opPut}
+	for id, e := range storage {
+		chunk.IDs = append(chunk.IDs, id)
+		chunk.Values = append(chunk.Values, e)
+
+		if len(chunk.IDs) == snapshotChunk {
+			if err := emit(chunk); err != nil {
+				return err
+			}
+			chunk = record{Op: opPut}
+		}
+	}
+	if len(chunk.IDs) > 0 {
+		if err := emit(chunk); err != nil {
+			return err
+		}
+	}
+
+	return emit(record{Op: opEnd})
+}
+
+// syncDir makes the renames in dir durable.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return d.Sync()
+}
+
+// Close waits for a running snapshot and flushes the log. The repository
+// can't be changed afterwards, closing it again returns the same result.
+func (a *Repo) Close() error {
+	p := a.persist
+	if p == nil {
+		return nil
+	}
+
+	p.closed.Do(func() {
+		p.closeErr = a.close(p)
+	})
+	return p.closeErr
+}
+
+func (a *Repo) close(p *persistence) error {
+	close(p.stop)
+	p.stopped.Wait()
+	p.snapshots.Wait()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if err := p.log.Sync(); err != nil {
+		p.log.Close()
+		return err
+	}
+	return p.log.Close()
+}
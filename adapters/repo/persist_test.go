@@ -0,0 +1,228 @@
+package repo
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"homework10/internal/ads"
+	"homework10/internal/query"
+)
+
+var valueIndex = query.Index{Name: "value", Ordered: true, Key: func(e interface{}) int64 { return int64(e.(int)) }}
+
+func open(t *testing.T, dir string, opts Options) *Repo {
+	repo, err := Open(dir, opts)
+	if err != nil {
+		t.Fatalf(`open %q: expect nil got %v`, dir, err)
+	}
+	return repo
+}
+
+// contents are the entities by ID and the next ID of the repository.
+func contents(repo *Repo) (map[int64]interface{}, int64) {
+	items := make(map[int64]interface{})
+	for id, e := range repo.storage {
+		items[id] = e
+	}
+	return items, repo.nextNum
+}
+
+func TestOpen_Replay(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	ad := ads.Ad{ID: 3, Title: "hello", Text: "world", CreatedAt: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	repo := open(t, dir, Options{Sync: SyncAlways})
+	assert.NoError(t, repo.Add(ctx, 1))
+	_, err := repo.AddBatch(ctx, []interface{}{2, 3, ad}, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, repo.Update(ctx, 0, 10))
+	assert.NoError(t, repo.UpdateBatch(ctx, []int64{1, 2}, []interface{}{20, 30}))
+	assert.NoError(t, repo.Delete(ctx, 3))
+	assert.NoError(t, repo.Delete(ctx, 2))
+	assert.ErrorIs(t, repo.Delete(ctx, 2), DefunctEntity)
+	assert.NoError(t, repo.Close())
+
+	reopened := open(t, dir, Options{Sync: SyncAlways})
+	defer reopened.Close()
+
+	items, nextNum := contents(reopened)
+	assert.Equal(t, map[int64]interface{}{0: 10, 1: 20}, items)
+	assert.Equal(t, int64(4), nextNum, "the IDs of deleted entities are not reused")
+
+	found, err := reopened.Find(ctx, valueIndex, 0, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{10, 20}, found)
+}
+
+func TestOpen_Snapshot(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	repo := open(t, dir, Options{Sync: SyncInterval, SyncInterval: time.Millisecond, SnapshotEvery: 3})
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, repo.Add(ctx, i))
+	}
+	assert.NoError(t, repo.Delete(ctx, 9))
+	assert.NoError(t, repo.Snapshot())
+	assert.NoError(t, repo.Update(ctx, 0, 100))
+	assert.NoError(t, repo.Close())
+
+	_, err := os.Stat(filepath.Join(dir, snapshotFile))
+	assert.NoError(t, err)
+	_, err = os.Stat(filepath.Join(dir, oldLogFile))
+	assert.True(t, os.IsNotExist(err))
+
+	reopened := open(t, dir, Options{Sync: SyncNever})
+	defer reopened.Close()
+
+	items, nextNum := contents(reopened)
+	assert.Len(t, items, 9)
+	assert.Equal(t, 100, items[0])
+	assert.Equal(t, int64(10), nextNum)
+}
+
+func TestOpen_InterruptedSnapshot(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	repo := open(t, dir, Options{Sync: SyncAlways})
+	_, err := repo.AddBatch(ctx, []interface{}{1, 2}, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, repo.Close())
+
+	// a crash after the log was moved aside, before the snapshot was written
+	assert.NoError(t, os.Rename(filepath.Join(dir, logFile), filepath.Join(dir, oldLogFile)))
+	repo = open(t, dir, Options{Sync: SyncAlways})
+	assert.NoError(t, repo.Add(ctx, 3))
+	assert.NoError(t, repo.Close())
+
+	_, err = os.Stat(filepath.Join(dir, oldLogFile))
+	assert.True(t, os.IsNotExist(err))
+
+	reopened := open(t, dir, Options{Sync: SyncAlways})
+	defer reopened.Close()
+
+	items, _ := contents(reopened)
+	assert.Equal(t, map[int64]interface{}{0: 1, 1: 2, 2: 3}, items)
+}
+
+func TestOpen_TruncatedLog(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	repo := open(t, dir, Options{Sync: SyncAlways})
+	assert.NoError(t, repo.Add(ctx, 1))
+	assert.NoError(t, repo.Add(ctx, 2))
+	complete, err := os.Stat(filepath.Join(dir, logFile))
+	assert.NoError(t, err)
+	assert.NoError(t, repo.Add(ctx, 3))
+	assert.NoError(t, repo.Close())
+
+	log, err := os.ReadFile(filepath.Join(dir, logFile))
+	assert.NoError(t, err)
+
+	// every cut inside the last record loses that record only
+	for size := complete.Size(); size < int64(len(log)); size++ {
+		cut := t.TempDir()
+		assert.NoError(t, os.WriteFile(filepath.Join(cut, logFile), log[:size], 0o644))
+
+		name := fmt.Sprintf("log cut at %d of %d", size, len(log))
+
+		repo, err := Open(cut, Options{Sync: SyncAlways})
+		if err != nil {
+			t.Fatalf(`test %q: expect nil got %v`, name, err)
+		}
+
+		items, _ := contents(repo)
+		if !reflect.DeepEqual(items, map[int64]interface{}{0: 1, 1: 2}) {
+			t.Fatalf(`test %q: expect the first two items got %v`, name, items)
+		}
+
+		// the next record follows the valid ones
+		assert.NoError(t, repo.Add(ctx, 4))
+		assert.NoError(t, repo.Close())
+
+		reopened := open(t, cut, Options{Sync: SyncAlways})
+		items, _ = contents(reopened)
+		assert.Equal(t, map[int64]interface{}{0: 1, 1: 2, 2: 4}, items)
+		assert.NoError(t, reopened.Close())
+	}
+}
+
+func TestOpen_Corrupt(t *testing.T) {
+	ctx := context.Background()
+
+	type Test struct {
+		Name   string
+		File   string
+		Damage func(data []byte) []byte
+		Expect error
+	}
+
+	tests := [...]Test{
+		{"Flipped bit in the first record", logFile, func(data []byte) []byte {
+			data[10] ^= 1
+			return data
+		}, CorruptLog},
+		{"Huge length", logFile, func(data []byte) []byte {
+			data[3] = 0xff
+			return data
+		}, CorruptLog},
+		{"Length past the end of the log", logFile, func(data []byte) []byte {
+			binary.LittleEndian.PutUint32(data[0:4], uint32(len(data)))
+			return data
+		}, CorruptLog},
+		{"Truncated snapshot", snapshotFile, func(data []byte) []byte {
+			return data[:len(data)-1]
+		}, CorruptSnapshot},
+		{"Flipped bit in the snapshot", snapshotFile, func(data []byte) []byte {
+			data[10] ^= 1
+			return data
+		}, CorruptSnapshot},
+	}
+
+	for _, test := range tests {
+		dir := t.TempDir()
+
+		repo := open(t, dir, Options{Sync: SyncAlways})
+		assert.NoError(t, repo.Add(ctx, 1))
+		assert.NoError(t, repo.Snapshot())
+		assert.NoError(t, repo.Add(ctx, 2))
+		assert.NoError(t, repo.Add(ctx, 3))
+		assert.NoError(t, repo.Close())
+
+		path := filepath.Join(dir, test.File)
+		data, err := os.ReadFile(path)
+		assert.NoError(t, err)
+		assert.NoError(t, os.WriteFile(path, test.Damage(data), 0o644))
+
+		_, err = Open(dir, Options{Sync: SyncAlways})
+		if !errors.Is(err, test.Expect) {
+			t.Fatalf(`test %q: expect %v got %v`, test.Name, test.Expect, err)
+		}
+	}
+}
+
+func TestClose_Twice(t *testing.T) {
+	repo := open(t, t.TempDir(), Options{Sync: SyncInterval, SyncInterval: time.Millisecond})
+	assert.NoError(t, repo.Close())
+	assert.NoError(t, repo.Close())
+}
+
+func TestOpen_UnknownSyncPolicy(t *testing.T) {
+	_, err := Open(t.TempDir(), Options{Sync: "sometimes"})
+	assert.ErrorIs(t, err, UnknownSyncPolicy)
+
+	_, err = Open(t.TempDir(), Options{Sync: SyncInterval})
+	assert.ErrorIs(t, err, UnknownSyncPolicy)
+}
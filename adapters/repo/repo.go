@@ -1,33 +1,103 @@
 package repo
 
 import (
+	"context"
 	"github.com/pkg/errors"
 	"homework10/internal/app"
+	"homework10/internal/query"
 	"sync"
 )
 
 func New() app.Repository {
-	return &Repo{storage: make(map[int64]interface{}), nextNum: 0}
+	return &Repo{storage: make(map[int64]interface{}), indexes: make(map[string]*index), nextNum: 0}
 }
 
+// Repo keeps the entities in a map. Reads share the lock, so they only wait
+// for writes, which also keep the indexes up to date.
 type Repo struct {
 	storage map[int64]interface{}
+	indexes map[string]*index
 	nextNum int64
-	mu      sync.Mutex
+	mu      sync.RWMutex
+
+	// persist is nil for a repository living in memory only, see Open
+	persist *persistence
+	// feed is nil for a repository not replicated, see Replicate and
+	// NewReplica
+	feed *feed
 }
 
-var DefunctEntity = errors.New("there is no entity with this id")
+var (
+	DefunctEntity  = errors.New("there is no entity with this id")
+	ExistingEntity = errors.New("there is already an entity with this id")
+	InvalidRange   = errors.New("the index is not ordered, the range must have a single key")
+)
+
+// put stores e under id, moving it in the indexes if it replaces another
+// entity.
+func (a *Repo) put(id int64, e interface{}) {
+	old, exists := a.storage[id]
+	a.storage[id] = e
+
+	for _, idx := range a.indexes {
+		key := idx.def.Key(e)
+		if exists {
+			oldKey := idx.def.Key(old)
+			if oldKey == key {
+				continue
+			}
+			idx.remove(oldKey, id)
+		}
+		idx.insert(key, id)
+	}
+}
 
-func (a *Repo) Add(e interface{}) error {
+// remove deletes the entity with id from the storage and the indexes.
+func (a *Repo) remove(id int64) {
+	e, exists := a.storage[id]
+	if !exists {
+		return
+	}
+
+	for _, idx := range a.indexes {
+		idx.remove(idx.def.Key(e), id)
+	}
+	delete(a.storage, id)
+}
+
+func (a *Repo) Add(ctx context.Context, e interface{}) error {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
-	a.storage[a.nextNum] = e
+	if err := a.write(record{Op: opPut, IDs: []int64{a.nextNum}, Values: []interface{}{e}}); err != nil {
+		return err
+	}
+
+	a.put(a.nextNum, e)
 	a.nextNum++
 	return nil
 }
 
-func (a *Repo) Update(id int64, e interface{}) error {
+func (a *Repo) Insert(ctx context.Context, id int64, e interface{}) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, exists := a.storage[id]; exists {
+		return ExistingEntity
+	}
+
+	if err := a.write(record{Op: opPut, IDs: []int64{id}, Values: []interface{}{e}}); err != nil {
+		return err
+	}
+
+	a.put(id, e)
+	if id >= a.nextNum {
+		a.nextNum = id + 1
+	}
+	return nil
+}
+
+func (a *Repo) Update(ctx context.Context, id int64, e interface{}) error {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
@@ -37,14 +107,41 @@ func (a *Repo) Update(id int64, e interface{}) error {
 		return DefunctEntity
 	}
 
-	a.storage[id] = e
+	if err := a.write(record{Op: opPut, IDs: []int64{id}, Values: []interface{}{e}}); err != nil {
+		return err
+	}
+
+	a.put(id, e)
 	return nil
 }
 
-func (a *Repo) Get(id int64) (interface{}, error) {
+func (a *Repo) Modify(ctx context.Context, id int64, change func(e interface{}) (interface{}, error)) (interface{}, error) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
+	old, exists := a.storage[id]
+
+	if !exists {
+		return nil, DefunctEntity
+	}
+
+	e, err := change(old)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.write(record{Op: opPut, IDs: []int64{id}, Values: []interface{}{e}}); err != nil {
+		return nil, err
+	}
+
+	a.put(id, e)
+	return e, nil
+}
+
+func (a *Repo) Get(ctx context.Context, id int64) (interface{}, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
 	_, exists := a.storage[id]
 
 	if !exists {
@@ -54,7 +151,7 @@ func (a *Repo) Get(id int64) (interface{}, error) {
 	return a.storage[id], nil
 }
 
-func (a *Repo) Delete(id int64) error {
+func (a *Repo) Delete(ctx context.Context, id int64) error {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
@@ -64,32 +161,36 @@ func (a *Repo) Delete(id int64) error {
 		return DefunctEntity
 	}
 
-	delete(a.storage, id)
+	if err := a.write(record{Op: opDelete, IDs: []int64{id}}); err != nil {
+		return err
+	}
+
+	a.remove(id)
 
 	return nil
 }
 
-func (a *Repo) CheckIdExist(id int64) bool {
-	a.mu.Lock()
-	defer a.mu.Unlock()
+func (a *Repo) CheckIdExist(ctx context.Context, id int64) bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
 
 	_, exists := a.storage[id]
 
 	return exists
 }
 
-func (a *Repo) GetNextId() int64 {
-	a.mu.Lock()
-	defer a.mu.Unlock()
+func (a *Repo) GetNextId(ctx context.Context) int64 {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
 
 	return a.nextNum
 }
 
-func (a *Repo) GetArray() []interface{} {
-	a.mu.Lock()
-	defer a.mu.Unlock()
+func (a *Repo) GetArray(ctx context.Context) []interface{} {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
 
-	arr := make([]interface{}, 0)
+	arr := make([]interface{}, 0, len(a.storage))
 
 	for _, e := range a.storage {
 		arr = append(arr, e)
@@ -97,3 +198,111 @@ func (a *Repo) GetArray() []interface{} {
 
 	return arr
 }
+
+func (a *Repo) AddBatch(ctx context.Context, es []interface{}, withId func(e interface{}, id int64) interface{}) ([]int64, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	ids := make([]int64, len(es))
+	stored := make([]interface{}, len(es))
+	for i, e := range es {
+		ids[i] = a.nextNum + int64(i)
+		stored[i] = e
+		if withId != nil {
+			stored[i] = withId(e, ids[i])
+		}
+	}
+	if err := a.write(record{Op: opPut, IDs: ids, Values: stored}); err != nil {
+		return nil, err
+	}
+
+	for i, e := range stored {
+		a.put(ids[i], e)
+	}
+	a.nextNum += int64(len(es))
+	return ids, nil
+}
+
+func (a *Repo) GetBatch(ctx context.Context, ids []int64) ([]interface{}, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	arr := make([]interface{}, len(ids))
+	for i, id := range ids {
+		arr[i] = a.storage[id]
+	}
+
+	return arr, nil
+}
+
+func (a *Repo) UpdateBatch(ctx context.Context, ids []int64, es []interface{}) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, id := range ids {
+		if _, exists := a.storage[id]; !exists {
+			return DefunctEntity
+		}
+	}
+
+	if err := a.write(record{Op: opPut, IDs: ids, Values: es}); err != nil {
+		return err
+	}
+
+	for i, id := range ids {
+		a.put(id, es[i])
+	}
+	return nil
+}
+
+func (a *Repo) Find(ctx context.Context, def query.Index, from int64, to int64) ([]interface{}, error) {
+	if !def.Ordered && from != to {
+		return nil, InvalidRange
+	}
+
+	a.mu.RLock()
+	idx, exists := a.indexes[def.Name]
+	if !exists {
+		a.mu.RUnlock()
+		a.buildIndex(def)
+		a.mu.RLock()
+		idx = a.indexes[def.Name]
+	}
+	defer a.mu.RUnlock()
+
+	ids := idx.find(from, to)
+	arr := make([]interface{}, len(ids))
+	for i, id := range ids {
+		arr[i] = a.storage[id]
+	}
+
+	return arr, nil
+}
+
+// buildIndex indexes the stored entities, unless another caller has done
+// it in the meantime.
+func (a *Repo) buildIndex(def query.Index) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, exists := a.indexes[def.Name]; exists {
+		return
+	}
+
+	keys := make(map[int64]int64, len(a.storage))
+	for id, e := range a.storage {
+		keys[id] = def.Key(e)
+	}
+	a.indexes[def.Name] = buildIndex(def, keys)
+}
+
+// Ping reports the failure of the last background snapshot or sync of a
+// persisted repository; one living in memory is always fine. It lets the
+// health checks treat every storage the same way.
+func (a *Repo) Ping(ctx context.Context) error {
+	if a.persist == nil {
+		return nil
+	}
+
+	return a.persist.lastErr()
+}
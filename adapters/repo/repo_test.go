@@ -1,9 +1,16 @@
 package repo
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"homework10/internal/ads"
 	"homework10/internal/app"
+	"homework10/internal/query"
+	"reflect"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestRepo_Add(t *testing.T) {
@@ -21,7 +28,7 @@ func TestRepo_Add(t *testing.T) {
 	repo := New()
 
 	for _, test := range tests {
-		got := repo.Add(test.Item)
+		got := repo.Add(context.Background(), test.Item)
 		if got != test.Expect {
 			t.Fatalf(`test %q: expect %v got %v`, test.Name, test.Expect, got)
 		}
@@ -32,13 +39,13 @@ func FuzzRepo_Get(f *testing.F) {
 	repo := New()
 
 	for i := 1; i <= 100; i++ {
-		_ = repo.Add(i)
+		_ = repo.Add(context.Background(), i)
 	}
 
 	f.Fuzz(func(t *testing.T, id int64) {
-		_, err := repo.Get(id)
+		_, err := repo.Get(context.Background(), id)
 		var expectErr error
-		if repo.CheckIdExist(id) {
+		if repo.CheckIdExist(context.Background(), id) {
 			expectErr = nil
 		} else {
 			expectErr = DefunctEntity
@@ -60,8 +67,8 @@ func TestRepo_Update(t *testing.T) {
 	setup := func(t *testing.T) {
 		t.Cleanup(teardown)
 		repo = New()
-		_ = repo.Add(1)
-		_ = repo.Add(2)
+		_ = repo.Add(context.Background(), 1)
+		_ = repo.Add(context.Background(), 2)
 		fmt.Println("Set up repo")
 	}
 
@@ -81,17 +88,218 @@ func TestRepo_Update(t *testing.T) {
 	t.Run("with Cleanup", func(t *testing.T) {
 		setup(t)
 		for _, test := range tests {
-			err := repo.Update(test.Pos, test.Item)
+			err := repo.Update(context.Background(), test.Pos, test.Item)
 			if err != test.Expect {
 				t.Fatalf(`test %q: expect %v got %v`, test.Name, test.Expect, err)
 			}
 		}
 
 		for _, test := range tests {
-			item, err := repo.Get(test.Pos)
+			item, err := repo.Get(context.Background(), test.Pos)
 			if err == nil && item != test.Item {
 				t.Fatalf(`test %q: expect %v at poition %d got %v`, test.Name, test.Item, test.Pos, item)
 			}
 		}
 	})
 }
+
+func TestRepo_Modify(t *testing.T) {
+	repo := New()
+	_ = repo.Add(context.Background(), 0)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = repo.Modify(context.Background(), 0, func(e interface{}) (interface{}, error) {
+				return e.(int) + 1, nil
+			})
+		}()
+	}
+	wg.Wait()
+
+	got, _ := repo.Get(context.Background(), 0)
+	if got != 100 {
+		t.Fatalf(`test %q: expect %v got %v`, "Concurrent increments", 100, got)
+	}
+
+	failed := errors.New("failed")
+	_, err := repo.Modify(context.Background(), 0, func(e interface{}) (interface{}, error) {
+		return -1, failed
+	})
+	got, _ = repo.Get(context.Background(), 0)
+	if err != failed || got != 100 {
+		t.Fatalf(`test %q: expect %v got %v, %v`, "Failed change", 100, got, err)
+	}
+
+	_, err = repo.Modify(context.Background(), 1, func(e interface{}) (interface{}, error) {
+		return e, nil
+	})
+	if err != DefunctEntity {
+		t.Fatalf(`test %q: expect %v got %v`, "Missing entity", DefunctEntity, err)
+	}
+}
+
+func TestRepo_Insert(t *testing.T) {
+	repo := New()
+
+	if err := repo.Insert(context.Background(), 5, "imported"); err != nil {
+		t.Fatalf(`test %q: expect nil got %v`, "Free ID", err)
+	}
+	if err := repo.Insert(context.Background(), 5, "again"); err != ExistingEntity {
+		t.Fatalf(`test %q: expect %v got %v`, "Taken ID", ExistingEntity, err)
+	}
+	if next := repo.GetNextId(context.Background()); next != 6 {
+		t.Fatalf(`test %q: expect %v got %v`, "Next ID", 6, next)
+	}
+
+	_ = repo.Insert(context.Background(), 2, "gap")
+	if next := repo.GetNextId(context.Background()); next != 6 {
+		t.Fatalf(`test %q: expect %v got %v`, "Next ID after a gap", 6, next)
+	}
+}
+
+func TestRepo_Batch(t *testing.T) {
+	repo := New()
+
+	ids, err := repo.AddBatch(context.Background(), []interface{}{1, 2, 3}, nil)
+	if err != nil || !reflect.DeepEqual(ids, []int64{0, 1, 2}) {
+		t.Fatalf(`test %q: expect [0 1 2] got %v, %v`, "Add batch", ids, err)
+	}
+
+	items, err := repo.GetBatch(context.Background(), []int64{2, 7, 0})
+	if err != nil || !reflect.DeepEqual(items, []interface{}{3, nil, 1}) {
+		t.Fatalf(`test %q: expect [3 <nil> 1] got %v, %v`, "Get batch", items, err)
+	}
+
+	err = repo.UpdateBatch(context.Background(), []int64{0, 7}, []interface{}{4, 5})
+	if err != DefunctEntity {
+		t.Fatalf(`test %q: expect %v got %v`, "Update batch with a missing item", DefunctEntity, err)
+	}
+
+	item, _ := repo.Get(context.Background(), 0)
+	if item != 1 {
+		t.Fatalf(`test %q: expect 1 got %v`, "Update batch with a missing item", item)
+	}
+
+	err = repo.UpdateBatch(context.Background(), []int64{0, 1}, []interface{}{4, 5})
+	if err != nil {
+		t.Fatalf(`test %q: expect nil got %v`, "Update batch", err)
+	}
+
+	items, _ = repo.GetBatch(context.Background(), []int64{0, 1, 2})
+	if !reflect.DeepEqual(items, []interface{}{4, 5, 3}) {
+		t.Fatalf(`test %q: expect [4 5 3] got %v`, "Update batch", items)
+	}
+}
+
+func TestRepo_Find(t *testing.T) {
+	parity := query.Index{Name: "parity", Key: func(e interface{}) int64 { return int64(e.(int) % 2) }}
+	value := query.Index{Name: "value", Ordered: true, Key: func(e interface{}) int64 { return int64(e.(int)) }}
+
+	repo := New()
+	ctx := context.Background()
+
+	// the parity index is built from the stored items, the value index is
+	// built before any item is stored
+	_, _ = repo.Find(ctx, value, 0, 0)
+	_, _ = repo.AddBatch(ctx, []interface{}{5, 2, 9, 4, 7}, nil)
+	_ = repo.Update(ctx, 1, 3)
+	_ = repo.Delete(ctx, 2)
+
+	type Test struct {
+		Name   string
+		Index  query.Index
+		From   int64
+		To     int64
+		Expect []interface{}
+		Err    error
+	}
+
+	tests := [...]Test{
+		{"Odd items by ID", parity, 1, 1, []interface{}{5, 3, 7}, nil},
+		{"Even items", parity, 0, 0, []interface{}{4}, nil},
+		{"Range of an unordered index", parity, 0, 1, nil, InvalidRange},
+		{"Range by value", value, 3, 7, []interface{}{3, 4, 5, 7}, nil},
+		{"Single value", value, 9, 9, []interface{}{}, nil},
+		{"Empty range", value, 8, 1, []interface{}{}, nil},
+	}
+
+	for _, test := range tests {
+		items, err := repo.Find(ctx, test.Index, test.From, test.To)
+		if err != test.Err || (err == nil && !reflect.DeepEqual(items, test.Expect)) {
+			t.Fatalf(`test %q: expect %v, %v got %v, %v`, test.Name, test.Expect, test.Err, items, err)
+		}
+	}
+}
+
+// adsPerAuthor stays the same as the number of ads grows, so that a lookup by
+// author returns the same number of ads.
+const adsPerAuthor = 100
+
+func newAdsRepo(n int) app.Repository {
+	created := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	es := make([]interface{}, n)
+	for i := range es {
+		es[i] = ads.Ad{ID: int64(i), Title: "hello", Text: "world", AuthorID: int64(i % (n / adsPerAuthor)),
+			Published: (i/(n/adsPerAuthor))%2 == 0, CreatedAt: created.Add(time.Duration(i) * time.Second)}
+	}
+
+	repo := New()
+	_, _ = repo.AddBatch(context.Background(), es, nil)
+	return repo
+}
+
+// BenchmarkListAds_UserFilter compares ListAds, which uses the author index,
+// with a scan of all ads. The time of the first stays about the same from 10
+// thousand to a million ads, the scan grows with the number of ads.
+func BenchmarkListAds_UserFilter(b *testing.B) {
+	for _, n := range []int{10_000, 100_000, 1_000_000} {
+		repo := newAdsRepo(n)
+		a := app.NewApp(repo, New(), New())
+		ctx := context.Background()
+		authors := int64(n / adsPerAuthor)
+
+		// builds the index
+		_, _ = a.ListAds(ctx, true, 0, time.Time{})
+
+		b.Run(fmt.Sprintf("index/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				list, err := a.ListAds(ctx, true, int64(i)%authors, time.Time{})
+				if err != nil || len(list) == 0 {
+					b.Fatalf("expect ads got %d, %v", len(list), err)
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("scan/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				var list []ads.Ad
+				for _, e := range repo.GetArray(ctx) {
+					if ad := e.(ads.Ad); ad.Published && ad.AuthorID == int64(i)%authors {
+						list = append(list, ad)
+					}
+				}
+				if len(list) == 0 {
+					b.Fatalf("expect ads got none")
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkRepo_ConcurrentGet(b *testing.B) {
+	repo := newAdsRepo(100_000)
+	ctx := context.Background()
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		var id int64
+		for pb.Next() {
+			_, _ = repo.Get(ctx, id%100_000)
+			id++
+		}
+	})
+}
@@ -0,0 +1,190 @@
+package repo
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"github.com/pkg/errors"
+	"hash/crc32"
+	"homework10/internal/ads"
+	"homework10/internal/conversations"
+	"homework10/internal/users"
+	"io"
+	"os"
+)
+
+// maxFrameSize bounds the length read from a frame header, a larger one can
+// only come from a damaged file.
+const maxFrameSize = 64 << 20
+
+var (
+	CorruptLog      = errors.New("the write-ahead log is corrupt")
+	CorruptSnapshot = errors.New("the snapshot is corrupt")
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+func init() {
+	// the repositories store the domain types as interface{} values
+	gob.Register(ads.Ad{})
+	gob.Register(users.User{})
+	gob.Register(conversations.Conversation{})
+}
+
+type op uint8
+
+const (
+	opPut op = iota + 1
+	opDelete
+	// opHeader starts a snapshot, opEnd ends a complete one
+	opHeader
+	opEnd
+)
+
+// record is a change of the storage. A batch is a single record, so that it
+// is replayed completely or not at all.
+type record struct {
+	Op      op
+	IDs     []int64
+	Values  []interface{}
+	NextNum int64
+}
+
+func encode(r record) ([]byte, error) {
+	var payload bytes.Buffer
+	if err := gob.NewEncoder(&payload).Encode(r); err != nil {
+		return nil, err
+	}
+	return payload.Bytes(), nil
+}
+
+func decode(payload []byte) (record, error) {
+	var rec record
+	err := gob.NewDecoder(bytes.NewReader(payload)).Decode(&rec)
+	return rec, err
+}
+
+// A frame holds an encoded record: the length and the CRC-32C of the
+// payload, both little endian, followed by the payload.
+func writeFrame(w io.Writer, payload []byte) error {
+	frame := make([]byte, 8, 8+len(payload))
+	binary.LittleEndian.PutUint32(frame[0:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(frame[4:8], crc32.Checksum(payload, castagnoli))
+	frame = append(frame, payload...)
+
+	// a single write, so that a frame is torn only by a crash
+	_, err := w.Write(frame)
+	return err
+}
+
+func writeRecord(w io.Writer, r record) error {
+	payload, err := encode(r)
+	if err != nil {
+		return err
+	}
+	return writeFrame(w, payload)
+}
+
+// readFrames calls apply for every record of the file and returns the size
+// of the valid part. A frame cut short at the end of the file is the torn
+// last write of a crash and is not an error, see torn; a damaged frame is.
+func readFrames(path string, apply func(record) error) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	r := bufio.NewReader(f)
+	var size int64
+	header := make([]byte, 8)
+
+	// cut handles a frame at size running past the end of the file
+	cut := func() (int64, error) {
+		if ok, err := torn(f, size, info.Size()); err != nil {
+			return size, err
+		} else if !ok {
+			return size, errors.Wrapf(CorruptLog, "the frame at %d runs past the end of the file", size)
+		}
+		return size, nil
+	}
+
+	for {
+		if _, err := io.ReadFull(r, header); errors.Is(err, io.EOF) {
+			return size, nil
+		} else if errors.Is(err, io.ErrUnexpectedEOF) {
+			return cut()
+		} else if err != nil {
+			return size, err
+		}
+
+		length := binary.LittleEndian.Uint32(header[0:4])
+		if length > maxFrameSize {
+			return size, errors.Wrapf(CorruptLog, "frame at %d is %d bytes long", size, length)
+		}
+
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(r, payload); errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return cut()
+		} else if err != nil {
+			return size, err
+		}
+
+		if crc32.Checksum(payload, castagnoli) != binary.LittleEndian.Uint32(header[4:8]) {
+			// a torn write may leave garbage in the last frame only
+			if size+8+int64(length) == info.Size() {
+				return size, nil
+			}
+			return size, errors.Wrapf(CorruptLog, "checksum mismatch in the frame at %d", size)
+		}
+
+		rec, err := decode(payload)
+		if err != nil {
+			return size, errors.Wrapf(CorruptLog, "frame at %d: %v", size, err)
+		}
+
+		if err := apply(rec); err != nil {
+			return size, err
+		}
+
+		size += 8 + int64(length)
+	}
+}
+
+// torn tells whether the end of the file from offset, too short for the
+// frame starting there, is the torn last write of a crash rather than a
+// frame with a damaged length followed by valid ones. A frame is written at
+// once, so a torn one is the only frame of the tail.
+func torn(f *os.File, offset int64, fileSize int64) (bool, error) {
+	if fileSize-offset > 8+maxFrameSize {
+		return false, nil
+	}
+
+	tail := make([]byte, fileSize-offset)
+	if _, err := f.ReadAt(tail, offset); err != nil {
+		return false, err
+	}
+
+	for i := 1; i+8 <= len(tail); i++ {
+		length := int(binary.LittleEndian.Uint32(tail[i : i+4]))
+		if length > len(tail)-i-8 {
+			continue
+		}
+
+		payload := tail[i+8 : i+8+length]
+		if crc32.Checksum(payload, castagnoli) != binary.LittleEndian.Uint32(tail[i+4:i+8]) {
+			continue
+		}
+		if _, err := decode(payload); err == nil {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
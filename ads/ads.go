@@ -10,4 +10,32 @@ type Ad struct {
 	Published bool
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	Revisions []Revision
+	Favorites int64
+}
+
+type Revision struct {
+	ID        int64
+	Title     string
+	Text      string
+	UpdatedAt time.Time
+}
+
+type StatusChange struct {
+	AdID      int64
+	UserID    int64
+	Published bool
+}
+
+// Result is one item of a batch call: the ad, or the error the single call
+// would have returned for it.
+type Result struct {
+	Ad  Ad
+	Err error
+}
+
+// Patch holds the fields of a partial update, nil ones are left as they are.
+type Patch struct {
+	Title *string
+	Text  *string
 }
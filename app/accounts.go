@@ -0,0 +1,462 @@
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"github.com/pkg/errors"
+	"homework10/internal/password"
+	"homework10/internal/users"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	minPasswordLength = 8
+	// bcrypt ignores the bytes after the 72nd
+	maxPasswordLength = 72
+	tokenLength       = 32
+)
+
+var InvalidEmail = errors.New("the email address is invalid")
+var WeakPassword = errors.New("the password must contain from 8 to 72 bytes")
+var EmailTaken = errors.New("there is already an account with this email")
+var InvalidCredentials = errors.New("the email or the password is wrong")
+var AccountLocked = errors.New("too many failed logins, the account is locked for a while")
+var InvalidToken = errors.New("the token is invalid or expired")
+var ResetUnavailable = errors.New("the password can't be reset without an email server")
+var SessionRequired = errors.New("an account can only be changed in a session of its user")
+
+// AccountPolicy sets how long sessions and reset tokens live, and how many
+// failed logins in a row lock an account for Lockout.
+type AccountPolicy struct {
+	SessionTTL      time.Duration
+	ResetTTL        time.Duration
+	MaxFailedLogins int
+	Lockout         time.Duration
+}
+
+var DefaultAccountPolicy = AccountPolicy{
+	SessionTTL:      24 * time.Hour,
+	ResetTTL:        time.Hour,
+	MaxFailedLogins: 5,
+	Lockout:         15 * time.Minute,
+}
+
+// Mailer sends a message to the email of the user, e.g. a password reset
+// token, which must not be kept in the inbox of ListNotifications.
+type Mailer interface {
+	Mail(ctx context.Context, user users.User, subject string, text string) error
+}
+
+func WithHasher(hasher password.Hasher) Option {
+	return func(a *AdService) {
+		a.hasher = hasher
+	}
+}
+
+func WithAccountPolicy(policy AccountPolicy) Option {
+	return func(a *AdService) {
+		a.accountPolicy = policy
+	}
+}
+
+func WithMailer(mailer Mailer) Option {
+	return func(a *AdService) {
+		a.mailer = mailer
+	}
+}
+
+// Register creates a user with an account to log in with the email and the
+// password.
+func (a *AdService) Register(ctx context.Context, name string, email string, pass string) (users.User, error) {
+	if !validEmail(email) {
+		return users.User{}, InvalidEmail
+	}
+	if err := validatePassword(pass); err != nil {
+		return users.User{}, err
+	}
+
+	hash, err := a.hasher.Hash(pass)
+	if err != nil {
+		return users.User{}, err
+	}
+
+	// nothing else takes the email between the check and the write
+	a.emailMu.Lock()
+	defer a.emailMu.Unlock()
+
+	_, found, err := a.accountByEmail(ctx, email)
+	if err != nil {
+		return users.User{}, err
+	} else if found {
+		return users.User{}, EmailTaken
+	}
+
+	user := users.User{
+		ID:           a.users.GetNextId(ctx),
+		Name:         name,
+		Email:        email,
+		RegisteredAt: time.Now().UTC(),
+		Credentials:  users.Credentials{PasswordHash: hash},
+	}
+
+	err = a.users.Add(ctx, user)
+	if err != nil {
+		return user, err
+	}
+
+	a.logger.InfoContext(ctx, "account registered", "user_id", user.ID)
+
+	return user, nil
+}
+
+// Login starts a session of the account with the email. The token of the
+// session is in the result only.
+func (a *AdService) Login(ctx context.Context, email string, pass string) (users.Session, error) {
+	user, found, err := a.accountByEmail(ctx, email)
+	if err != nil {
+		return users.Session{}, err
+	}
+	if !found {
+		// the same work as for a wrong password, so that the time of the
+		// answer does not tell whether the account exists
+		_ = a.hasher.Verify(a.dummyHash(), pass)
+		return users.Session{}, InvalidCredentials
+	}
+
+	err = a.checkPassword(ctx, user, pass)
+	if err != nil {
+		return users.Session{}, err
+	}
+
+	token, digest, err := newToken(user.ID)
+	if err != nil {
+		return users.Session{}, err
+	}
+
+	now := time.Now().UTC()
+	session := users.Session{UserID: user.ID, Digest: digest, CreatedAt: now, ExpiresAt: now.Add(a.accountPolicy.SessionTTL)}
+
+	_, err = a.modifyUser(ctx, user.ID, func(user *users.User) error {
+		// failed logins running at the same time may have locked it
+		if now.Before(user.Credentials.LockedUntil) {
+			return AccountLocked
+		}
+
+		session.ID = 1
+		sessions := make([]users.Session, 0, len(user.Credentials.Sessions)+1)
+		for _, s := range user.Credentials.Sessions {
+			if now.Before(s.ExpiresAt) {
+				sessions = append(sessions, s)
+			}
+			session.ID = max(session.ID, s.ID+1)
+		}
+		user.Credentials.Sessions = append(sessions, session)
+		user.Credentials.FailedLogins = 0
+		return nil
+	})
+	if err != nil {
+		return users.Session{}, err
+	}
+
+	a.logger.InfoContext(ctx, "user logged in", "user_id", user.ID, "session_id", session.ID)
+
+	session.Token = token
+	return session, nil
+}
+
+// Authenticate returns the user of the session with the token.
+func (a *AdService) Authenticate(ctx context.Context, token string) (users.User, error) {
+	user, i, err := a.sessionByToken(ctx, token)
+	if err != nil {
+		return users.User{}, err
+	}
+	if i < 0 {
+		return users.User{}, InvalidToken
+	}
+
+	return user, nil
+}
+
+// Logout ends the session with the token.
+func (a *AdService) Logout(ctx context.Context, token string) error {
+	user, i, err := a.sessionByToken(ctx, token)
+	if err != nil {
+		return err
+	}
+	if i < 0 {
+		return InvalidToken
+	}
+
+	ended := user.Credentials.Sessions[i].ID
+	_, err = a.modifyUser(ctx, user.ID, func(user *users.User) error {
+		sessions := make([]users.Session, 0, len(user.Credentials.Sessions))
+		for _, s := range user.Credentials.Sessions {
+			if s.ID != ended {
+				sessions = append(sessions, s)
+			}
+		}
+		if len(sessions) == len(user.Credentials.Sessions) {
+			// ended at the same time by another logout or a new password
+			return InvalidToken
+		}
+		user.Credentials.Sessions = sessions
+		return nil
+	})
+
+	return err
+}
+
+// ChangePassword replaces the password of the account, and ends all its
+// sessions. A wrong old password counts as a failed login.
+func (a *AdService) ChangePassword(ctx context.Context, userId int64, oldPass string, newPass string) error {
+	user, err := a.GetUser(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if !user.Credentials.HasPassword() {
+		return InvalidCredentials
+	}
+	if err := validatePassword(newPass); err != nil {
+		return err
+	}
+
+	err = a.checkPassword(ctx, user, oldPass)
+	if err != nil {
+		return err
+	}
+
+	err = a.setPassword(ctx, user, newPass, nil)
+	if err != nil {
+		return err
+	}
+
+	a.logger.InfoContext(ctx, "password changed", "user_id", user.ID)
+
+	return nil
+}
+
+// RequestPasswordReset mails a token to reset the password of the account
+// with the email. An unknown email is not an error, so that the answer does
+// not tell which accounts exist.
+func (a *AdService) RequestPasswordReset(ctx context.Context, email string) error {
+	if a.mailer == nil {
+		return ResetUnavailable
+	}
+
+	user, found, err := a.accountByEmail(ctx, email)
+	if err != nil || !found {
+		return err
+	}
+
+	token, digest, err := newToken(user.ID)
+	if err != nil {
+		return err
+	}
+
+	user, err = a.modifyUser(ctx, user.ID, func(user *users.User) error {
+		user.Credentials.ResetDigest = digest
+		user.Credentials.ResetExpiresAt = time.Now().UTC().Add(a.accountPolicy.ResetTTL)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	a.logger.InfoContext(ctx, "password reset requested", "user_id", user.ID)
+
+	return a.mailer.Mail(ctx, user, "Password reset",
+		fmt.Sprintf("Reset your password with this token within %s:\n\n%s\n\nIgnore this message if you did not ask for it.",
+			a.accountPolicy.ResetTTL, token))
+}
+
+// ResetPassword sets the password of the account the reset token was mailed
+// to. It ends the sessions of the account and unlocks it.
+func (a *AdService) ResetPassword(ctx context.Context, token string, newPass string) error {
+	userId, digest, ok := parseToken(token)
+	if !ok || !a.users.CheckIdExist(ctx, userId) {
+		return InvalidToken
+	}
+	if err := validatePassword(newPass); err != nil {
+		return err
+	}
+
+	user, err := a.GetUser(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	// checked again with the new password stored, so that the token is used
+	// once only
+	validToken := func(credentials users.Credentials) error {
+		if len(credentials.ResetDigest) == 0 || !time.Now().Before(credentials.ResetExpiresAt) ||
+			!password.Equal(credentials.ResetDigest, digest) {
+			return InvalidToken
+		}
+		return nil
+	}
+	if err := validToken(user.Credentials); err != nil {
+		return err
+	}
+
+	err = a.setPassword(ctx, user, newPass, validToken)
+	if err != nil {
+		return err
+	}
+
+	a.logger.InfoContext(ctx, "password reset", "user_id", user.ID)
+
+	return nil
+}
+
+// checkPassword verifies the password of the account. A wrong one counts as
+// a failed login and may lock the account. The count is kept by the stored
+// user, so that failed logins at the same time are all counted.
+func (a *AdService) checkPassword(ctx context.Context, user users.User, pass string) error {
+	if time.Now().Before(user.Credentials.LockedUntil) {
+		return AccountLocked
+	}
+
+	err := a.hasher.Verify(user.Credentials.PasswordHash, pass)
+	if errors.Is(err, password.Mismatch) {
+		locked := false
+		_, err = a.modifyUser(ctx, user.ID, func(user *users.User) error {
+			now := time.Now().UTC()
+			if now.Before(user.Credentials.LockedUntil) {
+				return AccountLocked
+			}
+
+			user.Credentials.FailedLogins++
+			locked = user.Credentials.FailedLogins >= a.accountPolicy.MaxFailedLogins
+			if locked {
+				user.Credentials.FailedLogins = 0
+				user.Credentials.LockedUntil = now.Add(a.accountPolicy.Lockout)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		if locked {
+			a.logger.WarnContext(ctx, "account locked", "user_id", user.ID)
+		}
+		return InvalidCredentials
+	}
+
+	return err
+}
+
+// setPassword replaces the password hash, and drops the sessions, the reset
+// token and the lock of the account. check, if any, vets the credentials
+// stored right before.
+func (a *AdService) setPassword(ctx context.Context, user users.User, pass string, check func(users.Credentials) error) error {
+	hash, err := a.hasher.Hash(pass)
+	if err != nil {
+		return err
+	}
+
+	_, err = a.modifyUser(ctx, user.ID, func(user *users.User) error {
+		if check != nil {
+			if err := check(user.Credentials); err != nil {
+				return err
+			}
+		}
+		user.Credentials = users.Credentials{PasswordHash: hash}
+		return nil
+	})
+	return err
+}
+
+// accountByEmail finds the user with an account with the email.
+func (a *AdService) accountByEmail(ctx context.Context, email string) (users.User, bool, error) {
+	key := emailKey(email)
+	res, err := a.users.Find(ctx, UsersByEmail, key, key)
+	if err != nil {
+		return users.User{}, false, err
+	}
+
+	for _, e := range res {
+		user := e.(users.User)
+		if user.Credentials.HasPassword() && strings.EqualFold(user.Email, email) {
+			return user, true, nil
+		}
+	}
+
+	return users.User{}, false, nil
+}
+
+// sessionByToken returns the user of the token and the index of its
+// session, -1 if it has none or it expired.
+func (a *AdService) sessionByToken(ctx context.Context, token string) (users.User, int, error) {
+	userId, digest, ok := parseToken(token)
+	if !ok || !a.users.CheckIdExist(ctx, userId) {
+		return users.User{}, -1, nil
+	}
+
+	user, err := a.GetUser(ctx, userId)
+	if err != nil {
+		return users.User{}, -1, err
+	}
+
+	now := time.Now()
+	for i, session := range user.Credentials.Sessions {
+		if password.Equal(session.Digest, digest) && now.Before(session.ExpiresAt) {
+			return user, i, nil
+		}
+	}
+
+	return user, -1, nil
+}
+
+func (a *AdService) dummyHash() string {
+	a.dummyOnce.Do(func() {
+		a.dummy, _ = a.hasher.Hash("a password nobody has")
+	})
+	return a.dummy
+}
+
+func validEmail(email string) bool {
+	at := strings.Index(email, "@")
+	return at > 0 && at < len(email)-1 && !strings.ContainsAny(email, " \r\n")
+}
+
+func validatePassword(pass string) error {
+	if len(pass) < minPasswordLength || len(pass) > maxPasswordLength {
+		return WeakPassword
+	}
+	return nil
+}
+
+// newToken makes a random token of the user, "<user ID>.<secret>", and the
+// digest it is stored as.
+func newToken(userId int64) (string, []byte, error) {
+	secret := make([]byte, tokenLength)
+	if _, err := rand.Read(secret); err != nil {
+		return "", nil, err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(secret)
+	digest := sha256.Sum256([]byte(encoded))
+
+	return strconv.FormatInt(userId, 10) + "." + encoded, digest[:], nil
+}
+
+func parseToken(token string) (int64, []byte, bool) {
+	id, secret, found := strings.Cut(token, ".")
+	if !found || secret == "" {
+		return 0, nil, false
+	}
+
+	userId, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return 0, nil, false
+	}
+
+	digest := sha256.Sum256([]byte(secret))
+	return userId, digest[:], true
+}
@@ -1,143 +1,404 @@
 package app
 
 import (
+	"context"
 	validator "github.com/Vdaleke/ad-validation"
 	"github.com/pkg/errors"
 	"homework10/internal/ads"
+	"homework10/internal/conversations"
+	"homework10/internal/password"
+	"homework10/internal/query"
 	"homework10/internal/users"
+	"log/slog"
 	"strings"
+	"sync"
 	"time"
 )
 
 type App interface {
-	CreateAd(title string, text string, userId int64) (ads.Ad, error)
-	ChangeAdStatus(adId int64, userId int64, published bool) (ads.Ad, error)
-	UpdateAd(adId int64, userId int64, title string, text string) (ads.Ad, error)
-	GetAd(adId int64) (ads.Ad, error)
-	DeleteAd(adId int64, userId int64) error
-	ListAds(pubFilter bool, userFilter int64, timeFilter time.Time) ([]ads.Ad, error)
-	SearchAds(pattern string) ([]ads.Ad, error)
-
-	CreateUser(name string, email string) (users.User, error)
-	UpdateUser(userId int64, name string, email string) (users.User, error)
-	GetUser(userId int64) (users.User, error)
-	DeleteUser(userId int64) error
+	CreateAd(ctx context.Context, title string, text string, userId int64) (ads.Ad, error)
+	ChangeAdStatus(ctx context.Context, adId int64, userId int64, published bool) (ads.Ad, error)
+	UpdateAd(ctx context.Context, adId int64, userId int64, title string, text string) (ads.Ad, error)
+	PatchAd(ctx context.Context, adId int64, userId int64, patch ads.Patch) (ads.Ad, error)
+	GetAd(ctx context.Context, adId int64) (ads.Ad, error)
+	DeleteAd(ctx context.Context, adId int64, userId int64) error
+	ListAds(ctx context.Context, pubFilter bool, userFilter int64, timeFilter time.Time) ([]ads.Ad, error)
+	SearchAds(ctx context.Context, pattern string) ([]ads.Ad, error)
+	ListAdRevisions(ctx context.Context, adId int64) ([]ads.Revision, error)
+	RollbackAd(ctx context.Context, adId int64, userId int64, revisionId int64) (ads.Ad, error)
+	BatchCreateAds(ctx context.Context, drafts []ads.Ad) ([]ads.Result, error)
+	BatchGetAds(ctx context.Context, adIds []int64) ([]ads.Result, error)
+	BatchChangeAdStatus(ctx context.Context, changes []ads.StatusChange) ([]ads.Result, error)
+
+	CreateUser(ctx context.Context, name string, email string) (users.User, error)
+	UpdateUser(ctx context.Context, userId int64, viewerId int64, name string, email string) (users.User, error)
+	PatchUser(ctx context.Context, userId int64, viewerId int64, patch users.Patch) (users.User, error)
+	GetUser(ctx context.Context, userId int64) (users.User, error)
+	ViewUser(ctx context.Context, userId int64, viewerId int64) (users.User, error)
+	DeleteUser(ctx context.Context, userId int64, viewerId int64) error
+	AddFavorite(ctx context.Context, userId int64, adId int64, notify bool) (ads.Ad, error)
+	RemoveFavorite(ctx context.Context, userId int64, adId int64) (ads.Ad, error)
+	ListFavorites(ctx context.Context, userId int64) ([]ads.Ad, error)
+	ListNotifications(ctx context.Context, userId int64) ([]users.Notification, error)
+	SaveSearch(ctx context.Context, userId int64, pattern string, authorFilter int64) (users.SavedSearch, error)
+	ListSavedSearches(ctx context.Context, userId int64) ([]users.SavedSearch, error)
+	DeleteSavedSearch(ctx context.Context, userId int64, searchId int64) error
+
+	OpenConversation(ctx context.Context, adId int64, userId int64) (conversations.Conversation, error)
+	ListConversations(ctx context.Context, userId int64) ([]conversations.Conversation, error)
+	SendMessage(ctx context.Context, conversationId int64, userId int64, text string) (conversations.Message, error)
+	ListMessages(ctx context.Context, conversationId int64, userId int64) ([]conversations.Message, error)
+	CloseConversation(ctx context.Context, conversationId int64, userId int64) (conversations.Conversation, error)
+
+	ImportAd(ctx context.Context, ad ads.Ad, dryRun bool) (ads.Ad, error)
+	ImportUser(ctx context.Context, user users.User, dryRun bool) (users.User, error)
+	ExportAds(ctx context.Context, emit func(ads.Ad) error) error
+	ExportUsers(ctx context.Context, emit func(users.User) error) error
+
+	Register(ctx context.Context, name string, email string, password string) (users.User, error)
+	Login(ctx context.Context, email string, password string) (users.Session, error)
+	Authenticate(ctx context.Context, token string) (users.User, error)
+	Logout(ctx context.Context, token string) error
+	ChangePassword(ctx context.Context, userId int64, oldPassword string, newPassword string) error
+	RequestPasswordReset(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, token string, newPassword string) error
 }
 
 type Repository interface {
-	Add(e interface{}) error
-	Update(id int64, ad interface{}) error
-	Get(id int64) (interface{}, error)
-	Delete(id int64) error
-	CheckIdExist(id int64) bool
-	GetNextId() int64
-	GetArray() []interface{}
+	Add(ctx context.Context, e interface{}) error
+	// Insert stores e under id, unless it is taken, and Add carries on
+	// after it.
+	Insert(ctx context.Context, id int64, e interface{}) error
+	Update(ctx context.Context, id int64, ad interface{}) error
+	Get(ctx context.Context, id int64) (interface{}, error)
+	Delete(ctx context.Context, id int64) error
+	CheckIdExist(ctx context.Context, id int64) bool
+	GetNextId(ctx context.Context) int64
+	GetArray(ctx context.Context) []interface{}
+	// Modify stores the result of change, given the entity with id, and
+	// returns it. No other write happens in between, so change must not use
+	// the repository. Nothing is stored if change fails.
+	Modify(ctx context.Context, id int64, change func(e interface{}) (interface{}, error)) (interface{}, error)
+
+	// AddBatch stores es as Add would, one after another, and returns the
+	// IDs they get. No other write happens in between. withId, if not nil,
+	// gives an entity its ID before it is stored.
+	AddBatch(ctx context.Context, es []interface{}, withId func(e interface{}, id int64) interface{}) ([]int64, error)
+	// GetBatch returns the entities in the order of ids, nil for the missing ones.
+	GetBatch(ctx context.Context, ids []int64) ([]interface{}, error)
+	// UpdateBatch stores es under ids, or nothing if one of them doesn't exist.
+	UpdateBatch(ctx context.Context, ids []int64, es []interface{}) error
+
+	// Find returns the entities with a key in the index from from to to,
+	// ordered by the key and then by ID. Only an ordered index answers a
+	// range, other indexes need from and to to be equal.
+	Find(ctx context.Context, index query.Index, from int64, to int64) ([]interface{}, error)
+}
+
+type Notifier interface {
+	Notify(ctx context.Context, user users.User, notification users.Notification) error
+}
+
+const DefaultRevisionLimit = 10
+
+// DefaultDeliveryQueue is how many notifications wait for the notifiers
+// given with WithNotifier before new ones are dropped.
+const DefaultDeliveryQueue = 1024
+
+type Option func(*AdService)
+
+func WithRevisionLimit(limit int) Option {
+	return func(a *AdService) {
+		a.revisionLimit = limit
+	}
 }
 
-func NewApp(adRepo Repository, userRepo Repository) App {
-	return &AdService{ads: adRepo, users: userRepo}
+func WithLogger(logger *slog.Logger) Option {
+	return func(a *AdService) {
+		a.logger = logger
+	}
+}
+
+// WithNotifier adds a notifier next to the inbox of the user. It is called
+// in the background, see DefaultDeliveryQueue.
+func WithNotifier(notifier Notifier) Option {
+	return func(a *AdService) {
+		a.notifiers = append(a.notifiers, notifier)
+	}
+}
+
+func WithDeliveryQueue(size int) Option {
+	return func(a *AdService) {
+		a.deliveryQueue = size
+	}
+}
+
+func NewApp(adRepo Repository, userRepo Repository, conversationRepo Repository, opts ...Option) App {
+	a := &AdService{ads: adRepo, users: userRepo, conversations: conversationRepo,
+		revisionLimit: DefaultRevisionLimit, logger: slog.Default(),
+		hasher: password.NewArgon2id(password.DefaultArgon2idParams), accountPolicy: DefaultAccountPolicy,
+		inbox: &inAppNotifier{users: userRepo}, deliveryQueue: DefaultDeliveryQueue}
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	if len(a.notifiers) > 0 {
+		a.deliveries = make(chan delivery, a.deliveryQueue)
+		go a.deliver()
+	}
+
+	return a
 }
 
 type AdService struct {
-	ads   Repository
-	users Repository
+	ads           Repository
+	users         Repository
+	conversations Repository
+	inbox         Notifier
+	notifiers     []Notifier
+	deliveryQueue int
+	deliveries    chan delivery
+	revisionLimit int
+	logger        *slog.Logger
+	hasher        password.Hasher
+	accountPolicy AccountPolicy
+	mailer        Mailer
+	dummy         string
+	dummyOnce     sync.Once
+	// emailMu is held from the check that an email is free to its write
+	emailMu sync.Mutex
 }
 
 var PermissionDenied = errors.New("the user does not have enough permission to edit the ad")
 var DefunctUser = errors.New("there is no user with this ID")
 var DefunctAd = errors.New("there is no ad with this ID")
+var DefunctRevision = errors.New("there is no revision of the ad with this ID")
 
-func (a *AdService) CreateAd(title string, text string, userId int64) (ads.Ad, error) {
-	if !a.users.CheckIdExist(userId) {
+func (a *AdService) CreateAd(ctx context.Context, title string, text string, userId int64) (ads.Ad, error) {
+	if !a.users.CheckIdExist(ctx, userId) {
 		return ads.Ad{}, DefunctUser
 	}
 
-	ad := ads.Ad{ID: a.ads.GetNextId(), Title: title, Text: text, AuthorID: userId, Published: false, CreatedAt: time.Now().UTC()}
+	ad := ads.Ad{ID: a.ads.GetNextId(ctx), Title: title, Text: text, AuthorID: userId, Published: false, CreatedAt: time.Now().UTC()}
 
 	err := validator.ValidateAd(title, text)
 	if err != nil {
 		return ad, err
 	}
 
-	return ad, a.ads.Add(ad)
+	a.addRevision(&ad, ad.CreatedAt)
+
+	err = a.ads.Add(ctx, ad)
+	if err != nil {
+		return ad, err
+	}
+
+	a.logger.InfoContext(ctx, "ad created", "ad_id", ad.ID, "user_id", userId)
+
+	return ad, nil
 }
 
-func (a *AdService) ChangeAdStatus(adId int64, userId int64, published bool) (ads.Ad, error) {
-	if !a.users.CheckIdExist(userId) {
+func (a *AdService) ChangeAdStatus(ctx context.Context, adId int64, userId int64, published bool) (ads.Ad, error) {
+	if !a.users.CheckIdExist(ctx, userId) {
 		return ads.Ad{}, DefunctUser
 	}
 
-	if !a.ads.CheckIdExist(adId) {
+	if !a.ads.CheckIdExist(ctx, adId) {
 		return ads.Ad{}, DefunctAd
 	}
 
-	res, err := a.ads.Get(adId)
-	ad := res.(ads.Ad)
+	var wasPublished bool
+	ad, err := a.modifyAd(ctx, adId, func(ad *ads.Ad) error {
+		if ad.AuthorID != userId {
+			return PermissionDenied
+		}
 
+		wasPublished = ad.Published
+		ad.Published = published
+		return nil
+	})
 	if err != nil {
 		return ad, err
 	}
 
-	if ad.AuthorID != userId {
-		return ad, PermissionDenied
+	if published == wasPublished {
+		return ad, nil
 	}
 
-	ad.Published = published
+	a.logger.InfoContext(ctx, "ad status changed", "ad_id", adId, "published", published)
+
+	if published {
+		a.notifyFavorites(ctx, ad, "has been published")
+		a.notifySavedSearches(ctx, ad)
+	} else {
+		a.notifyFavorites(ctx, ad, "has been unpublished")
+	}
+
+	return ad, nil
+}
 
-	return ad, a.ads.Update(adId, ad)
+func (a *AdService) UpdateAd(ctx context.Context, adId int64, userId int64, title string, text string) (ads.Ad, error) {
+	return a.PatchAd(ctx, adId, userId, ads.Patch{Title: &title, Text: &text})
 }
 
-func (a *AdService) UpdateAd(adId int64, userId int64, title string, text string) (ads.Ad, error) {
-	if !a.users.CheckIdExist(userId) {
+// PatchAd changes the fields set in the patch only. The other fields were
+// validated when stored, so an error is about a changed one.
+func (a *AdService) PatchAd(ctx context.Context, adId int64, userId int64, patch ads.Patch) (ads.Ad, error) {
+	if !a.users.CheckIdExist(ctx, userId) {
 		return ads.Ad{}, DefunctUser
 	}
-	if !a.ads.CheckIdExist(adId) {
+	if !a.ads.CheckIdExist(ctx, adId) {
 		return ads.Ad{}, DefunctAd
 	}
 
-	res, err := a.ads.Get(adId)
-	ad := res.(ads.Ad)
+	if patch.Title == nil && patch.Text == nil {
+		ad, err := a.GetAd(ctx, adId)
+		if err == nil && ad.AuthorID != userId {
+			err = PermissionDenied
+		}
+		return ad, err
+	}
+
+	ad, err := a.modifyAd(ctx, adId, func(ad *ads.Ad) error {
+		if ad.AuthorID != userId {
+			return PermissionDenied
+		}
+
+		title, text := ad.Title, ad.Text
+		if patch.Title != nil {
+			title = *patch.Title
+		}
+		if patch.Text != nil {
+			text = *patch.Text
+		}
 
+		err := validator.ValidateAd(title, text)
+		if err != nil {
+			return err
+		}
+
+		ad.Title = title
+		ad.Text = text
+		ad.UpdatedAt = time.Now().UTC()
+		a.addRevision(ad, ad.UpdatedAt)
+		return nil
+	})
 	if err != nil {
 		return ad, err
 	}
 
-	if ad.AuthorID != userId {
-		return ad, PermissionDenied
+	a.notifyFavorites(ctx, ad, "has been updated")
+
+	return ad, nil
+}
+
+func (a *AdService) ListAdRevisions(ctx context.Context, adId int64) ([]ads.Revision, error) {
+	ad, err := a.GetAd(ctx, adId)
+	if err != nil {
+		return nil, err
 	}
 
-	err = validator.ValidateAd(title, text)
+	revisions := make([]ads.Revision, len(ad.Revisions))
+	copy(revisions, ad.Revisions)
+
+	return revisions, nil
+}
+
+func (a *AdService) RollbackAd(ctx context.Context, adId int64, userId int64, revisionId int64) (ads.Ad, error) {
+	if !a.users.CheckIdExist(ctx, userId) {
+		return ads.Ad{}, DefunctUser
+	}
+	if !a.ads.CheckIdExist(ctx, adId) {
+		return ads.Ad{}, DefunctAd
+	}
+
+	ad, err := a.modifyAd(ctx, adId, func(ad *ads.Ad) error {
+		if ad.AuthorID != userId {
+			return PermissionDenied
+		}
+
+		for _, revision := range ad.Revisions {
+			if revision.ID == revisionId {
+				ad.Title = revision.Title
+				ad.Text = revision.Text
+				ad.UpdatedAt = time.Now().UTC()
+				a.addRevision(ad, ad.UpdatedAt)
+				return nil
+			}
+		}
+
+		return DefunctRevision
+	})
 	if err != nil {
 		return ad, err
 	}
 
-	ad.Title = title
-	ad.Text = text
-	ad.UpdatedAt = time.Now().UTC()
+	a.notifyFavorites(ctx, ad, "has been updated")
 
-	return ad, a.ads.Update(adId, ad)
+	return ad, nil
 }
 
-func (a *AdService) GetAd(adId int64) (ads.Ad, error) {
-	if !a.ads.CheckIdExist(adId) {
+// addRevision appends the current title and text of the ad to its history,
+// dropping the oldest revisions once the limit is exceeded. The history is
+// copied so that the stored ad never shares a backing array with the result.
+func (a *AdService) addRevision(ad *ads.Ad, updatedAt time.Time) {
+	nextId := int64(1)
+	if len(ad.Revisions) > 0 {
+		nextId = ad.Revisions[len(ad.Revisions)-1].ID + 1
+	}
+
+	revisions := make([]ads.Revision, 0, len(ad.Revisions)+1)
+	revisions = append(revisions, ad.Revisions...)
+	revisions = append(revisions, ads.Revision{ID: nextId, Title: ad.Title, Text: ad.Text, UpdatedAt: updatedAt})
+
+	if a.revisionLimit > 0 && len(revisions) > a.revisionLimit {
+		revisions = revisions[len(revisions)-a.revisionLimit:]
+	}
+
+	ad.Revisions = revisions
+}
+
+func (a *AdService) GetAd(ctx context.Context, adId int64) (ads.Ad, error) {
+	if !a.ads.CheckIdExist(ctx, adId) {
 		return ads.Ad{}, DefunctAd
 	}
 
-	res, err := a.ads.Get(adId)
-	ad := res.(ads.Ad)
+	res, err := a.ads.Get(ctx, adId)
+	if err != nil {
+		// deleted since checked
+		if !a.ads.CheckIdExist(ctx, adId) {
+			return ads.Ad{}, DefunctAd
+		}
+		return ads.Ad{}, err
+	}
+
+	return res.(ads.Ad), nil
+}
+
+// modifyAd changes the stored ad with no other write in between, see
+// Repository.Modify. It returns the ad change was given if change fails.
+func (a *AdService) modifyAd(ctx context.Context, adId int64, change func(ad *ads.Ad) error) (ads.Ad, error) {
+	var ad ads.Ad
+	_, err := a.ads.Modify(ctx, adId, func(e interface{}) (interface{}, error) {
+		ad = e.(ads.Ad)
+		if err := change(&ad); err != nil {
+			return nil, err
+		}
+		return ad, nil
+	})
 
 	return ad, err
 }
 
-func (a *AdService) DeleteAd(adId int64, userId int64) error {
-	if !a.users.CheckIdExist(userId) {
+func (a *AdService) DeleteAd(ctx context.Context, adId int64, userId int64) error {
+	if !a.users.CheckIdExist(ctx, userId) {
 		return DefunctUser
 	}
-	if !a.ads.CheckIdExist(adId) {
+	if !a.ads.CheckIdExist(ctx, adId) {
 		return DefunctAd
 	}
 
-	res, err := a.ads.Get(adId)
+	res, err := a.ads.Get(ctx, adId)
 	ad := res.(ads.Ad)
 
 	if err != nil {
@@ -147,11 +408,40 @@ func (a *AdService) DeleteAd(adId int64, userId int64) error {
 		return PermissionDenied
 	}
 
-	return a.ads.Delete(adId)
+	err = a.ads.Delete(ctx, adId)
+	if err != nil {
+		return err
+	}
+
+	a.logger.InfoContext(ctx, "ad deleted", "ad_id", adId, "user_id", userId)
+
+	err = a.closeConversations(ctx, func(conversation conversations.Conversation) bool {
+		return conversation.AdID == adId
+	})
+	if err != nil {
+		return err
+	}
+
+	return a.dropFavorites(ctx, ad)
 }
 
-func (a *AdService) ListAds(pubFilter bool, userFilter int64, timeFilter time.Time) ([]ads.Ad, error) {
-	res := a.ads.GetArray()
+// ListAds looks the ads up by the most selective filter and checks the
+// other ones.
+func (a *AdService) ListAds(ctx context.Context, pubFilter bool, userFilter int64, timeFilter time.Time) ([]ads.Ad, error) {
+	var res []interface{}
+	var err error
+	if userFilter != -1 {
+		res, err = a.ads.Find(ctx, AdsByAuthor, userFilter, userFilter)
+	} else if !timeFilter.IsZero() {
+		res, err = a.ads.Find(ctx, AdsByCreationTime, timeFilter.UnixNano(), timeFilter.UnixNano())
+	} else {
+		published := publishedKey(pubFilter)
+		res, err = a.ads.Find(ctx, AdsByPublished, published, published)
+	}
+	if err != nil {
+		return nil, err
+	}
+
 	adsArray := make([]ads.Ad, 0)
 
 	for _, e := range res {
@@ -166,8 +456,8 @@ func (a *AdService) ListAds(pubFilter bool, userFilter int64, timeFilter time.Ti
 	return adsArray, nil
 }
 
-func (a *AdService) SearchAds(pattern string) ([]ads.Ad, error) {
-	allAds := a.ads.GetArray()
+func (a *AdService) SearchAds(ctx context.Context, pattern string) ([]ads.Ad, error) {
+	allAds := a.ads.GetArray(ctx)
 	filteredAds := make([]ads.Ad, 0)
 
 	for _, e := range allAds {
@@ -180,36 +470,84 @@ func (a *AdService) SearchAds(pattern string) ([]ads.Ad, error) {
 	return filteredAds, nil
 }
 
-func (a *AdService) CreateUser(name string, email string) (users.User, error) {
-	user := users.User{ID: a.users.GetNextId(), Name: name, Email: email}
+func (a *AdService) CreateUser(ctx context.Context, name string, email string) (users.User, error) {
+	user := users.User{ID: a.users.GetNextId(ctx), Name: name, Email: email, RegisteredAt: time.Now().UTC()}
+
+	err := a.users.Add(ctx, user)
+	if err != nil {
+		return user, err
+	}
+
+	a.logger.InfoContext(ctx, "user created", "user_id", user.ID)
 
-	return user, a.users.Add(user)
+	return user, nil
 }
 
-func (a *AdService) UpdateUser(userId int64, name string, email string) (users.User, error) {
-	if !a.users.CheckIdExist(userId) {
+func (a *AdService) UpdateUser(ctx context.Context, userId int64, viewerId int64, name string, email string) (users.User, error) {
+	return a.PatchUser(ctx, userId, viewerId, users.Patch{Name: &name, Email: &email})
+}
+
+// PatchUser changes the fields set in the patch only. An account is changed
+// by its user only, viewerId is the user of the session of the change, -1
+// without one.
+func (a *AdService) PatchUser(ctx context.Context, userId int64, viewerId int64, patch users.Patch) (users.User, error) {
+	if !a.users.CheckIdExist(ctx, userId) {
 		return users.User{}, DefunctUser
 	}
 
-	res, err := a.users.Get(userId)
-	user := res.(users.User)
+	if patch.Email != nil {
+		a.emailMu.Lock()
+		defer a.emailMu.Unlock()
 
-	if err != nil {
-		return user, err
+		// an account logs in with its email, which must stay its own
+		account, found, err := a.accountByEmail(ctx, *patch.Email)
+		if err != nil {
+			return users.User{}, err
+		} else if found && account.ID != userId {
+			return users.User{}, EmailTaken
+		}
 	}
 
-	user.Name = name
-	user.Email = email
+	return a.modifyUser(ctx, userId, func(user *users.User) error {
+		if user.Credentials.HasPassword() && viewerId != userId {
+			return SessionRequired
+		}
+		if patch.Name != nil {
+			user.Name = *patch.Name
+		}
+		if patch.Email != nil && *patch.Email != user.Email {
+			// the password is reset by a mail to this address
+			if user.Credentials.HasPassword() && !validEmail(*patch.Email) {
+				return InvalidEmail
+			}
+			user.Email = *patch.Email
+		}
+		if patch.DisplayName != nil {
+			user.DisplayName = *patch.DisplayName
+		}
+		if patch.AvatarURL != nil {
+			user.AvatarURL = *patch.AvatarURL
+		}
+		if patch.Phone != nil {
+			user.Phone = *patch.Phone
+		}
+		if patch.City != nil {
+			user.City = *patch.City
+		}
+		if patch.Bio != nil {
+			user.Bio = *patch.Bio
+		}
 
-	return user, a.users.Update(userId, user)
+		return validateProfile(*user)
+	})
 }
 
-func (a *AdService) GetUser(userId int64) (users.User, error) {
-	if !a.users.CheckIdExist(userId) {
+func (a *AdService) GetUser(ctx context.Context, userId int64) (users.User, error) {
+	if !a.users.CheckIdExist(ctx, userId) {
 		return users.User{}, DefunctUser
 	}
 
-	res, err := a.users.Get(userId)
+	res, err := a.users.Get(ctx, userId)
 	user := res.(users.User)
 
 	if err != nil {
@@ -219,16 +557,66 @@ func (a *AdService) GetUser(userId int64) (users.User, error) {
 	return user, err
 }
 
-func (a *AdService) DeleteUser(userId int64) error {
-	for _, e := range a.ads.GetArray() {
+// modifyUser changes the stored user with no other write in between, see
+// Repository.Modify.
+func (a *AdService) modifyUser(ctx context.Context, userId int64, change func(user *users.User) error) (users.User, error) {
+	var user users.User
+	_, err := a.users.Modify(ctx, userId, func(e interface{}) (interface{}, error) {
+		user = e.(users.User)
+		if err := change(&user); err != nil {
+			return nil, err
+		}
+		return user, nil
+	})
+
+	return user, err
+}
+
+// DeleteUser deletes the user with its ads. An account is deleted by its
+// user only, viewerId is the user of the session, -1 without one.
+func (a *AdService) DeleteUser(ctx context.Context, userId int64, viewerId int64) error {
+	user, err := a.GetUser(ctx, userId)
+	if err != nil {
+		return err
+	}
+	if user.Credentials.HasPassword() && viewerId != userId {
+		return SessionRequired
+	}
+
+	for _, e := range a.ads.GetArray(ctx) {
 		ad := e.(ads.Ad)
 		if ad.AuthorID == userId {
-			err := a.DeleteAd(ad.ID, userId)
+			err := a.DeleteAd(ctx, ad.ID, userId)
 			if err != nil {
 				return err
 			}
 		}
 	}
 
-	return a.users.Delete(userId)
+	// again, with the favorites it has by now
+	user, err = a.GetUser(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	err = a.forgetFavorites(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	err = a.closeConversations(ctx, func(conversation conversations.Conversation) bool {
+		return conversation.IsParticipant(userId)
+	})
+	if err != nil {
+		return err
+	}
+
+	err = a.users.Delete(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	a.logger.InfoContext(ctx, "user deleted", "user_id", userId)
+
+	return nil
 }
@@ -1,26 +1,32 @@
 package app
 
 import (
+	"context"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/mock"
 	"homework10/internal/ads"
 	"homework10/internal/mocks"
+	"homework10/internal/password"
+	"homework10/internal/query"
+	"homework10/internal/users"
+	"strings"
 	"testing"
 )
 
 func TestAdService_CreateAd(t *testing.T) {
 	repo := &mocks.Repository{}
-	repo.On("Add", mock.Anything).
+	repo.On("Add", mock.Anything, mock.Anything).
 		Return(nil)
-	repo.On("CheckIdExist", mock.Anything).
+	repo.On("CheckIdExist", mock.Anything, mock.Anything).
 		Return(true)
 
 	nextId := int64(0)
 	repo.On("GetNextId", mock.Anything).
-		Return(func() int64 { defer func() { nextId++ }(); return nextId })
+		Return(func(context.Context) int64 { defer func() { nextId++ }(); return nextId })
 
-	app := NewApp(repo, repo)
+	app := NewApp(repo, repo, repo)
 
-	user, _ := app.CreateUser("test user", "test@email")
+	user, _ := app.CreateUser(context.Background(), "test user", "test@email")
 
 	type Test struct {
 		Name      string
@@ -42,9 +48,244 @@ func TestAdService_CreateAd(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		_, err := app.CreateAd(test.ad.Title, test.ad.Text, test.ad.AuthorID)
+		_, err := app.CreateAd(context.Background(), test.ad.Title, test.ad.Text, test.ad.AuthorID)
 		if err != test.ExpectErr {
 			t.Fatalf(`test %q: expect %v got %v`, test.Name, test.ExpectErr, err)
 		}
 	}
 }
+
+func TestAdService_BatchCreateAds(t *testing.T) {
+	userRepo := &mocks.Repository{}
+	userRepo.On("GetBatch", mock.Anything, []int64{0, 1, 0}).
+		Return([]interface{}{users.User{ID: 0}, nil, users.User{ID: 0}}, nil)
+
+	var stored []interface{}
+	adRepo := &mocks.Repository{}
+	adRepo.On("AddBatch", mock.Anything, mock.Anything, mock.Anything).
+		Return(func(_ context.Context, es []interface{}, withId func(e interface{}, id int64) interface{}) ([]int64, error) {
+			ids := make([]int64, len(es))
+			for i, e := range es {
+				ids[i] = int64(5 + i)
+				stored = append(stored, withId(e, ids[i]))
+			}
+			return ids, nil
+		}).Once()
+
+	app := NewApp(adRepo, userRepo, &mocks.Repository{})
+
+	results, err := app.BatchCreateAds(context.Background(), []ads.Ad{
+		{Title: "first", Text: "text", AuthorID: 0},
+		{Title: "second", Text: "text", AuthorID: 1},
+		{Title: "", Text: "text", AuthorID: 0},
+	})
+	if err != nil {
+		t.Fatalf(`test %q: expect nil got %v`, "Batch create", err)
+	}
+
+	if results[0].Err != nil || results[0].Ad.ID != 5 {
+		t.Fatalf(`test %q: expect ad 5 got %+v`, "Valid draft", results[0])
+	}
+	if results[1].Err != DefunctUser {
+		t.Fatalf(`test %q: expect %v got %v`, "Unknown author", DefunctUser, results[1].Err)
+	}
+	if results[2].Err == nil {
+		t.Fatalf(`test %q: expect an error got nil`, "Invalid draft")
+	}
+
+	adRepo.AssertNumberOfCalls(t, "AddBatch", 1)
+	adRepo.AssertNotCalled(t, "Add", mock.Anything, mock.Anything)
+	adRepo.AssertNotCalled(t, "GetNextId", mock.Anything)
+	if len(stored) != 1 || stored[0].(ads.Ad).ID != 5 {
+		t.Fatalf(`test %q: expect ad 5 stored got %v`, "Batch create", stored)
+	}
+}
+
+func TestAdService_AddFavorite_DeletedAd(t *testing.T) {
+	stored := users.User{ID: 0, Name: "test user"}
+
+	userRepo := &mocks.Repository{}
+	userRepo.On("CheckIdExist", mock.Anything, int64(0)).
+		Return(true)
+	userRepo.On("Modify", mock.Anything, int64(0), mock.Anything).
+		Return(func(_ context.Context, _ int64, change func(e interface{}) (interface{}, error)) (interface{}, error) {
+			e, err := change(stored)
+			if err == nil {
+				stored = e.(users.User)
+			}
+			return e, err
+		})
+
+	// the ad is deleted right after it is read
+	adRepo := &mocks.Repository{}
+	adRepo.On("CheckIdExist", mock.Anything, int64(1)).
+		Return(true).Once()
+	adRepo.On("CheckIdExist", mock.Anything, int64(1)).
+		Return(false)
+	adRepo.On("Get", mock.Anything, int64(1)).
+		Return(ads.Ad{ID: 1, Title: "hello"}, nil)
+	adRepo.On("Modify", mock.Anything, int64(1), mock.Anything).
+		Return(nil, errors.New("there is no entity with this id"))
+
+	app := NewApp(adRepo, userRepo, &mocks.Repository{})
+
+	_, err := app.AddFavorite(context.Background(), 0, 1, false)
+	if !errors.Is(err, DefunctAd) {
+		t.Fatalf(`test %q: expect %v got %v`, "Deleted ad", DefunctAd, err)
+	}
+	if len(stored.Favorites) != 0 {
+		t.Fatalf(`test %q: expect %v got %v`, "Deleted ad", "no favorites", stored.Favorites)
+	}
+}
+
+func TestAdService_PatchUser(t *testing.T) {
+	stored := users.User{ID: 0, Name: "test user", Email: "test@email", Credentials: users.Credentials{PasswordHash: "hash"}}
+	updates := 0
+
+	repo := &mocks.Repository{}
+	repo.On("CheckIdExist", mock.Anything, int64(0)).
+		Return(true)
+	repo.On("Find", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return([]interface{}{}, nil)
+	repo.On("Modify", mock.Anything, int64(0), mock.Anything).
+		Return(func(_ context.Context, _ int64, change func(e interface{}) (interface{}, error)) (interface{}, error) {
+			e, err := change(stored)
+			if err == nil {
+				updates++
+			}
+			return e, err
+		})
+
+	app := NewApp(repo, repo, repo)
+
+	text := func(s string) *string { return &s }
+	long := strings.Repeat("я", 101)
+
+	type Test struct {
+		Name      string
+		Viewer    int64
+		Patch     users.Patch
+		ExpectErr error
+	}
+
+	tests := [...]Test{
+		{"Whole profile", 0, users.Patch{DisplayName: text("Test"), AvatarURL: text("https://example.com/a.png"),
+			Phone: text("+7 (900) 123-45-67"), City: text("Moscow"), Bio: text("hello")}, nil},
+		{"Empty fields", 0, users.Patch{AvatarURL: text(""), Phone: text("")}, nil},
+		{"Long display name", 0, users.Patch{DisplayName: text(long)}, InvalidProfile},
+		{"Long city", 0, users.Patch{City: text(long)}, InvalidProfile},
+		{"Long bio", 0, users.Patch{Bio: text(strings.Repeat(long, 5))}, InvalidProfile},
+		{"Letters in phone", 0, users.Patch{Phone: text("call me")}, InvalidProfile},
+		{"Short phone", 0, users.Patch{Phone: text("123")}, InvalidProfile},
+		{"Relative avatar", 0, users.Patch{AvatarURL: text("/a.png")}, InvalidProfile},
+		{"Script avatar", 0, users.Patch{AvatarURL: text("javascript:alert(1)")}, InvalidProfile},
+		{"Same email", 0, users.Patch{Email: text("test@email")}, nil},
+		{"Profile without a session", -1, users.Patch{City: text("Moscow")}, SessionRequired},
+		{"Profile in another session", 1, users.Patch{City: text("Moscow")}, SessionRequired},
+		{"Email without a session", -1, users.Patch{Email: text("other@email")}, SessionRequired},
+		{"Email in another session", 1, users.Patch{Email: text("other@email")}, SessionRequired},
+		{"Invalid email", 0, users.Patch{Email: text("other")}, InvalidEmail},
+		{"Email in the session", 0, users.Patch{Email: text("other@email")}, nil},
+	}
+
+	for _, test := range tests {
+		_, err := app.PatchUser(context.Background(), 0, test.Viewer, test.Patch)
+		if !errors.Is(err, test.ExpectErr) {
+			t.Fatalf(`test %q: expect %v got %v`, test.Name, test.ExpectErr, err)
+		}
+	}
+
+	if updates != 4 {
+		t.Fatalf(`test %q: expect %v got %v`, "Updates", 4, updates)
+	}
+}
+
+func TestAdService_ViewUser(t *testing.T) {
+	user := users.User{ID: 0, Name: "test user", Email: "test@email", Phone: "+7 900 123 45 67",
+		City: "Moscow", Favorites: []users.Favorite{{AdID: 1}}}
+
+	repo := &mocks.Repository{}
+	repo.On("CheckIdExist", mock.Anything, int64(0)).
+		Return(true)
+	repo.On("Get", mock.Anything, int64(0)).
+		Return(user, nil)
+
+	app := NewApp(repo, repo, repo)
+
+	owner, err := app.ViewUser(context.Background(), 0, 0)
+	if err != nil || owner.Email != user.Email || owner.Phone != user.Phone || len(owner.Favorites) != 1 {
+		t.Fatalf(`test %q: expect %+v got %+v, %v`, "Owner", user, owner, err)
+	}
+
+	for _, viewerId := range []int64{1, -1} {
+		public, err := app.ViewUser(context.Background(), 0, viewerId)
+		if err != nil || public.Email != "" || public.Phone != "" || public.Favorites != nil || public.City != user.City {
+			t.Fatalf(`test %q: expect %+v got %+v, %v`, "Public", user.Public(), public, err)
+		}
+	}
+}
+
+// countingHasher stores passwords as they are, and counts the verifications.
+type countingHasher struct {
+	verified int
+}
+
+func (h *countingHasher) Hash(pass string) (string, error) {
+	return "plain:" + pass, nil
+}
+
+func (h *countingHasher) Verify(hash string, pass string) error {
+	h.verified++
+	if !password.Equal([]byte(hash), []byte("plain:"+pass)) {
+		return password.Mismatch
+	}
+	return nil
+}
+
+func TestAdService_Login(t *testing.T) {
+	user := users.User{ID: 0, Name: "test user", Email: "test@email",
+		Credentials: users.Credentials{PasswordHash: "plain:correct horse"}}
+
+	repo := &mocks.Repository{}
+	repo.On("Find", mock.Anything, mock.MatchedBy(func(index query.Index) bool { return index.Name == UsersByEmail.Name }),
+		mock.Anything, mock.Anything).
+		Return([]interface{}{user}, nil)
+	repo.On("Modify", mock.Anything, int64(0), mock.Anything).
+		Return(func(_ context.Context, _ int64, change func(e interface{}) (interface{}, error)) (interface{}, error) {
+			return change(user)
+		})
+
+	hasher := &countingHasher{}
+	app := NewApp(repo, repo, repo, WithHasher(hasher))
+
+	type Test struct {
+		Name      string
+		Email     string
+		Password  string
+		ExpectErr error
+	}
+
+	tests := [...]Test{
+		{"Unknown email", "other@email", "correct horse", InvalidCredentials},
+		{"Wrong password", "test@email", "correct horsf", InvalidCredentials},
+		{"Other case of email", "TEST@email", "correct horse", nil},
+		{"Right password", "test@email", "correct horse", nil},
+	}
+
+	for _, test := range tests {
+		// an unknown email costs a verification too, so that the time of the
+		// answer does not tell which accounts exist
+		before := hasher.verified
+
+		session, err := app.Login(context.Background(), test.Email, test.Password)
+		if !errors.Is(err, test.ExpectErr) {
+			t.Fatalf(`test %q: expect %v got %v`, test.Name, test.ExpectErr, err)
+		}
+		if hasher.verified-before != 1 {
+			t.Fatalf(`test %q: expect %v got %v`, test.Name, "1 verification", hasher.verified-before)
+		}
+		if err == nil && (session.Token == "" || session.UserID != user.ID) {
+			t.Fatalf(`test %q: expect a session of user %d got %+v`, test.Name, user.ID, session)
+		}
+	}
+}
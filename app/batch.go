@@ -0,0 +1,209 @@
+package app
+
+import (
+	"context"
+	validator "github.com/Vdaleke/ad-validation"
+	"github.com/pkg/errors"
+	"homework10/internal/ads"
+	"time"
+)
+
+// MaxBatchSize is the most items a batch call accepts.
+const MaxBatchSize = 100
+
+var BatchTooLarge = errors.New("too many items in the batch")
+
+// existingUsers looks the users up with a single repository call.
+func (a *AdService) existingUsers(ctx context.Context, userIds []int64) (map[int64]bool, error) {
+	res, err := a.users.GetBatch(ctx, userIds)
+	if err != nil {
+		return nil, err
+	}
+
+	exist := make(map[int64]bool, len(userIds))
+	for i, e := range res {
+		if e != nil {
+			exist[userIds[i]] = true
+		}
+	}
+
+	return exist, nil
+}
+
+// BatchCreateAds creates the valid drafts, given by their title, text and
+// author, with a single repository call.
+func (a *AdService) BatchCreateAds(ctx context.Context, drafts []ads.Ad) ([]ads.Result, error) {
+	if len(drafts) > MaxBatchSize {
+		return nil, BatchTooLarge
+	}
+
+	authorIds := make([]int64, len(drafts))
+	for i, draft := range drafts {
+		authorIds[i] = draft.AuthorID
+	}
+
+	authors, err := a.existingUsers(ctx, authorIds)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]ads.Result, len(drafts))
+	created := make([]interface{}, 0, len(drafts))
+	// the indexes of the drafts in created
+	indexes := make([]int, 0, len(drafts))
+	now := time.Now().UTC()
+
+	for i, draft := range drafts {
+		if !authors[draft.AuthorID] {
+			results[i].Err = DefunctUser
+			continue
+		}
+
+		if err := validator.ValidateAd(draft.Title, draft.Text); err != nil {
+			results[i].Err = err
+			continue
+		}
+
+		ad := ads.Ad{Title: draft.Title, Text: draft.Text, AuthorID: draft.AuthorID, CreatedAt: now}
+		a.addRevision(&ad, ad.CreatedAt)
+
+		created = append(created, ad)
+		indexes = append(indexes, i)
+	}
+
+	if len(created) == 0 {
+		return results, nil
+	}
+
+	// the IDs are given by the repository, so that no other ad takes them
+	ids, err := a.ads.AddBatch(ctx, created, func(e interface{}, id int64) interface{} {
+		ad := e.(ads.Ad)
+		ad.ID = id
+		return ad
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for j, e := range created {
+		ad := e.(ads.Ad)
+		ad.ID = ids[j]
+		results[indexes[j]].Ad = ad
+		a.logger.InfoContext(ctx, "ad created", "ad_id", ad.ID, "user_id", ad.AuthorID)
+	}
+
+	return results, nil
+}
+
+func (a *AdService) BatchGetAds(ctx context.Context, adIds []int64) ([]ads.Result, error) {
+	if len(adIds) > MaxBatchSize {
+		return nil, BatchTooLarge
+	}
+
+	res, err := a.ads.GetBatch(ctx, adIds)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]ads.Result, len(adIds))
+	for i, e := range res {
+		if e == nil {
+			results[i].Err = DefunctAd
+			continue
+		}
+		results[i].Ad = e.(ads.Ad)
+	}
+
+	return results, nil
+}
+
+// BatchChangeAdStatus applies the changes in order, so a later change of the
+// same ad wins, and stores the changed ads with a single repository call.
+func (a *AdService) BatchChangeAdStatus(ctx context.Context, changes []ads.StatusChange) ([]ads.Result, error) {
+	if len(changes) > MaxBatchSize {
+		return nil, BatchTooLarge
+	}
+
+	adIds := make([]int64, len(changes))
+	userIds := make([]int64, len(changes))
+	for i, change := range changes {
+		adIds[i], userIds[i] = change.AdID, change.UserID
+	}
+
+	authors, err := a.existingUsers(ctx, userIds)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := a.ads.GetBatch(ctx, adIds)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]ads.Result, len(changes))
+	wasPublished := make([]bool, len(changes))
+	changed := make(map[int64]ads.Ad, len(changes))
+	var ids []int64
+
+	for i, change := range changes {
+		if !authors[change.UserID] {
+			results[i].Err = DefunctUser
+			continue
+		}
+
+		ad, ok := changed[change.AdID]
+		if !ok {
+			if res[i] == nil {
+				results[i].Err = DefunctAd
+				continue
+			}
+			ad = res[i].(ads.Ad)
+		}
+
+		if ad.AuthorID != change.UserID {
+			results[i].Err = PermissionDenied
+			continue
+		}
+
+		if !ok {
+			ids = append(ids, change.AdID)
+		}
+
+		wasPublished[i] = ad.Published
+		ad.Published = change.Published
+		changed[change.AdID] = ad
+		results[i].Ad = ad
+	}
+
+	if len(ids) == 0 {
+		return results, nil
+	}
+
+	es := make([]interface{}, len(ids))
+	for i, id := range ids {
+		es[i] = changed[id]
+	}
+
+	err = a.ads.UpdateBatch(ctx, ids, es)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, result := range results {
+		if result.Err != nil || result.Ad.Published == wasPublished[i] {
+			continue
+		}
+
+		ad := result.Ad
+		a.logger.InfoContext(ctx, "ad status changed", "ad_id", ad.ID, "published", ad.Published)
+
+		if ad.Published {
+			a.notifyFavorites(ctx, ad, "has been published")
+			a.notifySavedSearches(ctx, ad)
+		} else {
+			a.notifyFavorites(ctx, ad, "has been unpublished")
+		}
+	}
+
+	return results, nil
+}
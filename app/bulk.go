@@ -0,0 +1,163 @@
+package app
+
+import (
+	"context"
+	validator "github.com/Vdaleke/ad-validation"
+	"github.com/pkg/errors"
+	"homework10/internal/ads"
+	"homework10/internal/users"
+	"time"
+)
+
+// NewId, or any negative ID, is the ID of an imported record written by
+// hand rather than exported, which gets the next free ID.
+const NewId int64 = -1
+
+var IdTaken = errors.New("the ID of the imported record is already taken")
+
+// ImportAd adds an ad read from an export: it keeps the ID, so that users
+// imported before keep their ads, the status and the times, and starts with
+// a single revision. With dryRun the ad is only checked.
+func (a *AdService) ImportAd(ctx context.Context, ad ads.Ad, dryRun bool) (ads.Ad, error) {
+	if !a.users.CheckIdExist(ctx, ad.AuthorID) {
+		return ads.Ad{}, DefunctUser
+	}
+
+	keepId := ad.ID >= 0
+	if keepId && a.ads.CheckIdExist(ctx, ad.ID) {
+		return ad, IdTaken
+	}
+
+	err := validator.ValidateAd(ad.Title, ad.Text)
+	if err != nil {
+		return ad, err
+	}
+
+	if ad.CreatedAt.IsZero() {
+		ad.CreatedAt = time.Now().UTC()
+	}
+
+	// a zero update time means the ad was never updated
+	revisionTime := ad.UpdatedAt
+	if revisionTime.IsZero() {
+		revisionTime = ad.CreatedAt
+	}
+
+	if !keepId {
+		ad.ID = a.ads.GetNextId(ctx)
+	}
+	ad.Favorites = 0
+	ad.Revisions = nil
+	a.addRevision(&ad, revisionTime)
+
+	if dryRun {
+		return ad, nil
+	}
+
+	if keepId {
+		err = a.ads.Insert(ctx, ad.ID, ad)
+	} else {
+		err = a.ads.Add(ctx, ad)
+	}
+	if err != nil {
+		return ad, err
+	}
+
+	a.logger.InfoContext(ctx, "ad imported", "ad_id", ad.ID, "user_id", ad.AuthorID)
+
+	return ad, nil
+}
+
+// ImportUser adds a user read from an export under the same ID, keeping
+// the profile and the registration time.
+func (a *AdService) ImportUser(ctx context.Context, user users.User, dryRun bool) (users.User, error) {
+	keepId := user.ID >= 0
+	if keepId && a.users.CheckIdExist(ctx, user.ID) {
+		return user, IdTaken
+	} else if !keepId {
+		user.ID = a.users.GetNextId(ctx)
+	}
+
+	user = users.User{
+		ID:           user.ID,
+		Name:         user.Name,
+		Email:        user.Email,
+		DisplayName:  user.DisplayName,
+		AvatarURL:    user.AvatarURL,
+		Phone:        user.Phone,
+		City:         user.City,
+		Bio:          user.Bio,
+		RegisteredAt: user.RegisteredAt,
+	}
+	if user.RegisteredAt.IsZero() {
+		user.RegisteredAt = time.Now().UTC()
+	}
+
+	err := validateProfile(user)
+	if err != nil {
+		return user, err
+	}
+
+	if dryRun {
+		return user, nil
+	}
+
+	if keepId {
+		err = a.users.Insert(ctx, user.ID, user)
+	} else {
+		err = a.users.Add(ctx, user)
+	}
+	if err != nil {
+		return user, err
+	}
+
+	a.logger.InfoContext(ctx, "user imported", "user_id", user.ID)
+
+	return user, nil
+}
+
+// ExportAds passes all ads, published or not, ordered by ID, to emit one
+// at a time, and stops at the first error of emit.
+func (a *AdService) ExportAds(ctx context.Context, emit func(ads.Ad) error) error {
+	return exportEach(ctx, a.ads, func(e interface{}) error { return emit(e.(ads.Ad)) })
+}
+
+// ExportUsers passes all users ordered by ID to emit, as ExportAds does.
+func (a *AdService) ExportUsers(ctx context.Context, emit func(users.User) error) error {
+	return exportEach(ctx, a.users, func(e interface{}) error { return emit(e.(users.User)) })
+}
+
+// exportEach passes the entities of repo ordered by ID to emit, looking up
+// a page of IDs at a time so that only one page is held in memory. The
+// entities added after it starts are left out.
+func exportEach(ctx context.Context, repo Repository, emit func(e interface{}) error) error {
+	end := repo.GetNextId(ctx)
+	ids := make([]int64, 0, MaxBatchSize)
+
+	for from := int64(0); from < end; from += MaxBatchSize {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		ids = ids[:0]
+		for id := from; id < min(from+MaxBatchSize, end); id++ {
+			ids = append(ids, id)
+		}
+
+		res, err := repo.GetBatch(ctx, ids)
+		if err != nil {
+			return err
+		}
+
+		for _, e := range res {
+			if e == nil {
+				continue
+			}
+			if err := emit(e); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,182 @@
+package app
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"homework10/internal/conversations"
+	"time"
+	"unicode/utf8"
+)
+
+const maxMessageLength = 500
+
+var DefunctConversation = errors.New("there is no conversation with this ID")
+var ClosedConversation = errors.New("the conversation has been closed")
+var OwnAd = errors.New("the user cannot start a conversation about their own ad")
+var InvalidMessage = errors.New("the message must contain from 1 to 500 characters")
+
+func (a *AdService) OpenConversation(ctx context.Context, adId int64, userId int64) (conversations.Conversation, error) {
+	if !a.users.CheckIdExist(ctx, userId) {
+		return conversations.Conversation{}, DefunctUser
+	}
+
+	ad, err := a.GetAd(ctx, adId)
+	if err != nil {
+		return conversations.Conversation{}, err
+	}
+
+	if ad.AuthorID == userId {
+		return conversations.Conversation{}, OwnAd
+	}
+
+	for _, e := range a.conversations.GetArray(ctx) {
+		conversation := e.(conversations.Conversation)
+		if conversation.AdID == adId && conversation.BuyerID == userId && !conversation.Closed {
+			return conversation, nil
+		}
+	}
+
+	conversation := conversations.Conversation{
+		ID:        a.conversations.GetNextId(ctx),
+		AdID:      adId,
+		BuyerID:   userId,
+		SellerID:  ad.AuthorID,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	return conversation, a.conversations.Add(ctx, conversation)
+}
+
+func (a *AdService) ListConversations(ctx context.Context, userId int64) ([]conversations.Conversation, error) {
+	if !a.users.CheckIdExist(ctx, userId) {
+		return nil, DefunctUser
+	}
+
+	userConversations := make([]conversations.Conversation, 0)
+	for _, e := range a.conversations.GetArray(ctx) {
+		conversation := e.(conversations.Conversation)
+		if conversation.IsParticipant(userId) {
+			userConversations = append(userConversations, conversation)
+		}
+	}
+
+	return userConversations, nil
+}
+
+func (a *AdService) SendMessage(ctx context.Context, conversationId int64, userId int64, text string) (conversations.Message, error) {
+	var message conversations.Message
+	_, err := a.modifyConversation(ctx, conversationId, userId, func(conversation *conversations.Conversation) error {
+		if conversation.Closed {
+			return ClosedConversation
+		}
+
+		if length := utf8.RuneCountInString(text); length == 0 || length > maxMessageLength {
+			return InvalidMessage
+		}
+
+		nextId := int64(1)
+		if len(conversation.Messages) > 0 {
+			nextId = conversation.Messages[len(conversation.Messages)-1].ID + 1
+		}
+
+		message = conversations.Message{ID: nextId, AuthorID: userId, Text: text, CreatedAt: time.Now().UTC()}
+
+		messages := make([]conversations.Message, 0, len(conversation.Messages)+1)
+		messages = append(messages, conversation.Messages...)
+		conversation.Messages = append(messages, message)
+		markRead(conversation, userId)
+		return nil
+	})
+	if err != nil {
+		return conversations.Message{}, err
+	}
+
+	return message, nil
+}
+
+func (a *AdService) ListMessages(ctx context.Context, conversationId int64, userId int64) ([]conversations.Message, error) {
+	conversation, err := a.modifyConversation(ctx, conversationId, userId, func(conversation *conversations.Conversation) error {
+		markRead(conversation, userId)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]conversations.Message, len(conversation.Messages))
+	copy(messages, conversation.Messages)
+
+	return messages, nil
+}
+
+func (a *AdService) CloseConversation(ctx context.Context, conversationId int64, userId int64) (conversations.Conversation, error) {
+	return a.modifyConversation(ctx, conversationId, userId, func(conversation *conversations.Conversation) error {
+		if conversation.SellerID != userId {
+			return PermissionDenied
+		}
+
+		conversation.Closed = true
+		return nil
+	})
+}
+
+// modifyConversation changes the stored conversation of a participant with
+// no other write in between, see Repository.Modify.
+func (a *AdService) modifyConversation(ctx context.Context, conversationId int64, userId int64,
+	change func(conversation *conversations.Conversation) error) (conversations.Conversation, error) {
+	if !a.users.CheckIdExist(ctx, userId) {
+		return conversations.Conversation{}, DefunctUser
+	}
+	if !a.conversations.CheckIdExist(ctx, conversationId) {
+		return conversations.Conversation{}, DefunctConversation
+	}
+
+	var conversation conversations.Conversation
+	_, err := a.conversations.Modify(ctx, conversationId, func(e interface{}) (interface{}, error) {
+		conversation = e.(conversations.Conversation)
+		if !conversation.IsParticipant(userId) {
+			return nil, PermissionDenied
+		}
+		if err := change(&conversation); err != nil {
+			return nil, err
+		}
+		return conversation, nil
+	})
+
+	return conversation, err
+}
+
+// closeConversations closes every conversation matching the filter, used
+// when an ad or one of the participants is deleted.
+func (a *AdService) closeConversations(ctx context.Context, match func(conversation conversations.Conversation) bool) error {
+	for _, e := range a.conversations.GetArray(ctx) {
+		conversation := e.(conversations.Conversation)
+		if conversation.Closed || !match(conversation) {
+			continue
+		}
+
+		_, err := a.conversations.Modify(ctx, conversation.ID, func(e interface{}) (interface{}, error) {
+			conversation := e.(conversations.Conversation)
+			conversation.Closed = true
+			return conversation, nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func markRead(conversation *conversations.Conversation, userId int64) {
+	if len(conversation.Messages) == 0 {
+		return
+	}
+
+	lastId := conversation.Messages[len(conversation.Messages)-1].ID
+	if userId == conversation.SellerID {
+		conversation.SellerRead = lastId
+	} else {
+		conversation.BuyerRead = lastId
+	}
+}
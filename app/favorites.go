@@ -0,0 +1,248 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"homework10/internal/ads"
+	"homework10/internal/users"
+	"time"
+)
+
+var DefunctFavorite = errors.New("the ad is not in the user's favorites")
+
+func (a *AdService) AddFavorite(ctx context.Context, userId int64, adId int64, notify bool) (ads.Ad, error) {
+	if !a.users.CheckIdExist(ctx, userId) {
+		return ads.Ad{}, DefunctUser
+	}
+
+	ad, err := a.GetAd(ctx, adId)
+	if err != nil {
+		return ad, err
+	}
+
+	var added bool
+	_, err = a.modifyUser(ctx, userId, func(user *users.User) error {
+		for i, favorite := range user.Favorites {
+			if favorite.AdID == adId {
+				favorites := make([]users.Favorite, len(user.Favorites))
+				copy(favorites, user.Favorites)
+				favorites[i].Notify = notify
+				user.Favorites = favorites
+
+				added = false
+				return nil
+			}
+		}
+
+		favorites := make([]users.Favorite, 0, len(user.Favorites)+1)
+		favorites = append(favorites, user.Favorites...)
+		user.Favorites = append(favorites, users.Favorite{AdID: adId, Notify: notify, CreatedAt: time.Now().UTC()})
+
+		added = true
+		return nil
+	})
+	if err != nil || !added {
+		return ad, err
+	}
+
+	ad, err = a.modifyAd(ctx, adId, func(ad *ads.Ad) error {
+		ad.Favorites++
+		return nil
+	})
+	if err != nil {
+		// the ad may have been deleted after its favorites were dropped
+		return ad, a.undoFavorite(ctx, userId, adId, err)
+	}
+
+	return ad, nil
+}
+
+// undoFavorite removes the favorite added for an ad whose counter failed to
+// change, err is the reason.
+func (a *AdService) undoFavorite(ctx context.Context, userId int64, adId int64, err error) error {
+	_, undoErr := a.modifyUser(ctx, userId, func(user *users.User) error {
+		favorites := make([]users.Favorite, 0, len(user.Favorites))
+		for _, favorite := range user.Favorites {
+			if favorite.AdID != adId {
+				favorites = append(favorites, favorite)
+			}
+		}
+
+		user.Favorites = favorites
+		return nil
+	})
+	if undoErr != nil {
+		return undoErr
+	}
+
+	if !a.ads.CheckIdExist(ctx, adId) {
+		return DefunctAd
+	}
+	return err
+}
+
+func (a *AdService) RemoveFavorite(ctx context.Context, userId int64, adId int64) (ads.Ad, error) {
+	if !a.users.CheckIdExist(ctx, userId) {
+		return ads.Ad{}, DefunctUser
+	}
+
+	ad, err := a.GetAd(ctx, adId)
+	if err != nil {
+		return ad, err
+	}
+
+	var removed users.Favorite
+	_, err = a.modifyUser(ctx, userId, func(user *users.User) error {
+		favorites := make([]users.Favorite, 0, len(user.Favorites))
+		for _, favorite := range user.Favorites {
+			if favorite.AdID != adId {
+				favorites = append(favorites, favorite)
+			} else {
+				removed = favorite
+			}
+		}
+
+		if len(favorites) == len(user.Favorites) {
+			return DefunctFavorite
+		}
+
+		user.Favorites = favorites
+		return nil
+	})
+	if err != nil {
+		return ad, err
+	}
+
+	changed, err := a.modifyAd(ctx, adId, func(ad *ads.Ad) error {
+		if ad.Favorites > 0 {
+			ad.Favorites--
+		}
+		return nil
+	})
+	if err != nil && !a.ads.CheckIdExist(ctx, adId) {
+		// deleted meanwhile, there is no counter to change
+		return ad, nil
+	} else if err != nil {
+		// puts the favorite back, so that it stays counted
+		_, undoErr := a.modifyUser(ctx, userId, func(user *users.User) error {
+			if hasFavorite(*user, adId) {
+				return nil
+			}
+			favorites := make([]users.Favorite, 0, len(user.Favorites)+1)
+			favorites = append(favorites, user.Favorites...)
+			user.Favorites = append(favorites, removed)
+			return nil
+		})
+		if undoErr != nil {
+			return ad, undoErr
+		}
+		return ad, err
+	}
+
+	return changed, nil
+}
+
+func (a *AdService) ListFavorites(ctx context.Context, userId int64) ([]ads.Ad, error) {
+	user, err := a.GetUser(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	favoriteAds := make([]ads.Ad, 0, len(user.Favorites))
+	for _, favorite := range user.Favorites {
+		ad, err := a.GetAd(ctx, favorite.AdID)
+		if errors.Is(err, DefunctAd) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		favoriteAds = append(favoriteAds, ad)
+	}
+
+	return favoriteAds, nil
+}
+
+// notifyFavorites notifies every user who has the ad in favorites with
+// notifications turned on, except for the author of the change.
+func (a *AdService) notifyFavorites(ctx context.Context, ad ads.Ad, event string) {
+	for _, e := range a.users.GetArray(ctx) {
+		user := e.(users.User)
+		if user.ID == ad.AuthorID {
+			continue
+		}
+
+		for _, favorite := range user.Favorites {
+			if favorite.AdID == ad.ID && favorite.Notify {
+				a.notify(ctx, user, ad.ID, fmt.Sprintf("the ad %q %s", ad.Title, event))
+				break
+			}
+		}
+	}
+}
+
+// dropFavorites removes a deleted ad from the favorites of all users,
+// notifying those who asked to follow it.
+func (a *AdService) dropFavorites(ctx context.Context, ad ads.Ad) error {
+	for _, e := range a.users.GetArray(ctx) {
+		if !hasFavorite(e.(users.User), ad.ID) {
+			continue
+		}
+
+		var notify bool
+		user, err := a.modifyUser(ctx, e.(users.User).ID, func(user *users.User) error {
+			favorites := make([]users.Favorite, 0, len(user.Favorites))
+			for _, favorite := range user.Favorites {
+				if favorite.AdID == ad.ID {
+					notify = favorite.Notify
+				} else {
+					favorites = append(favorites, favorite)
+				}
+			}
+
+			user.Favorites = favorites
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		if notify && user.ID != ad.AuthorID {
+			a.notify(ctx, user, ad.ID, fmt.Sprintf("the ad %q has been deleted", ad.Title))
+		}
+	}
+
+	return nil
+}
+
+func hasFavorite(user users.User, adId int64) bool {
+	for _, favorite := range user.Favorites {
+		if favorite.AdID == adId {
+			return true
+		}
+	}
+	return false
+}
+
+// forgetFavorites decrements the favorite counters of the ads a user has
+// bookmarked, used when the user is deleted.
+func (a *AdService) forgetFavorites(ctx context.Context, user users.User) error {
+	for _, favorite := range user.Favorites {
+		if !a.ads.CheckIdExist(ctx, favorite.AdID) {
+			continue
+		}
+
+		_, err := a.modifyAd(ctx, favorite.AdID, func(ad *ads.Ad) error {
+			if ad.Favorites > 0 {
+				ad.Favorites--
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,41 @@
+package app
+
+import (
+	"hash/fnv"
+	"homework10/internal/ads"
+	"homework10/internal/query"
+	"homework10/internal/users"
+	"strings"
+)
+
+// The indexes of the ads repository used by ListAds.
+var (
+	AdsByAuthor = query.Index{Name: "author", Key: func(e interface{}) int64 {
+		return e.(ads.Ad).AuthorID
+	}}
+	AdsByPublished = query.Index{Name: "published", Key: func(e interface{}) int64 {
+		return publishedKey(e.(ads.Ad).Published)
+	}}
+	AdsByCreationTime = query.Index{Name: "creation_time", Ordered: true, Key: func(e interface{}) int64 {
+		return e.(ads.Ad).CreatedAt.UnixNano()
+	}}
+)
+
+func publishedKey(published bool) int64 {
+	if published {
+		return 1
+	}
+	return 0
+}
+
+// UsersByEmail is the index of the users repository used to find an account
+// by email. The key is a hash of the email, so users sharing it are checked.
+var UsersByEmail = query.Index{Name: "email", Key: func(e interface{}) int64 {
+	return emailKey(e.(users.User).Email)
+}}
+
+func emailKey(email string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(strings.ToLower(email)))
+	return int64(h.Sum64())
+}
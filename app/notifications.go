@@ -0,0 +1,85 @@
+package app
+
+import (
+	"context"
+	"homework10/internal/users"
+	"time"
+)
+
+// inAppNotifier keeps notifications in the inbox of the user, so that they
+// can be listed with ListNotifications.
+type inAppNotifier struct {
+	users Repository
+}
+
+func (n *inAppNotifier) Notify(ctx context.Context, user users.User, notification users.Notification) error {
+	_, err := n.users.Modify(ctx, user.ID, func(e interface{}) (interface{}, error) {
+		user := e.(users.User)
+
+		notification.ID = 1
+		if len(user.Notifications) > 0 {
+			notification.ID = user.Notifications[len(user.Notifications)-1].ID + 1
+		}
+
+		notifications := make([]users.Notification, 0, len(user.Notifications)+1)
+		notifications = append(notifications, user.Notifications...)
+		user.Notifications = append(notifications, notification)
+
+		return user, nil
+	})
+
+	return err
+}
+
+func (a *AdService) ListNotifications(ctx context.Context, userId int64) ([]users.Notification, error) {
+	user, err := a.GetUser(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	notifications := make([]users.Notification, len(user.Notifications))
+	copy(notifications, user.Notifications)
+
+	return notifications, nil
+}
+
+// notify writes the notification to the inbox of the user and queues it
+// for the other notifiers, so that a slow one, like the email, does not hold
+// the change that triggered it. Delivery is best effort: a failing notifier
+// must not fail the change, and a notification is dropped when the queue is
+// full.
+func (a *AdService) notify(ctx context.Context, user users.User, adId int64, text string) {
+	notification := users.Notification{AdID: adId, Text: text, CreatedAt: time.Now().UTC()}
+
+	if err := a.inbox.Notify(ctx, user, notification); err != nil {
+		a.logger.WarnContext(ctx, "can't notify user", "user_id", user.ID, "error", err.Error())
+	}
+
+	if len(a.notifiers) == 0 {
+		return
+	}
+
+	select {
+	case a.deliveries <- delivery{ctx: context.WithoutCancel(ctx), user: user, notification: notification}:
+	default:
+		a.logger.WarnContext(ctx, "notification queue is full", "user_id", user.ID)
+	}
+}
+
+// delivery is a notification waiting in the queue of notify.
+type delivery struct {
+	ctx          context.Context
+	user         users.User
+	notification users.Notification
+}
+
+// deliver passes the queued notifications to the notifiers one by one.
+func (a *AdService) deliver() {
+	for d := range a.deliveries {
+		for _, notifier := range a.notifiers {
+			if err := notifier.Notify(d.ctx, d.user, d.notification); err != nil {
+				a.logger.WarnContext(d.ctx, "can't notify user", "user_id", d.user.ID, "error", err.Error())
+			}
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package app
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"homework10/internal/users"
+	"net/url"
+	"regexp"
+	"unicode/utf8"
+)
+
+const (
+	maxProfileFieldLength = 100
+	maxBioLength          = 500
+)
+
+var InvalidProfile = errors.New("the profile of the user is invalid")
+
+var phonePattern = regexp.MustCompile(`^\+?[0-9 ()-]{5,20}$`)
+
+// validateProfile checks the optional fields of the profile, an empty one is
+// always valid.
+func validateProfile(user users.User) error {
+	if utf8.RuneCountInString(user.DisplayName) > maxProfileFieldLength {
+		return errors.Wrap(InvalidProfile, "the display name must contain up to 100 characters")
+	}
+	if utf8.RuneCountInString(user.City) > maxProfileFieldLength {
+		return errors.Wrap(InvalidProfile, "the city must contain up to 100 characters")
+	}
+	if utf8.RuneCountInString(user.Bio) > maxBioLength {
+		return errors.Wrap(InvalidProfile, "the bio must contain up to 500 characters")
+	}
+	if user.Phone != "" && !phonePattern.MatchString(user.Phone) {
+		return errors.Wrap(InvalidProfile, "the phone must contain digits, spaces, dashes and parentheses only")
+	}
+	if user.AvatarURL != "" {
+		u, err := url.Parse(user.AvatarURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.Wrap(InvalidProfile, "the avatar must be an http or https URL")
+		}
+	}
+
+	return nil
+}
+
+// ViewUser returns the user as seen by the viewer: the owner sees the whole
+// profile, anybody else, e.g. a viewer with a negative ID, the public one.
+func (a *AdService) ViewUser(ctx context.Context, userId int64, viewerId int64) (users.User, error) {
+	user, err := a.GetUser(ctx, userId)
+	if err != nil {
+		return users.User{}, err
+	}
+
+	if viewerId != userId {
+		return user.Public(), nil
+	}
+	return user, nil
+}
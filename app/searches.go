@@ -0,0 +1,98 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"homework10/internal/ads"
+	"homework10/internal/users"
+	"strings"
+	"time"
+)
+
+var DefunctSavedSearch = errors.New("there is no saved search with this ID")
+
+func (a *AdService) SaveSearch(ctx context.Context, userId int64, pattern string, authorFilter int64) (users.SavedSearch, error) {
+	if !a.users.CheckIdExist(ctx, userId) {
+		return users.SavedSearch{}, DefunctUser
+	}
+
+	var search users.SavedSearch
+	_, err := a.modifyUser(ctx, userId, func(user *users.User) error {
+		nextId := int64(1)
+		if len(user.SavedSearches) > 0 {
+			nextId = user.SavedSearches[len(user.SavedSearches)-1].ID + 1
+		}
+
+		search = users.SavedSearch{ID: nextId, Pattern: pattern, AuthorID: authorFilter, CreatedAt: time.Now().UTC()}
+
+		searches := make([]users.SavedSearch, 0, len(user.SavedSearches)+1)
+		searches = append(searches, user.SavedSearches...)
+		user.SavedSearches = append(searches, search)
+		return nil
+	})
+
+	return search, err
+}
+
+func (a *AdService) ListSavedSearches(ctx context.Context, userId int64) ([]users.SavedSearch, error) {
+	user, err := a.GetUser(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	searches := make([]users.SavedSearch, len(user.SavedSearches))
+	copy(searches, user.SavedSearches)
+
+	return searches, nil
+}
+
+func (a *AdService) DeleteSavedSearch(ctx context.Context, userId int64, searchId int64) error {
+	if !a.users.CheckIdExist(ctx, userId) {
+		return DefunctUser
+	}
+
+	_, err := a.modifyUser(ctx, userId, func(user *users.User) error {
+		searches := make([]users.SavedSearch, 0, len(user.SavedSearches))
+		for _, search := range user.SavedSearches {
+			if search.ID != searchId {
+				searches = append(searches, search)
+			}
+		}
+
+		if len(searches) == len(user.SavedSearches) {
+			return DefunctSavedSearch
+		}
+
+		user.SavedSearches = searches
+		return nil
+	})
+
+	return err
+}
+
+// notifySavedSearches is called when an unpublished ad gets published
+// and notifies the users whose saved searches match it, once per user.
+func (a *AdService) notifySavedSearches(ctx context.Context, ad ads.Ad) {
+	for _, e := range a.users.GetArray(ctx) {
+		user := e.(users.User)
+		if user.ID == ad.AuthorID {
+			continue
+		}
+
+		for _, search := range user.SavedSearches {
+			if matchesSearch(search, ad) {
+				a.notify(ctx, user, ad.ID, fmt.Sprintf("the new ad %q matches your search %q", ad.Title, search.Pattern))
+				break
+			}
+		}
+	}
+}
+
+// matchesSearch applies the same rules as SearchAds and the author filter of
+// ListAds, where -1 stands for any author.
+func matchesSearch(search users.SavedSearch, ad ads.Ad) bool {
+	return ad.Published &&
+		(search.AuthorID == -1 || search.AuthorID == ad.AuthorID) &&
+		strings.Contains(ad.Title, search.Pattern)
+}
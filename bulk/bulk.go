@@ -0,0 +1,273 @@
+// Package bulk imports and exports ads and users as JSON lines or CSV,
+// one record at a time so that large files are never held in memory.
+package bulk
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"homework10/internal/ads"
+	"homework10/internal/app"
+	"homework10/internal/users"
+	"io"
+)
+
+type Format string
+
+const (
+	JSONL Format = "jsonl"
+	CSV   Format = "csv"
+)
+
+type Kind string
+
+const (
+	Ads   Kind = "ads"
+	Users Kind = "users"
+)
+
+// MaxReportErrors caps the line errors kept in a report, the failed lines
+// are still counted.
+const MaxReportErrors = 1000
+
+// MaxLineLength caps a line of JSON lines, a longer one is reported and
+// skipped without being held in memory.
+const MaxLineLength = 64 * 1024
+
+var (
+	UnknownFormat = errors.New("unknown format")
+	UnknownKind   = errors.New("unknown kind")
+	MissingColumn = errors.New("missing CSV column")
+	InvalidHeader = errors.New("invalid CSV header")
+	LineTooLong   = errors.New("the line is too long")
+)
+
+type LineError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+type Report struct {
+	DryRun   bool        `json:"dry_run"`
+	Total    int         `json:"total"`
+	Imported int         `json:"imported"`
+	Failed   int         `json:"failed"`
+	Errors   []LineError `json:"errors"`
+}
+
+func (r *Report) fail(line int, err error) {
+	r.Failed++
+	if len(r.Errors) < MaxReportErrors {
+		r.Errors = append(r.Errors, LineError{Line: line, Error: err.Error()})
+	}
+}
+
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case JSONL, CSV:
+		return f, nil
+	default:
+		return "", fmt.Errorf("%w %q", UnknownFormat, s)
+	}
+}
+
+func ParseKind(s string) (Kind, error) {
+	switch k := Kind(s); k {
+	case Ads, Users:
+		return k, nil
+	default:
+		return "", fmt.Errorf("%w %q", UnknownKind, s)
+	}
+}
+
+func ContentType(f Format) string {
+	if f == CSV {
+		return "text/csv; charset=utf-8"
+	}
+
+	return "application/x-ndjson"
+}
+
+func newRecord(kind Kind) record {
+	if kind == Ads {
+		return &adRecord{}
+	}
+
+	return &userRecord{}
+}
+
+func columns(kind Kind) (all []string, required []string) {
+	if kind == Ads {
+		return adColumns, []string{"title", "text", "author_id"}
+	}
+
+	return userColumns, []string{"name", "email"}
+}
+
+// Import adds the records of r one by one. A record that can't be parsed or
+// is rejected by the service, e.g. by validator.ValidateAd, is reported with
+// its line and doesn't stop the import. With dryRun nothing is stored.
+func Import(ctx context.Context, a app.App, r io.Reader, kind Kind, format Format, dryRun bool) (Report, error) {
+	report := Report{DryRun: dryRun, Errors: []LineError{}}
+
+	handle := func(line int, rec record, err error) {
+		report.Total++
+		if err == nil {
+			err = rec.importInto(ctx, a, dryRun)
+		}
+
+		if err != nil {
+			report.fail(line, err)
+		} else {
+			report.Imported++
+		}
+	}
+
+	var err error
+	switch format {
+	case JSONL:
+		err = readJSONL(ctx, r, kind, handle)
+	case CSV:
+		err = readCSV(ctx, r, kind, handle)
+	default:
+		err = fmt.Errorf("%w %q", UnknownFormat, format)
+	}
+
+	return report, err
+}
+
+func readJSONL(ctx context.Context, r io.Reader, kind Kind, handle func(int, record, error)) error {
+	br := bufio.NewReaderSize(r, MaxLineLength)
+
+	for line := 1; ; line++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		data, err := br.ReadSlice('\n')
+		if errors.Is(err, bufio.ErrBufferFull) {
+			handle(line, nil, fmt.Errorf("%w, the most is %d bytes", LineTooLong, MaxLineLength))
+			err = skipLine(br)
+		} else if len(bytes.TrimSpace(data)) > 0 {
+			rec := newRecord(kind)
+			handle(line, rec, json.Unmarshal(data, rec))
+		}
+
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+// skipLine reads the rest of a line longer than the buffer of br.
+func skipLine(br *bufio.Reader) error {
+	for {
+		_, err := br.ReadSlice('\n')
+		if !errors.Is(err, bufio.ErrBufferFull) {
+			return err
+		}
+	}
+}
+
+func readCSV(ctx context.Context, r io.Reader, kind Kind, handle func(int, record, error)) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return fmt.Errorf("%w: %v", InvalidHeader, err)
+	} else if err != nil {
+		return err
+	}
+
+	_, required := columns(kind)
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		index[name] = i
+	}
+	for _, name := range required {
+		if _, ok := index[name]; !ok {
+			return fmt.Errorf("%w %q", MissingColumn, name)
+		}
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		fields, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if errors.As(err, &parseErr) {
+			handle(parseErr.Line, nil, err)
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		line, _ := cr.FieldPos(0)
+		if len(fields) != len(header) {
+			handle(line, nil, fmt.Errorf("expect %d fields got %d", len(header), len(fields)))
+			continue
+		}
+
+		row := make(map[string]string, len(header))
+		for name, i := range index {
+			row[name] = fields[i]
+		}
+
+		rec := newRecord(kind)
+		handle(line, rec, rec.fromCSV(row))
+	}
+}
+
+// Export writes every record of the kind, ordered by ID, encoding each one
+// as soon as the service passes it.
+func Export(ctx context.Context, a app.App, w io.Writer, kind Kind, format Format) error {
+	var each func(write func(record) error) error
+	switch kind {
+	case Ads:
+		each = func(write func(record) error) error {
+			return a.ExportAds(ctx, func(ad ads.Ad) error { return write(newAdRecord(ad)) })
+		}
+	case Users:
+		each = func(write func(record) error) error {
+			return a.ExportUsers(ctx, func(user users.User) error { return write(newUserRecord(user)) })
+		}
+	default:
+		return fmt.Errorf("%w %q", UnknownKind, kind)
+	}
+
+	switch format {
+	case JSONL:
+		enc := json.NewEncoder(w)
+		return each(func(rec record) error { return enc.Encode(rec) })
+	case CSV:
+		cw := csv.NewWriter(w)
+		all, _ := columns(kind)
+		if err := cw.Write(all); err != nil {
+			return err
+		}
+		if err := each(func(rec record) error { return cw.Write(rec.csvRow()) }); err != nil {
+			return err
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		return fmt.Errorf("%w %q", UnknownFormat, format)
+	}
+}
@@ -0,0 +1,159 @@
+package bulk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"homework10/internal/adapters/repo"
+	"homework10/internal/app"
+)
+
+func newApp(t *testing.T) app.App {
+	a := app.NewApp(repo.New(), repo.New(), repo.New())
+
+	_, err := a.CreateUser(context.Background(), "Oleg", "oleg@testing.ru")
+	assert.NoError(t, err)
+
+	return a
+}
+
+func TestImport(t *testing.T) {
+	type Test struct {
+		Name     string
+		Kind     Kind
+		Format   Format
+		Input    string
+		Imported int
+		Lines    []int
+	}
+
+	tests := [...]Test{
+		{"Ads as JSON lines", Ads, JSONL, `{"title":"hello","text":"world","author_id":0}` + "\n\n" + `{"title":"bye","text":"world","author_id":0,"published":true}`, 2, nil},
+		{"Invalid JSON", Ads, JSONL, `{"title":"hello","text":"world","author_id":0}` + "\n" + `{"title":`, 1, []int{2}},
+		{"Rejected by the validator", Ads, JSONL, `{"title":"","text":"world","author_id":0}`, 0, []int{1}},
+		{"Unknown author", Ads, JSONL, `{"title":"hello","text":"world","author_id":7}`, 0, []int{1}},
+		{"Ads as CSV", Ads, CSV, "title,text,author_id\nhello,world,0\n\"multi\nline\",world,0\n", 2, nil},
+		{"CSV with a bad field", Ads, CSV, "title,text,author_id,published\nhello,world,0,maybe\nhello,world\nhello,world,0,true\n", 1, []int{2, 3}},
+		{"Users as CSV", Users, CSV, "email,name\nivan@testing.ru,Ivan\n", 1, nil},
+		{"Users as JSON lines", Users, JSONL, `{"id":7,"name":"Ivan","email":"ivan@testing.ru"}` + "\n" + `["Ivan"]`, 1, []int{2}},
+		{"Taken ID", Users, JSONL, `{"id":0,"name":"Ivan","email":"ivan@testing.ru"}`, 0, []int{1}},
+		{"Ad of an imported user", Ads, CSV, "id,title,text,author_id\n3,hello,world,0\n3,hello,world,0\n", 1, []int{3}},
+		{"Too long line", Ads, JSONL, strings.Repeat(" ", MaxLineLength) + `{"title":"hello","text":"world","author_id":0}` + "\n" +
+			`{"title":"bye","text":"world","author_id":0}` + "\n" + strings.Repeat(" ", 2*MaxLineLength), 1, []int{1, 3}},
+	}
+
+	for _, test := range tests {
+		report, err := Import(context.Background(), newApp(t), strings.NewReader(test.Input), test.Kind, test.Format, false)
+		if err != nil {
+			t.Fatalf(`test %q: expect nil got %v`, test.Name, err)
+		}
+
+		var lines []int
+		for _, e := range report.Errors {
+			lines = append(lines, e.Line)
+		}
+
+		if report.Imported != test.Imported || report.Failed != len(test.Lines) || !assert.ObjectsAreEqual(test.Lines, lines) {
+			t.Fatalf(`test %q: expect %d imported and errors on %v got %+v`, test.Name, test.Imported, test.Lines, report)
+		}
+	}
+}
+
+func TestImport_DryRun(t *testing.T) {
+	a := newApp(t)
+
+	input := `{"title":"hello","text":"world","author_id":0}` + "\n" + `{"title":"","text":"world","author_id":0}`
+	report, err := Import(context.Background(), a, strings.NewReader(input), Ads, JSONL, true)
+	assert.NoError(t, err)
+	assert.Equal(t, Report{DryRun: true, Total: 2, Imported: 1, Failed: 1, Errors: report.Errors}, report)
+
+	var exported bytes.Buffer
+	assert.NoError(t, Export(context.Background(), a, &exported, Ads, JSONL))
+	assert.Empty(t, exported.String())
+}
+
+func TestImport_MissingColumn(t *testing.T) {
+	_, err := Import(context.Background(), newApp(t), strings.NewReader("title,author_id\nhello,0\n"), Ads, CSV, false)
+	assert.ErrorIs(t, err, MissingColumn)
+
+	_, err = Import(context.Background(), newApp(t), strings.NewReader(`{"title":"hello"}`), Ads, CSV, false)
+	assert.ErrorIs(t, err, InvalidHeader)
+}
+
+func TestExportImport(t *testing.T) {
+	for _, format := range []Format{JSONL, CSV} {
+		a := newApp(t)
+
+		ad, err := a.CreateAd(context.Background(), "hello", "multi\nline, \"quoted\"", 0)
+		assert.NoError(t, err)
+		_, err = a.ChangeAdStatus(context.Background(), ad.ID, 0, true)
+		assert.NoError(t, err)
+
+		var exported bytes.Buffer
+		assert.NoError(t, Export(context.Background(), a, &exported, Ads, format))
+
+		b := newApp(t)
+		report, err := Import(context.Background(), b, bytes.NewReader(exported.Bytes()), Ads, format, false)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, report.Imported, format)
+
+		var again bytes.Buffer
+		assert.NoError(t, Export(context.Background(), b, &again, Ads, format))
+		assert.Equal(t, exported.String(), again.String(), format)
+	}
+}
+
+func TestExport_Pages(t *testing.T) {
+	a := newApp(t)
+
+	for i := 0; i < 2*app.MaxBatchSize+10; i++ {
+		_, err := a.CreateAd(context.Background(), fmt.Sprintf("ad %d", i), "world", 0)
+		assert.NoError(t, err)
+	}
+	for _, id := range []int64{0, app.MaxBatchSize, app.MaxBatchSize + 1} {
+		assert.NoError(t, a.DeleteAd(context.Background(), id, 0))
+	}
+
+	var exported bytes.Buffer
+	assert.NoError(t, Export(context.Background(), a, &exported, Ads, JSONL))
+
+	var ids []int64
+	dec := json.NewDecoder(&exported)
+	for dec.More() {
+		var rec adRecord
+		assert.NoError(t, dec.Decode(&rec))
+		ids = append(ids, *rec.ID)
+	}
+
+	assert.Len(t, ids, 2*app.MaxBatchSize+7)
+	assert.True(t, slices.IsSorted(ids))
+	assert.Equal(t, int64(1), ids[0])
+	assert.NotContains(t, ids, int64(app.MaxBatchSize))
+}
+
+func TestImport_KeepsIds(t *testing.T) {
+	a := app.NewApp(repo.New(), repo.New(), repo.New())
+
+	report, err := Import(context.Background(), a, strings.NewReader(`{"id":5,"name":"Ivan","email":"ivan@testing.ru"}`), Users, JSONL, false)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Imported)
+
+	report, err = Import(context.Background(), a, strings.NewReader(`{"id":9,"title":"hello","text":"world","author_id":5}`), Ads, JSONL, false)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Imported)
+
+	ad, err := a.GetAd(context.Background(), 9)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), ad.AuthorID)
+
+	user, err := a.CreateUser(context.Background(), "Oleg", "oleg@testing.ru")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(6), user.ID)
+}
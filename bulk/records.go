@@ -0,0 +1,228 @@
+package bulk
+
+import (
+	"context"
+	"fmt"
+	"homework10/internal/ads"
+	"homework10/internal/app"
+	"homework10/internal/users"
+	"strconv"
+	"time"
+)
+
+// record is a line of an export. The JSON names and the CSV columns are the
+// ones of the /api/v1 responses.
+type record interface {
+	fromCSV(row map[string]string) error
+	csvRow() []string
+	importInto(ctx context.Context, a app.App, dryRun bool) error
+}
+
+type adRecord struct {
+	// ID is nil for a record written by hand, which gets a new ID
+	ID        *int64    `json:"id"`
+	Title     string    `json:"title"`
+	Text      string    `json:"text"`
+	AuthorID  int64     `json:"author_id"`
+	Published bool      `json:"published"`
+	CreatedAt time.Time `json:"creation_time"`
+	UpdatedAt time.Time `json:"update_time"`
+	Favorites int64     `json:"favorites"`
+}
+
+var adColumns = []string{"id", "title", "text", "author_id", "published", "creation_time", "update_time", "favorites"}
+
+func newAdRecord(ad ads.Ad) *adRecord {
+	return &adRecord{
+		ID:        &ad.ID,
+		Title:     ad.Title,
+		Text:      ad.Text,
+		AuthorID:  ad.AuthorID,
+		Published: ad.Published,
+		CreatedAt: ad.CreatedAt,
+		UpdatedAt: ad.UpdatedAt,
+		Favorites: ad.Favorites,
+	}
+}
+
+func (r *adRecord) fromCSV(row map[string]string) (err error) {
+	r.Title, r.Text = row["title"], row["text"]
+
+	if r.ID, err = parseID(row); err != nil {
+		return err
+	}
+	if r.AuthorID, err = parseInt(row, "author_id"); err != nil {
+		return err
+	}
+	if r.Favorites, err = parseInt(row, "favorites"); err != nil {
+		return err
+	}
+	if v := row["published"]; v != "" {
+		if r.Published, err = strconv.ParseBool(v); err != nil {
+			return fmt.Errorf("published: %w", err)
+		}
+	}
+	if r.CreatedAt, err = parseTime(row, "creation_time"); err != nil {
+		return err
+	}
+	if r.UpdatedAt, err = parseTime(row, "update_time"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *adRecord) csvRow() []string {
+	return []string{
+		formatID(r.ID),
+		r.Title,
+		r.Text,
+		strconv.FormatInt(r.AuthorID, 10),
+		strconv.FormatBool(r.Published),
+		r.CreatedAt.Format(time.RFC3339Nano),
+		r.UpdatedAt.Format(time.RFC3339Nano),
+		strconv.FormatInt(r.Favorites, 10),
+	}
+}
+
+func (r *adRecord) importInto(ctx context.Context, a app.App, dryRun bool) error {
+	_, err := a.ImportAd(ctx, ads.Ad{
+		ID:        importID(r.ID),
+		Title:     r.Title,
+		Text:      r.Text,
+		AuthorID:  r.AuthorID,
+		Published: r.Published,
+		CreatedAt: r.CreatedAt,
+		UpdatedAt: r.UpdatedAt,
+	}, dryRun)
+
+	return err
+}
+
+type userRecord struct {
+	// ID is nil for a record written by hand, as for adRecord
+	ID               *int64    `json:"id"`
+	Name             string    `json:"name"`
+	Email            string    `json:"email"`
+	DisplayName      string    `json:"display_name"`
+	AvatarURL        string    `json:"avatar_url"`
+	Phone            string    `json:"phone"`
+	City             string    `json:"city"`
+	Bio              string    `json:"bio"`
+	RegistrationTime time.Time `json:"registration_time"`
+}
+
+var userColumns = []string{"id", "name", "email", "display_name", "avatar_url", "phone", "city", "bio", "registration_time"}
+
+func newUserRecord(user users.User) *userRecord {
+	return &userRecord{
+		ID:               &user.ID,
+		Name:             user.Name,
+		Email:            user.Email,
+		DisplayName:      user.DisplayName,
+		AvatarURL:        user.AvatarURL,
+		Phone:            user.Phone,
+		City:             user.City,
+		Bio:              user.Bio,
+		RegistrationTime: user.RegisteredAt,
+	}
+}
+
+func (r *userRecord) fromCSV(row map[string]string) (err error) {
+	r.Name, r.Email = row["name"], row["email"]
+	r.DisplayName, r.AvatarURL, r.Phone = row["display_name"], row["avatar_url"], row["phone"]
+	r.City, r.Bio = row["city"], row["bio"]
+
+	if r.ID, err = parseID(row); err != nil {
+		return err
+	}
+	if r.RegistrationTime, err = parseTime(row, "registration_time"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *userRecord) csvRow() []string {
+	return []string{
+		formatID(r.ID),
+		r.Name,
+		r.Email,
+		r.DisplayName,
+		r.AvatarURL,
+		r.Phone,
+		r.City,
+		r.Bio,
+		r.RegistrationTime.Format(time.RFC3339Nano),
+	}
+}
+
+func (r *userRecord) importInto(ctx context.Context, a app.App, dryRun bool) error {
+	_, err := a.ImportUser(ctx, users.User{
+		ID:           importID(r.ID),
+		Name:         r.Name,
+		Email:        r.Email,
+		DisplayName:  r.DisplayName,
+		AvatarURL:    r.AvatarURL,
+		Phone:        r.Phone,
+		City:         r.City,
+		Bio:          r.Bio,
+		RegisteredAt: r.RegistrationTime,
+	}, dryRun)
+
+	return err
+}
+
+func parseInt(row map[string]string, column string) (int64, error) {
+	v := row[column]
+	if v == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", column, err)
+	}
+
+	return n, nil
+}
+
+func parseID(row map[string]string) (*int64, error) {
+	if row["id"] == "" {
+		return nil, nil
+	}
+
+	id, err := parseInt(row, "id")
+	return &id, err
+}
+
+func formatID(id *int64) string {
+	if id == nil {
+		return ""
+	}
+
+	return strconv.FormatInt(*id, 10)
+}
+
+// importID is the ID given to the service for the ID of a record.
+func importID(id *int64) int64 {
+	if id == nil {
+		return app.NewId
+	}
+
+	return *id
+}
+
+func parseTime(row map[string]string, column string) (time.Time, error) {
+	v := row[column]
+	if v == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s: %w", column, err)
+	}
+
+	return t, nil
+}
@@ -0,0 +1,205 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"homework10/internal/app"
+	"homework10/internal/query"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// Backend stores values for a limited time. A backend may drop a value
+// before it expires, e.g. to make room for another one.
+type Backend interface {
+	Get(ctx context.Context, key string) (any, bool, error)
+	Set(ctx context.Context, key string, value any, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// Repository wraps r so that Get, GetArray and Find are served from the
+// backend, under keys prefixed with the repository name, e.g. "ads:7" and
+// "ads:all".
+// Every change made through the wrapper removes the keys it affects. The
+// backend is best effort: when it fails the repository is used directly and
+// a stale value lives at most for the TTL.
+func Repository(name string, r app.Repository, b Backend, ttl time.Duration) app.Repository {
+	return &repository{name: name, repo: r, backend: b, ttl: ttl}
+}
+
+type repository struct {
+	name    string
+	repo    app.Repository
+	backend Backend
+	ttl     time.Duration
+
+	// version changes with every write, a value read from the repository
+	// while it changed may be stale and is not cached
+	version atomic.Int64
+}
+
+func (r *repository) key(id int64) string {
+	return r.name + ":" + strconv.FormatInt(id, 10)
+}
+
+func (r *repository) allKey() string {
+	return r.name + ":all"
+}
+
+func (r *repository) generationKey() string {
+	return r.name + ":generation"
+}
+
+// invalidate runs after the write, so a read that started before it can't
+// cache the old value, see version.
+func (r *repository) invalidate(ctx context.Context, ids ...int64) {
+	r.version.Add(1)
+
+	keys := make([]string, 0, len(ids)+1)
+	for _, id := range ids {
+		keys = append(keys, r.key(id))
+	}
+	keys = append(keys, r.allKey(), r.generationKey())
+
+	_ = r.backend.Delete(ctx, keys...)
+}
+
+func (r *repository) Add(ctx context.Context, e interface{}) error {
+	err := r.repo.Add(ctx, e)
+	r.invalidate(ctx)
+	return err
+}
+
+func (r *repository) Insert(ctx context.Context, id int64, e interface{}) error {
+	err := r.repo.Insert(ctx, id, e)
+	r.invalidate(ctx, id)
+	return err
+}
+
+func (r *repository) Update(ctx context.Context, id int64, e interface{}) error {
+	err := r.repo.Update(ctx, id, e)
+	r.invalidate(ctx, id)
+	return err
+}
+
+func (r *repository) Modify(ctx context.Context, id int64, change func(e interface{}) (interface{}, error)) (interface{}, error) {
+	e, err := r.repo.Modify(ctx, id, change)
+	r.invalidate(ctx, id)
+	return e, err
+}
+
+func (r *repository) Get(ctx context.Context, id int64) (interface{}, error) {
+	key := r.key(id)
+	if e, ok, err := r.backend.Get(ctx, key); err == nil && ok {
+		return e, nil
+	}
+
+	version := r.version.Load()
+	e, err := r.repo.Get(ctx, id)
+	if err == nil && r.version.Load() == version {
+		_ = r.backend.Set(ctx, key, e, r.ttl)
+	}
+
+	return e, err
+}
+
+func (r *repository) Delete(ctx context.Context, id int64) error {
+	err := r.repo.Delete(ctx, id)
+	r.invalidate(ctx, id)
+	return err
+}
+
+func (r *repository) CheckIdExist(ctx context.Context, id int64) bool {
+	return r.repo.CheckIdExist(ctx, id)
+}
+
+func (r *repository) GetNextId(ctx context.Context) int64 {
+	return r.repo.GetNextId(ctx)
+}
+
+// GetArray returns a copy of the cached slice, so that callers can't change
+// the cached one.
+func (r *repository) GetArray(ctx context.Context) []interface{} {
+	key := r.allKey()
+	if v, ok, err := r.backend.Get(ctx, key); err == nil && ok {
+		if arr, ok := v.([]interface{}); ok {
+			return append([]interface{}(nil), arr...)
+		}
+	}
+
+	version := r.version.Load()
+	arr := r.repo.GetArray(ctx)
+	if r.version.Load() == version {
+		_ = r.backend.Set(ctx, key, append([]interface{}(nil), arr...), r.ttl)
+	}
+
+	return arr
+}
+
+func (r *repository) AddBatch(ctx context.Context, es []interface{}, withId func(e interface{}, id int64) interface{}) ([]int64, error) {
+	ids, err := r.repo.AddBatch(ctx, es, withId)
+	r.invalidate(ctx)
+	return ids, err
+}
+
+func (r *repository) GetBatch(ctx context.Context, ids []int64) ([]interface{}, error) {
+	return r.repo.GetBatch(ctx, ids)
+}
+
+// Find caches the results under the current generation, e.g.
+// "ads:find:1f2e3d4c:author:7:7". A write drops the generation, so that the
+// results found before it are never read again and expire.
+func (r *repository) Find(ctx context.Context, index query.Index, from int64, to int64) ([]interface{}, error) {
+	generation, ok := r.generation(ctx)
+	if !ok {
+		return r.repo.Find(ctx, index, from, to)
+	}
+
+	key := r.name + ":find:" + generation + ":" + index.Name + ":" + strconv.FormatInt(from, 10) + ":" + strconv.FormatInt(to, 10)
+	if v, ok, err := r.backend.Get(ctx, key); err == nil && ok {
+		if arr, ok := v.([]interface{}); ok {
+			return append([]interface{}(nil), arr...), nil
+		}
+	}
+
+	version := r.version.Load()
+	arr, err := r.repo.Find(ctx, index, from, to)
+	if err == nil && r.version.Load() == version {
+		_ = r.backend.Set(ctx, key, append([]interface{}(nil), arr...), r.ttl)
+	}
+
+	return arr, err
+}
+
+// generation returns the generation of the cached results, starting a new
+// one when there is none.
+func (r *repository) generation(ctx context.Context) (string, bool) {
+	key := r.generationKey()
+	v, ok, err := r.backend.Get(ctx, key)
+	if err != nil {
+		return "", false
+	}
+	if generation, isString := v.(string); ok && isString {
+		return generation, true
+	}
+
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", false
+	}
+
+	generation := hex.EncodeToString(b)
+	if err := r.backend.Set(ctx, key, generation, r.ttl); err != nil {
+		return "", false
+	}
+
+	return generation, true
+}
+
+func (r *repository) UpdateBatch(ctx context.Context, ids []int64, es []interface{}) error {
+	err := r.repo.UpdateBatch(ctx, ids, es)
+	r.invalidate(ctx, ids...)
+	return err
+}
@@ -0,0 +1,102 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"homework10/internal/ads"
+	"homework10/internal/app"
+	"homework10/internal/mocks"
+)
+
+func TestLRU(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	c := NewLRU(2)
+	c.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	assert.NoError(t, c.Set(ctx, "a", 1, time.Minute))
+	assert.NoError(t, c.Set(ctx, "b", 2, time.Hour))
+
+	// a is used more recently than b, so b makes room for c
+	_, ok, _ := c.Get(ctx, "a")
+	assert.True(t, ok)
+	assert.NoError(t, c.Set(ctx, "c", 3, time.Hour))
+
+	type Test struct {
+		Name    string
+		Key     string
+		Advance time.Duration
+		Value   any
+		Found   bool
+	}
+
+	tests := [...]Test{
+		{"Recently used", "a", 0, 1, true},
+		{"Least recently used", "b", 0, nil, false},
+		{"Newest", "c", 0, 3, true},
+		{"Expired", "a", 2 * time.Minute, nil, false},
+		{"Not expired", "c", 0, 3, true},
+	}
+
+	for _, test := range tests {
+		now = now.Add(test.Advance)
+
+		value, found, err := c.Get(ctx, test.Key)
+		if value != test.Value || found != test.Found || err != nil {
+			t.Fatalf(`test %q: expect (%v, %v, nil) got (%v, %v, %v)`, test.Name, test.Value, test.Found, value, found, err)
+		}
+	}
+
+	assert.NoError(t, c.Delete(ctx, "c", "d"))
+	assert.Equal(t, 0, c.Len())
+}
+
+func TestRepository(t *testing.T) {
+	ad := ads.Ad{ID: 1, Title: "hello", Text: "world"}
+	updated := ads.Ad{ID: 1, Title: "bye", Text: "world"}
+
+	r := &mocks.Repository{}
+	r.On("Get", mock.Anything, int64(1)).Return(ad, nil).Once()
+	r.On("GetArray", mock.Anything).Return([]interface{}{ad}).Once()
+	r.On("Find", mock.Anything, mock.Anything, int64(0), int64(0)).Return([]interface{}{ad}, nil).Once()
+	r.On("Update", mock.Anything, int64(1), updated).Return(nil).Once()
+	r.On("Get", mock.Anything, int64(1)).Return(updated, nil).Once()
+	r.On("GetArray", mock.Anything).Return([]interface{}{updated}).Once()
+	r.On("Find", mock.Anything, mock.Anything, int64(0), int64(0)).Return([]interface{}{updated}, nil).Once()
+
+	repo := Repository("ads", r, NewLRU(10), time.Minute)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		e, err := repo.Get(ctx, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, ad, e)
+
+		arr := repo.GetArray(ctx)
+		assert.Equal(t, []interface{}{ad}, arr)
+		arr[0] = nil
+
+		found, err := repo.Find(ctx, app.AdsByAuthor, 0, 0)
+		assert.NoError(t, err)
+		assert.Equal(t, []interface{}{ad}, found)
+	}
+
+	assert.NoError(t, repo.Update(ctx, 1, updated))
+
+	e, err := repo.Get(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, updated, e)
+	assert.Equal(t, []interface{}{updated}, repo.GetArray(ctx))
+
+	found, err := repo.Find(ctx, app.AdsByAuthor, 0, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{updated}, found)
+
+	r.AssertExpectations(t)
+}
@@ -0,0 +1,92 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+type lruEntry struct {
+	key     string
+	value   any
+	expires time.Time
+}
+
+// LRU keeps up to size values in memory and drops the least recently used
+// one to make room for a new one.
+type LRU struct {
+	size    int
+	entries map[string]*list.Element
+	order   *list.List
+	now     func() time.Time
+	mu      sync.Mutex
+}
+
+func NewLRU(size int) *LRU {
+	return &LRU{size: size, entries: make(map[string]*list.Element), order: list.New(), now: time.Now}
+}
+
+func (c *LRU) Get(ctx context.Context, key string) (any, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, exists := c.entries[key]
+	if !exists {
+		return nil, false, nil
+	}
+
+	e := el.Value.(*lruEntry)
+	if !c.now().Before(e.expires) {
+		c.order.Remove(el)
+		delete(c.entries, key)
+		return nil, false, nil
+	}
+
+	c.order.MoveToFront(el)
+	return e.value, true, nil
+}
+
+func (c *LRU) Set(ctx context.Context, key string, value any, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expires := c.now().Add(ttl)
+	if el, exists := c.entries[key]; exists {
+		e := el.Value.(*lruEntry)
+		e.value, e.expires = value, expires
+		c.order.MoveToFront(el)
+		return nil
+	}
+
+	c.entries[key] = c.order.PushFront(&lruEntry{key: key, value: value, expires: expires})
+
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry).key)
+	}
+
+	return nil
+}
+
+func (c *LRU) Delete(ctx context.Context, keys ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		if el, exists := c.entries[key]; exists {
+			c.order.Remove(el)
+			delete(c.entries, key)
+		}
+	}
+
+	return nil
+}
+
+func (c *LRU) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
@@ -0,0 +1,205 @@
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/gob"
+	"fmt"
+	"github.com/pkg/errors"
+	"homework10/internal/ads"
+	"homework10/internal/conversations"
+	"homework10/internal/users"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// maxIdleConns is how many connections Redis keeps open between commands.
+const maxIdleConns = 4
+
+var (
+	ServerError     = errors.New("cache server error")
+	UnexpectedReply = errors.New("unexpected reply from the cache server")
+)
+
+func init() {
+	// the repositories store the domain types as interface{} values
+	gob.Register(ads.Ad{})
+	gob.Register(users.User{})
+	gob.Register(conversations.Conversation{})
+	gob.Register([]interface{}{})
+}
+
+// envelope lets gob encode a value of any registered type.
+type envelope struct {
+	Value any
+}
+
+// Redis is a backend on a server speaking the Redis protocol, e.g. Redis,
+// Valkey or KeyDB, shared by all the instances of the service. Values are
+// encoded with gob.
+type Redis struct {
+	addr   string
+	dialer net.Dialer
+	idle   []*redisConn
+	mu     sync.Mutex
+}
+
+type redisConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func NewRedis(addr string) *Redis {
+	return &Redis{addr: addr, dialer: net.Dialer{Timeout: 5 * time.Second}}
+}
+
+func (c *Redis) Get(ctx context.Context, key string) (any, bool, error) {
+	reply, err := c.do(ctx, "GET", key)
+	if err != nil || reply == nil {
+		return nil, false, err
+	}
+
+	data, ok := reply.([]byte)
+	if !ok {
+		return nil, false, UnexpectedReply
+	}
+
+	var e envelope
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&e); err != nil {
+		return nil, false, err
+	}
+
+	return e.Value, true, nil
+}
+
+func (c *Redis) Set(ctx context.Context, key string, value any, ttl time.Duration) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(envelope{Value: value}); err != nil {
+		return err
+	}
+
+	_, err := c.do(ctx, "SET", key, buf.String(), "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	return err
+}
+
+func (c *Redis) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	_, err := c.do(ctx, append([]string{"DEL"}, keys...)...)
+	return err
+}
+
+// Ping lets the health checks report an unreachable cache server.
+func (c *Redis) Ping(ctx context.Context) error {
+	_, err := c.do(ctx, "PING")
+	return err
+}
+
+// do sends the command and reads its reply: a string, an integer, the bytes
+// of a bulk string or nil for a missing value.
+func (c *Redis) do(ctx context.Context, args ...string) (any, error) {
+	conn, err := c.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(5 * time.Second)
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&buf, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+
+	if _, err := conn.Write(buf.Bytes()); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	reply, err := readReply(conn.r)
+	if err != nil && !errors.Is(err, ServerError) {
+		conn.Close()
+		return nil, err
+	}
+
+	c.release(conn)
+	return reply, err
+}
+
+func (c *Redis) conn(ctx context.Context) (*redisConn, error) {
+	c.mu.Lock()
+	if n := len(c.idle); n > 0 {
+		conn := c.idle[n-1]
+		c.idle = c.idle[:n-1]
+		c.mu.Unlock()
+		return conn, nil
+	}
+	c.mu.Unlock()
+
+	conn, err := c.dialer.DialContext(ctx, "tcp", c.addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &redisConn{Conn: conn, r: bufio.NewReader(conn)}, nil
+}
+
+func (c *Redis) release(conn *redisConn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.idle) >= maxIdleConns {
+		conn.Close()
+		return
+	}
+	c.idle = append(c.idle, conn)
+}
+
+func readReply(r *bufio.Reader) (any, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, UnexpectedReply
+	}
+	line = line[:len(line)-2]
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, fmt.Errorf("%w: %s", ServerError, line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, UnexpectedReply
+		}
+		if n < 0 {
+			return nil, nil
+		}
+
+		data := make([]byte, n+2)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		return data[:n], nil
+	}
+
+	return nil, fmt.Errorf("%w: %q", UnexpectedReply, line)
+}
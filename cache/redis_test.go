@@ -0,0 +1,127 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"homework10/internal/ads"
+)
+
+// fakeRedis serves GET, SET, DEL and PING from a map, ignoring the TTL.
+func fakeRedis(t *testing.T) string {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		lis.Close()
+	})
+
+	var mu sync.Mutex
+	data := make(map[string]string)
+
+	serve := func(conn net.Conn) {
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+
+		for {
+			var n int
+			if _, err := fmt.Fscanf(r, "*%d\r\n", &n); err != nil {
+				return
+			}
+
+			args := make([]string, n)
+			for i := range args {
+				var size int
+				if _, err := fmt.Fscanf(r, "$%d\r\n", &size); err != nil {
+					return
+				}
+				buf := make([]byte, size+2)
+				if _, err := io.ReadFull(r, buf); err != nil {
+					return
+				}
+				args[i] = string(buf[:size])
+			}
+
+			mu.Lock()
+			switch args[0] {
+			case "PING":
+				fmt.Fprint(conn, "+PONG\r\n")
+			case "GET":
+				if v, ok := data[args[1]]; ok {
+					fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(v), v)
+				} else {
+					fmt.Fprint(conn, "$-1\r\n")
+				}
+			case "SET":
+				data[args[1]] = args[2]
+				fmt.Fprint(conn, "+OK\r\n")
+			case "DEL":
+				deleted := 0
+				for _, key := range args[1:] {
+					if _, ok := data[key]; ok {
+						delete(data, key)
+						deleted++
+					}
+				}
+				fmt.Fprint(conn, ":"+strconv.Itoa(deleted)+"\r\n")
+			default:
+				fmt.Fprintf(conn, "-ERR unknown command '%s'\r\n", args[0])
+			}
+			mu.Unlock()
+		}
+	}
+
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			go serve(conn)
+		}
+	}()
+
+	return lis.Addr().String()
+}
+
+func TestRedis(t *testing.T) {
+	c := NewRedis(fakeRedis(t))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	assert.NoError(t, c.Ping(ctx))
+
+	ad := ads.Ad{ID: 7, Title: "hello", Text: "world", CreatedAt: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+		Revisions: []ads.Revision{{ID: 0, Title: "hello", Text: "world"}}}
+
+	assert.NoError(t, c.Set(ctx, "ads:7", ad, time.Minute))
+	assert.NoError(t, c.Set(ctx, "ads:all", []interface{}{ad}, time.Minute))
+
+	v, ok, err := c.Get(ctx, "ads:7")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, ad, v)
+
+	v, ok, err = c.Get(ctx, "ads:all")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []interface{}{ad}, v)
+
+	assert.NoError(t, c.Delete(ctx, "ads:7", "ads:all"))
+
+	_, ok, err = c.Get(ctx, "ads:7")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	_, err = c.do(ctx, "FLUSHALL")
+	assert.ErrorIs(t, err, ServerError)
+	assert.NoError(t, c.Ping(ctx), "the connection is reused after a server error")
+}
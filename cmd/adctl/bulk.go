@@ -0,0 +1,232 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"homework10/internal/bulk"
+)
+
+var ImportFailed = errors.New("some records were not imported")
+
+// bulkCommand streams a file to or from the admin endpoints of the HTTP API,
+// the gRPC API has no equivalent.
+type bulkCommand struct {
+	name   string
+	usage  string
+	upload bool
+}
+
+var bulkCommands = []bulkCommand{
+	{name: "import", usage: "import a JSON lines or CSV file, see -dry-run", upload: true},
+	{name: "export", usage: "export all records as JSON lines or CSV"},
+}
+
+func findBulkCommand(name string) (bulkCommand, bool) {
+	for _, cmd := range bulkCommands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+
+	return bulkCommand{}, false
+}
+
+func (c cli) runBulk(ctx context.Context, cmd bulkCommand, args []string, settings func() (Profile, error)) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: expect %q or %q after %q", UnknownCommand, bulk.Ads, bulk.Users, cmd.name)
+	}
+
+	kind, err := bulk.ParseKind(args[0])
+	if err != nil {
+		return err
+	}
+
+	fs := flag.NewFlagSet("adctl "+cmd.name+" "+string(kind), flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	file := fs.String("file", "-", "`file` to read or write, - for stdin or stdout")
+	format := fs.String("format", "", "jsonl or csv, by default the extension of -file or jsonl")
+	dryRun := false
+	if cmd.upload {
+		fs.BoolVar(&dryRun, "dry-run", false, "validate the records without storing them")
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	if *format == "" {
+		*format = string(bulk.JSONL)
+		if ext := strings.TrimPrefix(filepath.Ext(*file), "."); ext == string(bulk.CSV) {
+			*format = ext
+		}
+	}
+	f, err := bulk.ParseFormat(*format)
+	if err != nil {
+		return err
+	}
+
+	profile, err := settings()
+	if err != nil {
+		return err
+	}
+
+	client, err := profile.httpClient()
+	if err != nil {
+		return err
+	}
+
+	query := url.Values{"format": {string(f)}}
+	if cmd.upload {
+		query.Set("dry_run", fmt.Sprint(dryRun))
+	}
+	endpoint := strings.TrimSuffix(profile.HTTPAddr, "/") + "/api/v1/admin/" + cmd.name + "/" + string(kind) + "?" + query.Encode()
+
+	if cmd.upload {
+		return c.importFile(ctx, client, endpoint, *file, f, profile)
+	}
+
+	return c.exportFile(ctx, client, endpoint, *file, profile)
+}
+
+func (c cli) importFile(ctx context.Context, client *http.Client, endpoint string, file string, format bulk.Format, profile Profile) error {
+	var body io.Reader = os.Stdin
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		body = f
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", bulk.ContentType(format))
+
+	resp, err := do(client, req, profile.Token)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return fmt.Errorf("can't decode the report: %w", err)
+	}
+
+	var report bulk.Report
+	if err := json.Unmarshal(envelope.Data, &report); err != nil {
+		return fmt.Errorf("can't decode the report: %w", err)
+	}
+
+	if err := writeReport(c.stdout, profile.Output, envelope.Data, report); err != nil {
+		return err
+	}
+
+	if report.Failed > 0 {
+		return fmt.Errorf("%w: %d of %d failed", ImportFailed, report.Failed, report.Total)
+	}
+
+	return nil
+}
+
+func (c cli) exportFile(ctx context.Context, client *http.Client, endpoint string, file string, profile Profile) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := do(client, req, profile.Token)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if file == "-" {
+		_, err = io.Copy(c.stdout, resp.Body)
+		return err
+	}
+
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// do sends req and turns the error envelope of a failed request into an error.
+func do(client *http.Client, req *http.Request, token string) (*http.Response, error) {
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+
+		var envelope struct {
+			Error string `json:"error"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil || envelope.Error == "" {
+			return nil, fmt.Errorf("%s", resp.Status)
+		}
+		return nil, fmt.Errorf("%s: %s", resp.Status, envelope.Error)
+	}
+
+	return resp, nil
+}
+
+// writeReport writes the report as received for JSON and YAML, and as a
+// summary followed by the line errors for a table.
+func writeReport(w io.Writer, format string, data json.RawMessage, report bulk.Report) error {
+	if format != "table" {
+		var v any
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		return writeData(w, format, nil, v)
+	}
+
+	summary := record{
+		names:  []string{"dry_run", "total", "imported", "failed"},
+		values: map[string]any{"dry_run": report.DryRun, "total": report.Total, "imported": report.Imported, "failed": report.Failed},
+	}
+	if err := writeTable(w, []record{summary}); err != nil {
+		return err
+	}
+
+	if len(report.Errors) == 0 {
+		return nil
+	}
+
+	lines := make([]record, 0, len(report.Errors))
+	for _, e := range report.Errors {
+		lines = append(lines, record{names: []string{"line", "error"}, values: map[string]any{"line": e.Line, "error": e.Error}})
+	}
+
+	fmt.Fprintln(w)
+	return writeTable(w, lines)
+}
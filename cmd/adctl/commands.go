@@ -0,0 +1,266 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"google.golang.org/protobuf/proto"
+	"time"
+
+	grpcPort "homework10/internal/ports/grpc"
+)
+
+type action func(ctx context.Context, c grpcPort.AdServiceClient) (proto.Message, error)
+
+// command is a subcommand like "ads create". Its flags function registers
+// the flags and returns the call to make once they are parsed.
+type command struct {
+	group string
+	name  string
+	usage string
+	flags func(fs *flag.FlagSet) action
+}
+
+var commands = []command{
+	{group: "ads", name: "create", usage: "create an ad", flags: func(fs *flag.FlagSet) action {
+		userID := fs.Int64("user", 0, "author `id`")
+		title := fs.String("title", "", "title of the ad")
+		text := fs.String("text", "", "text of the ad")
+		return func(ctx context.Context, c grpcPort.AdServiceClient) (proto.Message, error) {
+			return c.CreateAd(ctx, &grpcPort.CreateAdRequest{UserId: *userID, Title: *title, Text: *text})
+		}
+	}},
+	{group: "ads", name: "update", usage: "update the title and the text of an ad", flags: func(fs *flag.FlagSet) action {
+		adID := fs.Int64("id", 0, "ad `id`")
+		userID := fs.Int64("user", 0, "author `id`")
+		title := fs.String("title", "", "new title")
+		text := fs.String("text", "", "new text")
+		return func(ctx context.Context, c grpcPort.AdServiceClient) (proto.Message, error) {
+			return c.UpdateAd(ctx, &grpcPort.UpdateAdRequest{AdId: *adID, UserId: *userID, Title: *title, Text: *text})
+		}
+	}},
+	{group: "ads", name: "publish", usage: "publish an ad", flags: func(fs *flag.FlagSet) action {
+		return changeAdStatus(fs, true)
+	}},
+	{group: "ads", name: "unpublish", usage: "unpublish an ad", flags: func(fs *flag.FlagSet) action {
+		return changeAdStatus(fs, false)
+	}},
+	{group: "ads", name: "get", usage: "show an ad", flags: func(fs *flag.FlagSet) action {
+		adID := fs.Int64("id", 0, "ad `id`")
+		return func(ctx context.Context, c grpcPort.AdServiceClient) (proto.Message, error) {
+			return c.GetAd(ctx, &grpcPort.GetAdRequest{Id: *adID})
+		}
+	}},
+	{group: "ads", name: "delete", usage: "delete an ad", flags: func(fs *flag.FlagSet) action {
+		adID := fs.Int64("id", 0, "ad `id`")
+		userID := fs.Int64("user", 0, "author `id`")
+		return func(ctx context.Context, c grpcPort.AdServiceClient) (proto.Message, error) {
+			return c.DeleteAd(ctx, &grpcPort.DeleteAdRequest{AdId: *adID, AuthorId: *userID})
+		}
+	}},
+	{group: "ads", name: "list", usage: "list ads", flags: func(fs *flag.FlagSet) action {
+		published := fs.Bool("published", true, "list published ads, or unpublished ones when false")
+		userID := fs.Int64("user", -1, "list the ads of this author `id` only")
+		date := fs.String("date", "", "list the ads created on this RFC 3339 `time`'s day only")
+		return func(ctx context.Context, c grpcPort.AdServiceClient) (proto.Message, error) {
+			if *date != "" {
+				if _, err := time.Parse(time.RFC3339, *date); err != nil {
+					return nil, err
+				}
+			}
+			return c.ListAds(ctx, &grpcPort.ListAdsRequest{Published: *published, UserId: *userID, CreationTime: *date})
+		}
+	}},
+	{group: "ads", name: "search", usage: "search published ads by title", flags: func(fs *flag.FlagSet) action {
+		pattern := fs.String("pattern", "", "part of the title")
+		return func(ctx context.Context, c grpcPort.AdServiceClient) (proto.Message, error) {
+			return c.SearchAds(ctx, &grpcPort.SearchAdsRequest{Pattern: *pattern})
+		}
+	}},
+	{group: "ads", name: "revisions", usage: "list the revisions of an ad", flags: func(fs *flag.FlagSet) action {
+		adID := fs.Int64("id", 0, "ad `id`")
+		return func(ctx context.Context, c grpcPort.AdServiceClient) (proto.Message, error) {
+			return c.ListAdRevisions(ctx, &grpcPort.ListAdRevisionsRequest{AdId: *adID})
+		}
+	}},
+	{group: "ads", name: "rollback", usage: "restore a revision of an ad", flags: func(fs *flag.FlagSet) action {
+		adID := fs.Int64("id", 0, "ad `id`")
+		userID := fs.Int64("user", 0, "author `id`")
+		revisionID := fs.Int64("revision", 0, "revision `id`")
+		return func(ctx context.Context, c grpcPort.AdServiceClient) (proto.Message, error) {
+			return c.RollbackAd(ctx, &grpcPort.RollbackAdRequest{AdId: *adID, UserId: *userID, RevisionId: *revisionID})
+		}
+	}},
+
+	{group: "users", name: "create", usage: "create a user", flags: func(fs *flag.FlagSet) action {
+		name := fs.String("name", "", "user name")
+		email := fs.String("email", "", "user email")
+		return func(ctx context.Context, c grpcPort.AdServiceClient) (proto.Message, error) {
+			return c.CreateUser(ctx, &grpcPort.CreateUserRequest{Name: *name, Email: *email})
+		}
+	}},
+	{group: "users", name: "update", usage: "update a user, the token is a session of the user for an account", flags: func(fs *flag.FlagSet) action {
+		userID := fs.Int64("id", 0, "user `id`")
+		name := fs.String("name", "", "new name")
+		email := fs.String("email", "", "new email")
+		return func(ctx context.Context, c grpcPort.AdServiceClient) (proto.Message, error) {
+			return c.UpdateUser(ctx, &grpcPort.UpdateUserRequest{Id: *userID, Name: *name, Email: *email})
+		}
+	}},
+	{group: "users", name: "get", usage: "show a user, whole if the token is a session of the user", flags: func(fs *flag.FlagSet) action {
+		userID := fs.Int64("id", 0, "user `id`")
+		return func(ctx context.Context, c grpcPort.AdServiceClient) (proto.Message, error) {
+			return c.GetUser(ctx, &grpcPort.GetUserRequest{Id: *userID})
+		}
+	}},
+	{group: "users", name: "delete", usage: "delete a user, the token is a session of the user for an account", flags: func(fs *flag.FlagSet) action {
+		userID := fs.Int64("id", 0, "user `id`")
+		return func(ctx context.Context, c grpcPort.AdServiceClient) (proto.Message, error) {
+			return c.DeleteUser(ctx, &grpcPort.DeleteUserRequest{Id: *userID})
+		}
+	}},
+
+	{group: "accounts", name: "register", usage: "register a user with a password", flags: func(fs *flag.FlagSet) action {
+		name := fs.String("name", "", "user name")
+		email := fs.String("email", "", "email to log in with")
+		password := fs.String("password", "", "password")
+		return func(ctx context.Context, c grpcPort.AdServiceClient) (proto.Message, error) {
+			return c.Register(ctx, &grpcPort.RegisterRequest{Name: *name, Email: *email, Password: *password})
+		}
+	}},
+	{group: "accounts", name: "login", usage: "log in, the token of the session goes to -token", flags: func(fs *flag.FlagSet) action {
+		email := fs.String("email", "", "email of the account")
+		password := fs.String("password", "", "password")
+		return func(ctx context.Context, c grpcPort.AdServiceClient) (proto.Message, error) {
+			return c.Login(ctx, &grpcPort.LoginRequest{Email: *email, Password: *password})
+		}
+	}},
+	{group: "accounts", name: "logout", usage: "end the session of -token", flags: func(fs *flag.FlagSet) action {
+		return func(ctx context.Context, c grpcPort.AdServiceClient) (proto.Message, error) {
+			return c.Logout(ctx, &grpcPort.LogoutRequest{})
+		}
+	}},
+	{group: "accounts", name: "password", usage: "change the password, ending all sessions", flags: func(fs *flag.FlagSet) action {
+		userID := fs.Int64("user", 0, "user `id`")
+		oldPassword := fs.String("old", "", "current password")
+		newPassword := fs.String("new", "", "new password")
+		return func(ctx context.Context, c grpcPort.AdServiceClient) (proto.Message, error) {
+			return c.ChangePassword(ctx, &grpcPort.ChangePasswordRequest{UserId: *userID, OldPassword: *oldPassword, NewPassword: *newPassword})
+		}
+	}},
+	{group: "accounts", name: "forgot", usage: "mail a password reset token", flags: func(fs *flag.FlagSet) action {
+		email := fs.String("email", "", "email of the account")
+		return func(ctx context.Context, c grpcPort.AdServiceClient) (proto.Message, error) {
+			return c.RequestPasswordReset(ctx, &grpcPort.RequestPasswordResetRequest{Email: *email})
+		}
+	}},
+	{group: "accounts", name: "reset", usage: "set a new password with a mailed reset token", flags: func(fs *flag.FlagSet) action {
+		token := fs.String("reset-token", "", "the mailed reset token")
+		newPassword := fs.String("new", "", "new password")
+		return func(ctx context.Context, c grpcPort.AdServiceClient) (proto.Message, error) {
+			return c.ResetPassword(ctx, &grpcPort.ResetPasswordRequest{Token: *token, NewPassword: *newPassword})
+		}
+	}},
+
+	{group: "favorites", name: "add", usage: "add an ad to favorites", flags: func(fs *flag.FlagSet) action {
+		userID := fs.Int64("user", 0, "user `id`")
+		adID := fs.Int64("ad", 0, "ad `id`")
+		notify := fs.Bool("notify", false, "notify about changes of the ad")
+		return func(ctx context.Context, c grpcPort.AdServiceClient) (proto.Message, error) {
+			return c.AddFavorite(ctx, &grpcPort.AddFavoriteRequest{UserId: *userID, AdId: *adID, Notify: *notify})
+		}
+	}},
+	{group: "favorites", name: "remove", usage: "remove an ad from favorites", flags: func(fs *flag.FlagSet) action {
+		userID := fs.Int64("user", 0, "user `id`")
+		adID := fs.Int64("ad", 0, "ad `id`")
+		return func(ctx context.Context, c grpcPort.AdServiceClient) (proto.Message, error) {
+			return c.RemoveFavorite(ctx, &grpcPort.RemoveFavoriteRequest{UserId: *userID, AdId: *adID})
+		}
+	}},
+	{group: "favorites", name: "list", usage: "list favorite ads, the token is a session of the user", flags: func(fs *flag.FlagSet) action {
+		userID := fs.Int64("user", 0, "user `id`")
+		return func(ctx context.Context, c grpcPort.AdServiceClient) (proto.Message, error) {
+			return c.ListFavorites(ctx, &grpcPort.ListFavoritesRequest{UserId: *userID})
+		}
+	}},
+	{group: "notifications", name: "list", usage: "list notifications, the token is a session of the user", flags: func(fs *flag.FlagSet) action {
+		userID := fs.Int64("user", 0, "user `id`")
+		return func(ctx context.Context, c grpcPort.AdServiceClient) (proto.Message, error) {
+			return c.ListNotifications(ctx, &grpcPort.ListNotificationsRequest{UserId: *userID})
+		}
+	}},
+
+	{group: "searches", name: "save", usage: "save a search", flags: func(fs *flag.FlagSet) action {
+		userID := fs.Int64("user", 0, "user `id`")
+		pattern := fs.String("pattern", "", "part of the title")
+		authorID := fs.Int64("author", -1, "match the ads of this author `id` only")
+		return func(ctx context.Context, c grpcPort.AdServiceClient) (proto.Message, error) {
+			return c.SaveSearch(ctx, &grpcPort.SaveSearchRequest{UserId: *userID, Pattern: *pattern, AuthorId: *authorID})
+		}
+	}},
+	{group: "searches", name: "list", usage: "list saved searches, the token is a session of the user", flags: func(fs *flag.FlagSet) action {
+		userID := fs.Int64("user", 0, "user `id`")
+		return func(ctx context.Context, c grpcPort.AdServiceClient) (proto.Message, error) {
+			return c.ListSavedSearches(ctx, &grpcPort.ListSavedSearchesRequest{UserId: *userID})
+		}
+	}},
+	{group: "searches", name: "delete", usage: "delete a saved search", flags: func(fs *flag.FlagSet) action {
+		userID := fs.Int64("user", 0, "user `id`")
+		searchID := fs.Int64("id", 0, "search `id`")
+		return func(ctx context.Context, c grpcPort.AdServiceClient) (proto.Message, error) {
+			return c.DeleteSavedSearch(ctx, &grpcPort.DeleteSavedSearchRequest{UserId: *userID, SearchId: *searchID})
+		}
+	}},
+
+	{group: "conversations", name: "open", usage: "open a conversation with the author of an ad", flags: func(fs *flag.FlagSet) action {
+		adID := fs.Int64("ad", 0, "ad `id`")
+		userID := fs.Int64("user", 0, "buyer `id`")
+		return func(ctx context.Context, c grpcPort.AdServiceClient) (proto.Message, error) {
+			return c.OpenConversation(ctx, &grpcPort.OpenConversationRequest{AdId: *adID, UserId: *userID})
+		}
+	}},
+	{group: "conversations", name: "list", usage: "list conversations, the token is a session of the user", flags: func(fs *flag.FlagSet) action {
+		userID := fs.Int64("user", 0, "user `id`")
+		return func(ctx context.Context, c grpcPort.AdServiceClient) (proto.Message, error) {
+			return c.ListConversations(ctx, &grpcPort.ListConversationsRequest{UserId: *userID})
+		}
+	}},
+	{group: "conversations", name: "close", usage: "close a conversation", flags: func(fs *flag.FlagSet) action {
+		conversationID := fs.Int64("id", 0, "conversation `id`")
+		userID := fs.Int64("user", 0, "participant `id`")
+		return func(ctx context.Context, c grpcPort.AdServiceClient) (proto.Message, error) {
+			return c.CloseConversation(ctx, &grpcPort.CloseConversationRequest{ConversationId: *conversationID, UserId: *userID})
+		}
+	}},
+	{group: "messages", name: "send", usage: "send a message", flags: func(fs *flag.FlagSet) action {
+		conversationID := fs.Int64("conversation", 0, "conversation `id`")
+		userID := fs.Int64("user", 0, "participant `id`")
+		text := fs.String("text", "", "message text")
+		return func(ctx context.Context, c grpcPort.AdServiceClient) (proto.Message, error) {
+			return c.SendMessage(ctx, &grpcPort.SendMessageRequest{ConversationId: *conversationID, UserId: *userID, Text: *text})
+		}
+	}},
+	{group: "messages", name: "list", usage: "list the messages of a conversation, the token is a session of a participant", flags: func(fs *flag.FlagSet) action {
+		conversationID := fs.Int64("conversation", 0, "conversation `id`")
+		return func(ctx context.Context, c grpcPort.AdServiceClient) (proto.Message, error) {
+			return c.ListMessages(ctx, &grpcPort.ListMessagesRequest{ConversationId: *conversationID})
+		}
+	}},
+}
+
+func changeAdStatus(fs *flag.FlagSet, published bool) action {
+	adID := fs.Int64("id", 0, "ad `id`")
+	userID := fs.Int64("user", 0, "author `id`")
+	return func(ctx context.Context, c grpcPort.AdServiceClient) (proto.Message, error) {
+		return c.ChangeAdStatus(ctx, &grpcPort.ChangeAdStatusRequest{AdId: *adID, UserId: *userID, Published: published})
+	}
+}
+
+func findCommand(group string, name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.group == group && cmd.name == name {
+			return cmd, true
+		}
+	}
+
+	return command{}, false
+}
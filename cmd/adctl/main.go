@@ -0,0 +1,164 @@
+// Command adctl calls the ad service over gRPC:
+//
+//	adctl [-profile name] [-addr host:port] [-token token] [-o table|json|yaml] <group> <command> [flags]
+//
+// For example "adctl ads create -user 1 -title hello -text world" or
+// "adctl -o json users get -id 1". Run "adctl help" for the commands.
+//
+// The bulk commands, e.g. "adctl import ads -file ads.csv", use the admin
+// endpoints of the HTTP API instead, see -http-addr.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	grpcPort "homework10/internal/ports/grpc"
+)
+
+const envPrefix = "ADCTL_"
+
+var UnknownCommand = errors.New("unknown command")
+
+type cli struct {
+	stdout io.Writer
+	stderr io.Writer
+	getenv func(string) string
+	// dialOptions are added to the ones of the profile, e.g. a dialer
+	dialOptions []grpc.DialOption
+}
+
+func main() {
+	c := cli{stdout: os.Stdout, stderr: os.Stderr, getenv: os.Getenv}
+
+	if err := c.run(context.Background(), os.Args[1:]); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "adctl:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func (c cli) run(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("adctl", flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.Usage = c.usage(fs)
+
+	configFile := fs.String("config", c.getenv(envPrefix+"CONFIG"), "configuration `file` with the profiles")
+	profileName := fs.String("profile", c.getenv(envPrefix+"PROFILE"), "`name` of the profile to use")
+	addr := fs.String("addr", c.getenv(envPrefix+"ADDR"), "gRPC address of the service, overrides the profile")
+	httpAddr := fs.String("http-addr", c.getenv(envPrefix+"HTTP_ADDR"), "HTTP `URL` of the service for import and export, overrides the profile")
+	token := fs.String("token", c.getenv(envPrefix+"TOKEN"), "auth token, overrides the profile")
+	output := fs.String("o", c.getenv(envPrefix+"OUTPUT"), "output `format`: table, json or yaml")
+	timeout := fs.Duration("timeout", 10*time.Second, "timeout of the call")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() == 1 && fs.Arg(0) == "help" {
+		fs.SetOutput(c.stdout)
+		fs.Usage()
+		return nil
+	}
+	if fs.NArg() < 2 {
+		fs.Usage()
+		return fmt.Errorf("%w: expect a group and a command", UnknownCommand)
+	}
+
+	settings := func() (Profile, error) {
+		profile, err := loadProfile(*configFile, *profileName)
+		if err != nil {
+			return profile, err
+		}
+		if *addr != "" {
+			profile.Addr = *addr
+		}
+		if *httpAddr != "" {
+			profile.HTTPAddr = *httpAddr
+		}
+		if *token != "" {
+			profile.Token = *token
+		}
+		if *output != "" {
+			profile.Output = *output
+		}
+		return profile, nil
+	}
+
+	if bulkCmd, ok := findBulkCommand(fs.Arg(0)); ok {
+		ctx, cancel := context.WithTimeout(ctx, *timeout)
+		defer cancel()
+		return c.runBulk(ctx, bulkCmd, fs.Args()[1:], settings)
+	}
+
+	cmd, ok := findCommand(fs.Arg(0), fs.Arg(1))
+	if !ok {
+		return fmt.Errorf("%w %q", UnknownCommand, fs.Arg(0)+" "+fs.Arg(1))
+	}
+
+	cmdFlags := flag.NewFlagSet("adctl "+cmd.group+" "+cmd.name, flag.ContinueOnError)
+	cmdFlags.SetOutput(c.stderr)
+	call := cmd.flags(cmdFlags)
+	if err := cmdFlags.Parse(fs.Args()[2:]); err != nil {
+		return err
+	}
+
+	profile, err := settings()
+	if err != nil {
+		return err
+	}
+
+	opts, err := profile.dialOptions()
+	if err != nil {
+		return err
+	}
+
+	conn, err := grpc.NewClient(profile.Addr, append(opts, c.dialOptions...)...)
+	if err != nil {
+		return fmt.Errorf("can't connect to %s: %w", profile.Addr, err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+
+	resp, err := call(ctx, grpcPort.NewAdServiceClient(conn))
+	if err != nil {
+		if st, ok := status.FromError(err); ok {
+			return fmt.Errorf("%s: %s", st.Code(), st.Message())
+		}
+		return err
+	}
+
+	return write(c.stdout, profile.Output, resp)
+}
+
+func (c cli) usage(fs *flag.FlagSet) func() {
+	return func() {
+		out := fs.Output()
+		fmt.Fprintln(out, "Usage: adctl [flags] <group> <command> [command flags]")
+		fmt.Fprintln(out, "\nFlags:")
+		fs.PrintDefaults()
+		fmt.Fprintln(out, "\nCommands:")
+
+		tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		for _, cmd := range commands {
+			fmt.Fprintf(tw, "  %s %s\t%s\n", cmd.group, cmd.name, cmd.usage)
+		}
+		for _, cmd := range bulkCommands {
+			fmt.Fprintf(tw, "  %s <ads|users>\t%s\n", cmd.name, cmd.usage)
+		}
+		_ = tw.Flush()
+
+		fmt.Fprintln(out, "\nRun \"adctl <group> <command> -h\" for the flags of a command.")
+	}
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+	"gopkg.in/yaml.v3"
+
+	"homework10/internal/adapters/repo"
+	"homework10/internal/app"
+	"homework10/internal/bulk"
+	grpcPort "homework10/internal/ports/grpc"
+	"homework10/internal/ports/httpgin"
+)
+
+// newTestCLI runs the service on a bufconn listener and returns a cli
+// connected to it, and the authorization headers the service received.
+func newTestCLI(t *testing.T, env map[string]string) (*cli, *bytes.Buffer, *[]string) {
+	lis := bufconn.Listen(1024 * 1024)
+	t.Cleanup(func() {
+		lis.Close()
+	})
+
+	var tokens []string
+	srv := grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		tokens = append(tokens, md.Get("authorization")...)
+		return handler(ctx, req)
+	}))
+	t.Cleanup(func() {
+		srv.Stop()
+	})
+
+	grpcPort.RegisterAdServiceServer(srv, grpcPort.NewService(app.NewApp(repo.New(), repo.New(), repo.New())))
+
+	go func() {
+		assert.NoError(t, srv.Serve(lis), "srv.Serve")
+	}()
+
+	dialer := func(context.Context, string) (net.Conn, error) {
+		return lis.Dial()
+	}
+
+	if env == nil {
+		env = map[string]string{}
+	}
+	if _, ok := env["ADCTL_CONFIG"]; !ok {
+		env["ADCTL_CONFIG"] = filepath.Join(t.TempDir(), "absent.yaml")
+	}
+	if _, ok := env["ADCTL_ADDR"]; !ok {
+		env["ADCTL_ADDR"] = "passthrough:///bufnet"
+	}
+
+	stdout := &bytes.Buffer{}
+	c := &cli{
+		stdout:      stdout,
+		stderr:      &bytes.Buffer{},
+		getenv:      func(key string) string { return env[key] },
+		dialOptions: []grpc.DialOption{grpc.WithContextDialer(dialer)},
+	}
+
+	return c, stdout, &tokens
+}
+
+func TestCommands(t *testing.T) {
+	c, stdout, _ := newTestCLI(t, nil)
+
+	tests := []struct {
+		args   []string
+		expect []string
+	}{
+		{args: []string{"users", "create", "-name", "Oleg", "-email", "oleg@testing.ru"}, expect: []string{"ID", "NAME", "EMAIL", "Oleg", "oleg@testing.ru"}},
+		{args: []string{"ads", "create", "-user", "0", "-title", "hello", "-text", "world"}, expect: []string{"TITLE", "AUTHOR_ID", "hello", "false"}},
+		{args: []string{"ads", "publish", "-id", "0", "-user", "0"}, expect: []string{"true"}},
+		{args: []string{"ads", "update", "-id", "0", "-user", "0", "-title", "bye", "-text", "world"}, expect: []string{"bye"}},
+		{args: []string{"ads", "list"}, expect: []string{"CREATION_TIME", "bye"}},
+		{args: []string{"ads", "search", "-pattern", "by"}, expect: []string{"bye"}},
+		{args: []string{"ads", "revisions", "-id", "0"}, expect: []string{"UPDATE_TIME", "hello"}},
+		{args: []string{"users", "update", "-id", "0", "-name", "Ivan", "-email", "ivan@testing.ru"}, expect: []string{"Ivan"}},
+		{args: []string{"favorites", "add", "-user", "0", "-ad", "0", "-notify"}, expect: []string{"FAVORITES"}},
+		{args: []string{"searches", "save", "-user", "0", "-pattern", "car"}, expect: []string{"PATTERN", "car", "-1"}},
+		{args: []string{"ads", "delete", "-id", "0", "-user", "0"}},
+		{args: []string{"users", "get", "-id", "0"}, expect: []string{"Ivan"}},
+		{args: []string{"accounts", "register", "-name", "Anna", "-email", "anna@testing.ru", "-password", "long enough"}, expect: []string{"Anna"}},
+		{args: []string{"accounts", "login", "-email", "anna@testing.ru", "-password", "long enough"}, expect: []string{"TOKEN", "EXPIRATION_TIME"}},
+	}
+
+	for _, test := range tests {
+		stdout.Reset()
+
+		err := c.run(context.Background(), test.args)
+		if err != nil {
+			t.Fatalf(`test %q: expect nil got %v`, strings.Join(test.args, " "), err)
+		}
+
+		for _, expect := range test.expect {
+			if !strings.Contains(stdout.String(), expect) {
+				t.Fatalf(`test %q: expect %q in %q`, strings.Join(test.args, " "), expect, stdout.String())
+			}
+		}
+	}
+
+	// the lists of a user are shown in a session of the user only
+	err := c.run(context.Background(), []string{"favorites", "list", "-user", "1"})
+	assert.ErrorContains(t, err, "Unauthenticated: ")
+
+	stdout.Reset()
+	err = c.run(context.Background(), []string{"-o", "json", "accounts", "login", "-email", "anna@testing.ru", "-password", "long enough"})
+	assert.NoError(t, err)
+
+	var session map[string]any
+	assert.NoError(t, json.Unmarshal(stdout.Bytes(), &session))
+
+	stdout.Reset()
+	err = c.run(context.Background(), []string{"-token", session["token"].(string), "favorites", "list", "-user", "1"})
+	assert.NoError(t, err)
+}
+
+func TestOutputFormats(t *testing.T) {
+	c, stdout, _ := newTestCLI(t, nil)
+
+	err := c.run(context.Background(), []string{"-o", "json", "users", "create", "-name", "Oleg", "-email", "oleg@testing.ru"})
+	assert.NoError(t, err)
+
+	var user map[string]any
+	assert.NoError(t, json.Unmarshal(stdout.Bytes(), &user))
+	assert.NotEmpty(t, user["registration_time"])
+	delete(user, "registration_time")
+	assert.Equal(t, map[string]any{"id": float64(0), "name": "Oleg", "email": "oleg@testing.ru",
+		"display_name": "", "avatar_url": "", "phone": "", "city": "", "bio": ""}, user)
+
+	for _, title := range []string{"first", "second"} {
+		assert.NoError(t, c.run(context.Background(), []string{"ads", "create", "-user", "0", "-title", title, "-text", "text"}))
+	}
+
+	stdout.Reset()
+	err = c.run(context.Background(), []string{"-o", "yaml", "ads", "list", "-published=false"})
+	assert.NoError(t, err)
+
+	var ads []map[string]any
+	assert.NoError(t, yaml.Unmarshal(stdout.Bytes(), &ads))
+	assert.Len(t, ads, 2)
+	var titles []any
+	for _, ad := range ads {
+		titles = append(titles, ad["title"])
+		assert.Equal(t, 0, ad["author_id"])
+	}
+	assert.ElementsMatch(t, []any{"first", "second"}, titles)
+
+	stdout.Reset()
+	err = c.run(context.Background(), []string{"-o", "json", "ads", "search", "-pattern", "absent"})
+	assert.NoError(t, err)
+	assert.JSONEq(t, "[]", stdout.String())
+
+	err = c.run(context.Background(), []string{"-o", "xml", "users", "get", "-id", "0"})
+	assert.ErrorIs(t, err, UnknownOutput)
+}
+
+func TestErrors(t *testing.T) {
+	c, _, _ := newTestCLI(t, nil)
+
+	err := c.run(context.Background(), []string{"ads", "fly"})
+	assert.ErrorIs(t, err, UnknownCommand)
+
+	err = c.run(context.Background(), []string{"ads"})
+	assert.ErrorIs(t, err, UnknownCommand)
+
+	err = c.run(context.Background(), []string{"ads", "get", "-id", "first"})
+	assert.Error(t, err)
+
+	err = c.run(context.Background(), []string{"ads", "get", "-id", "7"})
+	assert.EqualError(t, err, "InvalidArgument: invalid information received")
+
+	err = c.run(context.Background(), []string{"-profile", "prod", "ads", "get", "-id", "0"})
+	assert.ErrorIs(t, err, UnknownProfile)
+}
+
+func TestProfiles(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	err := os.WriteFile(file, []byte(`
+current_profile: local
+profiles:
+  local:
+    addr: passthrough:///bufnet
+    token: local-token
+    output: json
+  broken:
+    addr: passthrough:///bufnet
+    tls:
+      ca_file: /absent/ca.pem
+`), 0o600)
+	assert.NoError(t, err)
+
+	c, stdout, tokens := newTestCLI(t, map[string]string{"ADCTL_CONFIG": file, "ADCTL_ADDR": ""})
+
+	err = c.run(context.Background(), []string{"users", "create", "-name", "Oleg", "-email", "oleg@testing.ru"})
+	assert.NoError(t, err)
+	assert.True(t, json.Valid(stdout.Bytes()), stdout.String())
+
+	// a bearer token is a session token for GetUser, so call one that ignores it
+	err = c.run(context.Background(), []string{"-token", "flag-token", "-o", "table", "ads", "list", "-published=false"})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"Bearer local-token", "Bearer flag-token"}, *tokens)
+
+	err = c.run(context.Background(), []string{"-profile", "broken", "users", "get", "-id", "0"})
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, UnknownProfile))
+}
+
+func TestBulkCommands(t *testing.T) {
+	const token = "0123456789abcdef0123456789abcdef"
+	server := httptest.NewServer(httpgin.NewHTTPServer(":18080", app.NewApp(repo.New(), repo.New(), repo.New()),
+		httpgin.AdminMW(httpgin.AdminAuth{Token: token})).Handler)
+	t.Cleanup(server.Close)
+
+	c, stdout, _ := newTestCLI(t, map[string]string{"ADCTL_HTTP_ADDR": server.URL, "ADCTL_TOKEN": token})
+
+	dir := t.TempDir()
+	usersFile := filepath.Join(dir, "users.csv")
+	assert.NoError(t, os.WriteFile(usersFile, []byte("name,email\nOleg,oleg@testing.ru\n"), 0o600))
+	adsFile := filepath.Join(dir, "ads.jsonl")
+	assert.NoError(t, os.WriteFile(adsFile, []byte(`{"title":"hello","text":"world","author_id":0}`+"\n"+`{"title":"","text":"world","author_id":0}`+"\n"), 0o600))
+
+	err := c.run(context.Background(), []string{"import", "users", "-file", usersFile})
+	assert.NoError(t, err)
+	assert.Contains(t, stdout.String(), "IMPORTED")
+
+	stdout.Reset()
+	err = c.run(context.Background(), []string{"-o", "json", "import", "ads", "-file", adsFile})
+	assert.ErrorIs(t, err, ImportFailed)
+
+	var report map[string]any
+	assert.NoError(t, json.Unmarshal(stdout.Bytes(), &report))
+	assert.Equal(t, float64(1), report["imported"])
+	assert.Equal(t, float64(1), report["failed"])
+
+	stdout.Reset()
+	err = c.run(context.Background(), []string{"export", "ads", "-format", "csv"})
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(stdout.String(), "id,title,text,author_id,"), stdout.String())
+	assert.Contains(t, stdout.String(), "\n0,hello,world,0,false,")
+
+	exported := filepath.Join(dir, "export.jsonl")
+	err = c.run(context.Background(), []string{"export", "users", "-file", exported})
+	assert.NoError(t, err)
+
+	data, err := os.ReadFile(exported)
+	assert.NoError(t, err)
+	var exportedUser map[string]any
+	assert.NoError(t, json.Unmarshal(data, &exportedUser))
+	assert.Equal(t, "oleg@testing.ru", exportedUser["email"])
+	assert.NotEmpty(t, exportedUser["registration_time"])
+
+	err = c.run(context.Background(), []string{"import", "messages"})
+	assert.ErrorIs(t, err, bulk.UnknownKind)
+
+	err = c.run(context.Background(), []string{"import", "ads", "-format", "csv", "-file", adsFile})
+	assert.EqualError(t, err, `400 Bad Request: invalid CSV header: parse error on line 1, column 2: bare " in non-quoted-field`)
+}
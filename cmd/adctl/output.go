@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gopkg.in/yaml.v3"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+var UnknownOutput = errors.New("unknown output format")
+
+// record is a response message, or an element of a list response, as
+// field names in declaration order and plain values.
+type record struct {
+	names  []string
+	values map[string]any
+}
+
+// records flattens msg into records: list responses, e.g. ListAdResponse,
+// give one record per element, other messages give one record.
+func records(msg proto.Message) (list []record, isList bool) {
+	m := msg.ProtoReflect()
+	fields := m.Descriptor().Fields()
+
+	if fields.Len() == 1 && fields.Get(0).IsList() && fields.Get(0).Message() != nil {
+		items := m.Get(fields.Get(0)).List()
+		list = make([]record, 0, items.Len())
+		for i := 0; i < items.Len(); i++ {
+			list = append(list, newRecord(items.Get(i).Message()))
+		}
+		return list, true
+	}
+
+	if fields.Len() == 0 {
+		return nil, false
+	}
+
+	return []record{newRecord(m)}, false
+}
+
+func newRecord(m protoreflect.Message) record {
+	fields := m.Descriptor().Fields()
+	r := record{names: make([]string, 0, fields.Len()), values: make(map[string]any, fields.Len())}
+
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		r.names = append(r.names, fd.JSONName())
+		r.values[fd.JSONName()] = plainValue(fd, m)
+	}
+
+	return r
+}
+
+func plainValue(fd protoreflect.FieldDescriptor, m protoreflect.Message) any {
+	v := m.Get(fd)
+
+	if fd.Message() != nil {
+		if !m.Has(fd) {
+			return nil
+		}
+		if ts, ok := v.Message().Interface().(*timestamppb.Timestamp); ok {
+			return ts.AsTime().Format(time.RFC3339)
+		}
+		return newRecord(v.Message()).values
+	}
+
+	if fd.Kind() == protoreflect.EnumKind {
+		return int32(v.Enum())
+	}
+
+	return v.Interface()
+}
+
+func write(w io.Writer, format string, msg proto.Message) error {
+	list, isList := records(msg)
+
+	var data any
+	if isList {
+		values := make([]map[string]any, 0, len(list))
+		for _, r := range list {
+			values = append(values, r.values)
+		}
+		data = values
+	} else if len(list) == 1 {
+		data = list[0].values
+	}
+
+	return writeData(w, format, list, data)
+}
+
+// writeData writes data as JSON or YAML, or list as a table.
+func writeData(w io.Writer, format string, list []record, data any) error {
+	switch format {
+	case "table":
+		return writeTable(w, list)
+	case "json":
+		if data == nil {
+			data = struct{}{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(data)
+	case "yaml":
+		if data == nil {
+			return nil
+		}
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(data); err != nil {
+			return err
+		}
+		return enc.Close()
+	default:
+		return fmt.Errorf("%w %q", UnknownOutput, format)
+	}
+}
+
+func writeTable(w io.Writer, list []record) error {
+	if len(list) == 0 {
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	names := list[0].names
+	header := make([]string, len(names))
+	for i, name := range names {
+		header[i] = strings.ToUpper(name)
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+
+	for _, r := range list {
+		cells := make([]string, len(names))
+		for i, name := range names {
+			if v := r.values[name]; v != nil {
+				cells[i] = fmt.Sprint(v)
+			}
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"gopkg.in/yaml.v3"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+var (
+	UnknownProfile = errors.New("unknown profile")
+	InvalidCA      = errors.New("no certificates found in the CA file")
+)
+
+// Profile is a named set of connection settings, e.g. for a local and a
+// production deployment.
+type Profile struct {
+	Addr     string     `yaml:"addr"`
+	HTTPAddr string     `yaml:"http_addr"`
+	Token    string     `yaml:"token"`
+	Output   string     `yaml:"output"`
+	TLS      ProfileTLS `yaml:"tls"`
+}
+
+type ProfileTLS struct {
+	Enabled    bool   `yaml:"enabled"`
+	CAFile     string `yaml:"ca_file"`
+	CertFile   string `yaml:"cert_file"`
+	KeyFile    string `yaml:"key_file"`
+	ServerName string `yaml:"server_name"`
+}
+
+// Profiles is the configuration file of adctl:
+//
+//	current_profile: local
+//	profiles:
+//	  local:
+//	    addr: localhost:50054
+//	  prod:
+//	    addr: ads.example.com:443
+//	    http_addr: https://ads.example.com
+//	    token: secret
+//	    tls:
+//	      enabled: true
+type Profiles struct {
+	CurrentProfile string             `yaml:"current_profile"`
+	Profiles       map[string]Profile `yaml:"profiles"`
+}
+
+func defaultConfigFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "adctl", "config.yaml")
+}
+
+// loadProfile returns the named profile, or the current one if name is
+// empty. A missing file is fine as long as no profile is asked for.
+func loadProfile(file string, name string) (Profile, error) {
+	profile := Profile{Addr: "localhost:50054", HTTPAddr: "http://localhost:9000", Output: "table"}
+	if file == "" {
+		file = defaultConfigFile()
+	}
+
+	var profiles Profiles
+	data, err := os.ReadFile(file)
+	if err == nil {
+		err = yaml.Unmarshal(data, &profiles)
+		if err != nil {
+			return profile, fmt.Errorf("can't parse config file: %w", err)
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return profile, fmt.Errorf("can't read config file: %w", err)
+	}
+
+	if name == "" {
+		name = profiles.CurrentProfile
+	}
+	if name == "" {
+		return profile, nil
+	}
+
+	p, ok := profiles.Profiles[name]
+	if !ok {
+		return profile, fmt.Errorf("%w %q", UnknownProfile, name)
+	}
+
+	if p.Addr != "" {
+		profile.Addr = p.Addr
+	}
+	if p.HTTPAddr != "" {
+		profile.HTTPAddr = p.HTTPAddr
+	}
+	if p.Output != "" {
+		profile.Output = p.Output
+	}
+	profile.Token, profile.TLS = p.Token, p.TLS
+
+	return profile, nil
+}
+
+// tlsConfig returns nil when the profile doesn't use TLS.
+func (p Profile) tlsConfig() (*tls.Config, error) {
+	if !p.TLS.Enabled && p.TLS.CAFile == "" {
+		return nil, nil
+	}
+
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12, ServerName: p.TLS.ServerName}
+
+	if p.TLS.CAFile != "" {
+		data, err := os.ReadFile(p.TLS.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("can't read CA file: %w", err)
+		}
+
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(data) {
+			return nil, InvalidCA
+		}
+	}
+
+	if p.TLS.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(p.TLS.CertFile, p.TLS.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("can't load key pair: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
+
+func (p Profile) dialOptions() ([]grpc.DialOption, error) {
+	cfg, err := p.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	creds := insecure.NewCredentials()
+	if cfg != nil {
+		creds = credentials.NewTLS(cfg)
+	}
+
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+	if p.Token != "" {
+		opts = append(opts, grpc.WithPerRPCCredentials(tokenCredentials(p.Token)))
+	}
+
+	return opts, nil
+}
+
+// httpClient is used by the commands the gRPC API doesn't cover.
+func (p Profile) httpClient() (*http.Client, error) {
+	cfg, err := p.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = cfg
+
+	return &http.Client{Transport: transport}, nil
+}
+
+// tokenCredentials sends the token as "authorization: Bearer <token>".
+type tokenCredentials string
+
+func (t tokenCredentials) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + string(t)}, nil
+}
+
+func (t tokenCredentials) RequireTransportSecurity() bool {
+	return false
+}
@@ -4,14 +4,38 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/gin-gonic/gin"
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/recovery"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"homework10/internal/adapters/notifier"
 	"homework10/internal/adapters/repo"
 	"homework10/internal/app"
+	"homework10/internal/cache"
+	"homework10/internal/config"
+	"homework10/internal/health"
+	"homework10/internal/idempotency"
+	"homework10/internal/logger"
+	"homework10/internal/metrics"
+	"homework10/internal/migrate"
+	"homework10/internal/password"
+	"homework10/internal/ports/gateway"
 	"homework10/internal/ports/httpgin"
+	"homework10/internal/ratelimit"
+	"homework10/internal/replication"
+	"homework10/internal/tlscert"
+	"homework10/internal/tracing"
+	"homework10/internal/users"
 	"log"
+	"log/slog"
 	"net"
 	"net/http"
 	"os"
@@ -23,28 +47,249 @@ import (
 )
 
 const (
-	gPort = ":50054"
-	hPort = ":9000"
+	serviceName         = "ad-service"
+	healthCheckInterval = 5 * time.Second
+	replicationRetry    = time.Second
+)
+
+// replicaMethods and replicaRoutes are the calls a read replica serves.
+var (
+	replicaMethods = []string{
+		grpcPort.AdService_GetAd_FullMethodName,
+		grpcPort.AdService_ListAds_FullMethodName,
+		grpcPort.AdService_SearchAds_FullMethodName,
+	}
+	replicaRoutes = []string{
+		"GET /api/v1/ads",
+		"GET /api/v1/ads/:ad_id",
+		"GET /api/v1/ads/search/:pattern",
+	}
 )
 
 func main() {
-	lis, err := net.Listen("tcp", gPort)
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(context.Background(), os.Args[2:], os.Stdout, logger.New(os.Stderr, "info")); err != nil {
+			log.Fatalf("migrate: %v", err)
+		}
+		return
+	}
+
+	cfg, err := config.Load(os.Args[1:], os.Getenv)
+	if err != nil {
+		log.Fatalf("failed to load config: %v", err)
+	}
+
+	l := logger.New(os.Stdout, cfg.LogLevel)
+	slog.SetDefault(l)
+
+	tp, err := tracing.NewProvider(context.Background(), serviceName, cfg.Tracing.Exporter, cfg.Tracing.Endpoint, os.Stdout)
 	if err != nil {
-		log.Fatalf("failed to listen: %v", err)
+		l.Error("failed to create tracer provider", "error", err.Error())
+		os.Exit(1)
 	}
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(tracing.Propagator())
 
-	adApp := app.NewApp(repo.New(), repo.New())
+	lis, err := net.Listen("tcp", cfg.GRPCAddr)
+	if err != nil {
+		l.Error("failed to listen", "addr", cfg.GRPCAddr, "error", err.Error())
+		os.Exit(1)
+	}
+
+	replica := cfg.Replication.Primary != ""
+
+	var repos map[string]*repo.Repo
+	if replica {
+		// the primary checks the schema of the copy
+		repos = make(map[string]*repo.Repo, len(repoNames))
+		for _, name := range repoNames {
+			repos[name] = repo.NewReplica()
+		}
+	} else {
+		var record migrate.Record
+		var closeStorage func()
+		repos, record, closeStorage, err = openStorage(cfg, l)
+		if err != nil {
+			l.Error("failed to open the storage", "error", err.Error())
+			os.Exit(1)
+		}
+		defer closeStorage()
+
+		if err := migrate.Check(context.Background(), migrate.Migrations, record, repositories(repos)); err != nil {
+			l.Error("the storage schema doesn't match the service", "error", err.Error())
+			closeStorage()
+			os.Exit(1)
+		}
+
+		if cfg.Replication.Backlog > 0 {
+			for _, r := range repos {
+				r.Replicate(cfg.Replication.Backlog, withoutCredentials)
+			}
+		}
+	}
+	adRepo, userRepo, conversationRepo := repos["ads"], repos["users"], repos["conversations"]
 
-	grpcServer := grpc.NewServer(grpc.ChainUnaryInterceptor(
-		logging.UnaryServerInterceptor(grpcPort.InterceptorLogger()),
+	m := metrics.New()
+	m.RegisterDomain(adRepo, userRepo)
+
+	h := health.New(grpcPort.AdService_ServiceDesc.ServiceName)
+	for name, r := range repos {
+		h.AddCheck("storage."+name, r.Ping)
+	}
+
+	// the cache wraps the instrumented repository, so that the metrics and
+	// the traces show the reads it saves. A replica applies the changes of
+	// the primary below the cache, so it doesn't cache.
+	adStorage := m.Repository("ads", tracing.Repository("ads", adRepo, tp))
+	switch {
+	case replica:
+	case cfg.Cache.Backend == "memory":
+		adStorage = cache.Repository("ads", adStorage, cache.NewLRU(cfg.Cache.Size), cfg.Cache.TTL)
+	case cfg.Cache.Backend == "redis":
+		redis := cache.NewRedis(cfg.Cache.Addr)
+		h.AddCheck("cache", redis.Ping)
+		adStorage = cache.Repository("ads", adStorage, redis, cfg.Cache.TTL)
+	}
+
+	hasher, err := password.New(cfg.Auth.Hasher)
+	if err != nil {
+		l.Error("failed to create the password hasher", "error", err.Error())
+		os.Exit(1)
+	}
+
+	email := notifier.NewEmail(cfg.SMTP.Addr, cfg.SMTP.From, cfg.SMTP.Timeout)
+	adApp := tracing.App(app.NewApp(
+		adStorage,
+		m.Repository("users", tracing.Repository("users", userRepo, tp)),
+		m.Repository("conversations", tracing.Repository("conversations", conversationRepo, tp)),
+		app.WithNotifier(email),
+		app.WithMailer(email),
+		app.WithRevisionLimit(cfg.RevisionLimit),
+		app.WithHasher(hasher),
+		app.WithAccountPolicy(app.AccountPolicy{
+			SessionTTL:      cfg.Auth.SessionTTL,
+			ResetTTL:        cfg.Auth.ResetTTL,
+			MaxFailedLogins: cfg.Auth.MaxFailedLogins,
+			Lockout:         cfg.Auth.Lockout,
+		}),
+		app.WithLogger(l)), tp)
+
+	limiter := ratelimit.New(cfg.Limits())
+	idempotencyStore := idempotency.New(cfg.Idempotency.TTL, cfg.Idempotency.MaxEntries)
+
+	var serverOpts []grpc.ServerOption
+	var certs *tlscert.Reloader
+	if cfg.TLS.Enabled() {
+		certs, err = tlscert.NewReloader(cfg.TLS.CertFile, cfg.TLS.KeyFile, cfg.TLS.ClientCAFile)
+		if err != nil {
+			l.Error("failed to load tls certificate", "error", err.Error())
+			os.Exit(1)
+		}
+		serverOpts = append(serverOpts, grpc.Creds(credentials.NewTLS(certs.ServerConfig(cfg.TLS.RequireClientCert))))
+	}
+
+	serverOpts = append(serverOpts, grpc.StatsHandler(otelgrpc.NewServerHandler()))
+
+	interceptors := []grpc.UnaryServerInterceptor{
+		grpcPort.RequestIDInterceptor,
+		grpcPort.PeerIdentityInterceptor,
+		grpcPort.MetricsInterceptor(m),
+		logging.UnaryServerInterceptor(grpcPort.InterceptorLogger(l)),
 		recovery.UnaryServerInterceptor([]recovery.Option{
 			recovery.WithRecoveryHandler(grpcPort.PanicInterceptor),
 		}...),
-	))
+		grpcPort.IdempotencyInterceptor(idempotencyStore),
+		grpcPort.RateLimitInterceptor(limiter, adApp),
+	}
+	if replica {
+		interceptors = append(interceptors, grpcPort.ReadOnlyInterceptor(replicaMethods...))
+	}
+
+	grpcServer := grpc.NewServer(append(serverOpts, grpc.ChainUnaryInterceptor(interceptors...),
+		grpc.ChainStreamInterceptor(grpcPort.PeerIdentityStreamInterceptor))...)
 	grpcService := grpcPort.NewService(adApp)
 	grpcPort.RegisterAdServiceServer(grpcServer, grpcService)
+	grpc_health_v1.RegisterHealthServer(grpcServer, h.Server())
+	if !replica && cfg.Replication.Backlog > 0 {
+		sources := make(map[string]replication.Source, len(repos))
+		for name, r := range repos {
+			sources[name] = r
+		}
+		grpcPort.RegisterReplicationServer(grpcServer, grpcPort.NewReplicationService(sources, cfg.Replication.Heartbeat, cfg.Replication.Replicas))
+	}
 
-	httpServer := httpgin.NewHTTPServer(hPort, adApp)
+	reflection.Register(grpcServer)
+
+	httpMiddlewares := []gin.HandlerFunc{otelgin.Middleware(serviceName), httpgin.PeerIdentityMW, httpgin.AdminMW(httpgin.AdminAuth{Token: cfg.Auth.AdminToken, Identities: cfg.Auth.AdminIdentities}), httpgin.MetricsMW(m), httpgin.IdempotencyMW(idempotencyStore), httpgin.RateLimitMW(limiter, adApp)}
+	if replica {
+		httpMiddlewares = append(httpMiddlewares, httpgin.ReadOnlyMW(replicaRoutes...))
+	}
+
+	var httpServer *http.Server
+	if cfg.HTTPRouter == "gateway" {
+		creds := insecure.NewCredentials()
+		if certs != nil {
+			creds = credentials.NewTLS(certs.LoopbackConfig())
+		}
+
+		conn, err := grpc.NewClient(loopbackAddr(lis.Addr()), grpc.WithTransportCredentials(creds), grpc.WithStatsHandler(otelgrpc.NewClientHandler()))
+		if err != nil {
+			l.Error("failed to connect the gateway", "error", err.Error())
+			os.Exit(1)
+		}
+		defer conn.Close()
+
+		gw, err := gateway.NewHandler(context.Background(), conn)
+		if err != nil {
+			l.Error("failed to register the gateway", "error", err.Error())
+			os.Exit(1)
+		}
+
+		httpServer = httpgin.NewGatewayServer(cfg.HTTPAddr, gw, httpMiddlewares...)
+	} else {
+		httpServer = httpgin.NewHTTPServer(cfg.HTTPAddr, adApp, httpMiddlewares...)
+	}
+
+	if err := httpgin.SetTrustedProxies(httpServer, cfg.TrustedProxies); err != nil {
+		l.Error("failed to set the trusted proxies", "error", err.Error())
+		os.Exit(1)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m.Handler())
+	mux.Handle("/healthz", h.LiveHandler())
+	mux.Handle("/readyz", h.ReadyHandler())
+	mux.Handle("/", httpServer.Handler)
+	httpServer.Handler = mux
+	if certs != nil {
+		httpServer.TLSConfig = certs.ServerConfig(cfg.TLS.RequireClientCert)
+	}
+
+	var follower *grpcPort.Replica
+	if replica {
+		creds := insecure.NewCredentials()
+		if certs != nil {
+			creds = credentials.NewTLS(certs.PeerConfig())
+		}
+
+		conn, err := grpc.NewClient(cfg.Replication.Primary, grpc.WithTransportCredentials(creds))
+		if err != nil {
+			l.Error("failed to connect to the primary", "addr", cfg.Replication.Primary, "error", err.Error())
+			os.Exit(1)
+		}
+		defer conn.Close()
+
+		sinks := make(map[string]replication.Sink, len(repos))
+		for name, r := range repos {
+			sinks[name] = r
+		}
+		follower = grpcPort.NewReplica(conn, sinks, replicationRetry, l)
+		h.AddCheck("replication", follower.ReadyCheck(3*cfg.Replication.Heartbeat))
+		m.RegisterReplication(repoNames, func(name string) (int64, time.Time) {
+			lag := follower.Lag()[name]
+			return lag.Changes, lag.LastEvent
+		})
+	}
 
 	eg, ctx := errgroup.WithContext(context.Background())
 
@@ -55,7 +300,8 @@ func main() {
 	eg.Go(func() error {
 		select {
 		case s := <-sigQuit:
-			log.Printf("captured signal: %v\n", s)
+			l.Info("captured signal", "signal", s.String())
+			h.Shutdown()
 			return fmt.Errorf("captured signal: %v", s)
 		case <-ctx.Done():
 			return nil
@@ -63,12 +309,34 @@ func main() {
 	})
 
 	eg.Go(func() error {
-		log.Printf("starting grpc cmd, listening on %s\n", gPort)
-		defer log.Printf("close grpc cmd listening on %s\n", gPort)
+		h.Run(ctx, healthCheckInterval)
+		return nil
+	})
+
+	if follower != nil {
+		eg.Go(func() error {
+			follower.Run(ctx)
+			return nil
+		})
+	}
+
+	if certs != nil {
+		eg.Go(func() error {
+			certs.Run(ctx, cfg.TLS.ReloadInterval, func(err error) {
+				l.Error("can't reload tls certificate", "error", err.Error())
+			})
+			return nil
+		})
+	}
+
+	eg.Go(func() error {
+		l.Info("starting grpc cmd", "addr", cfg.GRPCAddr)
+		defer l.Info("close grpc cmd", "addr", cfg.GRPCAddr)
 
 		errCh := make(chan error)
 
 		defer func() {
+			h.Shutdown()
 			grpcServer.GracefulStop()
 			_ = lis.Close()
 
@@ -90,24 +358,33 @@ func main() {
 	})
 
 	eg.Go(func() error {
-		log.Printf("starting http cmd, listening on %s\n", httpServer.Addr)
-		defer log.Printf("close http cmd listening on %s\n", httpServer.Addr)
+		l.Info("starting http cmd", "addr", httpServer.Addr)
+		defer l.Info("close http cmd", "addr", httpServer.Addr)
 
 		errCh := make(chan error)
 
 		defer func() {
-			shCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			h.Shutdown()
+
+			shCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
 			defer cancel()
 
 			if err := httpServer.Shutdown(shCtx); err != nil {
-				log.Printf("can't close http cmd listening on %s: %s", httpServer.Addr, err.Error())
+				l.Error("can't close http cmd", "addr", httpServer.Addr, "error", err.Error())
 			}
 
 			close(errCh)
 		}()
 
 		go func() {
-			if err := httpServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			var err error
+			if certs != nil {
+				err = httpServer.ListenAndServeTLS("", "")
+			} else {
+				err = httpServer.ListenAndServe()
+			}
+
+			if !errors.Is(err, http.ErrServerClosed) {
 				errCh <- err
 			}
 		}()
@@ -121,8 +398,41 @@ func main() {
 	})
 
 	if err := eg.Wait(); err != nil {
-		log.Printf("gracefully shutting down the servers: %s\n", err.Error())
+		l.Info("gracefully shutting down the servers", "reason", err.Error())
+	}
+
+	shCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	if err := tp.Shutdown(shCtx); err != nil {
+		l.Error("can't flush traces", "error", err.Error())
+	}
+
+	l.Info("servers were successfully shutdown")
+}
+
+// loopbackAddr is the address the gateway dials to reach the gRPC server
+// listening on addr, which may be a wildcard one like ":50054".
+func loopbackAddr(addr net.Addr) string {
+	host, port, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+
+	if ip := net.ParseIP(host); host == "" || ip != nil && ip.IsUnspecified() {
+		host = "localhost"
+	}
+
+	return net.JoinHostPort(host, port)
+}
+
+// withoutCredentials keeps the secrets of the users on the primary, the
+// replicas serve the ads only.
+func withoutCredentials(e interface{}) interface{} {
+	if user, ok := e.(users.User); ok {
+		user.Credentials = users.Credentials{}
+		return user
 	}
 
-	log.Println("servers were successfully shutdown")
+	return e
 }
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"homework10/internal/adapters/repo"
+	"homework10/internal/config"
+	"homework10/internal/migrate"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+var repoNames = []string{"ads", "users", "conversations"}
+
+// openStorage opens the repositories of the storage backend by name, and
+// the record of the migrations applied to them. The returned function closes
+// them.
+func openStorage(cfg config.Config, l *slog.Logger) (map[string]*repo.Repo, migrate.Record, func(), error) {
+	repos := make(map[string]*repo.Repo, len(repoNames))
+	closeAll := func() {
+		for name, r := range repos {
+			if err := r.Close(); err != nil {
+				l.Error("failed to close the storage", "name", name, "error", err.Error())
+			}
+		}
+	}
+
+	if cfg.Storage.Backend != "wal" {
+		for _, name := range repoNames {
+			repos[name] = repo.New().(*repo.Repo)
+		}
+		return repos, migrate.NewMemoryRecord(), closeAll, nil
+	}
+
+	opts := repo.Options{
+		Sync:          repo.SyncPolicy(cfg.Storage.Sync),
+		SyncInterval:  cfg.Storage.SyncInterval,
+		SnapshotEvery: cfg.Storage.SnapshotEvery,
+	}
+	for _, name := range repoNames {
+		r, err := repo.Open(filepath.Join(cfg.Storage.DSN, name), opts)
+		if err != nil {
+			closeAll()
+			return nil, nil, nil, fmt.Errorf("%s: %w", name, err)
+		}
+		repos[name] = r
+	}
+
+	return repos, migrate.NewFileRecord(filepath.Join(cfg.Storage.DSN, "schema.json")), closeAll, nil
+}
+
+func repositories(repos map[string]*repo.Repo) migrate.Repositories {
+	res := make(migrate.Repositories, len(repos))
+	for name, r := range repos {
+		res[name] = r
+	}
+	return res
+}
+
+// runMigrate runs "migrate up [version]", "migrate down [version]" or
+// "migrate status", followed by the flags selecting the storage, e.g.
+// "migrate up -storage wal -dsn data". Up goes to the latest version and
+// down reverts the last migration by default. The service must not be
+// running on the storage meanwhile.
+func runMigrate(ctx context.Context, args []string, out io.Writer, l *slog.Logger) error {
+	if len(args) == 0 {
+		return errors.New("expect up, down or status")
+	}
+	action, args := args[0], args[1:]
+
+	to := -1
+	if len(args) > 0 {
+		if v, err := strconv.Atoi(args[0]); err == nil {
+			to, args = v, args[1:]
+		}
+	}
+
+	cfg, err := config.Load(args, os.Getenv)
+	if err != nil {
+		return err
+	}
+	if cfg.Storage.Backend == "memory" {
+		return errors.New("the memory storage starts empty on every run, there is nothing to migrate")
+	}
+
+	repos, record, closeStorage, err := openStorage(cfg, l)
+	if err != nil {
+		return err
+	}
+	defer closeStorage()
+
+	version, err := migrate.Version(record)
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case "up":
+		if to < 0 {
+			to = migrate.Latest(migrate.Migrations)
+		}
+		done, err := migrate.Up(ctx, migrate.Migrations, record, repositories(repos), to)
+		for _, m := range done {
+			fmt.Fprintf(out, "applied %d %s\n", m.Version, m.Name)
+		}
+		return err
+	case "down":
+		if to < 0 {
+			to = max(version-1, 0)
+		}
+		done, err := migrate.Down(ctx, migrate.Migrations, record, repositories(repos), to)
+		for _, m := range done {
+			fmt.Fprintf(out, "reverted %d %s\n", m.Version, m.Name)
+		}
+		return err
+	case "status":
+		applied, err := record.Load()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "version %d, latest %d\n", version, migrate.Latest(migrate.Migrations))
+		for _, a := range applied {
+			fmt.Fprintf(out, "applied %d %s at %s\n", a.Version, a.Name, a.AppliedAt.Format("2006-01-02 15:04:05"))
+		}
+		for _, m := range migrate.Migrations[min(version, len(migrate.Migrations)):] {
+			fmt.Fprintf(out, "pending %d %s\n", m.Version, m.Name)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate command %q, expect up, down or status", action)
+	}
+}
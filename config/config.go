@@ -0,0 +1,492 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+	"homework10/internal/ratelimit"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const envPrefix = "ADS_"
+
+var InvalidConfig = errors.New("invalid configuration")
+
+type Config struct {
+	GRPCAddr        string               `yaml:"grpc_addr"`
+	HTTPAddr        string               `yaml:"http_addr"`
+	HTTPRouter      string               `yaml:"http_router"`
+	TrustedProxies  []string             `yaml:"trusted_proxies"`
+	ShutdownTimeout time.Duration        `yaml:"shutdown_timeout"`
+	RevisionLimit   int                  `yaml:"revision_limit"`
+	Storage         StorageConfig        `yaml:"storage"`
+	TLS             TLSConfig            `yaml:"tls"`
+	SMTP            SMTPConfig           `yaml:"smtp"`
+	RateLimits      map[string]RateLimit `yaml:"rate_limits"`
+	Idempotency     IdempotencyConfig    `yaml:"idempotency"`
+	Cache           CacheConfig          `yaml:"cache"`
+	Replication     ReplicationConfig    `yaml:"replication"`
+	Auth            AuthConfig           `yaml:"auth"`
+	LogLevel        string               `yaml:"log_level"`
+	Tracing         TracingConfig        `yaml:"tracing"`
+}
+
+// StorageConfig sets where the repositories are kept. The wal backend keeps
+// them in memory and persists them to a write-ahead log and snapshots in the
+// DSN directory, flushed to the disk as Sync says: always, every
+// SyncInterval or never.
+type StorageConfig struct {
+	Backend       string        `yaml:"backend"`
+	DSN           string        `yaml:"dsn"`
+	Sync          string        `yaml:"sync"`
+	SyncInterval  time.Duration `yaml:"sync_interval"`
+	SnapshotEvery int           `yaml:"snapshot_every"`
+}
+
+type TLSConfig struct {
+	CertFile          string        `yaml:"cert_file"`
+	KeyFile           string        `yaml:"key_file"`
+	ClientCAFile      string        `yaml:"client_ca_file"`
+	RequireClientCert bool          `yaml:"require_client_cert"`
+	ReloadInterval    time.Duration `yaml:"reload_interval"`
+}
+
+// SMTPConfig sets the server the emails are sent through, and how long
+// sending one may take.
+type SMTPConfig struct {
+	Addr    string        `yaml:"addr"`
+	From    string        `yaml:"from"`
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+type TracingConfig struct {
+	Exporter string `yaml:"exporter"`
+	Endpoint string `yaml:"endpoint"`
+}
+
+// IdempotencyConfig sets how long the response of a request made with an
+// idempotency key is replayed, and how many keys are kept at most.
+type IdempotencyConfig struct {
+	TTL        time.Duration `yaml:"ttl"`
+	MaxEntries int           `yaml:"max_entries"`
+}
+
+// CacheConfig sets where the ads read by GetAd and ListAds are cached:
+// nowhere, in memory or on a Redis-compatible server at Addr.
+type CacheConfig struct {
+	Backend string        `yaml:"backend"`
+	Addr    string        `yaml:"addr"`
+	TTL     time.Duration `yaml:"ttl"`
+	Size    int           `yaml:"size"`
+}
+
+// ReplicationConfig sets how the repositories are replicated. An instance
+// with a Primary gRPC address is a read replica following it; any other one
+// keeps its last Backlog changes for the Replicas, identified by their
+// client certificates, or serves none if it is zero, and sends them a
+// heartbeat when it has no change for a while.
+type ReplicationConfig struct {
+	Primary   string        `yaml:"primary"`
+	Backlog   int           `yaml:"backlog"`
+	Replicas  []string      `yaml:"replicas"`
+	Heartbeat time.Duration `yaml:"heartbeat"`
+}
+
+// AuthConfig sets how the passwords are hashed, argon2id or bcrypt, how long
+// sessions and reset tokens live, and how many failed logins in a row lock
+// an account for Lockout. The admin routes are open to the callers sending
+// AdminToken or with a client certificate of AdminIdentities, to nobody if
+// both are empty.
+type AuthConfig struct {
+	Hasher          string        `yaml:"hasher"`
+	SessionTTL      time.Duration `yaml:"session_ttl"`
+	ResetTTL        time.Duration `yaml:"reset_ttl"`
+	MaxFailedLogins int           `yaml:"max_failed_logins"`
+	Lockout         time.Duration `yaml:"lockout"`
+	AdminToken      string        `yaml:"admin_token"`
+	AdminIdentities []string      `yaml:"admin_identities"`
+}
+
+// MinAdminToken is the length of the shortest admin token accepted.
+const MinAdminToken = 32
+
+type RateLimit struct {
+	Rate  float64 `yaml:"rate"`
+	Burst int     `yaml:"burst"`
+}
+
+func (t TLSConfig) Enabled() bool {
+	return t.CertFile != ""
+}
+
+func (c Config) Limits() map[string]ratelimit.Rule {
+	rules := make(map[string]ratelimit.Rule, len(c.RateLimits))
+	for route, limit := range c.RateLimits {
+		rules[route] = ratelimit.Rule{Rate: limit.Rate, Burst: limit.Burst}
+	}
+
+	return rules
+}
+
+func Default() Config {
+	return Config{
+		GRPCAddr:        ":50054",
+		HTTPAddr:        ":9000",
+		HTTPRouter:      "gin",
+		ShutdownTimeout: 30 * time.Second,
+		RevisionLimit:   10,
+		Storage:         StorageConfig{Backend: "memory", Sync: "interval", SyncInterval: time.Second, SnapshotEvery: 10000},
+		TLS:             TLSConfig{ReloadInterval: 10 * time.Second},
+		SMTP:            SMTPConfig{Addr: "localhost:1025", From: "noreply@ads.local", Timeout: 10 * time.Second},
+		RateLimits: map[string]RateLimit{
+			"POST /api/v1/ads":                                     {Rate: 1, Burst: 5},
+			"POST /api/v1/ads:batch":                               {Rate: 1, Burst: 5},
+			"POST /api/v1/users":                                   {Rate: 1, Burst: 5},
+			"POST /api/v1/accounts":                                {Rate: 1, Burst: 5},
+			"POST /api/v1/sessions":                                {Rate: 1, Burst: 10},
+			"POST /api/v1/password-resets":                         {Rate: 0.1, Burst: 3},
+			"POST /api/v1/conversations/:conversation_id/messages": {Rate: 1, Burst: 10},
+			"/ad.AdService/CreateAd":                               {Rate: 1, Burst: 5},
+			"/ad.AdService/BatchCreateAds":                         {Rate: 1, Burst: 5},
+			"/ad.AdService/CreateUser":                             {Rate: 1, Burst: 5},
+			"/ad.AdService/Register":                               {Rate: 1, Burst: 5},
+			"/ad.AdService/Login":                                  {Rate: 1, Burst: 10},
+			"/ad.AdService/RequestPasswordReset":                   {Rate: 0.1, Burst: 3},
+			"/ad.AdService/SendMessage":                            {Rate: 1, Burst: 10},
+		},
+		Idempotency: IdempotencyConfig{TTL: 24 * time.Hour, MaxEntries: 100000},
+		Cache:       CacheConfig{Backend: "memory", Addr: "localhost:6379", TTL: time.Minute, Size: 10000},
+		Replication: ReplicationConfig{Heartbeat: time.Second},
+		Auth:        AuthConfig{Hasher: "argon2id", SessionTTL: 24 * time.Hour, ResetTTL: time.Hour, MaxFailedLogins: 5, Lockout: 15 * time.Minute},
+		LogLevel:    "info",
+		Tracing:     TracingConfig{Exporter: "none", Endpoint: "localhost:4317"},
+	}
+}
+
+// setting is an option that can be set from the environment, e.g.
+// ADS_GRPC_ADDR, and from the command line, e.g. -grpc-addr.
+type setting struct {
+	name   string
+	usage  string
+	set    func(string) error
+	isBool bool
+}
+
+func setString(dst *string) func(string) error {
+	return func(v string) error {
+		*dst = v
+		return nil
+	}
+}
+
+func setDuration(dst *time.Duration) func(string) error {
+	return func(v string) (err error) {
+		*dst, err = time.ParseDuration(v)
+		return err
+	}
+}
+
+func setInt(dst *int) func(string) error {
+	return func(v string) (err error) {
+		*dst, err = strconv.Atoi(v)
+		return err
+	}
+}
+
+func setList(dst *[]string) func(string) error {
+	return func(v string) error {
+		*dst = nil
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				*dst = append(*dst, item)
+			}
+		}
+		return nil
+	}
+}
+
+func setBool(dst *bool) func(string) error {
+	return func(v string) (err error) {
+		*dst, err = strconv.ParseBool(v)
+		return err
+	}
+}
+
+// Load builds the configuration from the defaults, a YAML file, environment
+// variables and command line flags, each source overriding the previous one.
+// The file is set with the -config flag or the ADS_CONFIG variable.
+func Load(args []string, getenv func(string) string) (Config, error) {
+	cfg := Default()
+
+	fs := flag.NewFlagSet("ad-service", flag.ContinueOnError)
+	configFile := fs.String("config", getenv(envPrefix+"CONFIG"), "path to the YAML configuration file")
+
+	settings := []setting{
+		{name: "grpc-addr", usage: "gRPC listen address", set: setString(&cfg.GRPCAddr)},
+		{name: "http-addr", usage: "HTTP listen address", set: setString(&cfg.HTTPAddr)},
+		{name: "http-router", usage: "router serving /api/v1: gin or gateway", set: setString(&cfg.HTTPRouter)},
+		{name: "trusted-proxies", usage: "comma-separated IPs or CIDRs of the proxies whose X-Forwarded-For is trusted", set: setList(&cfg.TrustedProxies)},
+		{name: "shutdown-timeout", usage: "graceful shutdown timeout", set: setDuration(&cfg.ShutdownTimeout)},
+		{name: "revision-limit", usage: "number of revisions kept per ad, 0 to keep all of them", set: setInt(&cfg.RevisionLimit)},
+		{name: "storage", usage: "storage backend: memory or wal", set: setString(&cfg.Storage.Backend)},
+		{name: "dsn", usage: "storage data source name, the directory of the wal backend", set: setString(&cfg.Storage.DSN)},
+		{name: "storage-sync", usage: "when the write-ahead log is flushed: always, interval or never", set: setString(&cfg.Storage.Sync)},
+		{name: "storage-sync-interval", usage: "how often the write-ahead log is flushed", set: setDuration(&cfg.Storage.SyncInterval)},
+		{name: "storage-snapshot-every", usage: "number of changes between snapshots, 0 to disable them", set: setInt(&cfg.Storage.SnapshotEvery)},
+		{name: "tls-cert", usage: "TLS certificate file", set: setString(&cfg.TLS.CertFile)},
+		{name: "tls-key", usage: "TLS key file", set: setString(&cfg.TLS.KeyFile)},
+		{name: "tls-client-ca", usage: "CA file to verify client certificates", set: setString(&cfg.TLS.ClientCAFile)},
+		{name: "tls-require-client-cert", usage: "reject clients without a certificate", set: setBool(&cfg.TLS.RequireClientCert), isBool: true},
+		{name: "tls-reload-interval", usage: "how often to check the TLS files for changes", set: setDuration(&cfg.TLS.ReloadInterval)},
+		{name: "smtp-timeout", usage: "how long sending an email may take", set: setDuration(&cfg.SMTP.Timeout)},
+		{name: "idempotency-ttl", usage: "how long to replay the response of a request with an idempotency key", set: setDuration(&cfg.Idempotency.TTL)},
+		{name: "idempotency-max-entries", usage: "number of idempotency keys kept at most", set: setInt(&cfg.Idempotency.MaxEntries)},
+		{name: "cache", usage: "cache backend: none, memory or redis", set: setString(&cfg.Cache.Backend)},
+		{name: "cache-addr", usage: "address of the redis cache server", set: setString(&cfg.Cache.Addr)},
+		{name: "cache-ttl", usage: "how long a cached ad is served", set: setDuration(&cfg.Cache.TTL)},
+		{name: "replicate-from", usage: "gRPC address of the primary to run as a read replica of", set: setString(&cfg.Replication.Primary)},
+		{name: "replication-backlog", usage: "number of changes kept for the replicas, 0 to serve none", set: setInt(&cfg.Replication.Backlog)},
+		{name: "replicas", usage: "comma-separated client certificate identities of the replicas allowed to follow", set: setList(&cfg.Replication.Replicas)},
+		{name: "replication-heartbeat", usage: "how often the replicas hear from an idle primary", set: setDuration(&cfg.Replication.Heartbeat)},
+		{name: "password-hasher", usage: "password hashing algorithm: argon2id or bcrypt", set: setString(&cfg.Auth.Hasher)},
+		{name: "session-ttl", usage: "how long a login session lasts", set: setDuration(&cfg.Auth.SessionTTL)},
+		{name: "reset-ttl", usage: "how long a password reset token is valid", set: setDuration(&cfg.Auth.ResetTTL)},
+		{name: "max-failed-logins", usage: "number of failed logins in a row that lock an account", set: setInt(&cfg.Auth.MaxFailedLogins)},
+		{name: "lockout", usage: "how long an account stays locked", set: setDuration(&cfg.Auth.Lockout)},
+		{name: "admin-token", usage: "bearer token of the admin routes, empty to accept none", set: setString(&cfg.Auth.AdminToken)},
+		{name: "admin-identities", usage: "comma-separated client certificate identities allowed on the admin routes", set: setList(&cfg.Auth.AdminIdentities)},
+		{name: "log-level", usage: "log level: debug, info, warn or error", set: setString(&cfg.LogLevel)},
+		{name: "trace-exporter", usage: "trace exporter: none, stdout or otlp", set: setString(&cfg.Tracing.Exporter)},
+		{name: "otlp-endpoint", usage: "OTLP gRPC collector address", set: setString(&cfg.Tracing.Endpoint)},
+	}
+
+	bySetting := make(map[string]setting, len(settings))
+	for _, s := range settings {
+		bySetting[s.name] = s
+		if s.isBool {
+			fs.Bool(s.name, false, s.usage)
+		} else {
+			fs.String(s.name, "", s.usage)
+		}
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return cfg, err
+	}
+
+	if *configFile != "" {
+		data, err := os.ReadFile(*configFile)
+		if err != nil {
+			return cfg, fmt.Errorf("can't read config file: %w", err)
+		}
+
+		// rate limits from the file replace the default ones instead of
+		// being merged into them
+		defaultLimits := cfg.RateLimits
+		cfg.RateLimits = nil
+
+		if err := yaml.Unmarshal(data, &cfg); err != nil {
+			return cfg, fmt.Errorf("can't parse config file: %w", err)
+		}
+
+		if cfg.RateLimits == nil {
+			cfg.RateLimits = defaultLimits
+		}
+	}
+
+	for _, s := range settings {
+		env := envPrefix + strings.ToUpper(strings.ReplaceAll(s.name, "-", "_"))
+		if v := getenv(env); v != "" {
+			if err := s.set(v); err != nil {
+				return cfg, fmt.Errorf("%w: %s: %s", InvalidConfig, env, err.Error())
+			}
+		}
+	}
+
+	var flagErr error
+	fs.Visit(func(f *flag.Flag) {
+		s, ok := bySetting[f.Name]
+		if !ok || flagErr != nil {
+			return
+		}
+		if err := s.set(f.Value.String()); err != nil {
+			flagErr = fmt.Errorf("%w: -%s: %s", InvalidConfig, f.Name, err.Error())
+		}
+	})
+	if flagErr != nil {
+		return cfg, flagErr
+	}
+
+	return cfg, cfg.Validate()
+}
+
+func (c Config) Validate() error {
+	var problems []string
+
+	if c.GRPCAddr == "" {
+		problems = append(problems, "grpc_addr is empty")
+	}
+	if c.HTTPAddr == "" {
+		problems = append(problems, "http_addr is empty")
+	}
+	if c.GRPCAddr != "" && c.GRPCAddr == c.HTTPAddr {
+		problems = append(problems, "grpc_addr and http_addr are the same")
+	}
+	if c.ShutdownTimeout <= 0 {
+		problems = append(problems, "shutdown_timeout must be positive")
+	}
+
+	for _, proxy := range c.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			problems = append(problems, fmt.Sprintf("trusted proxy %q is neither an IP nor a CIDR", proxy))
+		}
+	}
+
+	if c.RevisionLimit < 0 {
+		problems = append(problems, "revision_limit must not be negative")
+	}
+
+	switch c.HTTPRouter {
+	case "gin", "gateway":
+	default:
+		problems = append(problems, fmt.Sprintf("unknown http router %q", c.HTTPRouter))
+	}
+
+	switch c.Storage.Backend {
+	case "memory":
+	case "wal":
+		if c.Storage.DSN == "" {
+			problems = append(problems, "storage dsn must be the directory of the wal backend")
+		}
+		switch c.Storage.Sync {
+		case "always", "never":
+		case "interval":
+			if c.Storage.SyncInterval <= 0 {
+				problems = append(problems, "storage sync_interval must be positive")
+			}
+		default:
+			problems = append(problems, fmt.Sprintf("unknown storage sync policy %q", c.Storage.Sync))
+		}
+		if c.Storage.SnapshotEvery < 0 {
+			problems = append(problems, "storage snapshot_every must not be negative")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("unknown storage backend %q", c.Storage.Backend))
+	}
+
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		problems = append(problems, "tls cert_file and key_file must be set together")
+	}
+	if c.TLS.ClientCAFile != "" && !c.TLS.Enabled() {
+		problems = append(problems, "tls client_ca_file requires cert_file and key_file")
+	}
+	if c.TLS.RequireClientCert && c.TLS.ClientCAFile == "" {
+		problems = append(problems, "tls require_client_cert requires client_ca_file")
+	}
+	if c.TLS.Enabled() && c.TLS.ReloadInterval <= 0 {
+		problems = append(problems, "tls reload_interval must be positive")
+	}
+	for _, file := range []string{c.TLS.CertFile, c.TLS.KeyFile, c.TLS.ClientCAFile} {
+		if file == "" {
+			continue
+		}
+		if _, err := os.Stat(file); err != nil {
+			problems = append(problems, fmt.Sprintf("tls file %q is not accessible", file))
+		}
+	}
+
+	if c.SMTP.Timeout <= 0 {
+		problems = append(problems, "smtp timeout must be positive")
+	}
+
+	for route, limit := range c.RateLimits {
+		if limit.Rate < 0 || limit.Burst < 1 {
+			problems = append(problems, fmt.Sprintf("rate limit of %q must have a non-negative rate and a positive burst", route))
+		}
+	}
+
+	if c.Idempotency.TTL <= 0 {
+		problems = append(problems, "idempotency ttl must be positive")
+	}
+	if c.Idempotency.MaxEntries < 1 {
+		problems = append(problems, "idempotency max_entries must be positive")
+	}
+
+	switch c.Cache.Backend {
+	case "none":
+	case "memory":
+		if c.Cache.Size < 1 {
+			problems = append(problems, "cache size must be positive")
+		}
+	case "redis":
+		if c.Cache.Addr == "" {
+			problems = append(problems, "cache addr is empty")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("unknown cache backend %q", c.Cache.Backend))
+	}
+	if c.Cache.Backend != "none" && c.Cache.TTL <= 0 {
+		problems = append(problems, "cache ttl must be positive")
+	}
+
+	if c.Replication.Primary != "" && c.Storage.Backend != "memory" {
+		problems = append(problems, "a read replica keeps its copy in memory, storage backend must be memory")
+	}
+	if c.Replication.Backlog < 0 {
+		problems = append(problems, "replication backlog must not be negative")
+	}
+	if c.Replication.Backlog > 0 && (len(c.Replication.Replicas) == 0 || c.TLS.ClientCAFile == "") {
+		problems = append(problems, "replication backlog requires replicas and tls client_ca_file to authenticate them")
+	}
+	if c.Replication.Heartbeat <= 0 {
+		problems = append(problems, "replication heartbeat must be positive")
+	}
+
+	switch c.Auth.Hasher {
+	case "argon2id", "bcrypt":
+	default:
+		problems = append(problems, fmt.Sprintf("unknown password hasher %q", c.Auth.Hasher))
+	}
+	if c.Auth.SessionTTL <= 0 {
+		problems = append(problems, "auth session_ttl must be positive")
+	}
+	if c.Auth.ResetTTL <= 0 {
+		problems = append(problems, "auth reset_ttl must be positive")
+	}
+	if c.Auth.MaxFailedLogins < 1 {
+		problems = append(problems, "auth max_failed_logins must be positive")
+	}
+	if c.Auth.Lockout <= 0 {
+		problems = append(problems, "auth lockout must be positive")
+	}
+	if c.Auth.AdminToken != "" && len(c.Auth.AdminToken) < MinAdminToken {
+		problems = append(problems, fmt.Sprintf("auth admin_token must have at least %d bytes", MinAdminToken))
+	}
+
+	switch c.LogLevel {
+	case "debug", "info", "warn", "error":
+	default:
+		problems = append(problems, fmt.Sprintf("unknown log level %q", c.LogLevel))
+	}
+
+	switch c.Tracing.Exporter {
+	case "none", "stdout":
+	case "otlp":
+		if c.Tracing.Endpoint == "" {
+			problems = append(problems, "tracing endpoint is empty")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("unknown trace exporter %q", c.Tracing.Exporter))
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", InvalidConfig, strings.Join(problems, "; "))
+	}
+
+	return nil
+}
@@ -0,0 +1,193 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func env(vars map[string]string) func(string) string {
+	return func(key string) string {
+		return vars[key]
+	}
+}
+
+func writeFile(t *testing.T, name, data string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestLoadDefaults(t *testing.T) {
+	cfg, err := Load(nil, env(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.GRPCAddr != ":50054" || cfg.HTTPAddr != ":9000" {
+		t.Fatalf("unexpected addresses: %q %q", cfg.GRPCAddr, cfg.HTTPAddr)
+	}
+	if cfg.ShutdownTimeout != 30*time.Second {
+		t.Fatalf("expect 30s got %v", cfg.ShutdownTimeout)
+	}
+	if cfg.TLS.Enabled() || cfg.TLS.RequireClientCert {
+		t.Fatal("tls must be disabled by default")
+	}
+	if cfg.RevisionLimit != 10 {
+		t.Fatalf("expect 10 got %v", cfg.RevisionLimit)
+	}
+	if cfg.Replication.Backlog != 0 {
+		t.Fatal("replication must be disabled by default")
+	}
+	if len(cfg.Limits()) != len(Default().RateLimits) {
+		t.Fatal("default rate limits are not applied")
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	file := writeFile(t, "config.yaml", `
+grpc_addr: ":1001"
+http_addr: ":2001"
+shutdown_timeout: 5s
+log_level: debug
+smtp:
+  addr: "mail:25"
+  from: "ads@example.com"
+rate_limits:
+  "POST /api/v1/ads":
+    rate: 2
+    burst: 3
+`)
+
+	cfg, err := Load([]string{"-config", file}, env(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.GRPCAddr != ":1001" || cfg.HTTPAddr != ":2001" || cfg.ShutdownTimeout != 5*time.Second {
+		t.Fatalf("file values are not applied: %+v", cfg)
+	}
+	if cfg.SMTP.Addr != "mail:25" || cfg.SMTP.From != "ads@example.com" || cfg.LogLevel != "debug" {
+		t.Fatalf("file values are not applied: %+v", cfg)
+	}
+	if len(cfg.RateLimits) != 1 || cfg.RateLimits["POST /api/v1/ads"] != (RateLimit{Rate: 2, Burst: 3}) {
+		t.Fatalf("file rate limits must replace the defaults: %+v", cfg.RateLimits)
+	}
+
+	cfg, err = Load([]string{"-grpc-addr", ":1003"}, env(map[string]string{
+		"ADS_CONFIG":           file,
+		"ADS_GRPC_ADDR":        ":1002",
+		"ADS_HTTP_ADDR":        ":2002",
+		"ADS_SHUTDOWN_TIMEOUT": "1m",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.GRPCAddr != ":1003" {
+		t.Fatalf("flag must override env: got %q", cfg.GRPCAddr)
+	}
+	if cfg.HTTPAddr != ":2002" || cfg.ShutdownTimeout != time.Minute {
+		t.Fatalf("env must override file: %+v", cfg)
+	}
+	if cfg.LogLevel != "debug" {
+		t.Fatalf("file value must be kept: got %q", cfg.LogLevel)
+	}
+
+	cfg, err = Load(nil, env(map[string]string{"ADS_TRUSTED_PROXIES": "10.0.0.1, 192.168.0.0/16"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.TrustedProxies) != 2 || cfg.TrustedProxies[1] != "192.168.0.0/16" {
+		t.Fatalf("unexpected trusted proxies: %q", cfg.TrustedProxies)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	cert := writeFile(t, "cert.pem", "cert")
+
+	tests := []struct {
+		name string
+		args []string
+		env  map[string]string
+		file string
+	}{
+		{name: "same addresses", args: []string{"-grpc-addr", ":9000"}},
+		{name: "empty address", args: []string{"-http-addr", ""}},
+		{name: "negative timeout", args: []string{"-shutdown-timeout", "-1s"}},
+		{name: "bad env timeout", env: map[string]string{"ADS_SHUTDOWN_TIMEOUT": "soon"}},
+		{name: "negative revision limit", args: []string{"-revision-limit", "-1"}},
+		{name: "unknown storage", args: []string{"-storage", "postgres"}},
+		{name: "wal without dsn", args: []string{"-storage", "wal"}},
+		{name: "unknown sync policy", args: []string{"-storage", "wal", "-dsn", "data", "-storage-sync", "sometimes"}},
+		{name: "zero sync interval", args: []string{"-storage", "wal", "-dsn", "data", "-storage-sync-interval", "0s"}},
+		{name: "bad snapshot every", env: map[string]string{"ADS_STORAGE_SNAPSHOT_EVERY": "often"}},
+		{name: "bad trusted proxy", args: []string{"-trusted-proxies", "10.0.0.0/8, proxy.local"}},
+		{name: "unknown http router", args: []string{"-http-router", "chi"}},
+		{name: "cert without key", args: []string{"-tls-cert", cert}},
+		{name: "missing key file", args: []string{"-tls-cert", cert, "-tls-key", cert + ".missing"}},
+		{name: "client ca without tls", args: []string{"-tls-client-ca", cert}},
+		{name: "client cert without ca", args: []string{"-tls-cert", cert, "-tls-key", cert, "-tls-require-client-cert"}},
+		{name: "bad flag timeout", args: []string{"-shutdown-timeout", "soon"}},
+		{name: "unknown log level", env: map[string]string{"ADS_LOG_LEVEL": "verbose"}},
+		{name: "unknown trace exporter", args: []string{"-trace-exporter", "jaeger"}},
+		{name: "otlp without endpoint", args: []string{"-trace-exporter", "otlp", "-otlp-endpoint", ""}},
+		{name: "zero smtp timeout", args: []string{"-smtp-timeout", "0s"}},
+		{name: "zero idempotency ttl", args: []string{"-idempotency-ttl", "0s"}},
+		{name: "zero idempotency entries", args: []string{"-idempotency-max-entries", "0"}},
+		{name: "unknown cache backend", args: []string{"-cache", "memcached"}},
+		{name: "zero cache ttl", env: map[string]string{"ADS_CACHE_TTL": "0s"}},
+		{name: "persisted replica", args: []string{"-replicate-from", "primary:50054", "-storage", "wal", "-dsn", "data"}},
+		{name: "negative replication backlog", args: []string{"-replication-backlog", "-1"}},
+		{name: "replication without replicas", args: []string{"-replication-backlog", "100"}},
+		{name: "replication without client ca", args: []string{"-replication-backlog", "100", "-replicas", "spiffe://ads.local/replica"}},
+		{name: "zero replication heartbeat", env: map[string]string{"ADS_REPLICATION_HEARTBEAT": "0s"}},
+		{name: "unknown password hasher", args: []string{"-password-hasher", "md5"}},
+		{name: "zero session ttl", env: map[string]string{"ADS_SESSION_TTL": "0s"}},
+		{name: "zero failed logins", args: []string{"-max-failed-logins", "0"}},
+		{name: "short admin token", env: map[string]string{"ADS_ADMIN_TOKEN": "secret"}},
+		{name: "bad lockout", file: "auth:\n  lockout: -1m\n"},
+		{name: "zero burst", file: "rate_limits:\n  \"/ad.AdService/CreateAd\":\n    rate: 1\n    burst: 0\n"},
+		{name: "bad yaml", file: "grpc_addr: [\n"},
+	}
+
+	for _, test := range tests {
+		args := test.args
+		if test.file != "" {
+			args = append(args, "-config", writeFile(t, "config.yaml", test.file))
+		}
+
+		_, err := Load(args, env(test.env))
+		if err == nil {
+			t.Fatalf(`test %q: expect error got nil`, test.name)
+		}
+		if test.file == "" && !errors.Is(err, InvalidConfig) {
+			t.Fatalf(`test %q: expect %v got %v`, test.name, InvalidConfig, err)
+		}
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	_, err := Load([]string{"-config", filepath.Join(t.TempDir(), "absent.yaml")}, env(nil))
+	if err == nil {
+		t.Fatal("expect error for a missing config file")
+	}
+}
+
+func TestLoadTLS(t *testing.T) {
+	file := writeFile(t, "cert.pem", "cert")
+
+	cfg, err := Load([]string{"-tls-cert", file, "-tls-key", file, "-tls-client-ca", file, "-tls-require-client-cert"},
+		env(map[string]string{"ADS_TLS_RELOAD_INTERVAL": "1m"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := TLSConfig{CertFile: file, KeyFile: file, ClientCAFile: file, RequireClientCert: true, ReloadInterval: time.Minute}
+	if cfg.TLS != expected {
+		t.Fatalf("expect %+v got %+v", expected, cfg.TLS)
+	}
+}
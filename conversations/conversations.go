@@ -0,0 +1,44 @@
+package conversations
+
+import "time"
+
+type Conversation struct {
+	ID         int64
+	AdID       int64
+	BuyerID    int64
+	SellerID   int64
+	Closed     bool
+	CreatedAt  time.Time
+	Messages   []Message
+	BuyerRead  int64
+	SellerRead int64
+}
+
+type Message struct {
+	ID        int64
+	AuthorID  int64
+	Text      string
+	CreatedAt time.Time
+}
+
+func (c *Conversation) IsParticipant(userId int64) bool {
+	return c.BuyerID == userId || c.SellerID == userId
+}
+
+// Unread returns the number of messages the other participant has sent
+// since the user last read the conversation.
+func (c *Conversation) Unread(userId int64) int64 {
+	lastRead := c.BuyerRead
+	if userId == c.SellerID {
+		lastRead = c.SellerRead
+	}
+
+	var unread int64
+	for _, message := range c.Messages {
+		if message.AuthorID != userId && message.ID > lastRead {
+			unread++
+		}
+	}
+
+	return unread
+}
@@ -0,0 +1,164 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/pkg/errors"
+	grpchealth "google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var ShuttingDown = errors.New("the service is shutting down")
+
+// Checker reports whether a dependency of the service, e.g. the storage, is
+// available.
+type Checker func(ctx context.Context) error
+
+// Pinger is implemented by the storages that can check their connectivity.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Health keeps the readiness of the service for the HTTP probes and the
+// grpc.health.v1 service. services are the gRPC services whose status
+// follows the readiness, in addition to the overall "" one.
+type Health struct {
+	mu           sync.Mutex
+	checks       map[string]Checker
+	shuttingDown bool
+	services     []string
+	server       *grpchealth.Server
+}
+
+func New(services ...string) *Health {
+	return &Health{
+		checks:   make(map[string]Checker),
+		services: append([]string{""}, services...),
+		server:   grpchealth.NewServer(),
+	}
+}
+
+func (h *Health) AddCheck(name string, check Checker) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.checks[name] = check
+}
+
+// Server is the grpc.health.v1 implementation to register on the gRPC server.
+func (h *Health) Server() grpc_health_v1.HealthServer {
+	return h.server
+}
+
+// Check runs every check and returns the failed ones by name.
+func (h *Health) Check(ctx context.Context) map[string]error {
+	h.mu.Lock()
+	checks := make(map[string]Checker, len(h.checks))
+	for name, check := range h.checks {
+		checks[name] = check
+	}
+	shuttingDown := h.shuttingDown
+	h.mu.Unlock()
+
+	failed := make(map[string]error)
+	if shuttingDown {
+		failed["shutdown"] = ShuttingDown
+	}
+
+	for name, check := range checks {
+		if err := check(ctx); err != nil {
+			failed[name] = err
+		}
+	}
+
+	return failed
+}
+
+// Update runs the checks and sets the gRPC serving status accordingly.
+func (h *Health) Update(ctx context.Context) {
+	status := grpc_health_v1.HealthCheckResponse_SERVING
+	if len(h.Check(ctx)) > 0 {
+		status = grpc_health_v1.HealthCheckResponse_NOT_SERVING
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.shuttingDown {
+		return
+	}
+
+	for _, service := range h.services {
+		h.server.SetServingStatus(service, status)
+	}
+}
+
+// Run updates the status every interval until ctx is done.
+func (h *Health) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		h.Update(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Shutdown marks the service as not ready for good, so that the
+// orchestrator stops sending traffic while the servers drain.
+func (h *Health) Shutdown() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.shuttingDown {
+		return
+	}
+
+	h.shuttingDown = true
+	h.server.Shutdown()
+}
+
+type response struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// LiveHandler answers /healthz: the process is up and serving HTTP.
+func (h *Health) LiveHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeResponse(w, http.StatusOK, response{Status: "ok"})
+	})
+}
+
+// ReadyHandler answers /readyz: the dependencies are available and the
+// service is not shutting down.
+func (h *Health) ReadyHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		failed := h.Check(r.Context())
+		if len(failed) == 0 {
+			writeResponse(w, http.StatusOK, response{Status: "ok"})
+			return
+		}
+
+		checks := make(map[string]string, len(failed))
+		for name, err := range failed {
+			checks[name] = err.Error()
+		}
+
+		writeResponse(w, http.StatusServiceUnavailable, response{Status: "unavailable", Checks: checks})
+	})
+}
+
+func writeResponse(w http.ResponseWriter, status int, resp response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,119 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func probe(t *testing.T, handler http.Handler) (int, response) {
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	var resp response
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+
+	return rec.Code, resp
+}
+
+func TestHTTPProbes(t *testing.T) {
+	h := New()
+
+	var storageErr error
+	h.AddCheck("storage", func(context.Context) error { return storageErr })
+
+	if code, _ := probe(t, h.ReadyHandler()); code != http.StatusOK {
+		t.Fatalf("expect %d got %d", http.StatusOK, code)
+	}
+
+	storageErr = errors.New("connection refused")
+	code, resp := probe(t, h.ReadyHandler())
+	if code != http.StatusServiceUnavailable || resp.Checks["storage"] != "connection refused" {
+		t.Fatalf("unexpected readiness: %d %+v", code, resp)
+	}
+
+	storageErr = nil
+	h.Shutdown()
+	code, resp = probe(t, h.ReadyHandler())
+	if code != http.StatusServiceUnavailable || resp.Checks["shutdown"] != ShuttingDown.Error() {
+		t.Fatalf("unexpected readiness: %d %+v", code, resp)
+	}
+
+	if code, _ := probe(t, h.LiveHandler()); code != http.StatusOK {
+		t.Fatalf("liveness must not depend on readiness: got %d", code)
+	}
+}
+
+func TestGRPCHealth(t *testing.T) {
+	h := New("ad.AdService")
+
+	var storageErr error
+	h.AddCheck("storage", func(context.Context) error { return storageErr })
+
+	lis := bufconn.Listen(1024 * 1024)
+	t.Cleanup(func() {
+		lis.Close()
+	})
+
+	srv := grpc.NewServer()
+	t.Cleanup(srv.Stop)
+	grpc_health_v1.RegisterHealthServer(srv, h.Server())
+
+	go func() {
+		_ = srv.Serve(lis)
+	}()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+	})
+
+	client := grpc_health_v1.NewHealthClient(conn)
+	status := func(service string) grpc_health_v1.HealthCheckResponse_ServingStatus {
+		resp, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{Service: service})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.Status
+	}
+
+	type Test struct {
+		Name   string
+		Change func()
+		Expect grpc_health_v1.HealthCheckResponse_ServingStatus
+	}
+
+	tests := [...]Test{
+		{"storage available", func() {}, grpc_health_v1.HealthCheckResponse_SERVING},
+		{"storage lost", func() { storageErr = errors.New("timeout") }, grpc_health_v1.HealthCheckResponse_NOT_SERVING},
+		{"storage restored", func() { storageErr = nil }, grpc_health_v1.HealthCheckResponse_SERVING},
+		{"shutdown", h.Shutdown, grpc_health_v1.HealthCheckResponse_NOT_SERVING},
+	}
+
+	for _, test := range tests {
+		test.Change()
+		h.Update(context.Background())
+
+		for _, service := range []string{"", "ad.AdService"} {
+			if got := status(service); got != test.Expect {
+				t.Fatalf(`test %q: expect %v got %v`, test.Name, test.Expect, got)
+			}
+		}
+	}
+}
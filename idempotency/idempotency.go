@@ -0,0 +1,123 @@
+package idempotency
+
+import (
+	"container/list"
+	"github.com/pkg/errors"
+	"sync"
+	"time"
+)
+
+// MaxKeyLength is the longest key accepted, a UUID fits with room to spare.
+const MaxKeyLength = 255
+
+var (
+	InvalidKey = errors.New("the idempotency key must have 1 to 255 characters")
+	KeyReused  = errors.New("the idempotency key was used for another request")
+	InProgress = errors.New("a request with the idempotency key is in progress")
+)
+
+type entry struct {
+	key         string
+	fingerprint string
+	response    any
+	done        bool
+	expires     time.Time
+	// element is the place of the entry in Store.order
+	element *list.Element
+}
+
+// Store keeps the responses of the requests made with an idempotency key for
+// the TTL, so that a retried request gets the response of the first one
+// instead of being run again. It keeps at most maxEntries keys, the oldest
+// are forgotten first.
+type Store struct {
+	ttl        time.Duration
+	maxEntries int
+	entries    map[string]*entry
+	// order lists the entries from the oldest reserved to the newest
+	order *list.List
+	swept time.Time
+	now   func() time.Time
+	mu    sync.Mutex
+}
+
+func New(ttl time.Duration, maxEntries int) *Store {
+	return &Store{ttl: ttl, maxEntries: maxEntries, entries: make(map[string]*entry), order: list.New(), now: time.Now}
+}
+
+// Begin reserves the key, scoped e.g. by the route, for the request with the
+// fingerprint. When the key was used for the same request before, it returns
+// the stored response with done set and the request must not be run. Every
+// reservation must end with Finish or Abort.
+func (s *Store) Begin(key string, fingerprint string) (response any, done bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	e, exists := s.entries[key]
+	if exists && now.Before(e.expires) {
+		if e.fingerprint != fingerprint {
+			return nil, false, KeyReused
+		}
+		if !e.done {
+			return nil, false, InProgress
+		}
+		return e.response, true, nil
+	}
+
+	if exists {
+		s.remove(e)
+	}
+	for len(s.entries) >= s.maxEntries {
+		s.remove(s.order.Front().Value.(*entry))
+	}
+
+	e = &entry{key: key, fingerprint: fingerprint, expires: now.Add(s.ttl)}
+	e.element = s.order.PushBack(e)
+	s.entries[key] = e
+	return nil, false, nil
+}
+
+// Finish stores the response of the request that reserved the key.
+func (s *Store) Finish(key string, response any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, exists := s.entries[key]; exists {
+		e.response = response
+		e.done = true
+		e.expires = s.now().Add(s.ttl)
+	}
+}
+
+// Abort releases the key without storing a response, e.g. after an internal
+// error, so that a retry runs the request again.
+func (s *Store) Abort(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, exists := s.entries[key]; exists {
+		s.remove(e)
+	}
+}
+
+func (s *Store) remove(e *entry) {
+	s.order.Remove(e.element)
+	delete(s.entries, e.key)
+}
+
+// sweep drops the expired entries. It runs at most once a minute.
+func (s *Store) sweep(now time.Time) {
+	if now.Sub(s.swept) < time.Minute {
+		return
+	}
+	s.swept = now
+
+	for _, e := range s.entries {
+		if !now.Before(e.expires) {
+			s.remove(e)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package idempotency
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStore(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	store := New(time.Hour, 10)
+	store.now = func() time.Time { return now }
+
+	type Test struct {
+		Name        string
+		Key         string
+		Fingerprint string
+		Advance     time.Duration
+		Finish      any
+		Response    any
+		Done        bool
+		Err         error
+	}
+
+	tests := [...]Test{
+		{"First request", "a", "create", 0, nil, nil, false, nil},
+		{"Retry while in progress", "a", "create", 0, nil, nil, false, InProgress},
+		{"Retry after the response", "a", "create", time.Minute, "created", "created", true, nil},
+		{"Another payload", "a", "update", 0, nil, nil, false, KeyReused},
+		{"Another key", "b", "update", 0, nil, nil, false, nil},
+		{"Expired key", "a", "update", 2 * time.Hour, nil, nil, false, nil},
+	}
+
+	for _, test := range tests {
+		now = now.Add(test.Advance)
+		if test.Finish != nil {
+			store.Finish(test.Key, test.Finish)
+		}
+
+		response, done, err := store.Begin(test.Key, test.Fingerprint)
+		if response != test.Response || done != test.Done || err != test.Err {
+			t.Fatalf(`test %q: expect (%v, %v, %v) got (%v, %v, %v)`, test.Name, test.Response, test.Done, test.Err, response, done, err)
+		}
+	}
+}
+
+func TestStore_Abort(t *testing.T) {
+	store := New(time.Hour, 10)
+
+	_, _, err := store.Begin("a", "create")
+	assert.NoError(t, err)
+
+	store.Abort("a")
+
+	_, done, err := store.Begin("a", "create")
+	assert.NoError(t, err)
+	assert.False(t, done)
+}
+
+func TestStore_Sweep(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	store := New(time.Minute, 10)
+	store.now = func() time.Time { return now }
+
+	_, _, err := store.Begin("a", "create")
+	assert.NoError(t, err)
+	store.Finish("a", "created")
+	assert.Len(t, store.entries, 1)
+
+	now = now.Add(2 * time.Minute)
+
+	_, _, err = store.Begin("b", "create")
+	assert.NoError(t, err)
+	assert.Len(t, store.entries, 1)
+}
+
+func TestStore_MaxEntries(t *testing.T) {
+	store := New(time.Hour, 2)
+
+	for _, key := range []string{"a", "b", "c"} {
+		_, _, err := store.Begin(key, "create")
+		assert.NoError(t, err)
+		store.Finish(key, "created "+key)
+	}
+	assert.Len(t, store.entries, 2)
+	assert.Equal(t, 2, store.order.Len())
+
+	_, done, err := store.Begin("a", "create")
+	assert.NoError(t, err)
+	assert.False(t, done, "the oldest key must be forgotten")
+
+	response, done, err := store.Begin("c", "create")
+	assert.NoError(t, err)
+	assert.True(t, done)
+	assert.Equal(t, "created c", response)
+}
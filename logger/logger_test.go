@@ -0,0 +1,55 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestLoggerRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	l := New(&buf, "info").With("component", "test")
+
+	l.InfoContext(WithRequestID(context.Background(), "abc"), "hello", "n", 1)
+	l.DebugContext(WithRequestID(context.Background(), "abc"), "hidden")
+	l.WarnContext(context.Background(), "no request")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expect 2 lines got %d: %s", len(lines), buf.String())
+	}
+
+	var first, second map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &second); err != nil {
+		t.Fatal(err)
+	}
+
+	if first["request_id"] != "abc" || first["msg"] != "hello" || first["level"] != "INFO" || first["component"] != "test" {
+		t.Fatalf("unexpected record: %v", first)
+	}
+	if _, ok := second["request_id"]; ok || second["level"] != "WARN" {
+		t.Fatalf("unexpected record: %v", second)
+	}
+}
+
+func TestEnsureRequestID(t *testing.T) {
+	if id := EnsureRequestID("req-1"); id != "req-1" {
+		t.Fatalf("expect req-1 got %q", id)
+	}
+
+	for _, id := range []string{"", "bad id", "bad\nid", strings.Repeat("a", maxRequestIDLength+1)} {
+		generated := EnsureRequestID(id)
+		if generated == id || len(generated) != 32 {
+			t.Fatalf("test %q: expect a generated ID got %q", id, generated)
+		}
+	}
+
+	if EnsureRequestID("") == EnsureRequestID("") {
+		t.Fatal("generated IDs must differ")
+	}
+}
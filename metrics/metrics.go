@@ -0,0 +1,219 @@
+package metrics
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"homework10/internal/ads"
+	"homework10/internal/app"
+	"homework10/internal/query"
+	"net/http"
+	"time"
+)
+
+type Metrics struct {
+	registry     *prometheus.Registry
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+	grpcRequests *prometheus.CounterVec
+	grpcDuration *prometheus.HistogramVec
+	repoDuration *prometheus.HistogramVec
+}
+
+// New creates the metrics in their own registry, so that several servers can
+// live in one process, e.g. in tests.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "Number of HTTP requests by route, method and status code.",
+		}, []string{"route", "method", "code"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "HTTP request latency by route and method.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"route", "method"}),
+		grpcRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "grpc_requests_total",
+			Help: "Number of gRPC calls by method and status code.",
+		}, []string{"method", "code"}),
+		grpcDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "grpc_request_duration_seconds",
+			Help:    "gRPC call latency by method.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method"}),
+		repoDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "repository_operation_duration_seconds",
+			Help:    "Repository operation latency by repository and operation.",
+			Buckets: []float64{.00001, .00005, .0001, .0005, .001, .005, .01, .05, .1},
+		}, []string{"repository", "operation"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests, m.httpDuration,
+		m.grpcRequests, m.grpcDuration,
+		m.repoDuration,
+	)
+
+	return m
+}
+
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+func (m *Metrics) ObserveHTTP(route string, method string, code string, duration time.Duration) {
+	m.httpRequests.WithLabelValues(route, method, code).Inc()
+	m.httpDuration.WithLabelValues(route, method).Observe(duration.Seconds())
+}
+
+func (m *Metrics) ObserveGRPC(method string, code string, duration time.Duration) {
+	m.grpcRequests.WithLabelValues(method, code).Inc()
+	m.grpcDuration.WithLabelValues(method).Observe(duration.Seconds())
+}
+
+// RegisterDomain adds the gauges of the ads and users count, computed from
+// the repositories on every scrape.
+func (m *Metrics) RegisterDomain(adRepo app.Repository, userRepo app.Repository) {
+	m.registry.MustRegister(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "ads_total",
+			Help: "Number of ads.",
+		}, func() float64 {
+			return float64(len(adRepo.GetArray(context.Background())))
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "ads_published",
+			Help: "Number of published ads.",
+		}, func() float64 {
+			published := 0
+			for _, e := range adRepo.GetArray(context.Background()) {
+				if e.(ads.Ad).Published {
+					published++
+				}
+			}
+			return float64(published)
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "users_total",
+			Help: "Number of users.",
+		}, func() float64 {
+			return float64(len(userRepo.GetArray(context.Background())))
+		}),
+	)
+}
+
+// RegisterReplication adds the gauges of the lag of a read replica behind
+// the primary by repository, computed by lag on every scrape: the changes
+// not applied yet and the seconds since the last event of the primary.
+func (m *Metrics) RegisterReplication(repositories []string, lag func(repository string) (int64, time.Time)) {
+	for _, name := range repositories {
+		labels := prometheus.Labels{"repository": name}
+		m.registry.MustRegister(
+			prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+				Name:        "replication_lag_changes",
+				Help:        "Number of changes of the primary not applied by the replica.",
+				ConstLabels: labels,
+			}, func() float64 {
+				changes, _ := lag(name)
+				return float64(changes)
+			}),
+			prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+				Name:        "replication_last_event_age_seconds",
+				Help:        "Seconds since the replica heard from the primary, -1 before it does.",
+				ConstLabels: labels,
+			}, func() float64 {
+				_, lastEvent := lag(name)
+				if lastEvent.IsZero() {
+					return -1
+				}
+				return time.Since(lastEvent).Seconds()
+			}),
+		)
+	}
+}
+
+// Repository wraps r so that the duration of every operation is recorded
+// under the given repository name.
+func (m *Metrics) Repository(name string, r app.Repository) app.Repository {
+	return &repository{name: name, repo: r, duration: m.repoDuration}
+}
+
+type repository struct {
+	name     string
+	repo     app.Repository
+	duration *prometheus.HistogramVec
+}
+
+func (r *repository) observe(operation string, start time.Time) {
+	r.duration.WithLabelValues(r.name, operation).Observe(time.Since(start).Seconds())
+}
+
+func (r *repository) Add(ctx context.Context, e interface{}) error {
+	defer r.observe("add", time.Now())
+	return r.repo.Add(ctx, e)
+}
+
+func (r *repository) Insert(ctx context.Context, id int64, e interface{}) error {
+	defer r.observe("insert", time.Now())
+	return r.repo.Insert(ctx, id, e)
+}
+
+func (r *repository) Update(ctx context.Context, id int64, e interface{}) error {
+	defer r.observe("update", time.Now())
+	return r.repo.Update(ctx, id, e)
+}
+
+func (r *repository) Modify(ctx context.Context, id int64, change func(e interface{}) (interface{}, error)) (interface{}, error) {
+	defer r.observe("modify", time.Now())
+	return r.repo.Modify(ctx, id, change)
+}
+
+func (r *repository) Get(ctx context.Context, id int64) (interface{}, error) {
+	defer r.observe("get", time.Now())
+	return r.repo.Get(ctx, id)
+}
+
+func (r *repository) Delete(ctx context.Context, id int64) error {
+	defer r.observe("delete", time.Now())
+	return r.repo.Delete(ctx, id)
+}
+
+func (r *repository) CheckIdExist(ctx context.Context, id int64) bool {
+	defer r.observe("check_id_exist", time.Now())
+	return r.repo.CheckIdExist(ctx, id)
+}
+
+func (r *repository) GetNextId(ctx context.Context) int64 {
+	defer r.observe("get_next_id", time.Now())
+	return r.repo.GetNextId(ctx)
+}
+
+func (r *repository) GetArray(ctx context.Context) []interface{} {
+	defer r.observe("get_array", time.Now())
+	return r.repo.GetArray(ctx)
+}
+
+func (r *repository) AddBatch(ctx context.Context, es []interface{}, withId func(e interface{}, id int64) interface{}) ([]int64, error) {
+	defer r.observe("add_batch", time.Now())
+	return r.repo.AddBatch(ctx, es, withId)
+}
+
+func (r *repository) GetBatch(ctx context.Context, ids []int64) ([]interface{}, error) {
+	defer r.observe("get_batch", time.Now())
+	return r.repo.GetBatch(ctx, ids)
+}
+
+func (r *repository) UpdateBatch(ctx context.Context, ids []int64, es []interface{}) error {
+	defer r.observe("update_batch", time.Now())
+	return r.repo.UpdateBatch(ctx, ids, es)
+}
+
+func (r *repository) Find(ctx context.Context, index query.Index, from int64, to int64) ([]interface{}, error) {
+	defer r.observe("find", time.Now())
+	return r.repo.Find(ctx, index, from, to)
+}
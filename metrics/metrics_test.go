@@ -0,0 +1,100 @@
+package metrics
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"homework10/internal/adapters/repo"
+	"homework10/internal/ads"
+	"homework10/internal/users"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDomainGauges(t *testing.T) {
+	m := New()
+	adRepo, userRepo := repo.New(), repo.New()
+	m.RegisterDomain(adRepo, userRepo)
+
+	_ = userRepo.Add(context.Background(), users.User{ID: 0})
+	_ = adRepo.Add(context.Background(), ads.Ad{ID: 0, Published: true})
+	_ = adRepo.Add(context.Background(), ads.Ad{ID: 1})
+	_ = adRepo.Add(context.Background(), ads.Ad{ID: 2, Published: true})
+
+	expected := `
+# HELP ads_published Number of published ads.
+# TYPE ads_published gauge
+ads_published 2
+# HELP ads_total Number of ads.
+# TYPE ads_total gauge
+ads_total 3
+# HELP users_total Number of users.
+# TYPE users_total gauge
+users_total 1
+`
+	err := testutil.GatherAndCompare(m.registry, strings.NewReader(expected), "ads_total", "ads_published", "users_total")
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRepositoryTimings(t *testing.T) {
+	m := New()
+	r := m.Repository("ads", repo.New())
+
+	_ = r.Add(context.Background(), ads.Ad{ID: r.GetNextId(context.Background())})
+	_, _ = r.Get(context.Background(), 0)
+	_, _ = r.Get(context.Background(), 1)
+
+	families, err := m.registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counts := map[string]uint64{}
+	for _, family := range families {
+		if family.GetName() != "repository_operation_duration_seconds" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "operation" {
+					counts[label.GetValue()] = metric.GetHistogram().GetSampleCount()
+				}
+			}
+		}
+	}
+
+	expected := map[string]uint64{"add": 1, "get": 2, "get_next_id": 1}
+	if len(counts) != len(expected) {
+		t.Fatalf("expect %v got %v", expected, counts)
+	}
+	for operation, count := range expected {
+		if counts[operation] != count {
+			t.Fatalf("test %q: expect %v got %v", operation, count, counts[operation])
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	m := New()
+	m.ObserveHTTP("/api/v1/ads/:ad_id", "GET", "200", 10*time.Millisecond)
+	m.ObserveGRPC("/ad.AdService/GetAd", "OK", time.Millisecond)
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	body, _ := io.ReadAll(rec.Body)
+	for _, line := range []string{
+		`http_requests_total{code="200",method="GET",route="/api/v1/ads/:ad_id"} 1`,
+		`http_request_duration_seconds_count{method="GET",route="/api/v1/ads/:ad_id"} 1`,
+		`grpc_requests_total{code="OK",method="/ad.AdService/GetAd"} 1`,
+		`grpc_request_duration_seconds_count{method="/ad.AdService/GetAd"} 1`,
+	} {
+		if !strings.Contains(string(body), line) {
+			t.Fatalf("expect %q in:\n%s", line, body)
+		}
+	}
+}
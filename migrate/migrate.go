@@ -0,0 +1,179 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"homework10/internal/app"
+	"time"
+)
+
+var (
+	InvalidMigrations = errors.New("the migrations must be numbered 1, 2, 3 and so on")
+	UnknownVersion    = errors.New("there is no migration with this version")
+	Irreversible      = errors.New("the migration can't be reverted")
+	SchemaTooNew      = errors.New("the storage was migrated by a newer version of the service")
+	PendingMigrations = errors.New("the storage must be migrated, run the migrate command")
+)
+
+// Repositories are the repositories a migration changes by name: "ads",
+// "users" and "conversations".
+type Repositories map[string]app.Repository
+
+// Migration upgrades the stored entities to the schema of its version, and
+// Down reverts them to the previous one; a nil Down can't be reverted. A
+// migration interrupted by a crash is run again, so both must be
+// idempotent.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(ctx context.Context, repos Repositories) error
+	Down    func(ctx context.Context, repos Repositories) error
+}
+
+// Applied is a migration applied to a storage.
+type Applied struct {
+	Version   int       `json:"version"`
+	Name      string    `json:"name"`
+	AppliedAt time.Time `json:"applied_at"`
+}
+
+// Record keeps the migrations applied to a storage in order.
+type Record interface {
+	Load() ([]Applied, error)
+	Save(applied []Applied) error
+}
+
+// Latest is the version of the last migration.
+func Latest(migrations []Migration) int {
+	return len(migrations)
+}
+
+func validate(migrations []Migration) error {
+	for i, m := range migrations {
+		if m.Version != i+1 || m.Up == nil {
+			return errors.Wrapf(InvalidMigrations, "migration %d %q", m.Version, m.Name)
+		}
+	}
+	return nil
+}
+
+// Version is the version of the storage, 0 before its first migration.
+func Version(r Record) (int, error) {
+	applied, err := r.Load()
+	if err != nil || len(applied) == 0 {
+		return 0, err
+	}
+	return applied[len(applied)-1].Version, nil
+}
+
+// Up applies the migrations after the version of the storage up to the
+// version to, recording each one as soon as it is applied.
+func Up(ctx context.Context, migrations []Migration, r Record, repos Repositories, to int) ([]Migration, error) {
+	if err := validate(migrations); err != nil {
+		return nil, err
+	}
+	if to < 0 || to > Latest(migrations) {
+		return nil, errors.Wrapf(UnknownVersion, "%d", to)
+	}
+
+	applied, err := r.Load()
+	if err != nil {
+		return nil, err
+	}
+	if len(applied) > Latest(migrations) {
+		return nil, errors.Wrapf(SchemaTooNew, "version %d", len(applied))
+	}
+
+	var done []Migration
+	for _, m := range migrations[len(applied):to] {
+		if err := m.Up(ctx, repos); err != nil {
+			return done, fmt.Errorf("migration %d %q: %w", m.Version, m.Name, err)
+		}
+
+		applied = append(applied, Applied{Version: m.Version, Name: m.Name, AppliedAt: time.Now().UTC()})
+		if err := r.Save(applied); err != nil {
+			return done, err
+		}
+		done = append(done, m)
+	}
+
+	return done, nil
+}
+
+// Down reverts the migrations of the storage after the version to, the last
+// one first.
+func Down(ctx context.Context, migrations []Migration, r Record, repos Repositories, to int) ([]Migration, error) {
+	if err := validate(migrations); err != nil {
+		return nil, err
+	}
+
+	applied, err := r.Load()
+	if err != nil {
+		return nil, err
+	}
+	if len(applied) > Latest(migrations) {
+		return nil, errors.Wrapf(SchemaTooNew, "version %d", len(applied))
+	}
+	if to < 0 || to > len(applied) {
+		return nil, errors.Wrapf(UnknownVersion, "%d", to)
+	}
+
+	var done []Migration
+	for len(applied) > to {
+		m := migrations[len(applied)-1]
+		if m.Down == nil {
+			return done, errors.Wrapf(Irreversible, "migration %d %q", m.Version, m.Name)
+		}
+		if err := m.Down(ctx, repos); err != nil {
+			return done, fmt.Errorf("migration %d %q: %w", m.Version, m.Name, err)
+		}
+
+		applied = applied[:len(applied)-1]
+		if err := r.Save(applied); err != nil {
+			return done, err
+		}
+		done = append(done, m)
+	}
+
+	return done, nil
+}
+
+// Check is run on startup: it refuses a storage migrated by a newer
+// version of the service, or one with migrations to apply. A new storage,
+// whose repositories never had an entity, is recorded at the latest version
+// instead.
+func Check(ctx context.Context, migrations []Migration, r Record, repos Repositories) error {
+	if err := validate(migrations); err != nil {
+		return err
+	}
+
+	applied, err := r.Load()
+	if err != nil {
+		return err
+	}
+
+	switch latest := Latest(migrations); {
+	case len(applied) > latest:
+		return errors.Wrapf(SchemaTooNew, "version %d, this one knows up to %d", len(applied), latest)
+	case len(applied) == latest:
+		return nil
+	case len(applied) == 0 && empty(ctx, repos):
+		now := time.Now().UTC()
+		for _, m := range migrations {
+			applied = append(applied, Applied{Version: m.Version, Name: m.Name, AppliedAt: now})
+		}
+		return r.Save(applied)
+	default:
+		return errors.Wrapf(PendingMigrations, "version %d, expect %d", len(applied), latest)
+	}
+}
+
+func empty(ctx context.Context, repos Repositories) bool {
+	for _, r := range repos {
+		if r.GetNextId(ctx) != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,215 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"homework10/internal/adapters/repo"
+	"homework10/internal/ads"
+	"homework10/internal/users"
+)
+
+// scale multiplies the values of the "values" repository.
+func scale(k int) func(ctx context.Context, repos Repositories) error {
+	return func(ctx context.Context, repos Repositories) error {
+		r := repos["values"]
+		for id := int64(0); id < r.GetNextId(ctx); id++ {
+			e, err := r.Get(ctx, id)
+			if err != nil {
+				return err
+			}
+			if err := r.Update(ctx, id, e.(int)*k); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func unscale(k int) func(ctx context.Context, repos Repositories) error {
+	return func(ctx context.Context, repos Repositories) error {
+		r := repos["values"]
+		for id := int64(0); id < r.GetNextId(ctx); id++ {
+			e, err := r.Get(ctx, id)
+			if err != nil {
+				return err
+			}
+			if err := r.Update(ctx, id, e.(int)/k); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+var testMigrations = []Migration{
+	{Version: 1, Name: "tens", Up: scale(10), Down: unscale(10)},
+	{Version: 2, Name: "hundreds", Up: scale(10), Down: unscale(10)},
+	{Version: 3, Name: "final", Up: scale(2)},
+}
+
+func values(t *testing.T, repos Repositories) []interface{} {
+	ctx := context.Background()
+	var values []interface{}
+	for id := int64(0); id < repos["values"].GetNextId(ctx); id++ {
+		e, err := repos["values"].Get(ctx, id)
+		assert.NoError(t, err)
+		values = append(values, e)
+	}
+	return values
+}
+
+func TestUpDown(t *testing.T) {
+	ctx := context.Background()
+	repos := Repositories{"values": repo.New()}
+	_, err := repos["values"].AddBatch(ctx, []interface{}{1, 2}, nil)
+	assert.NoError(t, err)
+	record := NewFileRecord(filepath.Join(t.TempDir(), "schema.json"))
+
+	done, err := Up(ctx, testMigrations, record, repos, 2)
+	assert.NoError(t, err)
+	assert.Len(t, done, 2)
+	assert.Equal(t, []interface{}{100, 200}, values(t, repos))
+
+	version, err := Version(record)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, version)
+
+	// applied migrations are not run again
+	done, err = Up(ctx, testMigrations, record, repos, Latest(testMigrations))
+	assert.NoError(t, err)
+	assert.Len(t, done, 1)
+	assert.Equal(t, []interface{}{200, 400}, values(t, repos))
+
+	_, err = Down(ctx, testMigrations, record, repos, 0)
+	assert.ErrorIs(t, err, Irreversible)
+
+	applied, err := record.Load()
+	assert.NoError(t, err)
+	assert.Len(t, applied, 3)
+	assert.Equal(t, "final", applied[2].Name)
+	assert.False(t, applied[2].AppliedAt.IsZero())
+
+	done, err = Down(ctx, testMigrations[:2], record, repos, 0)
+	assert.ErrorIs(t, err, SchemaTooNew)
+	assert.Empty(t, done)
+
+	_, err = Up(ctx, testMigrations, record, repos, 4)
+	assert.ErrorIs(t, err, UnknownVersion)
+}
+
+func TestDown(t *testing.T) {
+	ctx := context.Background()
+	repos := Repositories{"values": repo.New()}
+	_, err := repos["values"].AddBatch(ctx, []interface{}{1, 2}, nil)
+	assert.NoError(t, err)
+	record := NewMemoryRecord()
+
+	_, err = Up(ctx, testMigrations, record, repos, 2)
+	assert.NoError(t, err)
+
+	done, err := Down(ctx, testMigrations, record, repos, 1)
+	assert.NoError(t, err)
+	assert.Len(t, done, 1)
+	assert.Equal(t, "hundreds", done[0].Name)
+	assert.Equal(t, []interface{}{10, 20}, values(t, repos))
+
+	_, err = Down(ctx, testMigrations, record, repos, 2)
+	assert.ErrorIs(t, err, UnknownVersion)
+
+	_, err = Down(ctx, testMigrations, record, repos, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{1, 2}, values(t, repos))
+
+	version, err := Version(record)
+	assert.NoError(t, err)
+	assert.Zero(t, version)
+}
+
+func TestCheck(t *testing.T) {
+	ctx := context.Background()
+
+	type Test struct {
+		Name    string
+		Applied int
+		Values  []interface{}
+		Expect  error
+	}
+
+	tests := [...]Test{
+		{"New storage", 0, nil, nil},
+		{"Up to date", 3, []interface{}{1}, nil},
+		{"Not migrated", 0, []interface{}{1}, PendingMigrations},
+		{"Pending migration", 2, []interface{}{1}, PendingMigrations},
+		{"Newer schema", 4, nil, SchemaTooNew},
+	}
+
+	for _, test := range tests {
+		repos := Repositories{"values": repo.New()}
+		if test.Values != nil {
+			_, err := repos["values"].AddBatch(ctx, test.Values, nil)
+			assert.NoError(t, err)
+		}
+
+		record := NewMemoryRecord()
+		var applied []Applied
+		for v := 1; v <= test.Applied; v++ {
+			applied = append(applied, Applied{Version: v})
+		}
+		assert.NoError(t, record.Save(applied))
+
+		err := Check(ctx, testMigrations, record, repos)
+		if !errors.Is(err, test.Expect) {
+			t.Fatalf(`test %q: expect %v got %v`, test.Name, test.Expect, err)
+		}
+	}
+
+	// a new storage is recorded at the latest version
+	record := NewMemoryRecord()
+	assert.NoError(t, Check(ctx, testMigrations, record, Repositories{"values": repo.New()}))
+	version, err := Version(record)
+	assert.NoError(t, err)
+	assert.Equal(t, Latest(testMigrations), version)
+}
+
+func TestInvalidMigrations(t *testing.T) {
+	invalid := []Migration{testMigrations[0], testMigrations[2]}
+
+	_, err := Up(context.Background(), invalid, NewMemoryRecord(), Repositories{}, 1)
+	assert.ErrorIs(t, err, InvalidMigrations)
+
+	assert.NoError(t, validate(Migrations))
+}
+
+func TestBackfillRegistration(t *testing.T) {
+	ctx := context.Background()
+	first := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	registered := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	repos := Repositories{"ads": repo.New(), "users": repo.New()}
+	_, err := repos["users"].AddBatch(ctx, []interface{}{
+		users.User{ID: 0, Favorites: []users.Favorite{{AdID: 0, CreatedAt: first.Add(time.Hour)}}},
+		users.User{ID: 1, SavedSearches: []users.SavedSearch{{Pattern: "cat", CreatedAt: first}}},
+		users.User{ID: 2},
+		users.User{ID: 3, RegisteredAt: registered},
+	}, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, repos["ads"].Add(ctx, ads.Ad{ID: 0, AuthorID: 0, CreatedAt: first.Add(2 * time.Hour)}))
+
+	assert.NoError(t, backfillRegistration(ctx, repos))
+
+	registeredAt := func(id int64) time.Time {
+		e, err := repos["users"].Get(ctx, id)
+		assert.NoError(t, err)
+		return e.(users.User).RegisteredAt
+	}
+	assert.Equal(t, first.Add(time.Hour), registeredAt(0))
+	assert.Equal(t, first, registeredAt(1))
+	assert.WithinDuration(t, time.Now(), registeredAt(2), time.Minute)
+	assert.Equal(t, registered, registeredAt(3))
+}
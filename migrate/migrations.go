@@ -0,0 +1,69 @@
+package migrate
+
+import (
+	"context"
+	"homework10/internal/ads"
+	"homework10/internal/users"
+	"time"
+)
+
+// Migrations are the schema versions of the stored entities, in order. A new
+// one is appended whenever a field of a domain type needs its stored values
+// upgraded, e.g. filled in for the existing entities.
+var Migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "baseline",
+		// the schema of the storage before the migrations were recorded
+		Up:   func(ctx context.Context, repos Repositories) error { return nil },
+		Down: func(ctx context.Context, repos Repositories) error { return nil },
+	},
+	{
+		Version: 2,
+		Name:    "user registration time",
+		Up:      backfillRegistration,
+		// the backfilled times are kept, the previous version ignores them
+		Down: func(ctx context.Context, repos Repositories) error { return nil },
+	},
+}
+
+// backfillRegistration gives the users stored before the profiles the time
+// of their first trace: the earliest of their ads, favorites and saved
+// searches, or the time of the migration.
+func backfillRegistration(ctx context.Context, repos Repositories) error {
+	firstAd := make(map[int64]time.Time)
+	for _, e := range repos["ads"].GetArray(ctx) {
+		ad := e.(ads.Ad)
+		if t, ok := firstAd[ad.AuthorID]; !ok || ad.CreatedAt.Before(t) {
+			firstAd[ad.AuthorID] = ad.CreatedAt
+		}
+	}
+
+	now := time.Now().UTC()
+	for _, e := range repos["users"].GetArray(ctx) {
+		user := e.(users.User)
+		if !user.RegisteredAt.IsZero() {
+			continue
+		}
+
+		user.RegisteredAt = now
+		earliest := func(t time.Time) {
+			if !t.IsZero() && t.Before(user.RegisteredAt) {
+				user.RegisteredAt = t
+			}
+		}
+		earliest(firstAd[user.ID])
+		for _, favorite := range user.Favorites {
+			earliest(favorite.CreatedAt)
+		}
+		for _, search := range user.SavedSearches {
+			earliest(search.CreatedAt)
+		}
+
+		if err := repos["users"].Update(ctx, user.ID, user); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package migrate
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// MemoryRecord is the record of a storage living in memory, which starts
+// empty on every run.
+type MemoryRecord struct {
+	applied []Applied
+	mu      sync.Mutex
+}
+
+func NewMemoryRecord() *MemoryRecord {
+	return &MemoryRecord{}
+}
+
+func (m *MemoryRecord) Load() ([]Applied, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]Applied(nil), m.applied...), nil
+}
+
+func (m *MemoryRecord) Save(applied []Applied) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.applied = append([]Applied(nil), applied...)
+	return nil
+}
+
+// FileRecord is the record of a storage persisted in a directory, kept as a
+// JSON file next to its data and replaced atomically.
+type FileRecord struct {
+	path string
+}
+
+func NewFileRecord(path string) *FileRecord {
+	return &FileRecord{path: path}
+}
+
+func (f *FileRecord) Load() ([]Applied, error) {
+	data, err := os.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var applied []Applied
+	if err := json.Unmarshal(data, &applied); err != nil {
+		return nil, err
+	}
+	return applied, nil
+}
+
+func (f *FileRecord) Save(applied []Applied) error {
+	data, err := json.MarshalIndent(applied, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o755); err != nil {
+		return err
+	}
+
+	tmp := f.path + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, f.path)
+}
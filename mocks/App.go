@@ -5,6 +5,10 @@ package mocks
 import (
 	ads "homework10/internal/ads"
 
+	context "context"
+
+	conversations "homework10/internal/conversations"
+
 	mock "github.com/stretchr/testify/mock"
 
 	time "time"
@@ -17,23 +21,23 @@ type App struct {
 	mock.Mock
 }
 
-// ChangeAdStatus provides a mock function with given fields: adId, userId, published
-func (_m *App) ChangeAdStatus(adId int64, userId int64, published bool) (ads.Ad, error) {
-	ret := _m.Called(adId, userId, published)
+// AddFavorite provides a mock function with given fields: ctx, userId, adId, notify
+func (_m *App) AddFavorite(ctx context.Context, userId int64, adId int64, notify bool) (ads.Ad, error) {
+	ret := _m.Called(ctx, userId, adId, notify)
 
 	var r0 ads.Ad
 	var r1 error
-	if rf, ok := ret.Get(0).(func(int64, int64, bool) (ads.Ad, error)); ok {
-		return rf(adId, userId, published)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, bool) (ads.Ad, error)); ok {
+		return rf(ctx, userId, adId, notify)
 	}
-	if rf, ok := ret.Get(0).(func(int64, int64, bool) ads.Ad); ok {
-		r0 = rf(adId, userId, published)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, bool) ads.Ad); ok {
+		r0 = rf(ctx, userId, adId, notify)
 	} else {
 		r0 = ret.Get(0).(ads.Ad)
 	}
 
-	if rf, ok := ret.Get(1).(func(int64, int64, bool) error); ok {
-		r1 = rf(adId, userId, published)
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, bool) error); ok {
+		r1 = rf(ctx, userId, adId, notify)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -41,23 +45,187 @@ func (_m *App) ChangeAdStatus(adId int64, userId int64, published bool) (ads.Ad,
 	return r0, r1
 }
 
-// CreateAd provides a mock function with given fields: title, text, userId
-func (_m *App) CreateAd(title string, text string, userId int64) (ads.Ad, error) {
-	ret := _m.Called(title, text, userId)
+// Authenticate provides a mock function with given fields: ctx, token
+func (_m *App) Authenticate(ctx context.Context, token string) (users.User, error) {
+	ret := _m.Called(ctx, token)
+
+	var r0 users.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (users.User, error)); ok {
+		return rf(ctx, token)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) users.User); ok {
+		r0 = rf(ctx, token)
+	} else {
+		r0 = ret.Get(0).(users.User)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, token)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// BatchChangeAdStatus provides a mock function with given fields: ctx, changes
+func (_m *App) BatchChangeAdStatus(ctx context.Context, changes []ads.StatusChange) ([]ads.Result, error) {
+	ret := _m.Called(ctx, changes)
+
+	var r0 []ads.Result
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []ads.StatusChange) ([]ads.Result, error)); ok {
+		return rf(ctx, changes)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []ads.StatusChange) []ads.Result); ok {
+		r0 = rf(ctx, changes)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]ads.Result)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []ads.StatusChange) error); ok {
+		r1 = rf(ctx, changes)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// BatchCreateAds provides a mock function with given fields: ctx, drafts
+func (_m *App) BatchCreateAds(ctx context.Context, drafts []ads.Ad) ([]ads.Result, error) {
+	ret := _m.Called(ctx, drafts)
+
+	var r0 []ads.Result
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []ads.Ad) ([]ads.Result, error)); ok {
+		return rf(ctx, drafts)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []ads.Ad) []ads.Result); ok {
+		r0 = rf(ctx, drafts)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]ads.Result)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []ads.Ad) error); ok {
+		r1 = rf(ctx, drafts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// BatchGetAds provides a mock function with given fields: ctx, adIds
+func (_m *App) BatchGetAds(ctx context.Context, adIds []int64) ([]ads.Result, error) {
+	ret := _m.Called(ctx, adIds)
+
+	var r0 []ads.Result
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []int64) ([]ads.Result, error)); ok {
+		return rf(ctx, adIds)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []int64) []ads.Result); ok {
+		r0 = rf(ctx, adIds)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]ads.Result)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []int64) error); ok {
+		r1 = rf(ctx, adIds)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ChangeAdStatus provides a mock function with given fields: ctx, adId, userId, published
+func (_m *App) ChangeAdStatus(ctx context.Context, adId int64, userId int64, published bool) (ads.Ad, error) {
+	ret := _m.Called(ctx, adId, userId, published)
+
+	var r0 ads.Ad
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, bool) (ads.Ad, error)); ok {
+		return rf(ctx, adId, userId, published)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, bool) ads.Ad); ok {
+		r0 = rf(ctx, adId, userId, published)
+	} else {
+		r0 = ret.Get(0).(ads.Ad)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, bool) error); ok {
+		r1 = rf(ctx, adId, userId, published)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ChangePassword provides a mock function with given fields: ctx, userId, oldPassword, newPassword
+func (_m *App) ChangePassword(ctx context.Context, userId int64, oldPassword string, newPassword string) error {
+	ret := _m.Called(ctx, userId, oldPassword, newPassword)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string, string) error); ok {
+		r0 = rf(ctx, userId, oldPassword, newPassword)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CloseConversation provides a mock function with given fields: ctx, conversationId, userId
+func (_m *App) CloseConversation(ctx context.Context, conversationId int64, userId int64) (conversations.Conversation, error) {
+	ret := _m.Called(ctx, conversationId, userId)
+
+	var r0 conversations.Conversation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) (conversations.Conversation, error)); ok {
+		return rf(ctx, conversationId, userId)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) conversations.Conversation); ok {
+		r0 = rf(ctx, conversationId, userId)
+	} else {
+		r0 = ret.Get(0).(conversations.Conversation)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64) error); ok {
+		r1 = rf(ctx, conversationId, userId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CreateAd provides a mock function with given fields: ctx, title, text, userId
+func (_m *App) CreateAd(ctx context.Context, title string, text string, userId int64) (ads.Ad, error) {
+	ret := _m.Called(ctx, title, text, userId)
 
 	var r0 ads.Ad
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string, string, int64) (ads.Ad, error)); ok {
-		return rf(title, text, userId)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int64) (ads.Ad, error)); ok {
+		return rf(ctx, title, text, userId)
 	}
-	if rf, ok := ret.Get(0).(func(string, string, int64) ads.Ad); ok {
-		r0 = rf(title, text, userId)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int64) ads.Ad); ok {
+		r0 = rf(ctx, title, text, userId)
 	} else {
 		r0 = ret.Get(0).(ads.Ad)
 	}
 
-	if rf, ok := ret.Get(1).(func(string, string, int64) error); ok {
-		r1 = rf(title, text, userId)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, int64) error); ok {
+		r1 = rf(ctx, title, text, userId)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -65,23 +233,23 @@ func (_m *App) CreateAd(title string, text string, userId int64) (ads.Ad, error)
 	return r0, r1
 }
 
-// CreateUser provides a mock function with given fields: name, email
-func (_m *App) CreateUser(name string, email string) (users.User, error) {
-	ret := _m.Called(name, email)
+// CreateUser provides a mock function with given fields: ctx, name, email
+func (_m *App) CreateUser(ctx context.Context, name string, email string) (users.User, error) {
+	ret := _m.Called(ctx, name, email)
 
 	var r0 users.User
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string, string) (users.User, error)); ok {
-		return rf(name, email)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (users.User, error)); ok {
+		return rf(ctx, name, email)
 	}
-	if rf, ok := ret.Get(0).(func(string, string) users.User); ok {
-		r0 = rf(name, email)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) users.User); ok {
+		r0 = rf(ctx, name, email)
 	} else {
 		r0 = ret.Get(0).(users.User)
 	}
 
-	if rf, ok := ret.Get(1).(func(string, string) error); ok {
-		r1 = rf(name, email)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, name, email)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -89,13 +257,13 @@ func (_m *App) CreateUser(name string, email string) (users.User, error) {
 	return r0, r1
 }
 
-// DeleteAd provides a mock function with given fields: adId, userId
-func (_m *App) DeleteAd(adId int64, userId int64) error {
-	ret := _m.Called(adId, userId)
+// DeleteAd provides a mock function with given fields: ctx, adId, userId
+func (_m *App) DeleteAd(ctx context.Context, adId int64, userId int64) error {
+	ret := _m.Called(ctx, adId, userId)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(int64, int64) error); ok {
-		r0 = rf(adId, userId)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) error); ok {
+		r0 = rf(ctx, adId, userId)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -103,13 +271,13 @@ func (_m *App) DeleteAd(adId int64, userId int64) error {
 	return r0
 }
 
-// DeleteUser provides a mock function with given fields: userId
-func (_m *App) DeleteUser(userId int64) error {
-	ret := _m.Called(userId)
+// DeleteSavedSearch provides a mock function with given fields: ctx, userId, searchId
+func (_m *App) DeleteSavedSearch(ctx context.Context, userId int64, searchId int64) error {
+	ret := _m.Called(ctx, userId, searchId)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(int64) error); ok {
-		r0 = rf(userId)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) error); ok {
+		r0 = rf(ctx, userId, searchId)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -117,23 +285,113 @@ func (_m *App) DeleteUser(userId int64) error {
 	return r0
 }
 
-// GetAd provides a mock function with given fields: adId
-func (_m *App) GetAd(adId int64) (ads.Ad, error) {
-	ret := _m.Called(adId)
+// DeleteUser provides a mock function with given fields: ctx, userId, viewerId
+func (_m *App) DeleteUser(ctx context.Context, userId int64, viewerId int64) error {
+	ret := _m.Called(ctx, userId, viewerId)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) error); ok {
+		r0 = rf(ctx, userId, viewerId)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ExportAds provides a mock function with given fields: ctx, emit
+func (_m *App) ExportAds(ctx context.Context, emit func(ads.Ad) error) error {
+	ret := _m.Called(ctx, emit)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, func(ads.Ad) error) error); ok {
+		r0 = rf(ctx, emit)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ExportUsers provides a mock function with given fields: ctx, emit
+func (_m *App) ExportUsers(ctx context.Context, emit func(users.User) error) error {
+	ret := _m.Called(ctx, emit)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, func(users.User) error) error); ok {
+		r0 = rf(ctx, emit)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetAd provides a mock function with given fields: ctx, adId
+func (_m *App) GetAd(ctx context.Context, adId int64) (ads.Ad, error) {
+	ret := _m.Called(ctx, adId)
+
+	var r0 ads.Ad
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (ads.Ad, error)); ok {
+		return rf(ctx, adId)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ads.Ad); ok {
+		r0 = rf(ctx, adId)
+	} else {
+		r0 = ret.Get(0).(ads.Ad)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, adId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUser provides a mock function with given fields: ctx, userId
+func (_m *App) GetUser(ctx context.Context, userId int64) (users.User, error) {
+	ret := _m.Called(ctx, userId)
+
+	var r0 users.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (users.User, error)); ok {
+		return rf(ctx, userId)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) users.User); ok {
+		r0 = rf(ctx, userId)
+	} else {
+		r0 = ret.Get(0).(users.User)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, userId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ImportAd provides a mock function with given fields: ctx, ad, dryRun
+func (_m *App) ImportAd(ctx context.Context, ad ads.Ad, dryRun bool) (ads.Ad, error) {
+	ret := _m.Called(ctx, ad, dryRun)
 
 	var r0 ads.Ad
 	var r1 error
-	if rf, ok := ret.Get(0).(func(int64) (ads.Ad, error)); ok {
-		return rf(adId)
+	if rf, ok := ret.Get(0).(func(context.Context, ads.Ad, bool) (ads.Ad, error)); ok {
+		return rf(ctx, ad, dryRun)
 	}
-	if rf, ok := ret.Get(0).(func(int64) ads.Ad); ok {
-		r0 = rf(adId)
+	if rf, ok := ret.Get(0).(func(context.Context, ads.Ad, bool) ads.Ad); ok {
+		r0 = rf(ctx, ad, dryRun)
 	} else {
 		r0 = ret.Get(0).(ads.Ad)
 	}
 
-	if rf, ok := ret.Get(1).(func(int64) error); ok {
-		r1 = rf(adId)
+	if rf, ok := ret.Get(1).(func(context.Context, ads.Ad, bool) error); ok {
+		r1 = rf(ctx, ad, dryRun)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -141,23 +399,49 @@ func (_m *App) GetAd(adId int64) (ads.Ad, error) {
 	return r0, r1
 }
 
-// GetUser provides a mock function with given fields: userId
-func (_m *App) GetUser(userId int64) (users.User, error) {
-	ret := _m.Called(userId)
+// ImportUser provides a mock function with given fields: ctx, user, dryRun
+func (_m *App) ImportUser(ctx context.Context, user users.User, dryRun bool) (users.User, error) {
+	ret := _m.Called(ctx, user, dryRun)
 
 	var r0 users.User
 	var r1 error
-	if rf, ok := ret.Get(0).(func(int64) (users.User, error)); ok {
-		return rf(userId)
+	if rf, ok := ret.Get(0).(func(context.Context, users.User, bool) (users.User, error)); ok {
+		return rf(ctx, user, dryRun)
 	}
-	if rf, ok := ret.Get(0).(func(int64) users.User); ok {
-		r0 = rf(userId)
+	if rf, ok := ret.Get(0).(func(context.Context, users.User, bool) users.User); ok {
+		r0 = rf(ctx, user, dryRun)
 	} else {
 		r0 = ret.Get(0).(users.User)
 	}
 
-	if rf, ok := ret.Get(1).(func(int64) error); ok {
-		r1 = rf(userId)
+	if rf, ok := ret.Get(1).(func(context.Context, users.User, bool) error); ok {
+		r1 = rf(ctx, user, dryRun)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListAdRevisions provides a mock function with given fields: ctx, adId
+func (_m *App) ListAdRevisions(ctx context.Context, adId int64) ([]ads.Revision, error) {
+	ret := _m.Called(ctx, adId)
+
+	var r0 []ads.Revision
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]ads.Revision, error)); ok {
+		return rf(ctx, adId)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []ads.Revision); ok {
+		r0 = rf(ctx, adId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]ads.Revision)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, adId)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -165,25 +449,51 @@ func (_m *App) GetUser(userId int64) (users.User, error) {
 	return r0, r1
 }
 
-// ListAds provides a mock function with given fields: pubFilter, userFilter, timeFilter
-func (_m *App) ListAds(pubFilter bool, userFilter int64, timeFilter time.Time) ([]ads.Ad, error) {
-	ret := _m.Called(pubFilter, userFilter, timeFilter)
+// ListAds provides a mock function with given fields: ctx, pubFilter, userFilter, timeFilter
+func (_m *App) ListAds(ctx context.Context, pubFilter bool, userFilter int64, timeFilter time.Time) ([]ads.Ad, error) {
+	ret := _m.Called(ctx, pubFilter, userFilter, timeFilter)
 
 	var r0 []ads.Ad
 	var r1 error
-	if rf, ok := ret.Get(0).(func(bool, int64, time.Time) ([]ads.Ad, error)); ok {
-		return rf(pubFilter, userFilter, timeFilter)
+	if rf, ok := ret.Get(0).(func(context.Context, bool, int64, time.Time) ([]ads.Ad, error)); ok {
+		return rf(ctx, pubFilter, userFilter, timeFilter)
 	}
-	if rf, ok := ret.Get(0).(func(bool, int64, time.Time) []ads.Ad); ok {
-		r0 = rf(pubFilter, userFilter, timeFilter)
+	if rf, ok := ret.Get(0).(func(context.Context, bool, int64, time.Time) []ads.Ad); ok {
+		r0 = rf(ctx, pubFilter, userFilter, timeFilter)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]ads.Ad)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(bool, int64, time.Time) error); ok {
-		r1 = rf(pubFilter, userFilter, timeFilter)
+	if rf, ok := ret.Get(1).(func(context.Context, bool, int64, time.Time) error); ok {
+		r1 = rf(ctx, pubFilter, userFilter, timeFilter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListConversations provides a mock function with given fields: ctx, userId
+func (_m *App) ListConversations(ctx context.Context, userId int64) ([]conversations.Conversation, error) {
+	ret := _m.Called(ctx, userId)
+
+	var r0 []conversations.Conversation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]conversations.Conversation, error)); ok {
+		return rf(ctx, userId)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []conversations.Conversation); ok {
+		r0 = rf(ctx, userId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]conversations.Conversation)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, userId)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -191,25 +501,51 @@ func (_m *App) ListAds(pubFilter bool, userFilter int64, timeFilter time.Time) (
 	return r0, r1
 }
 
-// SearchAds provides a mock function with given fields: pattern
-func (_m *App) SearchAds(pattern string) ([]ads.Ad, error) {
-	ret := _m.Called(pattern)
+// ListFavorites provides a mock function with given fields: ctx, userId
+func (_m *App) ListFavorites(ctx context.Context, userId int64) ([]ads.Ad, error) {
+	ret := _m.Called(ctx, userId)
 
 	var r0 []ads.Ad
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) ([]ads.Ad, error)); ok {
-		return rf(pattern)
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]ads.Ad, error)); ok {
+		return rf(ctx, userId)
 	}
-	if rf, ok := ret.Get(0).(func(string) []ads.Ad); ok {
-		r0 = rf(pattern)
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []ads.Ad); ok {
+		r0 = rf(ctx, userId)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]ads.Ad)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(pattern)
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, userId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListMessages provides a mock function with given fields: ctx, conversationId, userId
+func (_m *App) ListMessages(ctx context.Context, conversationId int64, userId int64) ([]conversations.Message, error) {
+	ret := _m.Called(ctx, conversationId, userId)
+
+	var r0 []conversations.Message
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) ([]conversations.Message, error)); ok {
+		return rf(ctx, conversationId, userId)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) []conversations.Message); ok {
+		r0 = rf(ctx, conversationId, userId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]conversations.Message)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64) error); ok {
+		r1 = rf(ctx, conversationId, userId)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -217,23 +553,383 @@ func (_m *App) SearchAds(pattern string) ([]ads.Ad, error) {
 	return r0, r1
 }
 
-// UpdateAd provides a mock function with given fields: adId, userId, title, text
-func (_m *App) UpdateAd(adId int64, userId int64, title string, text string) (ads.Ad, error) {
-	ret := _m.Called(adId, userId, title, text)
+// ListNotifications provides a mock function with given fields: ctx, userId
+func (_m *App) ListNotifications(ctx context.Context, userId int64) ([]users.Notification, error) {
+	ret := _m.Called(ctx, userId)
+
+	var r0 []users.Notification
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]users.Notification, error)); ok {
+		return rf(ctx, userId)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []users.Notification); ok {
+		r0 = rf(ctx, userId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]users.Notification)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, userId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListSavedSearches provides a mock function with given fields: ctx, userId
+func (_m *App) ListSavedSearches(ctx context.Context, userId int64) ([]users.SavedSearch, error) {
+	ret := _m.Called(ctx, userId)
+
+	var r0 []users.SavedSearch
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]users.SavedSearch, error)); ok {
+		return rf(ctx, userId)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []users.SavedSearch); ok {
+		r0 = rf(ctx, userId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]users.SavedSearch)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, userId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Login provides a mock function with given fields: ctx, email, password
+func (_m *App) Login(ctx context.Context, email string, password string) (users.Session, error) {
+	ret := _m.Called(ctx, email, password)
+
+	var r0 users.Session
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (users.Session, error)); ok {
+		return rf(ctx, email, password)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) users.Session); ok {
+		r0 = rf(ctx, email, password)
+	} else {
+		r0 = ret.Get(0).(users.Session)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, email, password)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Logout provides a mock function with given fields: ctx, token
+func (_m *App) Logout(ctx context.Context, token string) error {
+	ret := _m.Called(ctx, token)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, token)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// OpenConversation provides a mock function with given fields: ctx, adId, userId
+func (_m *App) OpenConversation(ctx context.Context, adId int64, userId int64) (conversations.Conversation, error) {
+	ret := _m.Called(ctx, adId, userId)
+
+	var r0 conversations.Conversation
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) (conversations.Conversation, error)); ok {
+		return rf(ctx, adId, userId)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) conversations.Conversation); ok {
+		r0 = rf(ctx, adId, userId)
+	} else {
+		r0 = ret.Get(0).(conversations.Conversation)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64) error); ok {
+		r1 = rf(ctx, adId, userId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PatchAd provides a mock function with given fields: ctx, adId, userId, patch
+func (_m *App) PatchAd(ctx context.Context, adId int64, userId int64, patch ads.Patch) (ads.Ad, error) {
+	ret := _m.Called(ctx, adId, userId, patch)
+
+	var r0 ads.Ad
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, ads.Patch) (ads.Ad, error)); ok {
+		return rf(ctx, adId, userId, patch)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, ads.Patch) ads.Ad); ok {
+		r0 = rf(ctx, adId, userId, patch)
+	} else {
+		r0 = ret.Get(0).(ads.Ad)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, ads.Patch) error); ok {
+		r1 = rf(ctx, adId, userId, patch)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PatchUser provides a mock function with given fields: ctx, userId, viewerId, patch
+func (_m *App) PatchUser(ctx context.Context, userId int64, viewerId int64, patch users.Patch) (users.User, error) {
+	ret := _m.Called(ctx, userId, viewerId, patch)
+
+	var r0 users.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, users.Patch) (users.User, error)); ok {
+		return rf(ctx, userId, viewerId, patch)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, users.Patch) users.User); ok {
+		r0 = rf(ctx, userId, viewerId, patch)
+	} else {
+		r0 = ret.Get(0).(users.User)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, users.Patch) error); ok {
+		r1 = rf(ctx, userId, viewerId, patch)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Register provides a mock function with given fields: ctx, name, email, password
+func (_m *App) Register(ctx context.Context, name string, email string, password string) (users.User, error) {
+	ret := _m.Called(ctx, name, email, password)
+
+	var r0 users.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) (users.User, error)); ok {
+		return rf(ctx, name, email, password)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) users.User); ok {
+		r0 = rf(ctx, name, email, password)
+	} else {
+		r0 = ret.Get(0).(users.User)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = rf(ctx, name, email, password)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RemoveFavorite provides a mock function with given fields: ctx, userId, adId
+func (_m *App) RemoveFavorite(ctx context.Context, userId int64, adId int64) (ads.Ad, error) {
+	ret := _m.Called(ctx, userId, adId)
+
+	var r0 ads.Ad
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) (ads.Ad, error)); ok {
+		return rf(ctx, userId, adId)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) ads.Ad); ok {
+		r0 = rf(ctx, userId, adId)
+	} else {
+		r0 = ret.Get(0).(ads.Ad)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64) error); ok {
+		r1 = rf(ctx, userId, adId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RequestPasswordReset provides a mock function with given fields: ctx, email
+func (_m *App) RequestPasswordReset(ctx context.Context, email string) error {
+	ret := _m.Called(ctx, email)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, email)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ResetPassword provides a mock function with given fields: ctx, token, newPassword
+func (_m *App) ResetPassword(ctx context.Context, token string, newPassword string) error {
+	ret := _m.Called(ctx, token, newPassword)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, token, newPassword)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RollbackAd provides a mock function with given fields: ctx, adId, userId, revisionId
+func (_m *App) RollbackAd(ctx context.Context, adId int64, userId int64, revisionId int64) (ads.Ad, error) {
+	ret := _m.Called(ctx, adId, userId, revisionId)
+
+	var r0 ads.Ad
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, int64) (ads.Ad, error)); ok {
+		return rf(ctx, adId, userId, revisionId)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, int64) ads.Ad); ok {
+		r0 = rf(ctx, adId, userId, revisionId)
+	} else {
+		r0 = ret.Get(0).(ads.Ad)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, int64) error); ok {
+		r1 = rf(ctx, adId, userId, revisionId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SaveSearch provides a mock function with given fields: ctx, userId, pattern, authorFilter
+func (_m *App) SaveSearch(ctx context.Context, userId int64, pattern string, authorFilter int64) (users.SavedSearch, error) {
+	ret := _m.Called(ctx, userId, pattern, authorFilter)
+
+	var r0 users.SavedSearch
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string, int64) (users.SavedSearch, error)); ok {
+		return rf(ctx, userId, pattern, authorFilter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string, int64) users.SavedSearch); ok {
+		r0 = rf(ctx, userId, pattern, authorFilter)
+	} else {
+		r0 = ret.Get(0).(users.SavedSearch)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, string, int64) error); ok {
+		r1 = rf(ctx, userId, pattern, authorFilter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SearchAds provides a mock function with given fields: ctx, pattern
+func (_m *App) SearchAds(ctx context.Context, pattern string) ([]ads.Ad, error) {
+	ret := _m.Called(ctx, pattern)
+
+	var r0 []ads.Ad
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]ads.Ad, error)); ok {
+		return rf(ctx, pattern)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []ads.Ad); ok {
+		r0 = rf(ctx, pattern)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]ads.Ad)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, pattern)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SendMessage provides a mock function with given fields: ctx, conversationId, userId, text
+func (_m *App) SendMessage(ctx context.Context, conversationId int64, userId int64, text string) (conversations.Message, error) {
+	ret := _m.Called(ctx, conversationId, userId, text)
+
+	var r0 conversations.Message
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, string) (conversations.Message, error)); ok {
+		return rf(ctx, conversationId, userId, text)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, string) conversations.Message); ok {
+		r0 = rf(ctx, conversationId, userId, text)
+	} else {
+		r0 = ret.Get(0).(conversations.Message)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, string) error); ok {
+		r1 = rf(ctx, conversationId, userId, text)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateAd provides a mock function with given fields: ctx, adId, userId, title, text
+func (_m *App) UpdateAd(ctx context.Context, adId int64, userId int64, title string, text string) (ads.Ad, error) {
+	ret := _m.Called(ctx, adId, userId, title, text)
 
 	var r0 ads.Ad
 	var r1 error
-	if rf, ok := ret.Get(0).(func(int64, int64, string, string) (ads.Ad, error)); ok {
-		return rf(adId, userId, title, text)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, string, string) (ads.Ad, error)); ok {
+		return rf(ctx, adId, userId, title, text)
 	}
-	if rf, ok := ret.Get(0).(func(int64, int64, string, string) ads.Ad); ok {
-		r0 = rf(adId, userId, title, text)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, string, string) ads.Ad); ok {
+		r0 = rf(ctx, adId, userId, title, text)
 	} else {
 		r0 = ret.Get(0).(ads.Ad)
 	}
 
-	if rf, ok := ret.Get(1).(func(int64, int64, string, string) error); ok {
-		r1 = rf(adId, userId, title, text)
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, string, string) error); ok {
+		r1 = rf(ctx, adId, userId, title, text)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateUser provides a mock function with given fields: ctx, userId, viewerId, name, email
+func (_m *App) UpdateUser(ctx context.Context, userId int64, viewerId int64, name string, email string) (users.User, error) {
+	ret := _m.Called(ctx, userId, viewerId, name, email)
+
+	var r0 users.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, string, string) (users.User, error)); ok {
+		return rf(ctx, userId, viewerId, name, email)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, string, string) users.User); ok {
+		r0 = rf(ctx, userId, viewerId, name, email)
+	} else {
+		r0 = ret.Get(0).(users.User)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, string, string) error); ok {
+		r1 = rf(ctx, userId, viewerId, name, email)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -241,23 +937,23 @@ func (_m *App) UpdateAd(adId int64, userId int64, title string, text string) (ad
 	return r0, r1
 }
 
-// UpdateUser provides a mock function with given fields: userId, name, email
-func (_m *App) UpdateUser(userId int64, name string, email string) (users.User, error) {
-	ret := _m.Called(userId, name, email)
+// ViewUser provides a mock function with given fields: ctx, userId, viewerId
+func (_m *App) ViewUser(ctx context.Context, userId int64, viewerId int64) (users.User, error) {
+	ret := _m.Called(ctx, userId, viewerId)
 
 	var r0 users.User
 	var r1 error
-	if rf, ok := ret.Get(0).(func(int64, string, string) (users.User, error)); ok {
-		return rf(userId, name, email)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) (users.User, error)); ok {
+		return rf(ctx, userId, viewerId)
 	}
-	if rf, ok := ret.Get(0).(func(int64, string, string) users.User); ok {
-		r0 = rf(userId, name, email)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) users.User); ok {
+		r0 = rf(ctx, userId, viewerId)
 	} else {
 		r0 = ret.Get(0).(users.User)
 	}
 
-	if rf, ok := ret.Get(1).(func(int64, string, string) error); ok {
-		r1 = rf(userId, name, email)
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64) error); ok {
+		r1 = rf(ctx, userId, viewerId)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -2,20 +2,26 @@
 
 package mocks
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	query "homework10/internal/query"
+)
 
 // Repository is an autogenerated mock type for the Repository type
 type Repository struct {
 	mock.Mock
 }
 
-// Add provides a mock function with given fields: e
-func (_m *Repository) Add(e interface{}) error {
-	ret := _m.Called(e)
+// Add provides a mock function with given fields: ctx, e
+func (_m *Repository) Add(ctx context.Context, e interface{}) error {
+	ret := _m.Called(ctx, e)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(interface{}) error); ok {
-		r0 = rf(e)
+	if rf, ok := ret.Get(0).(func(context.Context, interface{}) error); ok {
+		r0 = rf(ctx, e)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -23,13 +29,39 @@ func (_m *Repository) Add(e interface{}) error {
 	return r0
 }
 
-// CheckIdExist provides a mock function with given fields: id
-func (_m *Repository) CheckIdExist(id int64) bool {
-	ret := _m.Called(id)
+// AddBatch provides a mock function with given fields: ctx, es, withId
+func (_m *Repository) AddBatch(ctx context.Context, es []interface{}, withId func(e interface{}, id int64) interface{}) ([]int64, error) {
+	ret := _m.Called(ctx, es, withId)
+
+	var r0 []int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []interface{}, func(e interface{}, id int64) interface{}) ([]int64, error)); ok {
+		return rf(ctx, es, withId)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []interface{}, func(e interface{}, id int64) interface{}) []int64); ok {
+		r0 = rf(ctx, es, withId)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]int64)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []interface{}, func(e interface{}, id int64) interface{}) error); ok {
+		r1 = rf(ctx, es, withId)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CheckIdExist provides a mock function with given fields: ctx, id
+func (_m *Repository) CheckIdExist(ctx context.Context, id int64) bool {
+	ret := _m.Called(ctx, id)
 
 	var r0 bool
-	if rf, ok := ret.Get(0).(func(int64) bool); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, int64) bool); ok {
+		r0 = rf(ctx, id)
 	} else {
 		r0 = ret.Get(0).(bool)
 	}
@@ -37,13 +69,13 @@ func (_m *Repository) CheckIdExist(id int64) bool {
 	return r0
 }
 
-// Delete provides a mock function with given fields: id
-func (_m *Repository) Delete(id int64) error {
-	ret := _m.Called(id)
+// Delete provides a mock function with given fields: ctx, id
+func (_m *Repository) Delete(ctx context.Context, id int64) error {
+	ret := _m.Called(ctx, id)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(int64) error); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, id)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -51,25 +83,51 @@ func (_m *Repository) Delete(id int64) error {
 	return r0
 }
 
-// Get provides a mock function with given fields: id
-func (_m *Repository) Get(id int64) (interface{}, error) {
-	ret := _m.Called(id)
+// Find provides a mock function with given fields: ctx, index, from, to
+func (_m *Repository) Find(ctx context.Context, index query.Index, from int64, to int64) ([]interface{}, error) {
+	ret := _m.Called(ctx, index, from, to)
+
+	var r0 []interface{}
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, query.Index, int64, int64) ([]interface{}, error)); ok {
+		return rf(ctx, index, from, to)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, query.Index, int64, int64) []interface{}); ok {
+		r0 = rf(ctx, index, from, to)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]interface{})
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, query.Index, int64, int64) error); ok {
+		r1 = rf(ctx, index, from, to)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Get provides a mock function with given fields: ctx, id
+func (_m *Repository) Get(ctx context.Context, id int64) (interface{}, error) {
+	ret := _m.Called(ctx, id)
 
 	var r0 interface{}
 	var r1 error
-	if rf, ok := ret.Get(0).(func(int64) (interface{}, error)); ok {
-		return rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (interface{}, error)); ok {
+		return rf(ctx, id)
 	}
-	if rf, ok := ret.Get(0).(func(int64) interface{}); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, int64) interface{}); ok {
+		r0 = rf(ctx, id)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(interface{})
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(int64) error); ok {
-		r1 = rf(id)
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -77,13 +135,13 @@ func (_m *Repository) Get(id int64) (interface{}, error) {
 	return r0, r1
 }
 
-// GetArray provides a mock function with given fields:
-func (_m *Repository) GetArray() []interface{} {
-	ret := _m.Called()
+// GetArray provides a mock function with given fields: ctx
+func (_m *Repository) GetArray(ctx context.Context) []interface{} {
+	ret := _m.Called(ctx)
 
 	var r0 []interface{}
-	if rf, ok := ret.Get(0).(func() []interface{}); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(context.Context) []interface{}); ok {
+		r0 = rf(ctx)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]interface{})
@@ -93,13 +151,39 @@ func (_m *Repository) GetArray() []interface{} {
 	return r0
 }
 
-// GetNextId provides a mock function with given fields:
-func (_m *Repository) GetNextId() int64 {
-	ret := _m.Called()
+// GetBatch provides a mock function with given fields: ctx, ids
+func (_m *Repository) GetBatch(ctx context.Context, ids []int64) ([]interface{}, error) {
+	ret := _m.Called(ctx, ids)
+
+	var r0 []interface{}
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []int64) ([]interface{}, error)); ok {
+		return rf(ctx, ids)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []int64) []interface{}); ok {
+		r0 = rf(ctx, ids)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]interface{})
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []int64) error); ok {
+		r1 = rf(ctx, ids)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetNextId provides a mock function with given fields: ctx
+func (_m *Repository) GetNextId(ctx context.Context) int64 {
+	ret := _m.Called(ctx)
 
 	var r0 int64
-	if rf, ok := ret.Get(0).(func() int64); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(context.Context) int64); ok {
+		r0 = rf(ctx)
 	} else {
 		r0 = ret.Get(0).(int64)
 	}
@@ -107,13 +191,67 @@ func (_m *Repository) GetNextId() int64 {
 	return r0
 }
 
-// Update provides a mock function with given fields: id, ad
-func (_m *Repository) Update(id int64, ad interface{}) error {
-	ret := _m.Called(id, ad)
+// Insert provides a mock function with given fields: ctx, id, e
+func (_m *Repository) Insert(ctx context.Context, id int64, e interface{}) error {
+	ret := _m.Called(ctx, id, e)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(int64, interface{}) error); ok {
-		r0 = rf(id, ad)
+	if rf, ok := ret.Get(0).(func(context.Context, int64, interface{}) error); ok {
+		r0 = rf(ctx, id, e)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Modify provides a mock function with given fields: ctx, id, change
+func (_m *Repository) Modify(ctx context.Context, id int64, change func(e interface{}) (interface{}, error)) (interface{}, error) {
+	ret := _m.Called(ctx, id, change)
+
+	var r0 interface{}
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, func(e interface{}) (interface{}, error)) (interface{}, error)); ok {
+		return rf(ctx, id, change)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, func(e interface{}) (interface{}, error)) interface{}); ok {
+		r0 = rf(ctx, id, change)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(interface{})
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, func(e interface{}) (interface{}, error)) error); ok {
+		r1 = rf(ctx, id, change)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Update provides a mock function with given fields: ctx, id, ad
+func (_m *Repository) Update(ctx context.Context, id int64, ad interface{}) error {
+	ret := _m.Called(ctx, id, ad)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, interface{}) error); ok {
+		r0 = rf(ctx, id, ad)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateBatch provides a mock function with given fields: ctx, ids, es
+func (_m *Repository) UpdateBatch(ctx context.Context, ids []int64, es []interface{}) error {
+	ret := _m.Called(ctx, ids, es)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []int64, []interface{}) error); ok {
+		r0 = rf(ctx, ids, es)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -135,4 +273,3 @@ func NewRepository(t mockConstructorTestingTNewRepository) *Repository {
 
 	return mock
 }
-
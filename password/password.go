@@ -0,0 +1,154 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+)
+
+var (
+	Mismatch    = errors.New("the password does not match")
+	UnknownHash = errors.New("the hash was not made by a known algorithm")
+)
+
+// Hasher hashes passwords with a slow, salted algorithm. Verify accepts the
+// hashes of every hasher of the package, so that the stored ones keep
+// working when the configured algorithm changes.
+type Hasher interface {
+	Hash(password string) (string, error)
+	Verify(hash string, password string) error
+}
+
+// New returns the hasher of the algorithm, "argon2id" or "bcrypt", with its
+// default cost.
+func New(algorithm string) (Hasher, error) {
+	switch algorithm {
+	case "argon2id":
+		return NewArgon2id(DefaultArgon2idParams), nil
+	case "bcrypt":
+		return NewBcrypt(bcrypt.DefaultCost), nil
+	default:
+		return nil, fmt.Errorf("unknown password hasher %q", algorithm)
+	}
+}
+
+// Argon2idParams are the costs of a hash: Memory in KiB, Time passes over
+// it and Threads lanes.
+type Argon2idParams struct {
+	Memory  uint32
+	Time    uint32
+	Threads uint8
+	SaltLen uint32
+	KeyLen  uint32
+}
+
+// DefaultArgon2idParams are the second recommended option of RFC 9106 with
+// a memory of 64 MiB.
+var DefaultArgon2idParams = Argon2idParams{Memory: 64 * 1024, Time: 3, Threads: 4, SaltLen: 16, KeyLen: 32}
+
+// Argon2id makes hashes in the PHC string format, e.g.
+// $argon2id$v=19$m=65536,t=3,p=4$<salt>$<key>.
+type Argon2id struct {
+	params Argon2idParams
+}
+
+func NewArgon2id(params Argon2idParams) *Argon2id {
+	return &Argon2id{params: params}
+}
+
+func (a *Argon2id) Hash(password string) (string, error) {
+	salt := make([]byte, a.params.SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, a.params.Time, a.params.Memory, a.params.Threads, a.params.KeyLen)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version,
+		a.params.Memory, a.params.Time, a.params.Threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func (a *Argon2id) Verify(hash string, password string) error {
+	return Verify(hash, password)
+}
+
+// Bcrypt makes hashes in the modular crypt format, e.g. $2a$10$<salt><key>.
+// It uses the first 72 bytes of a password only.
+type Bcrypt struct {
+	cost int
+}
+
+func NewBcrypt(cost int) *Bcrypt {
+	return &Bcrypt{cost: cost}
+}
+
+func (b *Bcrypt) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), b.cost)
+	return string(hash), err
+}
+
+func (b *Bcrypt) Verify(hash string, password string) error {
+	return Verify(hash, password)
+}
+
+// Verify checks the password against a hash made by any hasher of the
+// package. The keys are compared in constant time.
+func Verify(hash string, password string) error {
+	switch {
+	case strings.HasPrefix(hash, "$argon2id$"):
+		return verifyArgon2id(hash, password)
+	case strings.HasPrefix(hash, "$2a$"), strings.HasPrefix(hash, "$2b$"), strings.HasPrefix(hash, "$2y$"):
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return Mismatch
+		}
+		return err
+	default:
+		return UnknownHash
+	}
+}
+
+func verifyArgon2id(hash string, password string) error {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return UnknownHash
+	}
+
+	var version int
+	var params Argon2idParams
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return UnknownHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Time, &params.Threads); err != nil {
+		return UnknownHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return UnknownHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return UnknownHash
+	}
+
+	other := argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Threads, uint32(len(key)))
+	if !Equal(key, other) {
+		return Mismatch
+	}
+
+	return nil
+}
+
+// Equal compares two secrets, e.g. keys or token digests, in a time that
+// depends on their length only, so that it tells nothing about where they
+// differ.
+func Equal(a []byte, b []byte) bool {
+	return subtle.ConstantTimeCompare(a, b) == 1
+}
@@ -0,0 +1,105 @@
+package password
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var fastArgon2idParams = Argon2idParams{Memory: 64, Time: 1, Threads: 1, SaltLen: 16, KeyLen: 32}
+
+func TestVerify(t *testing.T) {
+	hashers := map[string]Hasher{
+		"argon2id": NewArgon2id(fastArgon2idParams),
+		"bcrypt":   NewBcrypt(bcrypt.MinCost),
+	}
+
+	for name, hasher := range hashers {
+		hash, err := hasher.Hash("correct horse")
+		assert.NoError(t, err, name)
+
+		other, err := hasher.Hash("correct horse")
+		assert.NoError(t, err, name)
+		assert.NotEqual(t, hash, other, "%s: hashes of a password must have their own salt", name)
+
+		type Test struct {
+			Name     string
+			Hash     string
+			Password string
+			Expect   error
+		}
+
+		tests := [...]Test{
+			{"Right password", hash, "correct horse", nil},
+			{"Wrong password", hash, "correct horsf", Mismatch},
+			{"Empty password", hash, "", Mismatch},
+			{"Prefix of the password", hash, "correct", Mismatch},
+			{"Unknown algorithm", "$md5$" + hash, "correct horse", UnknownHash},
+			{"Plain text", "correct horse", "correct horse", UnknownHash},
+		}
+
+		for _, test := range tests {
+			err := hasher.Verify(test.Hash, test.Password)
+			if !errors.Is(err, test.Expect) {
+				t.Fatalf(`test %q: expect %v got %v`, name+": "+test.Name, test.Expect, err)
+			}
+
+			// any hasher verifies the hashes of the others
+			for _, verifier := range hashers {
+				if err := verifier.Verify(test.Hash, test.Password); !errors.Is(err, test.Expect) {
+					t.Fatalf(`test %q: expect %v got %v`, name+": "+test.Name, test.Expect, err)
+				}
+			}
+		}
+	}
+}
+
+func TestVerify_Argon2idParams(t *testing.T) {
+	hash, err := NewArgon2id(fastArgon2idParams).Hash("secret")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=1$"), hash)
+
+	// the costs are read from the hash, not from the hasher
+	assert.NoError(t, NewArgon2id(DefaultArgon2idParams).Verify(hash, "secret"))
+
+	tampered := strings.Replace(hash, "t=1", "t=2", 1)
+	assert.ErrorIs(t, Verify(tampered, "secret"), Mismatch)
+
+	truncated := hash[:strings.LastIndex(hash, "$")]
+	assert.ErrorIs(t, Verify(truncated, "secret"), UnknownHash)
+}
+
+func TestEqual(t *testing.T) {
+	type Test struct {
+		Name   string
+		A, B   string
+		Expect bool
+	}
+
+	tests := [...]Test{
+		{"Same", "0123456789abcdef", "0123456789abcdef", true},
+		{"First byte differs", "0123456789abcdef", "x123456789abcdef", false},
+		{"Last byte differs", "0123456789abcdef", "0123456789abcdex", false},
+		{"Prefix", "0123456789abcdef", "01234567", false},
+		{"Empty", "", "", true},
+	}
+
+	for _, test := range tests {
+		if got := Equal([]byte(test.A), []byte(test.B)); got != test.Expect {
+			t.Fatalf(`test %q: expect %v got %v`, test.Name, test.Expect, got)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, algorithm := range []string{"argon2id", "bcrypt"} {
+		_, err := New(algorithm)
+		assert.NoError(t, err, algorithm)
+	}
+
+	_, err := New("md5")
+	assert.Error(t, err)
+}
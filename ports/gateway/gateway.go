@@ -0,0 +1,67 @@
+package gateway
+
+import (
+	"context"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"homework10/internal/logger"
+	"net/http"
+	"strings"
+
+	grpcPort "homework10/internal/ports/grpc"
+)
+
+// forwardedHeaders are passed to the gRPC server as metadata so that traces
+// started by HTTP clients continue through the gateway, sessions are
+// authenticated and clients are rate limited by their own IP.
+var forwardedHeaders = map[string]bool{
+	"traceparent":             true,
+	"tracestate":              true,
+	"baggage":                 true,
+	"authorization":           true,
+	grpcPort.ClientIPMetadata: true,
+}
+
+// NewHandler serves the REST mapping of service.proto, calling the gRPC
+// server behind conn with the interceptors it has.
+func NewHandler(ctx context.Context, conn *grpc.ClientConn) (http.Handler, error) {
+	mux := runtime.NewServeMux(
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, newMarshaler()),
+		runtime.WithErrorHandler(errorHandler),
+		runtime.WithMetadata(requestMetadata),
+		runtime.WithIncomingHeaderMatcher(incomingHeader),
+		runtime.WithOutgoingHeaderMatcher(outgoingHeader),
+	)
+
+	if err := grpcPort.RegisterAdServiceHandler(ctx, mux, conn); err != nil {
+		return nil, err
+	}
+
+	return mergePatch(mux), nil
+}
+
+// requestMetadata passes the request ID set by the HTTP middleware, so that
+// both servers log the call under the same ID.
+func requestMetadata(ctx context.Context, _ *http.Request) metadata.MD {
+	if id := logger.RequestID(ctx); id != "" {
+		return metadata.Pairs(logger.RequestIDKey, id)
+	}
+
+	return nil
+}
+
+func incomingHeader(key string) (string, bool) {
+	key = strings.ToLower(key)
+	return key, forwardedHeaders[key]
+}
+
+func outgoingHeader(string) (string, bool) {
+	return "", false
+}
+
+func errorHandler(_ context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, w http.ResponseWriter, _ *http.Request, err error) {
+	st := status.Convert(err)
+	writeError(w, runtime.HTTPStatusFromCode(st.Code()), st.Message())
+}
@@ -0,0 +1,118 @@
+package gateway
+
+import (
+	"encoding/json"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"io"
+	"reflect"
+	"time"
+)
+
+// envelopeMarshaler writes responses the way the gin router does: wrapped
+// into {"data": ..., "error": null}, with 64-bit integers as JSON numbers
+// and timestamps in RFC 3339. Requests are decoded with protojson.
+type envelopeMarshaler struct {
+	runtime.JSONPb
+}
+
+func newMarshaler() *envelopeMarshaler {
+	return &envelopeMarshaler{JSONPb: runtime.JSONPb{
+		UnmarshalOptions: protojson.UnmarshalOptions{DiscardUnknown: true},
+	}}
+}
+
+func (m *envelopeMarshaler) ContentType(any) string {
+	return "application/json; charset=utf-8"
+}
+
+func (m *envelopeMarshaler) Marshal(v any) ([]byte, error) {
+	return json.Marshal(envelope{Data: toJSON(v)})
+}
+
+func (m *envelopeMarshaler) NewEncoder(w io.Writer) runtime.Encoder {
+	return runtime.EncoderFunc(func(v any) error {
+		data, err := m.Marshal(v)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	})
+}
+
+type envelope struct {
+	Data  any     `json:"data"`
+	Error *string `json:"error"`
+}
+
+// toJSON converts a message, or a list of messages selected with
+// response_body, into values encoding/json writes like the gin presenters.
+func toJSON(v any) any {
+	if msg, ok := v.(proto.Message); ok {
+		return messageJSON(msg.ProtoReflect())
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Slice {
+		list := make([]any, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			list = append(list, toJSON(rv.Index(i).Interface()))
+		}
+		return list
+	}
+
+	return v
+}
+
+func messageJSON(m protoreflect.Message) any {
+	if !m.IsValid() {
+		return nil
+	}
+
+	if ts, ok := m.Interface().(*timestamppb.Timestamp); ok {
+		return ts.AsTime().Format(time.RFC3339Nano)
+	}
+
+	fields := m.Descriptor().Fields()
+	obj := make(map[string]any, fields.Len())
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		value := m.Get(fd)
+
+		if fd.IsList() {
+			list := value.List()
+			items := make([]any, 0, list.Len())
+			for j := 0; j < list.Len(); j++ {
+				items = append(items, fieldJSON(fd, list.Get(j)))
+			}
+			obj[fd.JSONName()] = items
+			continue
+		}
+
+		if fd.Message() != nil && !m.Has(fd) {
+			obj[fd.JSONName()] = nil
+			continue
+		}
+
+		obj[fd.JSONName()] = fieldJSON(fd, value)
+	}
+
+	return obj
+}
+
+func fieldJSON(fd protoreflect.FieldDescriptor, v protoreflect.Value) any {
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return messageJSON(v.Message())
+	case protoreflect.EnumKind:
+		return int32(v.Enum())
+	case protoreflect.BytesKind:
+		return v.Bytes()
+	default:
+		return v.Interface()
+	}
+}
@@ -0,0 +1,84 @@
+package gateway
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// identityFields name the entity and the caller in a patch body, they are
+// never changed by it.
+var identityFields = map[string]bool{
+	"id":      true,
+	"ad_id":   true,
+	"user_id": true,
+}
+
+// mergePatch turns the body of a PATCH request without an update_mask into
+// the update request with the mask listing the fields in the body, so that
+// a JSON merge patch changes only the fields it has.
+func mergePatch(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		var patch map[string]json.RawMessage
+		if err := json.Unmarshal(body, &patch); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid merge patch: "+err.Error())
+			return
+		}
+
+		if _, ok := patch["update_mask"]; !ok {
+			var paths []string
+			for name, value := range patch {
+				if identityFields[name] {
+					continue
+				}
+				if string(value) == "null" {
+					writeError(w, http.StatusBadRequest, "invalid merge patch: field \""+name+"\" can't be removed")
+					return
+				}
+				paths = append(paths, jsonPath(name))
+			}
+			sort.Strings(paths)
+
+			mask, _ := json.Marshal(strings.Join(paths, ","))
+			patch["update_mask"] = mask
+			body, _ = json.Marshal(patch)
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		r.ContentLength = int64(len(body))
+		r.Header.Set("Content-Type", "application/json")
+		next.ServeHTTP(w, r)
+	})
+}
+
+// jsonPath is the path of a field in the JSON form of a field mask, which is
+// in lowerCamelCase: "display_name" is "displayName".
+func jsonPath(name string) string {
+	parts := strings.Split(name, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+func writeError(w http.ResponseWriter, code int, message string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(envelope{Error: &message})
+}
@@ -0,0 +1,157 @@
+package grpc
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"homework10/internal/app"
+	"homework10/internal/users"
+	"strings"
+)
+
+// AuthorizationMetadata carries the session token as "Bearer <token>".
+const AuthorizationMetadata = "authorization"
+
+var MissingToken = errors.New("the request has no bearer token in the authorization metadata")
+var OwnerOnly = errors.New("only a session of the user can do this")
+
+// bearerToken returns the session token of the authorization metadata.
+func bearerToken(ctx context.Context) (string, bool) {
+	values := metadata.ValueFromIncomingContext(ctx, AuthorizationMetadata)
+	if len(values) == 0 {
+		return "", false
+	}
+
+	token, found := strings.CutPrefix(values[0], "Bearer ")
+	return token, found && token != ""
+}
+
+// sessionUserID returns the ID of the user of the session whose token the
+// call has, or -1 for a call without one.
+func (a *AdService) sessionUserID(ctx context.Context) (int64, error) {
+	token, ok := bearerToken(ctx)
+	if !ok {
+		return -1, nil
+	}
+
+	user, err := a.adApp.Authenticate(ctx, token)
+	if err != nil {
+		return -1, err
+	}
+	return user.ID, nil
+}
+
+// requireSession returns the ID of the user of the session whose token the
+// call has, or the status of a call without a valid one.
+func (a *AdService) requireSession(ctx context.Context) (int64, error) {
+	viewerID, err := a.sessionUserID(ctx)
+	if err != nil {
+		return -1, accountError(err)
+	}
+	if viewerID < 0 {
+		return -1, status.Error(codes.Unauthenticated, MissingToken.Error())
+	}
+	return viewerID, nil
+}
+
+// sessionOf checks that the call has a session of the user.
+func (a *AdService) sessionOf(ctx context.Context, userID int64) error {
+	viewerID, err := a.requireSession(ctx)
+	if err != nil {
+		return err
+	}
+	if viewerID != userID {
+		return status.Error(codes.PermissionDenied, OwnerOnly.Error())
+	}
+	return nil
+}
+
+// accountError is the status of an error of the account flows.
+func accountError(err error) error {
+	switch {
+	case errors.Is(err, app.InvalidCredentials), errors.Is(err, app.InvalidToken):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, app.AccountLocked):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, app.ResetUnavailable):
+		return status.Error(codes.Unimplemented, err.Error())
+	case errors.Is(err, app.DefunctUser), errors.Is(err, app.InvalidEmail),
+		errors.Is(err, app.WeakPassword), errors.Is(err, app.EmailTaken):
+		return status.Error(codes.InvalidArgument, err.Error())
+	default:
+		return status.New(codes.Unknown, "an unknown error has occurred").Err()
+	}
+}
+
+func SessionSuccessResponse(session *users.Session) *SessionResponse {
+	return &SessionResponse{
+		Id:        session.ID,
+		UserId:    session.UserID,
+		Token:     session.Token,
+		CreatedAt: timestamppb.New(session.CreatedAt),
+		ExpiresAt: timestamppb.New(session.ExpiresAt),
+	}
+}
+
+func (a *AdService) Register(ctx context.Context, request *RegisterRequest) (*UserResponse, error) {
+	user, err := a.adApp.Register(ctx, request.Name, request.Email, request.Password)
+	if err != nil {
+		return &UserResponse{}, accountError(err)
+	}
+
+	return UserSuccessResponse(&user), nil
+}
+
+func (a *AdService) Login(ctx context.Context, request *LoginRequest) (*SessionResponse, error) {
+	session, err := a.adApp.Login(ctx, request.Email, request.Password)
+	if err != nil {
+		return &SessionResponse{}, accountError(err)
+	}
+
+	return SessionSuccessResponse(&session), nil
+}
+
+func (a *AdService) Logout(ctx context.Context, request *LogoutRequest) (*emptypb.Empty, error) {
+	token, ok := bearerToken(ctx)
+	if !ok {
+		return &emptypb.Empty{}, status.Error(codes.Unauthenticated, MissingToken.Error())
+	}
+
+	err := a.adApp.Logout(ctx, token)
+	if err != nil {
+		return &emptypb.Empty{}, accountError(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (a *AdService) ChangePassword(ctx context.Context, request *ChangePasswordRequest) (*emptypb.Empty, error) {
+	err := a.adApp.ChangePassword(ctx, request.UserId, request.OldPassword, request.NewPassword)
+	if err != nil {
+		return &emptypb.Empty{}, accountError(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (a *AdService) RequestPasswordReset(ctx context.Context, request *RequestPasswordResetRequest) (*emptypb.Empty, error) {
+	err := a.adApp.RequestPasswordReset(ctx, request.Email)
+	if err != nil {
+		return &emptypb.Empty{}, accountError(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (a *AdService) ResetPassword(ctx context.Context, request *ResetPasswordRequest) (*emptypb.Empty, error) {
+	err := a.adApp.ResetPassword(ctx, request.Token, request.NewPassword)
+	if err != nil {
+		return &emptypb.Empty{}, accountError(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
@@ -0,0 +1,69 @@
+package grpc
+
+import (
+	"context"
+	validator "github.com/Vdaleke/ad-validation"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"homework10/internal/ads"
+	"homework10/internal/app"
+)
+
+// itemStatus is the status the single call would have returned for the
+// error of a batch item.
+func itemStatus(err error) *status.Status {
+	if errors.Is(err, app.PermissionDenied) {
+		return status.New(codes.PermissionDenied, "the user does not have permission to edit the ad")
+	} else if errors.Is(err, validator.ValidationError) ||
+		errors.Is(err, app.DefunctUser) || errors.Is(err, app.DefunctAd) {
+		return status.New(codes.InvalidArgument, "invalid information received")
+	}
+
+	return status.New(codes.Unknown, "an unknown error has occurred")
+}
+
+func batchError(err error) error {
+	if errors.Is(err, app.BatchTooLarge) {
+		return status.Errorf(codes.InvalidArgument, "a batch can't have more than %d items", app.MaxBatchSize)
+	}
+
+	return status.New(codes.Unknown, "an unknown error has occurred").Err()
+}
+
+func (a *AdService) BatchCreateAds(ctx context.Context, request *BatchCreateAdsRequest) (*BatchAdResponse, error) {
+	drafts := make([]ads.Ad, len(request.Requests))
+	for i, r := range request.Requests {
+		drafts[i] = ads.Ad{Title: r.Title, Text: r.Text, AuthorID: r.UserId}
+	}
+
+	results, err := a.adApp.BatchCreateAds(ctx, drafts)
+	if err != nil {
+		return &BatchAdResponse{}, batchError(err)
+	}
+
+	return BatchSuccessResponse(&results), nil
+}
+
+func (a *AdService) BatchGetAds(ctx context.Context, request *BatchGetAdsRequest) (*BatchAdResponse, error) {
+	results, err := a.adApp.BatchGetAds(ctx, request.Ids)
+	if err != nil {
+		return &BatchAdResponse{}, batchError(err)
+	}
+
+	return BatchSuccessResponse(&results), nil
+}
+
+func (a *AdService) BatchChangeAdStatus(ctx context.Context, request *BatchChangeAdStatusRequest) (*BatchAdResponse, error) {
+	changes := make([]ads.StatusChange, len(request.Requests))
+	for i, r := range request.Requests {
+		changes[i] = ads.StatusChange{AdID: r.AdId, UserID: r.UserId, Published: r.Published}
+	}
+
+	results, err := a.adApp.BatchChangeAdStatus(ctx, changes)
+	if err != nil {
+		return &BatchAdResponse{}, batchError(err)
+	}
+
+	return BatchSuccessResponse(&results), nil
+}
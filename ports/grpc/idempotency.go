@@ -0,0 +1,97 @@
+package grpc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"homework10/internal/idempotency"
+)
+
+const (
+	IdempotencyKeyMetadata     = "idempotency-key"
+	IdempotentReplayedMetadata = "idempotent-replayed"
+)
+
+type storedCall struct {
+	resp any
+	err  error
+}
+
+// IdempotencyInterceptor replays the result of a call made again by the same
+// caller with the same idempotency-key metadata and request. Only successful calls and the
+// ones rejected as invalid or forbidden are stored, any other error lets the
+// call be retried with the key.
+func IdempotencyInterceptor(s *idempotency.Store) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		keys := metadata.ValueFromIncomingContext(ctx, IdempotencyKeyMetadata)
+		if len(keys) == 0 {
+			return handler(ctx, req)
+		}
+
+		key := keys[0]
+		if key == "" || len(key) > idempotency.MaxKeyLength {
+			return nil, status.Error(codes.InvalidArgument, idempotency.InvalidKey.Error())
+		}
+
+		msg, ok := req.(proto.Message)
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		data, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+
+		sum := sha256.Sum256(data)
+		scope := idempotencyCaller(ctx) + "|" + info.FullMethod + "|" + key
+
+		stored, done, err := s.Begin(scope, hex.EncodeToString(sum[:]))
+		if errors.Is(err, idempotency.KeyReused) {
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		} else if errors.Is(err, idempotency.InProgress) {
+			return nil, status.Error(codes.Aborted, err.Error())
+		}
+
+		if done {
+			call := stored.(storedCall)
+			_ = grpc.SetHeader(ctx, metadata.Pairs(IdempotentReplayedMetadata, "true"))
+			return call.resp, call.err
+		}
+
+		// the key is released when a handler panics as well
+		finished := false
+		defer func() {
+			if !finished {
+				s.Abort(scope)
+			}
+		}()
+
+		resp, err := handler(ctx, req)
+
+		switch status.Code(err) {
+		case codes.OK, codes.InvalidArgument, codes.PermissionDenied:
+			s.Finish(scope, storedCall{resp: resp, err: err})
+			finished = true
+		}
+
+		return resp, err
+	}
+}
+
+// idempotencyCaller tells apart the callers whose keys may collide: by their
+// session token, or else as the rate limiter does.
+func idempotencyCaller(ctx context.Context) string {
+	if token, ok := bearerToken(ctx); ok {
+		sum := sha256.Sum256([]byte(token))
+		return "token:" + hex.EncodeToString(sum[:])
+	}
+
+	return callIdentity(ctx)
+}
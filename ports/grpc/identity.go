@@ -0,0 +1,42 @@
+package grpc
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"homework10/internal/tlscert"
+)
+
+// PeerIdentityInterceptor puts the identity of a caller authenticated by a
+// client certificate into the context.
+func PeerIdentityInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	return handler(withPeerIdentity(ctx), req)
+}
+
+// PeerIdentityStreamInterceptor is PeerIdentityInterceptor for the
+// streaming calls.
+func PeerIdentityStreamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &identityStream{ServerStream: ss, ctx: withPeerIdentity(ss.Context())})
+}
+
+type identityStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *identityStream) Context() context.Context {
+	return s.ctx
+}
+
+func withPeerIdentity(ctx context.Context) context.Context {
+	if p, ok := peer.FromContext(ctx); ok {
+		if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			if identity := tlscert.Identity(&tlsInfo.State); identity != "" {
+				ctx = tlscert.WithIdentity(ctx, identity)
+			}
+		}
+	}
+
+	return ctx
+}
@@ -0,0 +1,21 @@
+package grpc
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"homework10/internal/metrics"
+	"time"
+)
+
+func MetricsInterceptor(m *metrics.Metrics) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		t := time.Now()
+
+		resp, err := handler(ctx, req)
+
+		m.ObserveGRPC(info.FullMethod, status.Code(err).String(), time.Since(t))
+
+		return resp, err
+	}
+}
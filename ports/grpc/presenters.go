@@ -3,6 +3,7 @@ package grpc
 import (
 	"google.golang.org/protobuf/types/known/timestamppb"
 	"homework10/internal/ads"
+	"homework10/internal/conversations"
 	"homework10/internal/users"
 )
 
@@ -15,6 +16,7 @@ func AdSuccessResponse(ad *ads.Ad) *AdResponse {
 		Published: ad.Published,
 		CreatedAt: timestamppb.New(ad.CreatedAt),
 		UpdatedAt: timestamppb.New(ad.UpdatedAt),
+		Favorites: ad.Favorites,
 	}
 }
 
@@ -29,10 +31,124 @@ func AdsSuccessResponse(ads *[]ads.Ad) *ListAdResponse {
 	}
 }
 
+func BatchSuccessResponse(results *[]ads.Result) *BatchAdResponse {
+	response := &BatchAdResponse{Results: make([]*BatchAdResult, 0, len(*results))}
+	for _, result := range *results {
+		if result.Err != nil {
+			response.Results = append(response.Results, &BatchAdResult{Error: itemStatus(result.Err).Proto()})
+		} else {
+			response.Results = append(response.Results, &BatchAdResult{Ad: AdSuccessResponse(&result.Ad)})
+		}
+	}
+
+	return response
+}
+
+func RevisionsSuccessResponse(revisions *[]ads.Revision) *ListRevisionResponse {
+	var revisionsResponseData []*RevisionResponse
+	for _, revision := range *revisions {
+		revisionsResponseData = append(revisionsResponseData, &RevisionResponse{
+			Id:        revision.ID,
+			Title:     revision.Title,
+			Text:      revision.Text,
+			UpdatedAt: timestamppb.New(revision.UpdatedAt),
+		})
+	}
+
+	return &ListRevisionResponse{
+		List: revisionsResponseData,
+	}
+}
+
 func UserSuccessResponse(user *users.User) *UserResponse {
 	return &UserResponse{
-		Id:    user.ID,
-		Name:  user.Name,
-		Email: user.Email,
+		Id:           user.ID,
+		Name:         user.Name,
+		Email:        user.Email,
+		DisplayName:  user.DisplayName,
+		AvatarUrl:    user.AvatarURL,
+		Phone:        user.Phone,
+		City:         user.City,
+		Bio:          user.Bio,
+		RegisteredAt: timestamppb.New(user.RegisteredAt),
+	}
+}
+
+func NotificationsSuccessResponse(notifications *[]users.Notification) *ListNotificationResponse {
+	var notificationsResponseData []*NotificationResponse
+	for _, notification := range *notifications {
+		notificationsResponseData = append(notificationsResponseData, &NotificationResponse{
+			Id:        notification.ID,
+			AdId:      notification.AdID,
+			Text:      notification.Text,
+			CreatedAt: timestamppb.New(notification.CreatedAt),
+		})
+	}
+
+	return &ListNotificationResponse{
+		List: notificationsResponseData,
+	}
+}
+
+func SavedSearchSuccessResponse(search *users.SavedSearch) *SavedSearchResponse {
+	return &SavedSearchResponse{
+		Id:        search.ID,
+		Pattern:   search.Pattern,
+		AuthorId:  search.AuthorID,
+		CreatedAt: timestamppb.New(search.CreatedAt),
+	}
+}
+
+func SavedSearchesSuccessResponse(searches *[]users.SavedSearch) *ListSavedSearchResponse {
+	var searchesResponseData []*SavedSearchResponse
+	for _, search := range *searches {
+		searchesResponseData = append(searchesResponseData, SavedSearchSuccessResponse(&search))
+	}
+
+	return &ListSavedSearchResponse{
+		List: searchesResponseData,
+	}
+}
+
+func ConversationSuccessResponse(conversation *conversations.Conversation, userId int64) *ConversationResponse {
+	return &ConversationResponse{
+		Id:        conversation.ID,
+		AdId:      conversation.AdID,
+		BuyerId:   conversation.BuyerID,
+		SellerId:  conversation.SellerID,
+		Closed:    conversation.Closed,
+		Unread:    conversation.Unread(userId),
+		CreatedAt: timestamppb.New(conversation.CreatedAt),
+	}
+}
+
+func ConversationsSuccessResponse(conversations *[]conversations.Conversation, userId int64) *ListConversationResponse {
+	var conversationsResponseData []*ConversationResponse
+	for _, conversation := range *conversations {
+		conversationsResponseData = append(conversationsResponseData, ConversationSuccessResponse(&conversation, userId))
+	}
+
+	return &ListConversationResponse{
+		List: conversationsResponseData,
+	}
+}
+
+func MessageSuccessResponse(message *conversations.Message) *MessageResponse {
+	return &MessageResponse{
+		Id:        message.ID,
+		AuthorId:  message.AuthorID,
+		Text:      message.Text,
+		CreatedAt: timestamppb.New(message.CreatedAt),
+	}
+}
+
+func MessagesSuccessResponse(messages *[]conversations.Message) *ListMessageResponse {
+	var messagesResponseData []*MessageResponse
+	for _, message := range *messages {
+		messagesResponseData = append(messagesResponseData, MessageSuccessResponse(&message))
+	}
+
+	return &ListMessageResponse{
+		List: messagesResponseData,
 	}
 }
@@ -0,0 +1,89 @@
+package grpc
+
+import (
+	"context"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"homework10/internal/app"
+	"homework10/internal/ratelimit"
+	"homework10/internal/tlscert"
+	"net"
+	"strconv"
+)
+
+// ClientIPMetadata carries the address of the HTTP client of a call made by
+// the gateway, which is the peer of all of them. It is trusted from a
+// loopback peer only.
+const ClientIPMetadata = "x-client-ip"
+
+// RateLimitInterceptor limits calls per full method name, e.g.
+// AdService_CreateAd_FullMethodName, and per identity: the user of the
+// session, or else the one callIdentity tells.
+func RateLimitInterceptor(l *ratelimit.Limiter, a app.App) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		allowed, retryAfter := l.Allow(info.FullMethod, limitIdentity(ctx, a))
+		if !allowed {
+			st, err := status.New(codes.ResourceExhausted, ratelimit.LimitExceeded.Error()).
+				WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(retryAfter)})
+			if err != nil {
+				return nil, status.New(codes.ResourceExhausted, ratelimit.LimitExceeded.Error()).Err()
+			}
+
+			return nil, st.Err()
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func limitIdentity(ctx context.Context, a app.App) string {
+	// an invalid token is left to the handler, the call is limited by its
+	// caller
+	if token, ok := bearerToken(ctx); ok {
+		if user, err := a.Authenticate(ctx, token); err == nil {
+			return "user:" + strconv.FormatInt(user.ID, 10)
+		}
+	}
+
+	return callIdentity(ctx)
+}
+
+// callIdentity is the HTTP client of the gateway, the service authenticated
+// by its client certificate or the peer address.
+func callIdentity(ctx context.Context) string {
+	host := peerHost(ctx)
+
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		if values := metadata.ValueFromIncomingContext(ctx, ClientIPMetadata); len(values) > 0 && values[0] != "" {
+			return "ip:" + values[0]
+		}
+	}
+
+	if identity := tlscert.IdentityFromContext(ctx); identity != "" {
+		return "service:" + identity
+	}
+
+	if host != "" {
+		return "ip:" + host
+	}
+
+	return "ip:unknown"
+}
+
+func peerHost(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
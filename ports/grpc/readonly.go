@@ -0,0 +1,27 @@
+package grpc
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"strings"
+)
+
+// ReadOnlyInterceptor rejects the calls of AdService other than the given
+// full method names, e.g. AdService_GetAd_FullMethodName, on a read replica.
+// Other services, e.g. the health checks, are not affected.
+func ReadOnlyInterceptor(methods ...string) grpc.UnaryServerInterceptor {
+	allowed := make(map[string]bool, len(methods))
+	for _, m := range methods {
+		allowed[m] = true
+	}
+
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if strings.HasPrefix(info.FullMethod, "/"+AdService_ServiceDesc.ServiceName+"/") && !allowed[info.FullMethod] {
+			return nil, status.Errorf(codes.Unimplemented, "%s is served by the primary, this instance is a read replica", info.FullMethod)
+		}
+
+		return handler(ctx, req)
+	}
+}
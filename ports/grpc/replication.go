@@ -0,0 +1,216 @@
+package grpc
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"homework10/internal/replication"
+	"homework10/internal/tlscert"
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ReplicaNotReady = errors.New("the replica hasn't caught up with the primary")
+
+// NewReplicationService streams the changes of the sources by repository
+// name, with a heartbeat when there is none for a while, so that the
+// replicas can tell an idle primary from a lost one. Only the callers
+// authenticated by a client certificate with one of the replicas
+// identities, see PeerIdentityStreamInterceptor, may follow them.
+func NewReplicationService(sources map[string]replication.Source, heartbeat time.Duration, replicas []string) ReplicationServer {
+	return &ReplicationService{sources: sources, heartbeat: heartbeat, replicas: replicas}
+}
+
+type ReplicationService struct {
+	sources   map[string]replication.Source
+	heartbeat time.Duration
+	replicas  []string
+}
+
+func (s *ReplicationService) Follow(request *FollowRequest, stream Replication_FollowServer) error {
+	identity := tlscert.IdentityFromContext(stream.Context())
+	if identity == "" {
+		return status.Error(codes.Unauthenticated, "a replica must present a client certificate")
+	}
+	if !slices.Contains(s.replicas, identity) {
+		return status.Errorf(codes.PermissionDenied, "%q is not a replica", identity)
+	}
+
+	source, exists := s.sources[request.Repository]
+	if !exists {
+		return status.Errorf(codes.NotFound, "there is no repository %q", request.Repository)
+	}
+
+	epoch, seq := request.Epoch, request.Seq
+	heartbeat := time.NewTicker(s.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		changes, next, err := source.Since(epoch, seq)
+		if errors.Is(err, replication.Behind) {
+			epoch, _ = source.Position()
+			changes, err = source.Dump()
+		}
+		if err != nil {
+			return status.New(codes.Internal, err.Error()).Err()
+		}
+
+		_, primarySeq := source.Position()
+		for _, c := range changes {
+			if err := stream.Send(&ChangeEvent{Epoch: epoch, Seq: c.Seq, Data: c.Data, PrimarySeq: primarySeq, SentAt: timestamppb.Now()}); err != nil {
+				return err
+			}
+			seq = c.Seq
+		}
+
+		// a dump is followed by the changes made while it was sent
+		if next == nil {
+			continue
+		}
+
+		select {
+		case <-next:
+		case <-heartbeat.C:
+			_, primarySeq = source.Position()
+			if err := stream.Send(&ChangeEvent{Epoch: epoch, Seq: seq, PrimarySeq: primarySeq, SentAt: timestamppb.Now()}); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return status.FromContextError(stream.Context().Err()).Err()
+		}
+	}
+}
+
+// Lag is how far a repository of a replica is behind the primary: the
+// changes it hasn't applied yet and the time of the last event of the
+// primary, a change or a heartbeat.
+type Lag struct {
+	Changes   int64
+	LastEvent time.Time
+	CaughtUp  bool
+}
+
+// Replica keeps the sinks of a read replica up to date with the
+// repositories of the same name on the primary, following them again after
+// retry when a stream breaks.
+type Replica struct {
+	client ReplicationClient
+	sinks  map[string]replication.Sink
+	retry  time.Duration
+	logger *slog.Logger
+
+	lag map[string]Lag
+	mu  sync.Mutex
+}
+
+func NewReplica(conn grpc.ClientConnInterface, sinks map[string]replication.Sink, retry time.Duration, logger *slog.Logger) *Replica {
+	return &Replica{client: NewReplicationClient(conn), sinks: sinks, retry: retry, logger: logger, lag: make(map[string]Lag)}
+}
+
+// Run follows the primary until ctx is done.
+func (r *Replica) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for name, sink := range r.sinks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.follow(ctx, name, sink)
+		}()
+	}
+	wg.Wait()
+}
+
+func (r *Replica) follow(ctx context.Context, name string, sink replication.Sink) {
+	for {
+		err := r.stream(ctx, name, sink)
+		if ctx.Err() != nil {
+			return
+		}
+		r.logger.Warn("replication stream broken", "repository", name, "error", err.Error())
+
+		select {
+		case <-time.After(r.retry):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (r *Replica) stream(ctx context.Context, name string, sink replication.Sink) error {
+	epoch, seq := sink.Position()
+	stream, err := r.client.Follow(ctx, &FollowRequest{Repository: name, Epoch: epoch, Seq: seq})
+	if err != nil {
+		return err
+	}
+
+	for {
+		event, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+
+		if len(event.Data) > 0 {
+			if err := sink.Apply(event.Epoch, replication.Change{Seq: event.Seq, Data: event.Data}); err != nil {
+				return err
+			}
+		}
+
+		// the changes of a dump share its number, the replica has caught
+		// up with it only once the whole dump is applied
+		appliedEpoch, applied := sink.Position()
+		r.setLag(name, Lag{
+			Changes:   event.PrimarySeq - applied,
+			LastEvent: time.Now(),
+			CaughtUp:  appliedEpoch == event.Epoch && applied >= event.Seq,
+		})
+	}
+}
+
+func (r *Replica) setLag(name string, lag Lag) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.lag[name] = lag
+}
+
+// Lag returns the lag of every repository, those the primary hasn't
+// answered for yet are missing.
+func (r *Replica) Lag() map[string]Lag {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	lag := make(map[string]Lag, len(r.lag))
+	for name, l := range r.lag {
+		lag[name] = l
+	}
+	return lag
+}
+
+// ReadyCheck fails until every repository has caught up with a dump of the
+// primary, and when one hasn't heard from it for maxSilence.
+func (r *Replica) ReadyCheck(maxSilence time.Duration) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		lag := r.Lag()
+
+		var behind []string
+		for name := range r.sinks {
+			l, exists := lag[name]
+			if !exists || !l.CaughtUp || time.Since(l.LastEvent) > maxSilence {
+				behind = append(behind, name)
+			}
+		}
+		if len(behind) > 0 {
+			sort.Strings(behind)
+			return errors.Wrap(ReplicaNotReady, strings.Join(behind, ", "))
+		}
+
+		return nil
+	}
+}
@@ -2,28 +2,46 @@ package grpc
 
 import (
 	"context"
-	"fmt"
 	validator "github.com/Vdaleke/ad-validation"
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
 	"github.com/pkg/errors"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/emptypb"
+	"homework10/internal/ads"
 	"homework10/internal/app"
-	"log"
-	"os"
+	"homework10/internal/logger"
+	"homework10/internal/users"
+	"log/slog"
 	"time"
 )
 
-func InterceptorLogger() logging.Logger {
-	return logging.LoggerFunc(func(_ context.Context, _ logging.Level, msg string, fields ...any) {
-		l := log.New(os.Stderr, "", log.Ldate|log.Ltime|log.Lshortfile)
-
-		msg = fmt.Sprintf("INFO :%v", msg)
-		l.Println(append([]any{msg}, fields...))
+func InterceptorLogger(l *slog.Logger) logging.Logger {
+	return logging.LoggerFunc(func(ctx context.Context, lvl logging.Level, msg string, fields ...any) {
+		l.Log(ctx, slog.Level(lvl), msg, fields...)
 	})
 }
 
+// RequestIDInterceptor takes the request ID from the x-request-id metadata or
+// generates a new one, puts it into the context and sends it back in the
+// response header.
+func RequestIDInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	var requestID string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(logger.RequestIDKey); len(values) > 0 {
+			requestID = values[0]
+		}
+	}
+	requestID = logger.EnsureRequestID(requestID)
+
+	ctx = logger.WithRequestID(ctx, requestID)
+	_ = grpc.SetHeader(ctx, metadata.Pairs(logger.RequestIDKey, requestID))
+
+	return handler(ctx, req)
+}
+
 func PanicInterceptor(p any) (err error) {
 	return status.Errorf(codes.Unknown, "panic triggered: %v", p)
 }
@@ -37,7 +55,7 @@ type AdService struct {
 }
 
 func (a *AdService) CreateAd(ctx context.Context, request *CreateAdRequest) (*AdResponse, error) {
-	ad, err := a.adApp.CreateAd(request.Title, request.Text, request.UserId)
+	ad, err := a.adApp.CreateAd(ctx, request.Title, request.Text, request.UserId)
 
 	if errors.Is(err, validator.ValidationError) || errors.Is(err, app.DefunctUser) {
 		return &AdResponse{}, status.New(codes.InvalidArgument, "invalid information received").Err()
@@ -49,7 +67,7 @@ func (a *AdService) CreateAd(ctx context.Context, request *CreateAdRequest) (*Ad
 }
 
 func (a *AdService) ChangeAdStatus(ctx context.Context, request *ChangeAdStatusRequest) (*AdResponse, error) {
-	ad, err := a.adApp.ChangeAdStatus(request.AdId, request.UserId, request.Published)
+	ad, err := a.adApp.ChangeAdStatus(ctx, request.AdId, request.UserId, request.Published)
 
 	if errors.Is(err, app.PermissionDenied) {
 		return &AdResponse{}, status.New(codes.PermissionDenied, "the user does not have permission to edit the ad").Err()
@@ -64,7 +82,24 @@ func (a *AdService) ChangeAdStatus(ctx context.Context, request *ChangeAdStatusR
 }
 
 func (a *AdService) UpdateAd(ctx context.Context, request *UpdateAdRequest) (*AdResponse, error) {
-	ad, err := a.adApp.UpdateAd(request.AdId, request.UserId, request.Title, request.Text)
+	var ad ads.Ad
+	var err error
+	if len(request.GetUpdateMask().GetPaths()) == 0 {
+		ad, err = a.adApp.UpdateAd(ctx, request.AdId, request.UserId, request.Title, request.Text)
+	} else {
+		var patch ads.Patch
+		for _, path := range request.UpdateMask.Paths {
+			switch path {
+			case "title":
+				patch.Title = &request.Title
+			case "text":
+				patch.Text = &request.Text
+			default:
+				return &AdResponse{}, status.Errorf(codes.InvalidArgument, "field %q can't be updated", path)
+			}
+		}
+		ad, err = a.adApp.PatchAd(ctx, request.AdId, request.UserId, patch)
+	}
 
 	if errors.Is(err, app.PermissionDenied) {
 		return &AdResponse{}, status.New(codes.PermissionDenied, "the user does not have permission to edit the ad").Err()
@@ -79,7 +114,7 @@ func (a *AdService) UpdateAd(ctx context.Context, request *UpdateAdRequest) (*Ad
 }
 
 func (a *AdService) GetAd(ctx context.Context, request *GetAdRequest) (*AdResponse, error) {
-	ad, err := a.adApp.GetAd(request.Id)
+	ad, err := a.adApp.GetAd(ctx, request.Id)
 
 	if errors.Is(err, app.DefunctAd) {
 		return &AdResponse{}, status.New(codes.InvalidArgument, "invalid information received").Err()
@@ -91,7 +126,7 @@ func (a *AdService) GetAd(ctx context.Context, request *GetAdRequest) (*AdRespon
 }
 
 func (a *AdService) DeleteAd(ctx context.Context, request *DeleteAdRequest) (*emptypb.Empty, error) {
-	err := a.adApp.DeleteAd(request.AdId, request.AuthorId)
+	err := a.adApp.DeleteAd(ctx, request.AdId, request.AuthorId)
 
 	if errors.Is(err, app.PermissionDenied) {
 		return &emptypb.Empty{}, status.New(codes.PermissionDenied, "the user does not have permission to edit the ad").Err()
@@ -107,7 +142,7 @@ func (a *AdService) DeleteAd(ctx context.Context, request *DeleteAdRequest) (*em
 func (a *AdService) ListAds(ctx context.Context, request *ListAdsRequest) (*ListAdResponse, error) {
 	timeFilter, _ := time.Parse(time.RFC3339, request.CreationTime)
 
-	ads, err := a.adApp.ListAds(request.Published, request.UserId, timeFilter)
+	ads, err := a.adApp.ListAds(ctx, request.Published, request.UserId, timeFilter)
 
 	if err != nil {
 		return &ListAdResponse{}, status.New(codes.Unknown, "an unknown error has occurred").Err()
@@ -117,7 +152,7 @@ func (a *AdService) ListAds(ctx context.Context, request *ListAdsRequest) (*List
 }
 
 func (a *AdService) SearchAds(ctx context.Context, request *SearchAdsRequest) (*ListAdResponse, error) {
-	ads, err := a.adApp.SearchAds(request.Pattern)
+	ads, err := a.adApp.SearchAds(ctx, request.Pattern)
 
 	if err != nil {
 		return &ListAdResponse{}, status.New(codes.Unknown, "an unknown error has occurred").Err()
@@ -126,8 +161,35 @@ func (a *AdService) SearchAds(ctx context.Context, request *SearchAdsRequest) (*
 	return AdsSuccessResponse(&ads), nil
 }
 
+func (a *AdService) ListAdRevisions(ctx context.Context, request *ListAdRevisionsRequest) (*ListRevisionResponse, error) {
+	revisions, err := a.adApp.ListAdRevisions(ctx, request.AdId)
+
+	if errors.Is(err, app.DefunctAd) {
+		return &ListRevisionResponse{}, status.New(codes.InvalidArgument, "invalid information received").Err()
+	} else if err != nil {
+		return &ListRevisionResponse{}, status.New(codes.Unknown, "an unknown error has occurred").Err()
+	}
+
+	return RevisionsSuccessResponse(&revisions), nil
+}
+
+func (a *AdService) RollbackAd(ctx context.Context, request *RollbackAdRequest) (*AdResponse, error) {
+	ad, err := a.adApp.RollbackAd(ctx, request.AdId, request.UserId, request.RevisionId)
+
+	if errors.Is(err, app.PermissionDenied) {
+		return &AdResponse{}, status.New(codes.PermissionDenied, "the user does not have permission to edit the ad").Err()
+	} else if errors.Is(err, app.DefunctUser) || errors.Is(err, app.DefunctAd) ||
+		errors.Is(err, app.DefunctRevision) {
+		return &AdResponse{}, status.New(codes.InvalidArgument, "invalid information received").Err()
+	} else if err != nil {
+		return &AdResponse{}, status.New(codes.Unknown, "an unknown error has occurred").Err()
+	}
+
+	return AdSuccessResponse(&ad), nil
+}
+
 func (a *AdService) CreateUser(ctx context.Context, request *CreateUserRequest) (*UserResponse, error) {
-	user, err := a.adApp.CreateUser(request.Name, request.Email)
+	user, err := a.adApp.CreateUser(ctx, request.Name, request.Email)
 
 	if err != nil {
 		return &UserResponse{}, status.New(codes.Unknown, "an unknown error has occurred").Err()
@@ -137,19 +199,65 @@ func (a *AdService) CreateUser(ctx context.Context, request *CreateUserRequest)
 }
 
 func (a *AdService) UpdateUser(ctx context.Context, request *UpdateUserRequest) (*UserResponse, error) {
-	user, err := a.adApp.UpdateUser(request.Id, request.Name, request.Email)
+	viewerID, err := a.sessionUserID(ctx)
+	if err != nil {
+		return &UserResponse{}, accountError(err)
+	}
 
-	if errors.Is(err, app.DefunctUser) {
+	var user users.User
+	if len(request.GetUpdateMask().GetPaths()) == 0 {
+		user, err = a.adApp.UpdateUser(ctx, request.Id, viewerID, request.Name, request.Email)
+	} else {
+		var patch users.Patch
+		for _, path := range request.UpdateMask.Paths {
+			switch path {
+			case "name":
+				patch.Name = &request.Name
+			case "email":
+				patch.Email = &request.Email
+			case "display_name":
+				patch.DisplayName = &request.DisplayName
+			case "avatar_url":
+				patch.AvatarURL = &request.AvatarUrl
+			case "phone":
+				patch.Phone = &request.Phone
+			case "city":
+				patch.City = &request.City
+			case "bio":
+				patch.Bio = &request.Bio
+			default:
+				return &UserResponse{}, status.Errorf(codes.InvalidArgument, "field %q can't be updated", path)
+			}
+		}
+		user, err = a.adApp.PatchUser(ctx, request.Id, viewerID, patch)
+	}
+
+	if errors.Is(err, app.DefunctUser) || errors.Is(err, app.InvalidProfile) {
 		return &UserResponse{}, status.New(codes.InvalidArgument, "invalid information received").Err()
+	} else if errors.Is(err, app.InvalidEmail) || errors.Is(err, app.EmailTaken) {
+		return &UserResponse{}, status.Error(codes.InvalidArgument, err.Error())
+	} else if errors.Is(err, app.SessionRequired) {
+		return &UserResponse{}, status.Error(codes.PermissionDenied, err.Error())
 	} else if err != nil {
 		return &UserResponse{}, status.New(codes.Unknown, "an unknown error has occurred").Err()
 	}
 
+	// the contacts are shown to the owner only, as by GetUser
+	if viewerID != user.ID {
+		user = user.Public()
+	}
+
 	return UserSuccessResponse(&user), nil
 }
 
 func (a *AdService) GetUser(ctx context.Context, request *GetUserRequest) (*UserResponse, error) {
-	user, err := a.adApp.GetUser(request.Id)
+	// without a session the profile is the public one
+	viewerID, err := a.sessionUserID(ctx)
+	if err != nil {
+		return &UserResponse{}, accountError(err)
+	}
+
+	user, err := a.adApp.ViewUser(ctx, request.Id, viewerID)
 
 	if errors.Is(err, app.DefunctUser) {
 		return &UserResponse{}, status.New(codes.InvalidArgument, "invalid information received").Err()
@@ -161,9 +269,113 @@ func (a *AdService) GetUser(ctx context.Context, request *GetUserRequest) (*User
 }
 
 func (a *AdService) DeleteUser(ctx context.Context, request *DeleteUserRequest) (*emptypb.Empty, error) {
-	err := a.adApp.DeleteUser(request.Id)
+	viewerID, err := a.sessionUserID(ctx)
+	if err != nil {
+		return &emptypb.Empty{}, accountError(err)
+	}
+
+	err = a.adApp.DeleteUser(ctx, request.Id, viewerID)
+
+	if errors.Is(err, app.DefunctUser) {
+		return &emptypb.Empty{}, status.New(codes.InvalidArgument, "invalid information received").Err()
+	} else if errors.Is(err, app.SessionRequired) {
+		return &emptypb.Empty{}, status.Error(codes.PermissionDenied, err.Error())
+	} else if err != nil {
+		return &emptypb.Empty{}, status.New(codes.Unknown, "an unknown error has occurred").Err()
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (a *AdService) AddFavorite(ctx context.Context, request *AddFavoriteRequest) (*AdResponse, error) {
+	ad, err := a.adApp.AddFavorite(ctx, request.UserId, request.AdId, request.Notify)
+
+	if errors.Is(err, app.DefunctUser) || errors.Is(err, app.DefunctAd) {
+		return &AdResponse{}, status.New(codes.InvalidArgument, "invalid information received").Err()
+	} else if err != nil {
+		return &AdResponse{}, status.New(codes.Unknown, "an unknown error has occurred").Err()
+	}
+
+	return AdSuccessResponse(&ad), nil
+}
+
+func (a *AdService) RemoveFavorite(ctx context.Context, request *RemoveFavoriteRequest) (*AdResponse, error) {
+	ad, err := a.adApp.RemoveFavorite(ctx, request.UserId, request.AdId)
+
+	if errors.Is(err, app.DefunctUser) || errors.Is(err, app.DefunctAd) ||
+		errors.Is(err, app.DefunctFavorite) {
+		return &AdResponse{}, status.New(codes.InvalidArgument, "invalid information received").Err()
+	} else if err != nil {
+		return &AdResponse{}, status.New(codes.Unknown, "an unknown error has occurred").Err()
+	}
+
+	return AdSuccessResponse(&ad), nil
+}
+
+func (a *AdService) ListFavorites(ctx context.Context, request *ListFavoritesRequest) (*ListAdResponse, error) {
+	if err := a.sessionOf(ctx, request.UserId); err != nil {
+		return &ListAdResponse{}, err
+	}
+
+	ads, err := a.adApp.ListFavorites(ctx, request.UserId)
+
+	if errors.Is(err, app.DefunctUser) {
+		return &ListAdResponse{}, status.New(codes.InvalidArgument, "invalid information received").Err()
+	} else if err != nil {
+		return &ListAdResponse{}, status.New(codes.Unknown, "an unknown error has occurred").Err()
+	}
+
+	return AdsSuccessResponse(&ads), nil
+}
+
+func (a *AdService) ListNotifications(ctx context.Context, request *ListNotificationsRequest) (*ListNotificationResponse, error) {
+	if err := a.sessionOf(ctx, request.UserId); err != nil {
+		return &ListNotificationResponse{}, err
+	}
+
+	notifications, err := a.adApp.ListNotifications(ctx, request.UserId)
 
 	if errors.Is(err, app.DefunctUser) {
+		return &ListNotificationResponse{}, status.New(codes.InvalidArgument, "invalid information received").Err()
+	} else if err != nil {
+		return &ListNotificationResponse{}, status.New(codes.Unknown, "an unknown error has occurred").Err()
+	}
+
+	return NotificationsSuccessResponse(&notifications), nil
+}
+
+func (a *AdService) SaveSearch(ctx context.Context, request *SaveSearchRequest) (*SavedSearchResponse, error) {
+	search, err := a.adApp.SaveSearch(ctx, request.UserId, request.Pattern, request.AuthorId)
+
+	if errors.Is(err, app.DefunctUser) {
+		return &SavedSearchResponse{}, status.New(codes.InvalidArgument, "invalid information received").Err()
+	} else if err != nil {
+		return &SavedSearchResponse{}, status.New(codes.Unknown, "an unknown error has occurred").Err()
+	}
+
+	return SavedSearchSuccessResponse(&search), nil
+}
+
+func (a *AdService) ListSavedSearches(ctx context.Context, request *ListSavedSearchesRequest) (*ListSavedSearchResponse, error) {
+	if err := a.sessionOf(ctx, request.UserId); err != nil {
+		return &ListSavedSearchResponse{}, err
+	}
+
+	searches, err := a.adApp.ListSavedSearches(ctx, request.UserId)
+
+	if errors.Is(err, app.DefunctUser) {
+		return &ListSavedSearchResponse{}, status.New(codes.InvalidArgument, "invalid information received").Err()
+	} else if err != nil {
+		return &ListSavedSearchResponse{}, status.New(codes.Unknown, "an unknown error has occurred").Err()
+	}
+
+	return SavedSearchesSuccessResponse(&searches), nil
+}
+
+func (a *AdService) DeleteSavedSearch(ctx context.Context, request *DeleteSavedSearchRequest) (*emptypb.Empty, error) {
+	err := a.adApp.DeleteSavedSearch(ctx, request.UserId, request.SearchId)
+
+	if errors.Is(err, app.DefunctUser) || errors.Is(err, app.DefunctSavedSearch) {
 		return &emptypb.Empty{}, status.New(codes.InvalidArgument, "invalid information received").Err()
 	} else if err != nil {
 		return &emptypb.Empty{}, status.New(codes.Unknown, "an unknown error has occurred").Err()
@@ -171,3 +383,80 @@ func (a *AdService) DeleteUser(ctx context.Context, request *DeleteUserRequest)
 
 	return &emptypb.Empty{}, nil
 }
+
+func (a *AdService) OpenConversation(ctx context.Context, request *OpenConversationRequest) (*ConversationResponse, error) {
+	conversation, err := a.adApp.OpenConversation(ctx, request.AdId, request.UserId)
+
+	if errors.Is(err, app.DefunctUser) || errors.Is(err, app.DefunctAd) || errors.Is(err, app.OwnAd) {
+		return &ConversationResponse{}, status.New(codes.InvalidArgument, "invalid information received").Err()
+	} else if err != nil {
+		return &ConversationResponse{}, status.New(codes.Unknown, "an unknown error has occurred").Err()
+	}
+
+	return ConversationSuccessResponse(&conversation, request.UserId), nil
+}
+
+func (a *AdService) ListConversations(ctx context.Context, request *ListConversationsRequest) (*ListConversationResponse, error) {
+	if err := a.sessionOf(ctx, request.UserId); err != nil {
+		return &ListConversationResponse{}, err
+	}
+
+	conversations, err := a.adApp.ListConversations(ctx, request.UserId)
+
+	if errors.Is(err, app.DefunctUser) {
+		return &ListConversationResponse{}, status.New(codes.InvalidArgument, "invalid information received").Err()
+	} else if err != nil {
+		return &ListConversationResponse{}, status.New(codes.Unknown, "an unknown error has occurred").Err()
+	}
+
+	return ConversationsSuccessResponse(&conversations, request.UserId), nil
+}
+
+func (a *AdService) SendMessage(ctx context.Context, request *SendMessageRequest) (*MessageResponse, error) {
+	message, err := a.adApp.SendMessage(ctx, request.ConversationId, request.UserId, request.Text)
+
+	if errors.Is(err, app.PermissionDenied) {
+		return &MessageResponse{}, status.New(codes.PermissionDenied, "the user is not a participant of the conversation").Err()
+	} else if errors.Is(err, app.DefunctUser) || errors.Is(err, app.DefunctConversation) ||
+		errors.Is(err, app.ClosedConversation) || errors.Is(err, app.InvalidMessage) {
+		return &MessageResponse{}, status.New(codes.InvalidArgument, "invalid information received").Err()
+	} else if err != nil {
+		return &MessageResponse{}, status.New(codes.Unknown, "an unknown error has occurred").Err()
+	}
+
+	return MessageSuccessResponse(&message), nil
+}
+
+func (a *AdService) ListMessages(ctx context.Context, request *ListMessagesRequest) (*ListMessageResponse, error) {
+	// the messages are read by the participants only
+	viewerID, err := a.requireSession(ctx)
+	if err != nil {
+		return &ListMessageResponse{}, err
+	}
+
+	messages, err := a.adApp.ListMessages(ctx, request.ConversationId, viewerID)
+
+	if errors.Is(err, app.PermissionDenied) {
+		return &ListMessageResponse{}, status.New(codes.PermissionDenied, "the user is not a participant of the conversation").Err()
+	} else if errors.Is(err, app.DefunctUser) || errors.Is(err, app.DefunctConversation) {
+		return &ListMessageResponse{}, status.New(codes.InvalidArgument, "invalid information received").Err()
+	} else if err != nil {
+		return &ListMessageResponse{}, status.New(codes.Unknown, "an unknown error has occurred").Err()
+	}
+
+	return MessagesSuccessResponse(&messages), nil
+}
+
+func (a *AdService) CloseConversation(ctx context.Context, request *CloseConversationRequest) (*ConversationResponse, error) {
+	conversation, err := a.adApp.CloseConversation(ctx, request.ConversationId, request.UserId)
+
+	if errors.Is(err, app.PermissionDenied) {
+		return &ConversationResponse{}, status.New(codes.PermissionDenied, "only the author of the ad can close the conversation").Err()
+	} else if errors.Is(err, app.DefunctUser) || errors.Is(err, app.DefunctConversation) {
+		return &ConversationResponse{}, status.New(codes.InvalidArgument, "invalid information received").Err()
+	} else if err != nil {
+		return &ConversationResponse{}, status.New(codes.Unknown, "an unknown error has occurred").Err()
+	}
+
+	return ConversationSuccessResponse(&conversation, request.UserId), nil
+}
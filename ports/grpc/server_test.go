@@ -8,11 +8,14 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 	"google.golang.org/grpc/test/bufconn"
 	"homework10/internal/ads"
 	"homework10/internal/app"
 	"homework10/internal/mocks"
+	"homework10/internal/tlscert"
 	"homework10/internal/users"
 	"net"
 	"testing"
@@ -31,7 +34,7 @@ func BenchmarkAdService_CreateUser(b *testing.B) {
 	})
 
 	mockedApp := &mocks.App{}
-	mockedApp.On("CreateUser", mock.Anything, mock.Anything).
+	mockedApp.On("CreateUser", mock.Anything, mock.Anything, mock.Anything).
 		Return(users.User{}, nil)
 
 	svc := NewService(mockedApp)
@@ -77,7 +80,7 @@ func TestAdService_DefunctUser(t *testing.T) {
 
 	mockedApp := &mocks.App{}
 
-	mockedApp.On("CreateUser", mock.Anything).
+	mockedApp.On("CreateUser", mock.Anything, mock.Anything).
 		Return(users.User{}, nil)
 
 	svc := NewService(mockedApp)
@@ -105,19 +108,19 @@ func TestAdService_DefunctUser(t *testing.T) {
 
 	client := NewAdServiceClient(conn)
 
-	mockedApp.On("CreateAd", mock.Anything, mock.Anything, mock.Anything).
+	mockedApp.On("CreateAd", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 		Return(ads.Ad{}, app.DefunctUser)
-	mockedApp.On("UpdateAd", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+	mockedApp.On("UpdateAd", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 		Return(ads.Ad{}, app.DefunctUser)
-	mockedApp.On("ChangeAdStatus", mock.Anything, mock.Anything, mock.Anything).
+	mockedApp.On("ChangeAdStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 		Return(ads.Ad{}, app.DefunctUser)
-	mockedApp.On("DeleteAd", mock.Anything, mock.Anything).
+	mockedApp.On("DeleteAd", mock.Anything, mock.Anything, mock.Anything).
 		Return(app.DefunctUser)
-	mockedApp.On("UpdateUser", mock.Anything, mock.Anything, mock.Anything).
+	mockedApp.On("UpdateUser", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 		Return(users.User{}, app.DefunctUser)
-	mockedApp.On("GetUser", mock.Anything).
+	mockedApp.On("ViewUser", mock.Anything, mock.Anything, mock.Anything).
 		Return(users.User{}, app.DefunctUser)
-	mockedApp.On("DeleteUser", mock.Anything).
+	mockedApp.On("DeleteUser", mock.Anything, mock.Anything, mock.Anything).
 		Return(app.DefunctUser)
 
 	_, err = client.CreateAd(ctx, &CreateAdRequest{
@@ -184,7 +187,7 @@ func TestAdService_UnknownError(t *testing.T) {
 
 	mockedApp := &mocks.App{}
 
-	mockedApp.On("CreateUser", mock.Anything).
+	mockedApp.On("CreateUser", mock.Anything, mock.Anything).
 		Return(users.User{}, nil)
 
 	svc := NewService(mockedApp)
@@ -212,23 +215,23 @@ func TestAdService_UnknownError(t *testing.T) {
 
 	client := NewAdServiceClient(conn)
 
-	mockedApp.On("CreateAd", mock.Anything, mock.Anything, mock.Anything).
+	mockedApp.On("CreateAd", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 		Return(ads.Ad{}, errors.New("Unknown error"))
-	mockedApp.On("GetAd", mock.Anything).
+	mockedApp.On("GetAd", mock.Anything, mock.Anything).
 		Return(ads.Ad{}, errors.New("Unknown error"))
-	mockedApp.On("UpdateAd", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+	mockedApp.On("UpdateAd", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 		Return(ads.Ad{}, errors.New("Unknown error"))
-	mockedApp.On("ChangeAdStatus", mock.Anything, mock.Anything, mock.Anything).
+	mockedApp.On("ChangeAdStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 		Return(ads.Ad{}, errors.New("Unknown error"))
-	mockedApp.On("DeleteAd", mock.Anything, mock.Anything).
+	mockedApp.On("DeleteAd", mock.Anything, mock.Anything, mock.Anything).
 		Return(errors.New("Unknown error"))
-	mockedApp.On("CreateUser", mock.Anything, mock.Anything).
+	mockedApp.On("CreateUser", mock.Anything, mock.Anything, mock.Anything).
 		Return(users.User{}, errors.New("Unknown error"))
-	mockedApp.On("UpdateUser", mock.Anything, mock.Anything, mock.Anything).
+	mockedApp.On("UpdateUser", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 		Return(users.User{}, errors.New("Unknown error"))
-	mockedApp.On("GetUser", mock.Anything).
+	mockedApp.On("ViewUser", mock.Anything, mock.Anything, mock.Anything).
 		Return(users.User{}, errors.New("Unknown error"))
-	mockedApp.On("DeleteUser", mock.Anything).
+	mockedApp.On("DeleteUser", mock.Anything, mock.Anything, mock.Anything).
 		Return(errors.New("Unknown error"))
 
 	_, err = client.CreateAd(ctx, &CreateAdRequest{
@@ -290,3 +293,30 @@ func TestAdService_UnknownError(t *testing.T) {
 
 	assert.ErrorIs(t, err, status.New(codes.Unknown, "an unknown error has occurred").Err())
 }
+
+func TestCallIdentity(t *testing.T) {
+	withPeer := func(ip string, md metadata.MD) context.Context {
+		ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 4000}})
+		return metadata.NewIncomingContext(ctx, md)
+	}
+
+	type Test struct {
+		Name   string
+		Ctx    context.Context
+		Expect string
+	}
+
+	tests := [...]Test{
+		{"Peer address", withPeer("203.0.113.7", nil), "ip:203.0.113.7"},
+		{"Client IP from the gateway", withPeer("127.0.0.1", metadata.Pairs(ClientIPMetadata, "203.0.113.8")), "ip:203.0.113.8"},
+		{"Client IP from another peer", withPeer("203.0.113.7", metadata.Pairs(ClientIPMetadata, "203.0.113.8")), "ip:203.0.113.7"},
+		{"Service", tlscert.WithIdentity(withPeer("203.0.113.7", nil), "spiffe://ads.local/billing"), "service:spiffe://ads.local/billing"},
+		{"No peer", context.Background(), "ip:unknown"},
+	}
+
+	for _, test := range tests {
+		if got := callIdentity(test.Ctx); got != test.Expect {
+			t.Fatalf(`test %q: expect %v got %v`, test.Name, test.Expect, got)
+		}
+	}
+}
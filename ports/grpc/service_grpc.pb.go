@@ -20,17 +20,40 @@ import (
 const _ = grpc.SupportPackageIsVersion7
 
 const (
-	AdService_CreateAd_FullMethodName       = "/ad.AdService/CreateAd"
-	AdService_ChangeAdStatus_FullMethodName = "/ad.AdService/ChangeAdStatus"
-	AdService_UpdateAd_FullMethodName       = "/ad.AdService/UpdateAd"
-	AdService_GetAd_FullMethodName          = "/ad.AdService/GetAd"
-	AdService_DeleteAd_FullMethodName       = "/ad.AdService/DeleteAd"
-	AdService_ListAds_FullMethodName        = "/ad.AdService/ListAds"
-	AdService_SearchAds_FullMethodName      = "/ad.AdService/SearchAds"
-	AdService_CreateUser_FullMethodName     = "/ad.AdService/CreateUser"
-	AdService_UpdateUser_FullMethodName     = "/ad.AdService/UpdateUser"
-	AdService_GetUser_FullMethodName        = "/ad.AdService/GetUser"
-	AdService_DeleteUser_FullMethodName     = "/ad.AdService/DeleteUser"
+	AdService_CreateAd_FullMethodName             = "/ad.AdService/CreateAd"
+	AdService_ChangeAdStatus_FullMethodName       = "/ad.AdService/ChangeAdStatus"
+	AdService_UpdateAd_FullMethodName             = "/ad.AdService/UpdateAd"
+	AdService_GetAd_FullMethodName                = "/ad.AdService/GetAd"
+	AdService_DeleteAd_FullMethodName             = "/ad.AdService/DeleteAd"
+	AdService_ListAds_FullMethodName              = "/ad.AdService/ListAds"
+	AdService_SearchAds_FullMethodName            = "/ad.AdService/SearchAds"
+	AdService_ListAdRevisions_FullMethodName      = "/ad.AdService/ListAdRevisions"
+	AdService_RollbackAd_FullMethodName           = "/ad.AdService/RollbackAd"
+	AdService_BatchCreateAds_FullMethodName       = "/ad.AdService/BatchCreateAds"
+	AdService_BatchGetAds_FullMethodName          = "/ad.AdService/BatchGetAds"
+	AdService_BatchChangeAdStatus_FullMethodName  = "/ad.AdService/BatchChangeAdStatus"
+	AdService_CreateUser_FullMethodName           = "/ad.AdService/CreateUser"
+	AdService_UpdateUser_FullMethodName           = "/ad.AdService/UpdateUser"
+	AdService_GetUser_FullMethodName              = "/ad.AdService/GetUser"
+	AdService_DeleteUser_FullMethodName           = "/ad.AdService/DeleteUser"
+	AdService_Register_FullMethodName             = "/ad.AdService/Register"
+	AdService_Login_FullMethodName                = "/ad.AdService/Login"
+	AdService_Logout_FullMethodName               = "/ad.AdService/Logout"
+	AdService_ChangePassword_FullMethodName       = "/ad.AdService/ChangePassword"
+	AdService_RequestPasswordReset_FullMethodName = "/ad.AdService/RequestPasswordReset"
+	AdService_ResetPassword_FullMethodName        = "/ad.AdService/ResetPassword"
+	AdService_AddFavorite_FullMethodName          = "/ad.AdService/AddFavorite"
+	AdService_RemoveFavorite_FullMethodName       = "/ad.AdService/RemoveFavorite"
+	AdService_ListFavorites_FullMethodName        = "/ad.AdService/ListFavorites"
+	AdService_ListNotifications_FullMethodName    = "/ad.AdService/ListNotifications"
+	AdService_SaveSearch_FullMethodName           = "/ad.AdService/SaveSearch"
+	AdService_ListSavedSearches_FullMethodName    = "/ad.AdService/ListSavedSearches"
+	AdService_DeleteSavedSearch_FullMethodName    = "/ad.AdService/DeleteSavedSearch"
+	AdService_OpenConversation_FullMethodName     = "/ad.AdService/OpenConversation"
+	AdService_ListConversations_FullMethodName    = "/ad.AdService/ListConversations"
+	AdService_SendMessage_FullMethodName          = "/ad.AdService/SendMessage"
+	AdService_ListMessages_FullMethodName         = "/ad.AdService/ListMessages"
+	AdService_CloseConversation_FullMethodName    = "/ad.AdService/CloseConversation"
 )
 
 // AdServiceClient is the client API for AdService service.
@@ -44,10 +67,37 @@ type AdServiceClient interface {
 	DeleteAd(ctx context.Context, in *DeleteAdRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	ListAds(ctx context.Context, in *ListAdsRequest, opts ...grpc.CallOption) (*ListAdResponse, error)
 	SearchAds(ctx context.Context, in *SearchAdsRequest, opts ...grpc.CallOption) (*ListAdResponse, error)
+	ListAdRevisions(ctx context.Context, in *ListAdRevisionsRequest, opts ...grpc.CallOption) (*ListRevisionResponse, error)
+	RollbackAd(ctx context.Context, in *RollbackAdRequest, opts ...grpc.CallOption) (*AdResponse, error)
+	// The batch calls have no HTTP binding, POST /api/v1/ads:batch of the gin
+	// router combines them.
+	BatchCreateAds(ctx context.Context, in *BatchCreateAdsRequest, opts ...grpc.CallOption) (*BatchAdResponse, error)
+	BatchGetAds(ctx context.Context, in *BatchGetAdsRequest, opts ...grpc.CallOption) (*BatchAdResponse, error)
+	BatchChangeAdStatus(ctx context.Context, in *BatchChangeAdStatusRequest, opts ...grpc.CallOption) (*BatchAdResponse, error)
 	CreateUser(ctx context.Context, in *CreateUserRequest, opts ...grpc.CallOption) (*UserResponse, error)
 	UpdateUser(ctx context.Context, in *UpdateUserRequest, opts ...grpc.CallOption) (*UserResponse, error)
 	GetUser(ctx context.Context, in *GetUserRequest, opts ...grpc.CallOption) (*UserResponse, error)
 	DeleteUser(ctx context.Context, in *DeleteUserRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// The session token is sent as "Bearer <token>" in the authorization
+	// metadata, e.g. to Logout, and to GetUser to view the whole profile.
+	Register(ctx context.Context, in *RegisterRequest, opts ...grpc.CallOption) (*UserResponse, error)
+	Login(ctx context.Context, in *LoginRequest, opts ...grpc.CallOption) (*SessionResponse, error)
+	Logout(ctx context.Context, in *LogoutRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	ChangePassword(ctx context.Context, in *ChangePasswordRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	RequestPasswordReset(ctx context.Context, in *RequestPasswordResetRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	ResetPassword(ctx context.Context, in *ResetPasswordRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	AddFavorite(ctx context.Context, in *AddFavoriteRequest, opts ...grpc.CallOption) (*AdResponse, error)
+	RemoveFavorite(ctx context.Context, in *RemoveFavoriteRequest, opts ...grpc.CallOption) (*AdResponse, error)
+	ListFavorites(ctx context.Context, in *ListFavoritesRequest, opts ...grpc.CallOption) (*ListAdResponse, error)
+	ListNotifications(ctx context.Context, in *ListNotificationsRequest, opts ...grpc.CallOption) (*ListNotificationResponse, error)
+	SaveSearch(ctx context.Context, in *SaveSearchRequest, opts ...grpc.CallOption) (*SavedSearchResponse, error)
+	ListSavedSearches(ctx context.Context, in *ListSavedSearchesRequest, opts ...grpc.CallOption) (*ListSavedSearchResponse, error)
+	DeleteSavedSearch(ctx context.Context, in *DeleteSavedSearchRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	OpenConversation(ctx context.Context, in *OpenConversationRequest, opts ...grpc.CallOption) (*ConversationResponse, error)
+	ListConversations(ctx context.Context, in *ListConversationsRequest, opts ...grpc.CallOption) (*ListConversationResponse, error)
+	SendMessage(ctx context.Context, in *SendMessageRequest, opts ...grpc.CallOption) (*MessageResponse, error)
+	ListMessages(ctx context.Context, in *ListMessagesRequest, opts ...grpc.CallOption) (*ListMessageResponse, error)
+	CloseConversation(ctx context.Context, in *CloseConversationRequest, opts ...grpc.CallOption) (*ConversationResponse, error)
 }
 
 type adServiceClient struct {
@@ -121,6 +171,51 @@ func (c *adServiceClient) SearchAds(ctx context.Context, in *SearchAdsRequest, o
 	return out, nil
 }
 
+func (c *adServiceClient) ListAdRevisions(ctx context.Context, in *ListAdRevisionsRequest, opts ...grpc.CallOption) (*ListRevisionResponse, error) {
+	out := new(ListRevisionResponse)
+	err := c.cc.Invoke(ctx, AdService_ListAdRevisions_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) RollbackAd(ctx context.Context, in *RollbackAdRequest, opts ...grpc.CallOption) (*AdResponse, error) {
+	out := new(AdResponse)
+	err := c.cc.Invoke(ctx, AdService_RollbackAd_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) BatchCreateAds(ctx context.Context, in *BatchCreateAdsRequest, opts ...grpc.CallOption) (*BatchAdResponse, error) {
+	out := new(BatchAdResponse)
+	err := c.cc.Invoke(ctx, AdService_BatchCreateAds_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) BatchGetAds(ctx context.Context, in *BatchGetAdsRequest, opts ...grpc.CallOption) (*BatchAdResponse, error) {
+	out := new(BatchAdResponse)
+	err := c.cc.Invoke(ctx, AdService_BatchGetAds_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) BatchChangeAdStatus(ctx context.Context, in *BatchChangeAdStatusRequest, opts ...grpc.CallOption) (*BatchAdResponse, error) {
+	out := new(BatchAdResponse)
+	err := c.cc.Invoke(ctx, AdService_BatchChangeAdStatus_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *adServiceClient) CreateUser(ctx context.Context, in *CreateUserRequest, opts ...grpc.CallOption) (*UserResponse, error) {
 	out := new(UserResponse)
 	err := c.cc.Invoke(ctx, AdService_CreateUser_FullMethodName, in, out, opts...)
@@ -157,6 +252,168 @@ func (c *adServiceClient) DeleteUser(ctx context.Context, in *DeleteUserRequest,
 	return out, nil
 }
 
+func (c *adServiceClient) Register(ctx context.Context, in *RegisterRequest, opts ...grpc.CallOption) (*UserResponse, error) {
+	out := new(UserResponse)
+	err := c.cc.Invoke(ctx, AdService_Register_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) Login(ctx context.Context, in *LoginRequest, opts ...grpc.CallOption) (*SessionResponse, error) {
+	out := new(SessionResponse)
+	err := c.cc.Invoke(ctx, AdService_Login_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) Logout(ctx context.Context, in *LogoutRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, AdService_Logout_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) ChangePassword(ctx context.Context, in *ChangePasswordRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, AdService_ChangePassword_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) RequestPasswordReset(ctx context.Context, in *RequestPasswordResetRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, AdService_RequestPasswordReset_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) ResetPassword(ctx context.Context, in *ResetPasswordRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, AdService_ResetPassword_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) AddFavorite(ctx context.Context, in *AddFavoriteRequest, opts ...grpc.CallOption) (*AdResponse, error) {
+	out := new(AdResponse)
+	err := c.cc.Invoke(ctx, AdService_AddFavorite_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) RemoveFavorite(ctx context.Context, in *RemoveFavoriteRequest, opts ...grpc.CallOption) (*AdResponse, error) {
+	out := new(AdResponse)
+	err := c.cc.Invoke(ctx, AdService_RemoveFavorite_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) ListFavorites(ctx context.Context, in *ListFavoritesRequest, opts ...grpc.CallOption) (*ListAdResponse, error) {
+	out := new(ListAdResponse)
+	err := c.cc.Invoke(ctx, AdService_ListFavorites_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) ListNotifications(ctx context.Context, in *ListNotificationsRequest, opts ...grpc.CallOption) (*ListNotificationResponse, error) {
+	out := new(ListNotificationResponse)
+	err := c.cc.Invoke(ctx, AdService_ListNotifications_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) SaveSearch(ctx context.Context, in *SaveSearchRequest, opts ...grpc.CallOption) (*SavedSearchResponse, error) {
+	out := new(SavedSearchResponse)
+	err := c.cc.Invoke(ctx, AdService_SaveSearch_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) ListSavedSearches(ctx context.Context, in *ListSavedSearchesRequest, opts ...grpc.CallOption) (*ListSavedSearchResponse, error) {
+	out := new(ListSavedSearchResponse)
+	err := c.cc.Invoke(ctx, AdService_ListSavedSearches_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) DeleteSavedSearch(ctx context.Context, in *DeleteSavedSearchRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, AdService_DeleteSavedSearch_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) OpenConversation(ctx context.Context, in *OpenConversationRequest, opts ...grpc.CallOption) (*ConversationResponse, error) {
+	out := new(ConversationResponse)
+	err := c.cc.Invoke(ctx, AdService_OpenConversation_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) ListConversations(ctx context.Context, in *ListConversationsRequest, opts ...grpc.CallOption) (*ListConversationResponse, error) {
+	out := new(ListConversationResponse)
+	err := c.cc.Invoke(ctx, AdService_ListConversations_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) SendMessage(ctx context.Context, in *SendMessageRequest, opts ...grpc.CallOption) (*MessageResponse, error) {
+	out := new(MessageResponse)
+	err := c.cc.Invoke(ctx, AdService_SendMessage_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) ListMessages(ctx context.Context, in *ListMessagesRequest, opts ...grpc.CallOption) (*ListMessageResponse, error) {
+	out := new(ListMessageResponse)
+	err := c.cc.Invoke(ctx, AdService_ListMessages_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adServiceClient) CloseConversation(ctx context.Context, in *CloseConversationRequest, opts ...grpc.CallOption) (*ConversationResponse, error) {
+	out := new(ConversationResponse)
+	err := c.cc.Invoke(ctx, AdService_CloseConversation_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // AdServiceServer is the server API for AdService service.
 // All implementations should embed UnimplementedAdServiceServer
 // for forward compatibility
@@ -168,10 +425,37 @@ type AdServiceServer interface {
 	DeleteAd(context.Context, *DeleteAdRequest) (*emptypb.Empty, error)
 	ListAds(context.Context, *ListAdsRequest) (*ListAdResponse, error)
 	SearchAds(context.Context, *SearchAdsRequest) (*ListAdResponse, error)
+	ListAdRevisions(context.Context, *ListAdRevisionsRequest) (*ListRevisionResponse, error)
+	RollbackAd(context.Context, *RollbackAdRequest) (*AdResponse, error)
+	// The batch calls have no HTTP binding, POST /api/v1/ads:batch of the gin
+	// router combines them.
+	BatchCreateAds(context.Context, *BatchCreateAdsRequest) (*BatchAdResponse, error)
+	BatchGetAds(context.Context, *BatchGetAdsRequest) (*BatchAdResponse, error)
+	BatchChangeAdStatus(context.Context, *BatchChangeAdStatusRequest) (*BatchAdResponse, error)
 	CreateUser(context.Context, *CreateUserRequest) (*UserResponse, error)
 	UpdateUser(context.Context, *UpdateUserRequest) (*UserResponse, error)
 	GetUser(context.Context, *GetUserRequest) (*UserResponse, error)
 	DeleteUser(context.Context, *DeleteUserRequest) (*emptypb.Empty, error)
+	// The session token is sent as "Bearer <token>" in the authorization
+	// metadata, e.g. to Logout, and to GetUser to view the whole profile.
+	Register(context.Context, *RegisterRequest) (*UserResponse, error)
+	Login(context.Context, *LoginRequest) (*SessionResponse, error)
+	Logout(context.Context, *LogoutRequest) (*emptypb.Empty, error)
+	ChangePassword(context.Context, *ChangePasswordRequest) (*emptypb.Empty, error)
+	RequestPasswordReset(context.Context, *RequestPasswordResetRequest) (*emptypb.Empty, error)
+	ResetPassword(context.Context, *ResetPasswordRequest) (*emptypb.Empty, error)
+	AddFavorite(context.Context, *AddFavoriteRequest) (*AdResponse, error)
+	RemoveFavorite(context.Context, *RemoveFavoriteRequest) (*AdResponse, error)
+	ListFavorites(context.Context, *ListFavoritesRequest) (*ListAdResponse, error)
+	ListNotifications(context.Context, *ListNotificationsRequest) (*ListNotificationResponse, error)
+	SaveSearch(context.Context, *SaveSearchRequest) (*SavedSearchResponse, error)
+	ListSavedSearches(context.Context, *ListSavedSearchesRequest) (*ListSavedSearchResponse, error)
+	DeleteSavedSearch(context.Context, *DeleteSavedSearchRequest) (*emptypb.Empty, error)
+	OpenConversation(context.Context, *OpenConversationRequest) (*ConversationResponse, error)
+	ListConversations(context.Context, *ListConversationsRequest) (*ListConversationResponse, error)
+	SendMessage(context.Context, *SendMessageRequest) (*MessageResponse, error)
+	ListMessages(context.Context, *ListMessagesRequest) (*ListMessageResponse, error)
+	CloseConversation(context.Context, *CloseConversationRequest) (*ConversationResponse, error)
 }
 
 // UnimplementedAdServiceServer should be embedded to have forward compatible implementations.
@@ -199,6 +483,21 @@ func (UnimplementedAdServiceServer) ListAds(context.Context, *ListAdsRequest) (*
 func (UnimplementedAdServiceServer) SearchAds(context.Context, *SearchAdsRequest) (*ListAdResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SearchAds not implemented")
 }
+func (UnimplementedAdServiceServer) ListAdRevisions(context.Context, *ListAdRevisionsRequest) (*ListRevisionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAdRevisions not implemented")
+}
+func (UnimplementedAdServiceServer) RollbackAd(context.Context, *RollbackAdRequest) (*AdResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RollbackAd not implemented")
+}
+func (UnimplementedAdServiceServer) BatchCreateAds(context.Context, *BatchCreateAdsRequest) (*BatchAdResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchCreateAds not implemented")
+}
+func (UnimplementedAdServiceServer) BatchGetAds(context.Context, *BatchGetAdsRequest) (*BatchAdResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchGetAds not implemented")
+}
+func (UnimplementedAdServiceServer) BatchChangeAdStatus(context.Context, *BatchChangeAdStatusRequest) (*BatchAdResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchChangeAdStatus not implemented")
+}
 func (UnimplementedAdServiceServer) CreateUser(context.Context, *CreateUserRequest) (*UserResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CreateUser not implemented")
 }
@@ -211,6 +510,60 @@ func (UnimplementedAdServiceServer) GetUser(context.Context, *GetUserRequest) (*
 func (UnimplementedAdServiceServer) DeleteUser(context.Context, *DeleteUserRequest) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteUser not implemented")
 }
+func (UnimplementedAdServiceServer) Register(context.Context, *RegisterRequest) (*UserResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Register not implemented")
+}
+func (UnimplementedAdServiceServer) Login(context.Context, *LoginRequest) (*SessionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Login not implemented")
+}
+func (UnimplementedAdServiceServer) Logout(context.Context, *LogoutRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Logout not implemented")
+}
+func (UnimplementedAdServiceServer) ChangePassword(context.Context, *ChangePasswordRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ChangePassword not implemented")
+}
+func (UnimplementedAdServiceServer) RequestPasswordReset(context.Context, *RequestPasswordResetRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RequestPasswordReset not implemented")
+}
+func (UnimplementedAdServiceServer) ResetPassword(context.Context, *ResetPasswordRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResetPassword not implemented")
+}
+func (UnimplementedAdServiceServer) AddFavorite(context.Context, *AddFavoriteRequest) (*AdResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddFavorite not implemented")
+}
+func (UnimplementedAdServiceServer) RemoveFavorite(context.Context, *RemoveFavoriteRequest) (*AdResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveFavorite not implemented")
+}
+func (UnimplementedAdServiceServer) ListFavorites(context.Context, *ListFavoritesRequest) (*ListAdResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListFavorites not implemented")
+}
+func (UnimplementedAdServiceServer) ListNotifications(context.Context, *ListNotificationsRequest) (*ListNotificationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListNotifications not implemented")
+}
+func (UnimplementedAdServiceServer) SaveSearch(context.Context, *SaveSearchRequest) (*SavedSearchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SaveSearch not implemented")
+}
+func (UnimplementedAdServiceServer) ListSavedSearches(context.Context, *ListSavedSearchesRequest) (*ListSavedSearchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSavedSearches not implemented")
+}
+func (UnimplementedAdServiceServer) DeleteSavedSearch(context.Context, *DeleteSavedSearchRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteSavedSearch not implemented")
+}
+func (UnimplementedAdServiceServer) OpenConversation(context.Context, *OpenConversationRequest) (*ConversationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method OpenConversation not implemented")
+}
+func (UnimplementedAdServiceServer) ListConversations(context.Context, *ListConversationsRequest) (*ListConversationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListConversations not implemented")
+}
+func (UnimplementedAdServiceServer) SendMessage(context.Context, *SendMessageRequest) (*MessageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SendMessage not implemented")
+}
+func (UnimplementedAdServiceServer) ListMessages(context.Context, *ListMessagesRequest) (*ListMessageResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListMessages not implemented")
+}
+func (UnimplementedAdServiceServer) CloseConversation(context.Context, *CloseConversationRequest) (*ConversationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CloseConversation not implemented")
+}
 
 // UnsafeAdServiceServer may be embedded to opt out of forward compatibility for this service.
 // Use of this interface is not recommended, as added methods to AdServiceServer will
@@ -349,6 +702,96 @@ func _AdService_SearchAds_Handler(srv interface{}, ctx context.Context, dec func
 	return interceptor(ctx, in, info, handler)
 }
 
+func _AdService_ListAdRevisions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAdRevisionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).ListAdRevisions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_ListAdRevisions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).ListAdRevisions(ctx, req.(*ListAdRevisionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_RollbackAd_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RollbackAdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).RollbackAd(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_RollbackAd_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).RollbackAd(ctx, req.(*RollbackAdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_BatchCreateAds_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchCreateAdsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).BatchCreateAds(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_BatchCreateAds_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).BatchCreateAds(ctx, req.(*BatchCreateAdsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_BatchGetAds_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchGetAdsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).BatchGetAds(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_BatchGetAds_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).BatchGetAds(ctx, req.(*BatchGetAdsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_BatchChangeAdStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchChangeAdStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).BatchChangeAdStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_BatchChangeAdStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).BatchChangeAdStatus(ctx, req.(*BatchChangeAdStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _AdService_CreateUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(CreateUserRequest)
 	if err := dec(in); err != nil {
@@ -421,26 +864,350 @@ func _AdService_DeleteUser_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
-// AdService_ServiceDesc is the grpc.ServiceDesc for AdService service.
-// It's only intended for direct use with grpc.RegisterService,
-// and not to be introspected or modified (even as a copy)
-var AdService_ServiceDesc = grpc.ServiceDesc{
-	ServiceName: "ad.AdService",
-	HandlerType: (*AdServiceServer)(nil),
-	Methods: []grpc.MethodDesc{
-		{
-			MethodName: "CreateAd",
-			Handler:    _AdService_CreateAd_Handler,
-		},
-		{
-			MethodName: "ChangeAdStatus",
-			Handler:    _AdService_ChangeAdStatus_Handler,
-		},
-		{
-			MethodName: "UpdateAd",
-			Handler:    _AdService_UpdateAd_Handler,
-		},
-		{
+func _AdService_Register_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegisterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).Register(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_Register_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).Register(ctx, req.(*RegisterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_Login_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LoginRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).Login(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_Login_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).Login(ctx, req.(*LoginRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_Logout_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LogoutRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).Logout(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_Logout_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).Logout(ctx, req.(*LogoutRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_ChangePassword_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChangePasswordRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).ChangePassword(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_ChangePassword_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).ChangePassword(ctx, req.(*ChangePasswordRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_RequestPasswordReset_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RequestPasswordResetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).RequestPasswordReset(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_RequestPasswordReset_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).RequestPasswordReset(ctx, req.(*RequestPasswordResetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_ResetPassword_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResetPasswordRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).ResetPassword(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_ResetPassword_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).ResetPassword(ctx, req.(*ResetPasswordRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_AddFavorite_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddFavoriteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).AddFavorite(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_AddFavorite_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).AddFavorite(ctx, req.(*AddFavoriteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_RemoveFavorite_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveFavoriteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).RemoveFavorite(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_RemoveFavorite_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).RemoveFavorite(ctx, req.(*RemoveFavoriteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_ListFavorites_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListFavoritesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).ListFavorites(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_ListFavorites_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).ListFavorites(ctx, req.(*ListFavoritesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_ListNotifications_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListNotificationsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).ListNotifications(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_ListNotifications_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).ListNotifications(ctx, req.(*ListNotificationsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_SaveSearch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SaveSearchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).SaveSearch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_SaveSearch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).SaveSearch(ctx, req.(*SaveSearchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_ListSavedSearches_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSavedSearchesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).ListSavedSearches(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_ListSavedSearches_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).ListSavedSearches(ctx, req.(*ListSavedSearchesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_DeleteSavedSearch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteSavedSearchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).DeleteSavedSearch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_DeleteSavedSearch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).DeleteSavedSearch(ctx, req.(*DeleteSavedSearchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_OpenConversation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OpenConversationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).OpenConversation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_OpenConversation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).OpenConversation(ctx, req.(*OpenConversationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_ListConversations_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListConversationsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).ListConversations(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_ListConversations_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).ListConversations(ctx, req.(*ListConversationsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_SendMessage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SendMessageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).SendMessage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_SendMessage_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).SendMessage(ctx, req.(*SendMessageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_ListMessages_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListMessagesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).ListMessages(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_ListMessages_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).ListMessages(ctx, req.(*ListMessagesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdService_CloseConversation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CloseConversationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdServiceServer).CloseConversation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdService_CloseConversation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdServiceServer).CloseConversation(ctx, req.(*CloseConversationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// AdService_ServiceDesc is the grpc.ServiceDesc for AdService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var AdService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "ad.AdService",
+	HandlerType: (*AdServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateAd",
+			Handler:    _AdService_CreateAd_Handler,
+		},
+		{
+			MethodName: "ChangeAdStatus",
+			Handler:    _AdService_ChangeAdStatus_Handler,
+		},
+		{
+			MethodName: "UpdateAd",
+			Handler:    _AdService_UpdateAd_Handler,
+		},
+		{
 			MethodName: "GetAd",
 			Handler:    _AdService_GetAd_Handler,
 		},
@@ -456,6 +1223,26 @@ var AdService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "SearchAds",
 			Handler:    _AdService_SearchAds_Handler,
 		},
+		{
+			MethodName: "ListAdRevisions",
+			Handler:    _AdService_ListAdRevisions_Handler,
+		},
+		{
+			MethodName: "RollbackAd",
+			Handler:    _AdService_RollbackAd_Handler,
+		},
+		{
+			MethodName: "BatchCreateAds",
+			Handler:    _AdService_BatchCreateAds_Handler,
+		},
+		{
+			MethodName: "BatchGetAds",
+			Handler:    _AdService_BatchGetAds_Handler,
+		},
+		{
+			MethodName: "BatchChangeAdStatus",
+			Handler:    _AdService_BatchChangeAdStatus_Handler,
+		},
 		{
 			MethodName: "CreateUser",
 			Handler:    _AdService_CreateUser_Handler,
@@ -472,7 +1259,194 @@ var AdService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteUser",
 			Handler:    _AdService_DeleteUser_Handler,
 		},
+		{
+			MethodName: "Register",
+			Handler:    _AdService_Register_Handler,
+		},
+		{
+			MethodName: "Login",
+			Handler:    _AdService_Login_Handler,
+		},
+		{
+			MethodName: "Logout",
+			Handler:    _AdService_Logout_Handler,
+		},
+		{
+			MethodName: "ChangePassword",
+			Handler:    _AdService_ChangePassword_Handler,
+		},
+		{
+			MethodName: "RequestPasswordReset",
+			Handler:    _AdService_RequestPasswordReset_Handler,
+		},
+		{
+			MethodName: "ResetPassword",
+			Handler:    _AdService_ResetPassword_Handler,
+		},
+		{
+			MethodName: "AddFavorite",
+			Handler:    _AdService_AddFavorite_Handler,
+		},
+		{
+			MethodName: "RemoveFavorite",
+			Handler:    _AdService_RemoveFavorite_Handler,
+		},
+		{
+			MethodName: "ListFavorites",
+			Handler:    _AdService_ListFavorites_Handler,
+		},
+		{
+			MethodName: "ListNotifications",
+			Handler:    _AdService_ListNotifications_Handler,
+		},
+		{
+			MethodName: "SaveSearch",
+			Handler:    _AdService_SaveSearch_Handler,
+		},
+		{
+			MethodName: "ListSavedSearches",
+			Handler:    _AdService_ListSavedSearches_Handler,
+		},
+		{
+			MethodName: "DeleteSavedSearch",
+			Handler:    _AdService_DeleteSavedSearch_Handler,
+		},
+		{
+			MethodName: "OpenConversation",
+			Handler:    _AdService_OpenConversation_Handler,
+		},
+		{
+			MethodName: "ListConversations",
+			Handler:    _AdService_ListConversations_Handler,
+		},
+		{
+			MethodName: "SendMessage",
+			Handler:    _AdService_SendMessage_Handler,
+		},
+		{
+			MethodName: "ListMessages",
+			Handler:    _AdService_ListMessages_Handler,
+		},
+		{
+			MethodName: "CloseConversation",
+			Handler:    _AdService_CloseConversation_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "lesson9/homework/internal/ports/grpc/service.proto",
 }
+
+const (
+	Replication_Follow_FullMethodName = "/ad.Replication/Follow"
+)
+
+// ReplicationClient is the client API for Replication service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ReplicationClient interface {
+	Follow(ctx context.Context, in *FollowRequest, opts ...grpc.CallOption) (Replication_FollowClient, error)
+}
+
+type replicationClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewReplicationClient(cc grpc.ClientConnInterface) ReplicationClient {
+	return &replicationClient{cc}
+}
+
+func (c *replicationClient) Follow(ctx context.Context, in *FollowRequest, opts ...grpc.CallOption) (Replication_FollowClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Replication_ServiceDesc.Streams[0], Replication_Follow_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &replicationFollowClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Replication_FollowClient interface {
+	Recv() (*ChangeEvent, error)
+	grpc.ClientStream
+}
+
+type replicationFollowClient struct {
+	grpc.ClientStream
+}
+
+func (x *replicationFollowClient) Recv() (*ChangeEvent, error) {
+	m := new(ChangeEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ReplicationServer is the server API for Replication service.
+// All implementations should embed UnimplementedReplicationServer
+// for forward compatibility
+type ReplicationServer interface {
+	Follow(*FollowRequest, Replication_FollowServer) error
+}
+
+// UnimplementedReplicationServer should be embedded to have forward compatible implementations.
+type UnimplementedReplicationServer struct {
+}
+
+func (UnimplementedReplicationServer) Follow(*FollowRequest, Replication_FollowServer) error {
+	return status.Errorf(codes.Unimplemented, "method Follow not implemented")
+}
+
+// UnsafeReplicationServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ReplicationServer will
+// result in compilation errors.
+type UnsafeReplicationServer interface {
+	mustEmbedUnimplementedReplicationServer()
+}
+
+func RegisterReplicationServer(s grpc.ServiceRegistrar, srv ReplicationServer) {
+	s.RegisterService(&Replication_ServiceDesc, srv)
+}
+
+func _Replication_Follow_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(FollowRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ReplicationServer).Follow(m, &replicationFollowServer{stream})
+}
+
+type Replication_FollowServer interface {
+	Send(*ChangeEvent) error
+	grpc.ServerStream
+}
+
+type replicationFollowServer struct {
+	grpc.ServerStream
+}
+
+func (x *replicationFollowServer) Send(m *ChangeEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// Replication_ServiceDesc is the grpc.ServiceDesc for Replication service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Replication_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "ad.Replication",
+	HandlerType: (*ReplicationServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Follow",
+			Handler:       _Replication_Follow_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "lesson9/homework/internal/ports/grpc/service.proto",
+}
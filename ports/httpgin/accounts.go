@@ -0,0 +1,193 @@
+package httpgin
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"homework10/internal/app"
+)
+
+var MissingToken = errors.New("the request has no bearer token in the Authorization header")
+var OwnerOnly = errors.New("only a session of the user can do this")
+
+// bearerToken returns the session token of the Authorization header.
+func bearerToken(c *gin.Context) (string, bool) {
+	token, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	return token, found && token != ""
+}
+
+// sessionUserID returns the ID of the user of the session whose token the
+// request has, or -1 for a request without one.
+func sessionUserID(c *gin.Context, a app.App) (int64, error) {
+	token, ok := bearerToken(c)
+	if !ok {
+		return -1, nil
+	}
+
+	user, err := a.Authenticate(c.Request.Context(), token)
+	if err != nil {
+		return -1, err
+	}
+	return user.ID, nil
+}
+
+// requireSession returns the ID of the user of the session whose token the
+// request has. Without a valid one it returns the status to answer with.
+func requireSession(c *gin.Context, a app.App) (int64, int, error) {
+	viewerID, err := sessionUserID(c, a)
+	if err != nil {
+		return -1, accountErrorStatus(err), err
+	}
+	if viewerID < 0 {
+		return -1, http.StatusUnauthorized, MissingToken
+	}
+	return viewerID, http.StatusOK, nil
+}
+
+// sessionOf checks that the request has a session of the user, the status
+// to answer with is returned otherwise.
+func sessionOf(c *gin.Context, a app.App, userID int64) (int, error) {
+	viewerID, status, err := requireSession(c, a)
+	if err != nil {
+		return status, err
+	}
+	if viewerID != userID {
+		return http.StatusForbidden, OwnerOnly
+	}
+	return http.StatusOK, nil
+}
+
+// accountErrorStatus is the status of an error of the account flows.
+func accountErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, app.InvalidCredentials), errors.Is(err, app.InvalidToken):
+		return http.StatusUnauthorized
+	case errors.Is(err, app.AccountLocked):
+		return http.StatusTooManyRequests
+	case errors.Is(err, app.ResetUnavailable):
+		return http.StatusNotImplemented
+	case errors.Is(err, app.DefunctUser), errors.Is(err, app.InvalidEmail),
+		errors.Is(err, app.WeakPassword), errors.Is(err, app.EmailTaken):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func register(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody registerRequest
+		if err := c.ShouldBindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, UserErrorResponse(err))
+			return
+		}
+
+		user, err := a.Register(c.Request.Context(), reqBody.Name, reqBody.Email, reqBody.Password)
+		if err != nil {
+			c.JSON(accountErrorStatus(err), UserErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, UserSuccessResponse(&user))
+	}
+}
+
+func login(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody loginRequest
+		if err := c.ShouldBindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, UserErrorResponse(err))
+			return
+		}
+
+		session, err := a.Login(c.Request.Context(), reqBody.Email, reqBody.Password)
+		if err != nil {
+			c.JSON(accountErrorStatus(err), UserErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, SessionSuccessResponse(&session))
+	}
+}
+
+func logout(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := bearerToken(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, UserErrorResponse(MissingToken))
+			return
+		}
+
+		err := a.Logout(c.Request.Context(), token)
+		if err != nil {
+			c.JSON(accountErrorStatus(err), UserErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, EmptySuccessResponse())
+	}
+}
+
+func changePassword(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody changePasswordRequest
+		if err := c.ShouldBindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, UserErrorResponse(err))
+			return
+		}
+
+		userID, err := strconv.Atoi(c.Param("user_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, UserErrorResponse(err))
+			return
+		}
+
+		err = a.ChangePassword(c.Request.Context(), int64(userID), reqBody.OldPassword, reqBody.NewPassword)
+		if err != nil {
+			c.JSON(accountErrorStatus(err), UserErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, EmptySuccessResponse())
+	}
+}
+
+func requestPasswordReset(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody passwordResetRequest
+		if err := c.ShouldBindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, UserErrorResponse(err))
+			return
+		}
+
+		err := a.RequestPasswordReset(c.Request.Context(), reqBody.Email)
+		if err != nil {
+			c.JSON(accountErrorStatus(err), UserErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, EmptySuccessResponse())
+	}
+}
+
+func confirmPasswordReset(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody confirmPasswordResetRequest
+		if err := c.ShouldBindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, UserErrorResponse(err))
+			return
+		}
+
+		err := a.ResetPassword(c.Request.Context(), reqBody.Token, reqBody.NewPassword)
+		if err != nil {
+			c.JSON(accountErrorStatus(err), UserErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, EmptySuccessResponse())
+	}
+}
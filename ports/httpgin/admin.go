@@ -0,0 +1,124 @@
+package httpgin
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"homework10/internal/app"
+	"homework10/internal/bulk"
+	"homework10/internal/password"
+	"homework10/internal/tlscert"
+)
+
+var AdminOnly = errors.New("the route is reserved to administrators")
+
+// AdminAuth tells who may use AdminRouter: a caller sending Token as its
+// bearer token, or one authenticated by a client certificate with one of
+// Identities. The zero AdminAuth lets nobody in.
+type AdminAuth struct {
+	Token      string
+	Identities []string
+}
+
+// adminKey marks the gin context of a request made by an administrator.
+const adminKey = "admin"
+
+// AdminMW recognizes the administrators of auth, it has to run after
+// PeerIdentityMW.
+func AdminMW(auth AdminAuth) gin.HandlerFunc {
+	digest := sha256.Sum256([]byte(auth.Token))
+
+	return func(c *gin.Context) {
+		identity := tlscert.IdentityFromContext(c.Request.Context())
+		token, ok := bearerToken(c)
+		other := sha256.Sum256([]byte(token))
+
+		if (identity != "" && slices.Contains(auth.Identities, identity)) ||
+			(auth.Token != "" && ok && password.Equal(digest[:], other[:])) {
+			c.Set(adminKey, true)
+		}
+
+		c.Next()
+	}
+}
+
+func requireAdmin(c *gin.Context) {
+	if !c.GetBool(adminKey) {
+		c.AbortWithStatusJSON(http.StatusForbidden, AdErrorResponse(AdminOnly))
+		return
+	}
+
+	c.Next()
+}
+
+func AdminRouter(r *gin.RouterGroup, a app.App) {
+	r.Use(requireAdmin)
+	r.POST("/import/:kind", importRecords(a))
+	r.GET("/export/:kind", exportRecords(a))
+}
+
+func bulkParams(c *gin.Context) (bulk.Kind, bulk.Format, error) {
+	kind, err := bulk.ParseKind(c.Param("kind"))
+	if err != nil {
+		return kind, "", err
+	}
+
+	format, err := bulk.ParseFormat(c.DefaultQuery("format", string(bulk.JSONL)))
+
+	return kind, format, err
+}
+
+func importRecords(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		kind, format, err := bulkParams(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		dryRun, err := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		report, err := bulk.Import(c.Request.Context(), a, c.Request.Body, kind, format, dryRun)
+
+		if errors.Is(err, bulk.MissingColumn) || errors.Is(err, bulk.InvalidHeader) {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, AdErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, ReportSuccessResponse(&report))
+	}
+}
+
+func exportRecords(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		kind, format, err := bulkParams(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		c.Header("Content-Type", bulk.ContentType(format))
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", string(kind)+"."+string(format)))
+		c.Status(http.StatusOK)
+
+		// the status is sent with the first record, so a later error can
+		// only cut the file short
+		if err := bulk.Export(c.Request.Context(), a, c.Writer, kind, format); err != nil {
+			slog.Default().ErrorContext(c.Request.Context(), "export failed", "kind", kind, "error", err.Error())
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package httpgin
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+)
+
+// bufferingWriter holds the body back until the handler is done, so that it
+// can be replaced by a 304 response.
+type bufferingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bufferingWriter) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
+
+func (w *bufferingWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+// ConditionalGetMW tags successful responses with an ETag of the body and
+// answers 304 Not Modified when the If-None-Match header has it. Clients may
+// store the responses but must revalidate them before use.
+func ConditionalGetMW(c *gin.Context) {
+	w := &bufferingWriter{ResponseWriter: c.Writer}
+	c.Writer = w
+	defer func() {
+		c.Writer = w.ResponseWriter
+	}()
+
+	c.Next()
+
+	if w.Status() != http.StatusOK {
+		_, _ = w.ResponseWriter.Write(w.body.Bytes())
+		return
+	}
+
+	sum := sha256.Sum256(w.body.Bytes())
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	header := w.Header()
+	header.Set("ETag", etag)
+	header.Set("Cache-Control", "no-cache")
+
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		header.Del("Content-Type")
+		w.ResponseWriter.WriteHeader(http.StatusNotModified)
+		w.ResponseWriter.WriteHeaderNow()
+		return
+	}
+
+	_, _ = w.ResponseWriter.Write(w.body.Bytes())
+}
+
+// etagMatches is the weak comparison If-None-Match uses.
+func etagMatches(header string, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
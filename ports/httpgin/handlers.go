@@ -1,13 +1,17 @@
 package httpgin
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	validator "github.com/Vdaleke/ad-validation"
 	"github.com/gin-gonic/gin"
 	"homework10/internal/ads"
 	"homework10/internal/app"
 	"homework10/internal/users"
+	"io"
 	"net/http"
+	"slices"
 	"strconv"
 	"time"
 )
@@ -21,7 +25,7 @@ func createAd(a app.App) gin.HandlerFunc {
 			return
 		}
 
-		ad, err := a.CreateAd(reqBody.Title, reqBody.Text, reqBody.UserID)
+		ad, err := a.CreateAd(c.Request.Context(), reqBody.Title, reqBody.Text, reqBody.UserID)
 
 		if errors.Is(err, validator.ValidationError) || errors.Is(err, app.DefunctUser) {
 			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
@@ -35,6 +39,72 @@ func createAd(a app.App) gin.HandlerFunc {
 	}
 }
 
+// adErrorStatus is the status code of the ad handlers for err.
+func adErrorStatus(err error) int {
+	if errors.Is(err, app.PermissionDenied) {
+		return http.StatusForbidden
+	} else if errors.Is(err, validator.ValidationError) ||
+		errors.Is(err, app.DefunctUser) || errors.Is(err, app.DefunctAd) {
+		return http.StatusBadRequest
+	}
+
+	return http.StatusInternalServerError
+}
+
+func batchAds(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// the route is "/ads:batch" with "batch" as a parameter, gin can't
+		// match a literal colon
+		if c.Param("batch") != ":batch" {
+			c.AbortWithStatus(http.StatusNotFound)
+			return
+		}
+
+		var reqBody batchAdsRequest
+		if err := c.ShouldBindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		drafts := make([]ads.Ad, len(reqBody.Create))
+		for i, r := range reqBody.Create {
+			drafts[i] = ads.Ad{Title: r.Title, Text: r.Text, AuthorID: r.UserID}
+		}
+		changes := make([]ads.StatusChange, len(reqBody.ChangeStatus))
+		for i, r := range reqBody.ChangeStatus {
+			changes[i] = ads.StatusChange{AdID: r.AdID, UserID: r.UserID, Published: r.Published}
+		}
+
+		created, err := a.BatchCreateAds(c.Request.Context(), drafts)
+		if err != nil {
+			c.JSON(batchErrorStatus(err), AdErrorResponse(err))
+			return
+		}
+
+		changed, err := a.BatchChangeAdStatus(c.Request.Context(), changes)
+		if err != nil {
+			c.JSON(batchErrorStatus(err), AdErrorResponse(err))
+			return
+		}
+
+		got, err := a.BatchGetAds(c.Request.Context(), reqBody.Get)
+		if err != nil {
+			c.JSON(batchErrorStatus(err), AdErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, BatchSuccessResponse(created, changed, got))
+	}
+}
+
+func batchErrorStatus(err error) int {
+	if errors.Is(err, app.BatchTooLarge) {
+		return http.StatusBadRequest
+	}
+
+	return http.StatusInternalServerError
+}
+
 func changeAdStatus(a app.App) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var reqBody changeAdStatusRequest
@@ -49,7 +119,7 @@ func changeAdStatus(a app.App) gin.HandlerFunc {
 			return
 		}
 
-		ad, err := a.ChangeAdStatus(int64(adID), reqBody.UserID, reqBody.Published)
+		ad, err := a.ChangeAdStatus(c.Request.Context(), int64(adID), reqBody.UserID, reqBody.Published)
 
 		if errors.Is(err, app.PermissionDenied) {
 			c.JSON(http.StatusForbidden, AdErrorResponse(err))
@@ -81,7 +151,7 @@ func updateAd(a app.App) gin.HandlerFunc {
 			return
 		}
 
-		ad, err := a.UpdateAd(int64(adID), reqBody.UserID, reqBody.Title, reqBody.Text)
+		ad, err := a.UpdateAd(c.Request.Context(), int64(adID), reqBody.UserID, reqBody.Title, reqBody.Text)
 
 		if errors.Is(err, app.PermissionDenied) {
 			c.JSON(http.StatusForbidden, AdErrorResponse(err))
@@ -99,6 +169,63 @@ func updateAd(a app.App) gin.HandlerFunc {
 	}
 }
 
+var InvalidPatch = errors.New("invalid merge patch")
+
+// bindMergePatch decodes a JSON merge patch (RFC 7396) into obj. Only the
+// fields listed can be patched, and none of them can be removed with null.
+// The caller key, if not empty, names the user making the change, which is
+// decoded into obj as well but is not a field of the resource.
+func bindMergePatch(c *gin.Context, obj any, caller string, fields ...string) error {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return err
+	}
+
+	var patch map[string]json.RawMessage
+	if err := json.Unmarshal(body, &patch); err != nil {
+		return fmt.Errorf("%w: %v", InvalidPatch, err)
+	}
+
+	for name, value := range patch {
+		if name != caller && !slices.Contains(fields, name) {
+			return fmt.Errorf("%w: field %q can't be patched", InvalidPatch, name)
+		}
+		if string(value) == "null" {
+			return fmt.Errorf("%w: field %q can't be removed", InvalidPatch, name)
+		}
+	}
+
+	if err := json.Unmarshal(body, obj); err != nil {
+		return fmt.Errorf("%w: %v", InvalidPatch, err)
+	}
+
+	return nil
+}
+
+func patchAd(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody patchAdRequest
+		if err := bindMergePatch(c, &reqBody, "user_id", "title", "text"); err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		adID, err := strconv.Atoi(c.Param("ad_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		ad, err := a.PatchAd(c.Request.Context(), int64(adID), reqBody.UserID, ads.Patch{Title: reqBody.Title, Text: reqBody.Text})
+		if err != nil {
+			c.JSON(adErrorStatus(err), AdErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, AdSuccessResponse(&ad))
+	}
+}
+
 func getAd(a app.App) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		adID, err := strconv.Atoi(c.Param("ad_id"))
@@ -107,7 +234,7 @@ func getAd(a app.App) gin.HandlerFunc {
 			return
 		}
 
-		ad, err := a.GetAd(int64(adID))
+		ad, err := a.GetAd(c.Request.Context(), int64(adID))
 
 		if errors.Is(err, app.DefunctAd) {
 			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
@@ -135,7 +262,7 @@ func deleteAd(a app.App) gin.HandlerFunc {
 			return
 		}
 
-		err = a.DeleteAd(int64(adID), reqBody.UserID)
+		err = a.DeleteAd(c.Request.Context(), int64(adID), reqBody.UserID)
 		if errors.Is(err, app.PermissionDenied) {
 			c.JSON(http.StatusForbidden, AdErrorResponse(err))
 			return
@@ -165,7 +292,7 @@ func listAds(a app.App) gin.HandlerFunc {
 
 		timeFilter, _ := time.Parse(time.RFC3339, c.Query("creation_time"))
 
-		ads, err := a.ListAds(pubFilter, int64(userFilter), timeFilter)
+		ads, err := a.ListAds(c.Request.Context(), pubFilter, int64(userFilter), timeFilter)
 
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, AdErrorResponse(err))
@@ -180,7 +307,7 @@ func searchAds(a app.App) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		pattern := c.Param("pattern")
 
-		ads, err := a.SearchAds(pattern)
+		ads, err := a.SearchAds(c.Request.Context(), pattern)
 
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, AdErrorResponse(err))
@@ -191,6 +318,60 @@ func searchAds(a app.App) gin.HandlerFunc {
 	}
 }
 
+func listAdRevisions(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adID, err := strconv.Atoi(c.Param("ad_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		revisions, err := a.ListAdRevisions(c.Request.Context(), int64(adID))
+
+		if errors.Is(err, app.DefunctAd) {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, AdErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, RevisionsSuccessResponse(&revisions))
+	}
+}
+
+func rollbackAd(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody rollbackAdRequest
+		if err := c.ShouldBindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		adID, err := strconv.Atoi(c.Param("ad_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		ad, err := a.RollbackAd(c.Request.Context(), int64(adID), reqBody.UserID, reqBody.RevisionID)
+
+		if errors.Is(err, app.PermissionDenied) {
+			c.JSON(http.StatusForbidden, AdErrorResponse(err))
+			return
+		} else if errors.Is(err, app.DefunctUser) || errors.Is(err, app.DefunctAd) ||
+			errors.Is(err, app.DefunctRevision) {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, AdErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, AdSuccessResponse(&ad))
+	}
+}
+
 func createUser(a app.App) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var reqBody createUserRequest
@@ -200,7 +381,7 @@ func createUser(a app.App) gin.HandlerFunc {
 			return
 		}
 
-		user, err := a.CreateUser(reqBody.Name, reqBody.Email)
+		user, err := a.CreateUser(c.Request.Context(), reqBody.Name, reqBody.Email)
 
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, UserErrorResponse(err))
@@ -225,16 +406,81 @@ func updateUser(a app.App) gin.HandlerFunc {
 			return
 		}
 
-		user, err := a.UpdateUser(int64(userID), reqBody.Name, reqBody.Email)
+		viewerID, err := sessionUserID(c, a)
+		if err != nil {
+			c.JSON(accountErrorStatus(err), UserErrorResponse(err))
+			return
+		}
 
-		if errors.Is(err, app.DefunctUser) {
+		user, err := a.UpdateUser(c.Request.Context(), int64(userID), viewerID, reqBody.Name, reqBody.Email)
+
+		if errors.Is(err, app.DefunctUser) || errors.Is(err, app.InvalidEmail) || errors.Is(err, app.EmailTaken) {
 			c.JSON(http.StatusBadRequest, UserErrorResponse(err))
 			return
+		} else if errors.Is(err, app.SessionRequired) {
+			c.JSON(http.StatusForbidden, UserErrorResponse(err))
+			return
 		} else if err != nil {
 			c.JSON(http.StatusInternalServerError, UserErrorResponse(err))
 			return
 		}
 
+		// the contacts are shown to the owner only, as by getUser
+		if viewerID != user.ID {
+			user = user.Public()
+		}
+
+		c.JSON(http.StatusOK, UserSuccessResponse(&user))
+	}
+}
+
+func patchUser(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody patchUserRequest
+		if err := bindMergePatch(c, &reqBody, "", "name", "email", "display_name", "avatar_url", "phone", "city", "bio"); err != nil {
+			c.JSON(http.StatusBadRequest, UserErrorResponse(err))
+			return
+		}
+
+		userID, err := strconv.Atoi(c.Param("user_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, UserErrorResponse(err))
+			return
+		}
+
+		viewerID, err := sessionUserID(c, a)
+		if err != nil {
+			c.JSON(accountErrorStatus(err), UserErrorResponse(err))
+			return
+		}
+
+		user, err := a.PatchUser(c.Request.Context(), int64(userID), viewerID, users.Patch{
+			Name:        reqBody.Name,
+			Email:       reqBody.Email,
+			DisplayName: reqBody.DisplayName,
+			AvatarURL:   reqBody.AvatarURL,
+			Phone:       reqBody.Phone,
+			City:        reqBody.City,
+			Bio:         reqBody.Bio,
+		})
+
+		if errors.Is(err, app.DefunctUser) || errors.Is(err, app.InvalidProfile) ||
+			errors.Is(err, app.InvalidEmail) || errors.Is(err, app.EmailTaken) {
+			c.JSON(http.StatusBadRequest, UserErrorResponse(err))
+			return
+		} else if errors.Is(err, app.SessionRequired) {
+			c.JSON(http.StatusForbidden, UserErrorResponse(err))
+			return
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, UserErrorResponse(err))
+			return
+		}
+
+		// the contacts are shown to the owner only, as by getUser
+		if viewerID != user.ID {
+			user = user.Public()
+		}
+
 		c.JSON(http.StatusOK, UserSuccessResponse(&user))
 	}
 }
@@ -247,7 +493,14 @@ func getUser(a app.App) gin.HandlerFunc {
 			return
 		}
 
-		user, err := a.GetUser(int64(userID))
+		// without a session the profile is the public one
+		viewerID, err := sessionUserID(c, a)
+		if err != nil {
+			c.JSON(accountErrorStatus(err), UserErrorResponse(err))
+			return
+		}
+
+		user, err := a.ViewUser(c.Request.Context(), int64(userID), viewerID)
 
 		if errors.Is(err, app.DefunctUser) {
 			c.JSON(http.StatusBadRequest, UserErrorResponse(err))
@@ -269,11 +522,20 @@ func deleteUser(a app.App) gin.HandlerFunc {
 			return
 		}
 
-		err = a.DeleteUser(int64(userID))
+		viewerID, err := sessionUserID(c, a)
+		if err != nil {
+			c.JSON(accountErrorStatus(err), UserErrorResponse(err))
+			return
+		}
+
+		err = a.DeleteUser(c.Request.Context(), int64(userID), viewerID)
 
 		if errors.Is(err, app.DefunctUser) {
 			c.JSON(http.StatusBadRequest, UserErrorResponse(err))
 			return
+		} else if errors.Is(err, app.SessionRequired) {
+			c.JSON(http.StatusForbidden, UserErrorResponse(err))
+			return
 		} else if err != nil {
 			c.JSON(http.StatusInternalServerError, UserErrorResponse(err))
 			return
@@ -282,3 +544,352 @@ func deleteUser(a app.App) gin.HandlerFunc {
 		c.JSON(http.StatusOK, UserSuccessResponse(&users.User{}))
 	}
 }
+
+func addFavorite(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody addFavoriteRequest
+		if err := c.ShouldBindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		userID, err := strconv.Atoi(c.Param("user_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		ad, err := a.AddFavorite(c.Request.Context(), int64(userID), reqBody.AdID, reqBody.Notify)
+
+		if errors.Is(err, app.DefunctUser) || errors.Is(err, app.DefunctAd) {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, AdErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, AdSuccessResponse(&ad))
+	}
+}
+
+func removeFavorite(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := strconv.Atoi(c.Param("user_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		adID, err := strconv.Atoi(c.Param("ad_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		ad, err := a.RemoveFavorite(c.Request.Context(), int64(userID), int64(adID))
+
+		if errors.Is(err, app.DefunctUser) || errors.Is(err, app.DefunctAd) ||
+			errors.Is(err, app.DefunctFavorite) {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, AdErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, AdSuccessResponse(&ad))
+	}
+}
+
+func listFavorites(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := strconv.Atoi(c.Param("user_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		}
+
+		if status, err := sessionOf(c, a, int64(userID)); err != nil {
+			c.JSON(status, AdErrorResponse(err))
+			return
+		}
+
+		ads, err := a.ListFavorites(c.Request.Context(), int64(userID))
+
+		if errors.Is(err, app.DefunctUser) {
+			c.JSON(http.StatusBadRequest, AdErrorResponse(err))
+			return
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, AdErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, AdsSuccessResponse(&ads))
+	}
+}
+
+func listNotifications(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := strconv.Atoi(c.Param("user_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, UserErrorResponse(err))
+			return
+		}
+
+		if status, err := sessionOf(c, a, int64(userID)); err != nil {
+			c.JSON(status, UserErrorResponse(err))
+			return
+		}
+
+		notifications, err := a.ListNotifications(c.Request.Context(), int64(userID))
+
+		if errors.Is(err, app.DefunctUser) {
+			c.JSON(http.StatusBadRequest, UserErrorResponse(err))
+			return
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, UserErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, NotificationsSuccessResponse(&notifications))
+	}
+}
+
+func saveSearch(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody saveSearchRequest
+		if err := c.ShouldBindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, UserErrorResponse(err))
+			return
+		}
+
+		userID, err := strconv.Atoi(c.Param("user_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, UserErrorResponse(err))
+			return
+		}
+
+		authorFilter := int64(-1)
+		if reqBody.AuthorID != nil {
+			authorFilter = *reqBody.AuthorID
+		}
+
+		search, err := a.SaveSearch(c.Request.Context(), int64(userID), reqBody.Pattern, authorFilter)
+
+		if errors.Is(err, app.DefunctUser) {
+			c.JSON(http.StatusBadRequest, UserErrorResponse(err))
+			return
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, UserErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, SavedSearchSuccessResponse(&search))
+	}
+}
+
+func listSavedSearches(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := strconv.Atoi(c.Param("user_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, UserErrorResponse(err))
+			return
+		}
+
+		if status, err := sessionOf(c, a, int64(userID)); err != nil {
+			c.JSON(status, UserErrorResponse(err))
+			return
+		}
+
+		searches, err := a.ListSavedSearches(c.Request.Context(), int64(userID))
+
+		if errors.Is(err, app.DefunctUser) {
+			c.JSON(http.StatusBadRequest, UserErrorResponse(err))
+			return
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, UserErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, SavedSearchesSuccessResponse(&searches))
+	}
+}
+
+func deleteSavedSearch(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := strconv.Atoi(c.Param("user_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, UserErrorResponse(err))
+			return
+		}
+
+		searchID, err := strconv.Atoi(c.Param("search_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, UserErrorResponse(err))
+			return
+		}
+
+		err = a.DeleteSavedSearch(c.Request.Context(), int64(userID), int64(searchID))
+
+		if errors.Is(err, app.DefunctUser) || errors.Is(err, app.DefunctSavedSearch) {
+			c.JSON(http.StatusBadRequest, UserErrorResponse(err))
+			return
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, UserErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, SavedSearchSuccessResponse(&users.SavedSearch{}))
+	}
+}
+
+func openConversation(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody openConversationRequest
+		if err := c.ShouldBindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, ConversationErrorResponse(err))
+			return
+		}
+
+		adID, err := strconv.Atoi(c.Param("ad_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ConversationErrorResponse(err))
+			return
+		}
+
+		conversation, err := a.OpenConversation(c.Request.Context(), int64(adID), reqBody.UserID)
+
+		if errors.Is(err, app.DefunctUser) || errors.Is(err, app.DefunctAd) || errors.Is(err, app.OwnAd) {
+			c.JSON(http.StatusBadRequest, ConversationErrorResponse(err))
+			return
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, ConversationErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, ConversationSuccessResponse(&conversation, reqBody.UserID))
+	}
+}
+
+func listConversations(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := strconv.Atoi(c.Param("user_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ConversationErrorResponse(err))
+			return
+		}
+
+		if status, err := sessionOf(c, a, int64(userID)); err != nil {
+			c.JSON(status, ConversationErrorResponse(err))
+			return
+		}
+
+		conversations, err := a.ListConversations(c.Request.Context(), int64(userID))
+
+		if errors.Is(err, app.DefunctUser) {
+			c.JSON(http.StatusBadRequest, ConversationErrorResponse(err))
+			return
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, ConversationErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, ConversationsSuccessResponse(&conversations, int64(userID)))
+	}
+}
+
+func sendMessage(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody sendMessageRequest
+		if err := c.ShouldBindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, ConversationErrorResponse(err))
+			return
+		}
+
+		conversationID, err := strconv.Atoi(c.Param("conversation_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ConversationErrorResponse(err))
+			return
+		}
+
+		message, err := a.SendMessage(c.Request.Context(), int64(conversationID), reqBody.UserID, reqBody.Text)
+
+		if errors.Is(err, app.PermissionDenied) {
+			c.JSON(http.StatusForbidden, ConversationErrorResponse(err))
+			return
+		} else if errors.Is(err, app.DefunctUser) || errors.Is(err, app.DefunctConversation) ||
+			errors.Is(err, app.ClosedConversation) || errors.Is(err, app.InvalidMessage) {
+			c.JSON(http.StatusBadRequest, ConversationErrorResponse(err))
+			return
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, ConversationErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, MessageSuccessResponse(&message))
+	}
+}
+
+func listMessages(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		conversationID, err := strconv.Atoi(c.Param("conversation_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ConversationErrorResponse(err))
+			return
+		}
+
+		// the messages are read by the participants only
+		viewerID, status, err := requireSession(c, a)
+		if err != nil {
+			c.JSON(status, ConversationErrorResponse(err))
+			return
+		}
+
+		messages, err := a.ListMessages(c.Request.Context(), int64(conversationID), viewerID)
+
+		if errors.Is(err, app.PermissionDenied) {
+			c.JSON(http.StatusForbidden, ConversationErrorResponse(err))
+			return
+		} else if errors.Is(err, app.DefunctUser) || errors.Is(err, app.DefunctConversation) {
+			c.JSON(http.StatusBadRequest, ConversationErrorResponse(err))
+			return
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, ConversationErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, MessagesSuccessResponse(&messages))
+	}
+}
+
+func closeConversation(a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var reqBody closeConversationRequest
+		if err := c.ShouldBindJSON(&reqBody); err != nil {
+			c.JSON(http.StatusBadRequest, ConversationErrorResponse(err))
+			return
+		}
+
+		conversationID, err := strconv.Atoi(c.Param("conversation_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, ConversationErrorResponse(err))
+			return
+		}
+
+		conversation, err := a.CloseConversation(c.Request.Context(), int64(conversationID), reqBody.UserID)
+
+		if errors.Is(err, app.PermissionDenied) {
+			c.JSON(http.StatusForbidden, ConversationErrorResponse(err))
+			return
+		} else if errors.Is(err, app.DefunctUser) || errors.Is(err, app.DefunctConversation) {
+			c.JSON(http.StatusBadRequest, ConversationErrorResponse(err))
+			return
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, ConversationErrorResponse(err))
+			return
+		}
+
+		c.JSON(http.StatusOK, ConversationSuccessResponse(&conversation, reqBody.UserID))
+	}
+}
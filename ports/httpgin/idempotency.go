@@ -0,0 +1,127 @@
+package httpgin
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"homework10/internal/idempotency"
+	"io"
+	"net/http"
+)
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+// MaxIdempotentBody is the largest body of a request with an idempotency
+// key, which is read in full to be compared with the retries.
+const MaxIdempotentBody = 1 << 20
+
+type storedResponse struct {
+	status      int
+	contentType string
+	body        []byte
+}
+
+// recordingWriter keeps a copy of the body written to the client.
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// IdempotencyMW replays the response of a POST request made again by the
+// same caller with the same Idempotency-Key header and body, so that a
+// retried create doesn't create a duplicate. Server errors and rate limited
+// requests are not stored and can be retried with the key.
+func IdempotencyMW(s *idempotency.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if c.Request.Method != http.MethodPost || key == "" {
+			c.Next()
+			return
+		}
+
+		if len(key) > idempotency.MaxKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, AdErrorResponse(idempotency.InvalidKey))
+			return
+		}
+
+		var body []byte
+		if c.Request.Body != nil {
+			var err error
+			body, err = io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, MaxIdempotentBody))
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, AdErrorResponse(err))
+				return
+			} else if err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, AdErrorResponse(err))
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		sum := sha256.Sum256(body)
+		scope := idempotencyCaller(c) + "|" + c.Request.Method + " " + c.Request.URL.Path + "|" + key
+
+		response, done, err := s.Begin(scope, hex.EncodeToString(sum[:]))
+		if errors.Is(err, idempotency.KeyReused) {
+			c.AbortWithStatusJSON(http.StatusUnprocessableEntity, AdErrorResponse(err))
+			return
+		} else if errors.Is(err, idempotency.InProgress) {
+			c.AbortWithStatusJSON(http.StatusConflict, AdErrorResponse(err))
+			return
+		}
+
+		if done {
+			stored := response.(storedResponse)
+			c.Header(IdempotentReplayedHeader, "true")
+			c.Data(stored.status, stored.contentType, stored.body)
+			c.Abort()
+			return
+		}
+
+		w := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+
+		// the key is released when a handler panics as well
+		stored := false
+		defer func() {
+			if !stored {
+				s.Abort(scope)
+			}
+		}()
+
+		c.Next()
+
+		status := w.Status()
+		if status < http.StatusInternalServerError && status != http.StatusTooManyRequests {
+			s.Finish(scope, storedResponse{status: status, contentType: w.Header().Get("Content-Type"), body: w.body.Bytes()})
+			stored = true
+		}
+	}
+}
+
+// idempotencyCaller tells apart the callers whose keys may collide: by their
+// session token, or else as the rate limiter does.
+func idempotencyCaller(c *gin.Context) string {
+	if token, ok := bearerToken(c); ok {
+		sum := sha256.Sum256([]byte(token))
+		return "token:" + hex.EncodeToString(sum[:])
+	}
+
+	return requestIdentity(c)
+}
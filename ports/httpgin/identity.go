@@ -0,0 +1,16 @@
+package httpgin
+
+import (
+	"github.com/gin-gonic/gin"
+	"homework10/internal/tlscert"
+)
+
+// PeerIdentityMW puts the identity of a caller authenticated by a client
+// certificate into the request context.
+func PeerIdentityMW(c *gin.Context) {
+	if identity := tlscert.Identity(c.Request.TLS); identity != "" {
+		c.Request = c.Request.WithContext(tlscert.WithIdentity(c.Request.Context(), identity))
+	}
+
+	c.Next()
+}
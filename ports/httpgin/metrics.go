@@ -0,0 +1,25 @@
+package httpgin
+
+import (
+	"github.com/gin-gonic/gin"
+	"homework10/internal/metrics"
+	"strconv"
+	"time"
+)
+
+// MetricsMW records the requests by route template, e.g.
+// "/api/v1/ads/:ad_id", so that the label count stays bounded.
+func MetricsMW(m *metrics.Metrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		t := time.Now()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		m.ObserveHTTP(route, c.Request.Method, strconv.Itoa(c.Writer.Status()), time.Since(t))
+	}
+}
@@ -0,0 +1,317 @@
+package httpgin
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3gen"
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files/v2"
+	"homework10/internal/bulk"
+	"homework10/internal/idempotency"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+const (
+	apiPrefix  = "/api/v1"
+	docsPrefix = "/api/docs"
+)
+
+// swaggerInitializer replaces the one shipped with Swagger UI, which opens
+// the petstore example.
+const swaggerInitializer = `window.onload = function() {
+  window.ui = SwaggerUIBundle({
+    url: "openapi.json",
+    dom_id: "#swagger-ui",
+    deepLinking: true,
+    presets: [SwaggerUIBundle.presets.apis, SwaggerUIStandalonePreset],
+    layout: "StandaloneLayout"
+  });
+};
+`
+
+type queryParam struct {
+	name        string
+	schema      *openapi3.Schema
+	description string
+}
+
+// routeDoc describes a route of AppRouter or AdminRouter. Request and
+// response are zero values of the structs the handler binds and the
+// presenter writes into the "data" field. Routes taking or returning files
+// list their media types in upload and download instead. A request of a
+// patch route is a JSON merge patch, a conditional route supports ETags. A
+// nil response is a null "data" field. An unauthorized route answers 401 to
+// wrong credentials or session tokens.
+type routeDoc struct {
+	method       string
+	path         string
+	summary      string
+	query        []queryParam
+	request      any
+	response     any
+	upload       []string
+	download     []string
+	forbidden    bool
+	unauthorized bool
+	patch        bool
+	conditional  bool
+}
+
+var bulkQuery = []queryParam{
+	{name: "format", schema: openapi3.NewStringSchema().WithEnum("jsonl", "csv"), description: "file format, jsonl by default"},
+}
+
+var bulkMedia = []string{"application/x-ndjson", "text/csv"}
+
+var routeDocs = []routeDoc{
+	{method: http.MethodPost, path: "/ads", summary: "Create an ad", request: createAdRequest{}, response: adResponse{}},
+	{method: http.MethodPost, path: "/ads:batch", summary: "Create ads, change their status and get them, with a result per item", request: batchAdsRequest{}, response: batchAdsResponse{}},
+	{method: http.MethodPut, path: "/ads/:ad_id/status", summary: "Publish or unpublish an ad", request: changeAdStatusRequest{}, response: adResponse{}, forbidden: true},
+	{method: http.MethodPut, path: "/ads/:ad_id", summary: "Update the title and the text of an ad", request: updateAdRequest{}, response: adResponse{}, forbidden: true},
+	{method: http.MethodPatch, path: "/ads/:ad_id", summary: "Change some fields of an ad", request: patchAdRequest{}, response: adResponse{}, forbidden: true, patch: true},
+	{method: http.MethodPut, path: "/ads/:ad_id/rollback", summary: "Restore a revision of an ad", request: rollbackAdRequest{}, response: adResponse{}, forbidden: true},
+	{method: http.MethodGet, path: "/ads", summary: "List ads", query: []queryParam{
+		{name: "published", schema: openapi3.NewBoolSchema(), description: "list unpublished ads when false, published ones otherwise"},
+		{name: "user_id", schema: openapi3.NewInt64Schema(), description: "list the ads of the author only"},
+		{name: "creation_time", schema: openapi3.NewDateTimeSchema(), description: "list the ads created on the same day only"},
+	}, response: []adResponse{}, conditional: true},
+	{method: http.MethodGet, path: "/ads/:ad_id", summary: "Get an ad", response: adResponse{}, conditional: true},
+	{method: http.MethodGet, path: "/ads/:ad_id/revisions", summary: "List the revisions of an ad", response: []revisionResponse{}},
+	{method: http.MethodGet, path: "/ads/search/:pattern", summary: "Search published ads by title", response: []adResponse{}},
+	{method: http.MethodDelete, path: "/ads/:ad_id", summary: "Delete an ad", request: deleteAdRequest{}, response: adResponse{}, forbidden: true},
+
+	{method: http.MethodPost, path: "/users", summary: "Create a user", request: createUserRequest{}, response: userResponse{}},
+	{method: http.MethodPut, path: "/users/:user_id", summary: "Update a user, an account in a session of the user only. Answered without the email and the phone unless the session is the user's", request: updateUserRequest{}, response: userResponse{}, unauthorized: true, forbidden: true},
+	{method: http.MethodPatch, path: "/users/:user_id", summary: "Change some fields of a user, answered as PUT is", request: patchUserRequest{}, response: userResponse{}, patch: true, unauthorized: true, forbidden: true},
+	{method: http.MethodGet, path: "/users/:user_id", summary: "Get a user, without the email and the phone unless the session is the user's", response: userResponse{}, unauthorized: true},
+	{method: http.MethodDelete, path: "/users/:user_id", summary: "Delete a user, an account in a session of the user only", response: userResponse{}, unauthorized: true, forbidden: true},
+
+	{method: http.MethodPost, path: "/accounts", summary: "Register a user with a password", request: registerRequest{}, response: userResponse{}},
+	{method: http.MethodPost, path: "/sessions", summary: "Log in, the token of the session is sent as a bearer token", request: loginRequest{}, response: sessionResponse{}, unauthorized: true},
+	{method: http.MethodDelete, path: "/sessions", summary: "Log out of the session of the bearer token", unauthorized: true},
+	{method: http.MethodPut, path: "/users/:user_id/password", summary: "Change the password, ending all sessions", request: changePasswordRequest{}, unauthorized: true},
+	{method: http.MethodPost, path: "/password-resets", summary: "Mail a password reset token to the account with the email", request: passwordResetRequest{}},
+	{method: http.MethodPost, path: "/password-resets/confirm", summary: "Set a new password with a reset token", request: confirmPasswordResetRequest{}, unauthorized: true},
+
+	{method: http.MethodPost, path: "/users/:user_id/favorites", summary: "Add an ad to favorites", request: addFavoriteRequest{}, response: adResponse{}},
+	{method: http.MethodGet, path: "/users/:user_id/favorites", summary: "List favorite ads, in a session of the user", response: []adResponse{}, unauthorized: true, forbidden: true},
+	{method: http.MethodDelete, path: "/users/:user_id/favorites/:ad_id", summary: "Remove an ad from favorites", response: adResponse{}},
+	{method: http.MethodGet, path: "/users/:user_id/notifications", summary: "List notifications, in a session of the user", response: []notificationResponse{}, unauthorized: true, forbidden: true},
+	{method: http.MethodPost, path: "/users/:user_id/searches", summary: "Save a search", request: saveSearchRequest{}, response: savedSearchResponse{}},
+	{method: http.MethodGet, path: "/users/:user_id/searches", summary: "List saved searches, in a session of the user", response: []savedSearchResponse{}, unauthorized: true, forbidden: true},
+	{method: http.MethodDelete, path: "/users/:user_id/searches/:search_id", summary: "Delete a saved search", response: savedSearchResponse{}},
+
+	{method: http.MethodPost, path: "/ads/:ad_id/conversations", summary: "Open a conversation with the author of an ad", request: openConversationRequest{}, response: conversationResponse{}},
+	{method: http.MethodGet, path: "/users/:user_id/conversations", summary: "List conversations, in a session of the user", response: []conversationResponse{}, unauthorized: true, forbidden: true},
+	{method: http.MethodGet, path: "/conversations/:conversation_id/messages", summary: "List messages, in a session of a participant", response: []messageResponse{}, unauthorized: true, forbidden: true},
+	{method: http.MethodPost, path: "/conversations/:conversation_id/messages", summary: "Send a message", request: sendMessageRequest{}, response: messageResponse{}, forbidden: true},
+	{method: http.MethodPut, path: "/conversations/:conversation_id/close", summary: "Close a conversation", request: closeConversationRequest{}, response: conversationResponse{}, forbidden: true},
+
+	{method: http.MethodPost, path: "/admin/import/:kind", summary: "Import ads or users, reporting the lines that failed", query: append([]queryParam{
+		{name: "dry_run", schema: openapi3.NewBoolSchema(), description: "check the records without storing them"},
+	}, bulkQuery...), upload: bulkMedia, response: bulk.Report{}, forbidden: true},
+	{method: http.MethodGet, path: "/admin/export/:kind", summary: "Export ads or users", query: bulkQuery, download: bulkMedia, forbidden: true},
+}
+
+// OpenAPIPath converts a gin route path, e.g. "/ads/:ad_id", into an
+// OpenAPI one, e.g. "/ads/{ad_id}".
+func OpenAPIPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// OpenAPI describes the routes of AppRouter and AdminRouter, served under
+// /api/v1.
+func OpenAPI() (*openapi3.T, error) {
+	doc := &openapi3.T{
+		OpenAPI: "3.0.3",
+		Info: &openapi3.Info{
+			Title:   "Advertisement publishing service",
+			Version: "1.0.0",
+		},
+		Servers:    openapi3.Servers{{URL: apiPrefix}},
+		Paths:      openapi3.NewPaths(),
+		Components: &openapi3.Components{Schemas: openapi3.Schemas{}},
+	}
+
+	doc.Components.Schemas["error"] = openapi3.NewSchemaRef("", openapi3.NewObjectSchema().
+		WithPropertyRef("data", openapi3.NewSchemaRef("", &openapi3.Schema{Nullable: true})).
+		WithProperty("error", openapi3.NewStringSchema()))
+
+	for _, route := range routeDocs {
+		op, err := operation(doc.Components.Schemas, route)
+		if err != nil {
+			return nil, fmt.Errorf("can't describe %s %s: %w", route.method, route.path, err)
+		}
+
+		doc.AddOperation(OpenAPIPath(route.path), route.method, op)
+	}
+
+	return doc, nil
+}
+
+func operation(schemas openapi3.Schemas, route routeDoc) (*openapi3.Operation, error) {
+	op := openapi3.NewOperation()
+	op.Summary = route.summary
+	op.OperationID = operationID(route)
+	op.Responses = openapi3.NewResponses()
+
+	for _, segment := range strings.Split(route.path, "/") {
+		if !strings.HasPrefix(segment, ":") {
+			continue
+		}
+
+		name := segment[1:]
+		schema := openapi3.NewStringSchema()
+		if strings.HasSuffix(name, "_id") {
+			schema = openapi3.NewInt64Schema()
+		} else if name == "kind" {
+			schema = schema.WithEnum("ads", "users")
+		}
+		op.AddParameter(openapi3.NewPathParameter(name).WithSchema(schema))
+	}
+
+	if route.method == http.MethodPost {
+		p := openapi3.NewHeaderParameter(IdempotencyKeyHeader).WithSchema(openapi3.NewStringSchema().WithMaxLength(idempotency.MaxKeyLength))
+		p.Description = "replays the response of the first request made with the key and the same body"
+		op.AddParameter(p)
+	}
+
+	if route.conditional {
+		p := openapi3.NewHeaderParameter("If-None-Match").WithSchema(openapi3.NewStringSchema())
+		p.Description = "ETag of a stored response, answered with 304 if it is still current"
+		op.AddParameter(p)
+		op.AddResponse(http.StatusNotModified, openapi3.NewResponse().WithDescription("Not modified"))
+	}
+
+	for _, param := range route.query {
+		p := openapi3.NewQueryParameter(param.name).WithSchema(param.schema)
+		p.Description = param.description
+		op.AddParameter(p)
+	}
+
+	if route.request != nil {
+		ref, err := schemaRef(schemas, route.request)
+		if err != nil {
+			return nil, err
+		}
+
+		body := openapi3.NewRequestBody().WithRequired(true).WithJSONSchemaRef(ref)
+		if route.patch {
+			body.Content["application/merge-patch+json"] = openapi3.NewMediaType().WithSchemaRef(ref)
+		}
+		op.RequestBody = &openapi3.RequestBodyRef{Value: body}
+	} else if route.upload != nil {
+		op.RequestBody = &openapi3.RequestBodyRef{Value: openapi3.NewRequestBody().WithRequired(true).
+			WithContent(openapi3.NewContentWithSchema(openapi3.NewBytesSchema(), route.upload))}
+	}
+
+	if route.download != nil {
+		op.AddResponse(http.StatusOK, openapi3.NewResponse().WithDescription("OK").
+			WithContent(openapi3.NewContentWithSchema(openapi3.NewBytesSchema(), route.download)))
+	} else {
+		data := openapi3.NewSchemaRef("", &openapi3.Schema{Nullable: true})
+		if route.response != nil {
+			ref, err := schemaRef(schemas, route.response)
+			if err != nil {
+				return nil, err
+			}
+			data = ref
+		}
+
+		envelope := openapi3.NewObjectSchema().
+			WithPropertyRef("data", data).
+			WithPropertyRef("error", openapi3.NewSchemaRef("", &openapi3.Schema{Type: &openapi3.Types{openapi3.TypeString}, Nullable: true}))
+		op.AddResponse(http.StatusOK, openapi3.NewResponse().WithDescription("OK").WithJSONSchema(envelope))
+	}
+
+	errorRef := openapi3.NewSchemaRef("#/components/schemas/error", nil)
+	op.AddResponse(http.StatusBadRequest, openapi3.NewResponse().WithDescription("Invalid request").WithJSONSchemaRef(errorRef))
+	if route.unauthorized {
+		op.AddResponse(http.StatusUnauthorized, openapi3.NewResponse().WithDescription("Wrong credentials or session token").WithJSONSchemaRef(errorRef))
+	}
+	if route.forbidden {
+		op.AddResponse(http.StatusForbidden, openapi3.NewResponse().WithDescription("The user is not allowed to do it").WithJSONSchemaRef(errorRef))
+	}
+	op.AddResponse(http.StatusTooManyRequests, openapi3.NewResponse().WithDescription("Rate limit exceeded").WithJSONSchemaRef(errorRef))
+	op.AddResponse(http.StatusInternalServerError, openapi3.NewResponse().WithDescription("Internal error").WithJSONSchemaRef(errorRef))
+
+	return op, nil
+}
+
+// schemaRef registers the struct of v, or of its elements for a slice, as a
+// component schema and returns a reference to it.
+func schemaRef(schemas openapi3.Schemas, v any) (*openapi3.SchemaRef, error) {
+	t := reflect.TypeOf(v)
+	if t.Kind() == reflect.Slice {
+		item, err := schemaRef(schemas, reflect.Zero(t.Elem()).Interface())
+		if err != nil {
+			return nil, err
+		}
+
+		return openapi3.NewSchemaRef("", &openapi3.Schema{Type: &openapi3.Types{openapi3.TypeArray}, Items: item}), nil
+	}
+
+	if _, ok := schemas[t.Name()]; !ok {
+		schema, err := openapi3gen.NewSchemaRefForValue(v, schemas)
+		if err != nil {
+			return nil, err
+		}
+		schemas[t.Name()] = schema
+	}
+
+	return openapi3.NewSchemaRef("#/components/schemas/"+t.Name(), nil), nil
+}
+
+func operationID(route routeDoc) string {
+	id := strings.ToLower(route.method)
+	for _, word := range strings.FieldsFunc(route.path, func(r rune) bool { return r == '/' || r == ':' || r == '_' }) {
+		id += strings.ToUpper(word[:1]) + word[1:]
+	}
+
+	return id
+}
+
+// DocsRouter serves the OpenAPI document at openapi.json and Swagger UI.
+func DocsRouter(r *gin.RouterGroup) {
+	var (
+		once sync.Once
+		spec []byte
+		err  error
+	)
+
+	files := http.StripPrefix(r.BasePath(), http.FileServer(http.FS(swaggerFiles.FS)))
+
+	r.GET("/*file", func(c *gin.Context) {
+		switch c.Param("file") {
+		case "/openapi.json":
+			once.Do(func() {
+				var doc *openapi3.T
+				if doc, err = OpenAPI(); err == nil {
+					spec, err = json.Marshal(doc)
+				}
+			})
+
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, AdErrorResponse(err))
+				return
+			}
+
+			c.Data(http.StatusOK, "application/json; charset=utf-8", spec)
+		case "/swagger-initializer.js":
+			c.Data(http.StatusOK, "application/javascript; charset=utf-8", []byte(swaggerInitializer))
+		default:
+			files.ServeHTTP(c.Writer, c.Request)
+		}
+	})
+}
@@ -3,7 +3,10 @@ package httpgin
 import (
 	"github.com/gin-gonic/gin"
 	"homework10/internal/ads"
+	"homework10/internal/bulk"
+	"homework10/internal/conversations"
 	"homework10/internal/users"
+	"net/http"
 	"time"
 )
 
@@ -21,6 +24,7 @@ type adResponse struct {
 	Published bool      `json:"published"`
 	CreatedAt time.Time `json:"creation_time"`
 	UpdatedAt time.Time `json:"update_time"`
+	Favorites int64     `json:"favorites"`
 }
 
 type changeAdStatusRequest struct {
@@ -34,19 +38,74 @@ type updateAdRequest struct {
 	UserID int64  `json:"user_id"`
 }
 
+// patchAdRequest is a JSON merge patch, a nil field is left as is. UserID is
+// the caller, as in updateAdRequest, and not a field of the ad.
+type patchAdRequest struct {
+	Title  *string `json:"title"`
+	Text   *string `json:"text"`
+	UserID int64   `json:"user_id"`
+}
+
+type adStatusChangeRequest struct {
+	AdID      int64 `json:"ad_id"`
+	Published bool  `json:"published"`
+	UserID    int64 `json:"user_id"`
+}
+
+// batchAdsRequest is run in the order of its fields, so the ads created by a
+// request can't be changed by the same request as their IDs aren't known yet.
+type batchAdsRequest struct {
+	Create       []createAdRequest       `json:"create"`
+	ChangeStatus []adStatusChangeRequest `json:"change_status"`
+	Get          []int64                 `json:"get"`
+}
+
+// batchAdResult is the response the single request would have got, with its
+// status code.
+type batchAdResult struct {
+	Status int         `json:"status"`
+	Data   *adResponse `json:"data"`
+	Error  *string     `json:"error"`
+}
+
+type batchAdsResponse struct {
+	Create       []batchAdResult `json:"create"`
+	ChangeStatus []batchAdResult `json:"change_status"`
+	Get          []batchAdResult `json:"get"`
+}
+
 type deleteAdRequest struct {
 	UserID int64 `json:"user_id"`
 }
 
+type revisionResponse struct {
+	ID        int64     `json:"id"`
+	Title     string    `json:"title"`
+	Text      string    `json:"text"`
+	UpdatedAt time.Time `json:"update_time"`
+}
+
+type rollbackAdRequest struct {
+	RevisionID int64 `json:"revision_id"`
+	UserID     int64 `json:"user_id"`
+}
+
 type createUserRequest struct {
 	Name  string `json:"name"`
 	Email string `json:"email"`
 }
 
+// userResponse omits the contact info of a profile viewed by another user.
 type userResponse struct {
-	ID    int64  `json:"id"`
-	Name  string `json:"name"`
-	Email string `json:"email"`
+	ID               int64     `json:"id"`
+	Name             string    `json:"name"`
+	Email            string    `json:"email,omitempty"`
+	DisplayName      string    `json:"display_name"`
+	AvatarURL        string    `json:"avatar_url"`
+	Phone            string    `json:"phone,omitempty"`
+	City             string    `json:"city"`
+	Bio              string    `json:"bio"`
+	RegistrationTime time.Time `json:"registration_time"`
 }
 
 type updateUserRequest struct {
@@ -54,6 +113,104 @@ type updateUserRequest struct {
 	Email string `json:"email"`
 }
 
+type patchUserRequest struct {
+	Name        *string `json:"name"`
+	Email       *string `json:"email"`
+	DisplayName *string `json:"display_name"`
+	AvatarURL   *string `json:"avatar_url"`
+	Phone       *string `json:"phone"`
+	City        *string `json:"city"`
+	Bio         *string `json:"bio"`
+}
+
+type registerRequest struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type loginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// sessionResponse has the token of the session only when it is created.
+type sessionResponse struct {
+	ID             int64     `json:"id"`
+	UserID         int64     `json:"user_id"`
+	Token          string    `json:"token,omitempty"`
+	CreationTime   time.Time `json:"creation_time"`
+	ExpirationTime time.Time `json:"expiration_time"`
+}
+
+type changePasswordRequest struct {
+	OldPassword string `json:"old_password"`
+	NewPassword string `json:"new_password"`
+}
+
+type passwordResetRequest struct {
+	Email string `json:"email"`
+}
+
+type confirmPasswordResetRequest struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+type addFavoriteRequest struct {
+	AdID   int64 `json:"ad_id"`
+	Notify bool  `json:"notify"`
+}
+
+type saveSearchRequest struct {
+	Pattern  string `json:"pattern"`
+	AuthorID *int64 `json:"author_id"`
+}
+
+type savedSearchResponse struct {
+	ID        int64     `json:"id"`
+	Pattern   string    `json:"pattern"`
+	AuthorID  int64     `json:"author_id"`
+	CreatedAt time.Time `json:"creation_time"`
+}
+
+type notificationResponse struct {
+	ID        int64     `json:"id"`
+	AdID      int64     `json:"ad_id"`
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"creation_time"`
+}
+
+type openConversationRequest struct {
+	UserID int64 `json:"user_id"`
+}
+
+type sendMessageRequest struct {
+	Text   string `json:"text"`
+	UserID int64  `json:"user_id"`
+}
+
+type closeConversationRequest struct {
+	UserID int64 `json:"user_id"`
+}
+
+type conversationResponse struct {
+	ID        int64     `json:"id"`
+	AdID      int64     `json:"ad_id"`
+	BuyerID   int64     `json:"buyer_id"`
+	SellerID  int64     `json:"seller_id"`
+	Closed    bool      `json:"closed"`
+	Unread    int64     `json:"unread"`
+	CreatedAt time.Time `json:"creation_time"`
+}
+
+type messageResponse struct {
+	ID        int64     `json:"id"`
+	AuthorID  int64     `json:"author_id"`
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"creation_time"`
+}
+
 func AdSuccessResponse(ad *ads.Ad) *gin.H {
 	return &gin.H{
 		"data": adResponse{
@@ -64,6 +221,7 @@ func AdSuccessResponse(ad *ads.Ad) *gin.H {
 			Published: ad.Published,
 			CreatedAt: ad.CreatedAt,
 			UpdatedAt: ad.UpdatedAt,
+			Favorites: ad.Favorites,
 		},
 		"error": nil,
 	}
@@ -80,6 +238,7 @@ func AdsSuccessResponse(ads *[]ads.Ad) *gin.H {
 			Published: (*ads)[i].Published,
 			CreatedAt: (*ads)[i].CreatedAt,
 			UpdatedAt: (*ads)[i].UpdatedAt,
+			Favorites: (*ads)[i].Favorites,
 		})
 	}
 
@@ -89,6 +248,49 @@ func AdsSuccessResponse(ads *[]ads.Ad) *gin.H {
 	}
 }
 
+func newBatchAdResults(results []ads.Result) []batchAdResult {
+	response := make([]batchAdResult, 0, len(results))
+	for _, result := range results {
+		if result.Err != nil {
+			msg := result.Err.Error()
+			response = append(response, batchAdResult{Status: adErrorStatus(result.Err), Error: &msg})
+			continue
+		}
+
+		ad := result.Ad
+		response = append(response, batchAdResult{Status: http.StatusOK, Data: &adResponse{
+			ID:        ad.ID,
+			Title:     ad.Title,
+			Text:      ad.Text,
+			AuthorID:  ad.AuthorID,
+			Published: ad.Published,
+			CreatedAt: ad.CreatedAt,
+			UpdatedAt: ad.UpdatedAt,
+			Favorites: ad.Favorites,
+		}})
+	}
+
+	return response
+}
+
+func BatchSuccessResponse(created, changed, got []ads.Result) *gin.H {
+	return &gin.H{
+		"data": batchAdsResponse{
+			Create:       newBatchAdResults(created),
+			ChangeStatus: newBatchAdResults(changed),
+			Get:          newBatchAdResults(got),
+		},
+		"error": nil,
+	}
+}
+
+func ReportSuccessResponse(report *bulk.Report) *gin.H {
+	return &gin.H{
+		"data":  report,
+		"error": nil,
+	}
+}
+
 func AdErrorResponse(err error) *gin.H {
 	return &gin.H{
 		"data":  nil,
@@ -96,20 +298,177 @@ func AdErrorResponse(err error) *gin.H {
 	}
 }
 
+func RevisionsSuccessResponse(revisions *[]ads.Revision) *gin.H {
+	revisionsResponseData := make([]revisionResponse, 0, len(*revisions))
+	for _, revision := range *revisions {
+		revisionsResponseData = append(revisionsResponseData, revisionResponse{
+			ID:        revision.ID,
+			Title:     revision.Title,
+			Text:      revision.Text,
+			UpdatedAt: revision.UpdatedAt,
+		})
+	}
+
+	return &gin.H{
+		"data":  revisionsResponseData,
+		"error": nil,
+	}
+}
+
 func UserSuccessResponse(user *users.User) *gin.H {
 	return &gin.H{
 		"data": userResponse{
-			ID:    user.ID,
-			Name:  user.Name,
-			Email: user.Email,
+			ID:               user.ID,
+			Name:             user.Name,
+			Email:            user.Email,
+			DisplayName:      user.DisplayName,
+			AvatarURL:        user.AvatarURL,
+			Phone:            user.Phone,
+			City:             user.City,
+			Bio:              user.Bio,
+			RegistrationTime: user.RegisteredAt,
+		},
+		"error": nil,
+	}
+}
+
+func SessionSuccessResponse(session *users.Session) *gin.H {
+	return &gin.H{
+		"data": sessionResponse{
+			ID:             session.ID,
+			UserID:         session.UserID,
+			Token:          session.Token,
+			CreationTime:   session.CreatedAt,
+			ExpirationTime: session.ExpiresAt,
 		},
 		"error": nil,
 	}
 }
 
+// EmptySuccessResponse answers a call with nothing to return.
+func EmptySuccessResponse() *gin.H {
+	return &gin.H{
+		"data":  nil,
+		"error": nil,
+	}
+}
+
+func NotificationsSuccessResponse(notifications *[]users.Notification) *gin.H {
+	notificationsResponseData := make([]notificationResponse, 0, len(*notifications))
+	for _, notification := range *notifications {
+		notificationsResponseData = append(notificationsResponseData, notificationResponse{
+			ID:        notification.ID,
+			AdID:      notification.AdID,
+			Text:      notification.Text,
+			CreatedAt: notification.CreatedAt,
+		})
+	}
+
+	return &gin.H{
+		"data":  notificationsResponseData,
+		"error": nil,
+	}
+}
+
+func SavedSearchSuccessResponse(search *users.SavedSearch) *gin.H {
+	return &gin.H{
+		"data": savedSearchResponse{
+			ID:        search.ID,
+			Pattern:   search.Pattern,
+			AuthorID:  search.AuthorID,
+			CreatedAt: search.CreatedAt,
+		},
+		"error": nil,
+	}
+}
+
+func SavedSearchesSuccessResponse(searches *[]users.SavedSearch) *gin.H {
+	searchesResponseData := make([]savedSearchResponse, 0, len(*searches))
+	for _, search := range *searches {
+		searchesResponseData = append(searchesResponseData, savedSearchResponse{
+			ID:        search.ID,
+			Pattern:   search.Pattern,
+			AuthorID:  search.AuthorID,
+			CreatedAt: search.CreatedAt,
+		})
+	}
+
+	return &gin.H{
+		"data":  searchesResponseData,
+		"error": nil,
+	}
+}
+
 func UserErrorResponse(err error) *gin.H {
 	return &gin.H{
 		"data":  nil,
 		"error": err.Error(),
 	}
 }
+
+func newConversationResponse(conversation *conversations.Conversation, userID int64) conversationResponse {
+	return conversationResponse{
+		ID:        conversation.ID,
+		AdID:      conversation.AdID,
+		BuyerID:   conversation.BuyerID,
+		SellerID:  conversation.SellerID,
+		Closed:    conversation.Closed,
+		Unread:    conversation.Unread(userID),
+		CreatedAt: conversation.CreatedAt,
+	}
+}
+
+func ConversationSuccessResponse(conversation *conversations.Conversation, userID int64) *gin.H {
+	return &gin.H{
+		"data":  newConversationResponse(conversation, userID),
+		"error": nil,
+	}
+}
+
+func ConversationsSuccessResponse(conversations *[]conversations.Conversation, userID int64) *gin.H {
+	conversationsResponseData := make([]conversationResponse, 0, len(*conversations))
+	for i := range *conversations {
+		conversationsResponseData = append(conversationsResponseData, newConversationResponse(&(*conversations)[i], userID))
+	}
+
+	return &gin.H{
+		"data":  conversationsResponseData,
+		"error": nil,
+	}
+}
+
+func MessageSuccessResponse(message *conversations.Message) *gin.H {
+	return &gin.H{
+		"data": messageResponse{
+			ID:        message.ID,
+			AuthorID:  message.AuthorID,
+			Text:      message.Text,
+			CreatedAt: message.CreatedAt,
+		},
+		"error": nil,
+	}
+}
+
+func MessagesSuccessResponse(messages *[]conversations.Message) *gin.H {
+	messagesResponseData := make([]messageResponse, 0, len(*messages))
+	for _, message := range *messages {
+		messagesResponseData = append(messagesResponseData, messageResponse{
+			ID:        message.ID,
+			AuthorID:  message.AuthorID,
+			Text:      message.Text,
+			CreatedAt: message.CreatedAt,
+		})
+	}
+
+	return &gin.H{
+		"data":  messagesResponseData,
+		"error": nil,
+	}
+}
+
+func ConversationErrorResponse(err error) *gin.H {
+	return &gin.H{
+		"data":  nil,
+		"error": err.Error(),
+	}
+}
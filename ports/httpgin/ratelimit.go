@@ -0,0 +1,50 @@
+package httpgin
+
+import (
+	"github.com/gin-gonic/gin"
+	"homework10/internal/app"
+	"homework10/internal/ratelimit"
+	"homework10/internal/tlscert"
+	"math"
+	"net/http"
+	"strconv"
+)
+
+// RateLimitMW limits requests per route, e.g. "POST /api/v1/ads", and per
+// identity: the user of the session, the service authenticated by its client
+// certificate or the client IP. The route is the path of the request, so
+// that the rules apply behind the gateway as well, see ratelimit.New. Nothing
+// the client sends chooses its bucket but a valid session, the IP is the peer
+// address unless the peer is a trusted proxy, see SetTrustedProxies.
+func RateLimitMW(l *ratelimit.Limiter, a app.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		allowed, retryAfter := l.Allow(c.Request.Method+" "+c.Request.URL.Path, limitIdentity(c, a))
+		if !allowed {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, AdErrorResponse(ratelimit.LimitExceeded))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func limitIdentity(c *gin.Context, a app.App) string {
+	// an invalid token is left to the handler, the request is limited by
+	// its caller
+	if userID, err := sessionUserID(c, a); err == nil && userID >= 0 {
+		return "user:" + strconv.FormatInt(userID, 10)
+	}
+
+	return requestIdentity(c)
+}
+
+// requestIdentity is the service authenticated by its client certificate or
+// the client IP.
+func requestIdentity(c *gin.Context) string {
+	if identity := tlscert.IdentityFromContext(c.Request.Context()); identity != "" {
+		return "service:" + identity
+	}
+
+	return "ip:" + c.ClientIP()
+}
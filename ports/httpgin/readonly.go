@@ -0,0 +1,29 @@
+package httpgin
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"net/http"
+	"strings"
+)
+
+var ReadReplica = errors.New("this instance is a read replica, the request must be sent to the primary")
+
+// ReadOnlyMW rejects the API routes other than the given ones, e.g.
+// "GET /api/v1/ads/:ad_id", on a read replica.
+func ReadOnlyMW(routes ...string) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(routes))
+	for _, r := range routes {
+		allowed[r] = true
+	}
+
+	return func(c *gin.Context) {
+		route := c.Request.Method + " " + c.FullPath()
+		if strings.HasPrefix(c.FullPath(), "/api/") && !allowed[route] {
+			c.AbortWithStatusJSON(http.StatusNotImplemented, AdErrorResponse(ReadReplica))
+			return
+		}
+
+		c.Next()
+	}
+}
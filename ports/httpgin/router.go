@@ -2,36 +2,76 @@ package httpgin
 
 import (
 	"github.com/gin-gonic/gin"
-	"log"
+	"log/slog"
+	"net/http"
 	"time"
 
 	"homework10/internal/app"
+	"homework10/internal/logger"
 )
 
+// CustomMW takes the request ID from the X-Request-ID header or generates a
+// new one, puts it into the request context and logs the request.
 func CustomMW(c *gin.Context) {
 	t := time.Now()
 
+	requestID := logger.EnsureRequestID(c.GetHeader(logger.RequestIDHeader))
+	c.Request = c.Request.WithContext(logger.WithRequestID(c.Request.Context(), requestID))
+	c.Header(logger.RequestIDHeader, requestID)
+
 	c.Next()
 
 	latency := time.Since(t)
 	status := c.Writer.Status()
 
-	log.Println("latency", latency, "method", c.Request.Method, "path", c.Request.URL.Path, "status", status)
+	level := slog.LevelInfo
+	if status >= http.StatusInternalServerError {
+		level = slog.LevelError
+	} else if status >= http.StatusBadRequest {
+		level = slog.LevelWarn
+	}
+
+	slog.Default().Log(c.Request.Context(), level, "http request",
+		"latency", latency, "method", c.Request.Method, "path", c.Request.URL.Path, "status", status)
 }
 
 func AppRouter(r *gin.RouterGroup, a app.App) {
-	r.Use(CustomMW)
-
 	r.POST("/ads", createAd(a))
+	r.POST("/ads:batch", batchAds(a))
 	r.PUT("/ads/:ad_id/status", changeAdStatus(a))
 	r.PUT("/ads/:ad_id", updateAd(a))
-	r.GET("/ads", listAds(a))
-	r.GET("/ads/:ad_id", getAd(a))
+	r.PATCH("/ads/:ad_id", patchAd(a))
+	r.PUT("/ads/:ad_id/rollback", rollbackAd(a))
+	r.GET("/ads", ConditionalGetMW, listAds(a))
+	r.GET("/ads/:ad_id", ConditionalGetMW, getAd(a))
+	r.GET("/ads/:ad_id/revisions", listAdRevisions(a))
 	r.GET("/ads/search/:pattern", searchAds(a))
 	r.DELETE("/ads/:ad_id", deleteAd(a))
 
 	r.POST("/users", createUser(a))
 	r.PUT("/users/:user_id", updateUser(a))
+	r.PATCH("/users/:user_id", patchUser(a))
 	r.GET("/users/:user_id", getUser(a))
 	r.DELETE("/users/:user_id", deleteUser(a))
+
+	r.POST("/accounts", register(a))
+	r.POST("/sessions", login(a))
+	r.DELETE("/sessions", logout(a))
+	r.PUT("/users/:user_id/password", changePassword(a))
+	r.POST("/password-resets", requestPasswordReset(a))
+	r.POST("/password-resets/confirm", confirmPasswordReset(a))
+
+	r.POST("/users/:user_id/favorites", addFavorite(a))
+	r.GET("/users/:user_id/favorites", listFavorites(a))
+	r.DELETE("/users/:user_id/favorites/:ad_id", removeFavorite(a))
+	r.GET("/users/:user_id/notifications", listNotifications(a))
+	r.POST("/users/:user_id/searches", saveSearch(a))
+	r.GET("/users/:user_id/searches", listSavedSearches(a))
+	r.DELETE("/users/:user_id/searches/:search_id", deleteSavedSearch(a))
+
+	r.POST("/ads/:ad_id/conversations", openConversation(a))
+	r.GET("/users/:user_id/conversations", listConversations(a))
+	r.GET("/conversations/:conversation_id/messages", listMessages(a))
+	r.POST("/conversations/:conversation_id/messages", sendMessage(a))
+	r.PUT("/conversations/:conversation_id/close", closeConversation(a))
 }
@@ -1,6 +1,7 @@
 package httpgin
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -8,13 +9,31 @@ import (
 	"homework10/internal/app"
 )
 
-func NewHTTPServer(port string, a app.App) *http.Server {
-	gin.SetMode(gin.ReleaseMode)
-
-	router := gin.Default()
+func NewHTTPServer(port string, a app.App, middlewares ...gin.HandlerFunc) *http.Server {
+	router := newRouter(middlewares...)
 
-	api := router.Group("/api/v1")
+	api := router.Group(apiPrefix)
 	AppRouter(api, a)
+	AdminRouter(api.Group("/admin"), a)
+
+	httpServer := http.Server{
+		Addr:    port,
+		Handler: router,
+	}
+
+	return &httpServer
+}
+
+// NewGatewayServer serves /api/v1 with the handler generated from
+// service.proto instead of AppRouter, behind the same middlewares.
+func NewGatewayServer(port string, gateway http.Handler, middlewares ...gin.HandlerFunc) *http.Server {
+	router := newRouter(middlewares...)
+
+	router.Any(apiPrefix+"/*path", func(c *gin.Context) {
+		// the gRPC server only sees the gateway, see grpc.ClientIPMetadata
+		c.Request.Header.Set(clientIPHeader, c.ClientIP())
+		gateway.ServeHTTP(c.Writer, c.Request)
+	})
 
 	httpServer := http.Server{
 		Addr:    port,
@@ -23,3 +42,34 @@ func NewHTTPServer(port string, a app.App) *http.Server {
 
 	return &httpServer
 }
+
+// clientIPHeader is forwarded by the gateway as grpc.ClientIPMetadata.
+const clientIPHeader = "X-Client-Ip"
+
+var NotGin = errors.New("the server is not served by a gin router")
+
+// SetTrustedProxies lets the client IP be taken from the X-Forwarded-For
+// header of the requests coming from the proxies, IPs or CIDRs, of a server
+// made by NewHTTPServer or NewGatewayServer.
+func SetTrustedProxies(srv *http.Server, proxies []string) error {
+	router, ok := srv.Handler.(*gin.Engine)
+	if !ok {
+		return NotGin
+	}
+
+	return router.SetTrustedProxies(proxies)
+}
+
+func newRouter(middlewares ...gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.ReleaseMode)
+
+	router := gin.New()
+	// the client IP is the peer address until SetTrustedProxies is called
+	_ = router.SetTrustedProxies(nil)
+	router.Use(gin.Recovery(), CustomMW)
+	router.Use(middlewares...)
+
+	DocsRouter(router.Group(docsPrefix))
+
+	return router
+}
@@ -0,0 +1,9 @@
+package query
+
+// Index maps an entity to a key, e.g. an ad to its author. A repository
+// builds an index the first time it is used and keeps it up to date.
+type Index struct {
+	Name    string
+	Key     func(e interface{}) int64
+	Ordered bool
+}
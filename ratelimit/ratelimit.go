@@ -0,0 +1,140 @@
+package ratelimit
+
+import (
+	"github.com/pkg/errors"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var LimitExceeded = errors.New("too many requests, try again later")
+
+// Rule allows Burst requests at once and refills them at Rate requests
+// per second.
+type Rule struct {
+	Rate  float64
+	Burst int
+}
+
+type bucket struct {
+	rule    Rule
+	tokens  float64
+	updated time.Time
+}
+
+// Limiter keeps a token bucket per route and identity. Routes without a rule
+// are not limited.
+type Limiter struct {
+	rules map[string]Rule
+	// patterns are the routes of rules with parameters, in order
+	patterns []string
+	buckets  map[string]*bucket
+	swept    time.Time
+	now      func() time.Time
+	mu       sync.Mutex
+}
+
+// New makes a limiter with the rules of routes like "POST /api/v1/ads", or
+// with parameters like "POST /api/v1/conversations/:conversation_id/messages",
+// which is the rule of the requests to the paths it matches.
+func New(rules map[string]Rule) *Limiter {
+	var patterns []string
+	for route := range rules {
+		if strings.Contains(route, "/:") {
+			patterns = append(patterns, route)
+		}
+	}
+	sort.Strings(patterns)
+
+	return &Limiter{rules: rules, patterns: patterns, buckets: make(map[string]*bucket), now: time.Now}
+}
+
+// Allow takes a token from the bucket of the identity on the route. When the
+// bucket is empty it reports how long to wait for the next token.
+func (l *Limiter) Allow(route string, identity string) (bool, time.Duration) {
+	route, rule, limited := l.rule(route)
+	if !limited {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	key := route + "|" + identity
+	b, exists := l.buckets[key]
+	if !exists {
+		b = &bucket{rule: rule, tokens: float64(rule.Burst), updated: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = math.Min(float64(rule.Burst), b.tokens+now.Sub(b.updated).Seconds()*rule.Rate)
+	b.updated = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	if rule.Rate <= 0 {
+		return false, time.Duration(math.MaxInt64)
+	}
+
+	return false, time.Duration((1 - b.tokens) / rule.Rate * float64(time.Second))
+}
+
+// rule returns the rule of the route and the route it is given for, which
+// names the buckets, so that all the paths of a pattern share them.
+func (l *Limiter) rule(route string) (string, Rule, bool) {
+	if rule, ok := l.rules[route]; ok {
+		return route, rule, true
+	}
+
+	segments := strings.Split(route, "/")
+	for _, pattern := range l.patterns {
+		if matches(strings.Split(pattern, "/"), segments) {
+			return pattern, l.rules[pattern], true
+		}
+	}
+
+	return route, Rule{}, false
+}
+
+// matches tells whether the segments of a path match the ones of a pattern,
+// where a parameter like ":ad_id" matches any segment but an empty one.
+func matches(pattern []string, segments []string) bool {
+	if len(pattern) != len(segments) {
+		return false
+	}
+
+	for i, p := range pattern {
+		if strings.HasPrefix(p, ":") {
+			if segments[i] == "" {
+				return false
+			}
+		} else if p != segments[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// sweep drops the buckets that have been refilled completely, since a new
+// bucket would be in the same state. It runs at most once a minute.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.swept) < time.Minute {
+		return
+	}
+	l.swept = now
+
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.updated).Seconds()*b.rule.Rate >= float64(b.rule.Burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
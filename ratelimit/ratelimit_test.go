@@ -0,0 +1,89 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiter_Allow(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	limiter := New(map[string]Rule{"POST /ads": {Rate: 1, Burst: 2}})
+	limiter.now = func() time.Time { return now }
+
+	type Test struct {
+		Name       string
+		Route      string
+		Identity   string
+		Advance    time.Duration
+		Allowed    bool
+		RetryAfter time.Duration
+	}
+
+	tests := [...]Test{
+		{"First request", "POST /ads", "user:1", 0, true, 0},
+		{"Second request within burst", "POST /ads", "user:1", 0, true, 0},
+		{"Bucket is empty", "POST /ads", "user:1", 0, false, time.Second},
+		{"Half of the token refilled", "POST /ads", "user:1", 500 * time.Millisecond, false, 500 * time.Millisecond},
+		{"Token refilled", "POST /ads", "user:1", 500 * time.Millisecond, true, 0},
+		{"Another identity", "POST /ads", "user:2", 0, true, 0},
+		{"Route without a rule", "GET /ads", "user:1", 0, true, 0},
+	}
+
+	for _, test := range tests {
+		now = now.Add(test.Advance)
+
+		allowed, retryAfter := limiter.Allow(test.Route, test.Identity)
+		if allowed != test.Allowed || retryAfter != test.RetryAfter {
+			t.Fatalf(`test %q: expect (%v, %v) got (%v, %v)`, test.Name, test.Allowed, test.RetryAfter, allowed, retryAfter)
+		}
+	}
+}
+
+func TestLimiter_Patterns(t *testing.T) {
+	limiter := New(map[string]Rule{
+		"PUT /ads/:ad_id/status": {Rate: 0, Burst: 1},
+		"PUT /ads/1/status":      {Rate: 0, Burst: 2},
+	})
+
+	type Test struct {
+		Name    string
+		Route   string
+		Allowed bool
+	}
+
+	tests := [...]Test{
+		{"Path of the pattern", "PUT /ads/0/status", true},
+		{"Another path of the pattern", "PUT /ads/2/status", false},
+		{"Route of its own", "PUT /ads/1/status", true},
+		{"Empty parameter", "PUT /ads//status", true},
+		{"Longer path", "PUT /ads/0/status/1", true},
+		{"Another method", "GET /ads/0/status", true},
+	}
+
+	for _, test := range tests {
+		allowed, _ := limiter.Allow(test.Route, "user:1")
+		if allowed != test.Allowed {
+			t.Fatalf(`test %q: expect %v got %v`, test.Name, test.Allowed, allowed)
+		}
+	}
+}
+
+func TestLimiter_Sweep(t *testing.T) {
+	now := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	limiter := New(map[string]Rule{"POST /ads": {Rate: 1, Burst: 1}})
+	limiter.now = func() time.Time { return now }
+
+	allowed, _ := limiter.Allow("POST /ads", "ip:127.0.0.1")
+	assert.True(t, allowed)
+	assert.Len(t, limiter.buckets, 1)
+
+	now = now.Add(2 * time.Minute)
+
+	allowed, _ = limiter.Allow("POST /ads", "ip:127.0.0.2")
+	assert.True(t, allowed)
+	assert.Len(t, limiter.buckets, 1)
+}
@@ -0,0 +1,36 @@
+// Package replication describes how the repositories of a primary instance
+// are followed by read replicas: the primary numbers every change, and a
+// replica applies them in the same order to a local copy.
+package replication
+
+import "github.com/pkg/errors"
+
+// Change is an encoded change of a repository numbered seq. A dump of the
+// whole repository is a run of changes with the number of the last change
+// it includes.
+type Change struct {
+	Seq  int64
+	Data []byte
+}
+
+// Behind is returned for a position the primary can't continue from: the
+// changes after it are no longer kept, or it belongs to another epoch.
+var Behind = errors.New("the changes after this position are not available")
+
+// Source is a repository of the primary. Its epoch changes whenever the
+// numbering of its changes starts over, e.g. on a restart.
+type Source interface {
+	Position() (epoch string, seq int64)
+	// Since returns the changes after seq and a channel closed as soon as
+	// there is a newer one.
+	Since(epoch string, seq int64) ([]Change, <-chan struct{}, error)
+	// Dump returns the changes rebuilding the repository at its position.
+	Dump() ([]Change, error)
+}
+
+// Sink is a repository of a replica. Its position is the one of the primary
+// change it applied last.
+type Sink interface {
+	Position() (epoch string, seq int64)
+	Apply(epoch string, c Change) error
+}
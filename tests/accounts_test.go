@@ -0,0 +1,518 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+
+	"homework10/internal/adapters/repo"
+	"homework10/internal/app"
+	"homework10/internal/password"
+	grpcPort "homework10/internal/ports/grpc"
+	"homework10/internal/ports/httpgin"
+	"homework10/internal/users"
+)
+
+type sessionData struct {
+	ID             int64     `json:"id"`
+	UserID         int64     `json:"user_id"`
+	Token          string    `json:"token"`
+	CreatedAt      time.Time `json:"creation_time"`
+	ExpirationTime time.Time `json:"expiration_time"`
+}
+
+type sessionResponse struct {
+	Data sessionData `json:"data"`
+}
+
+// mailbox keeps the mails of the app instead of sending them.
+type mailbox struct {
+	mu    sync.Mutex
+	mails map[string][]string
+}
+
+func (m *mailbox) Mail(_ context.Context, user users.User, _ string, text string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.mails[user.Email] = append(m.mails[user.Email], text)
+	return nil
+}
+
+func (m *mailbox) last(email string) (string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	mails := m.mails[email]
+	if len(mails) == 0 {
+		return "", false
+	}
+	return mails[len(mails)-1], true
+}
+
+// resetToken is the token of a password reset mail, the line with a dot.
+func resetToken(mail string) string {
+	for _, line := range strings.Split(mail, "\n") {
+		if strings.Contains(line, ".") && !strings.Contains(line, " ") {
+			return line
+		}
+	}
+	return ""
+}
+
+func newAccountsApp(mailer app.Mailer, opts ...app.Option) app.App {
+	return app.NewApp(repo.New(), repo.New(), repo.New(), append([]app.Option{
+		app.WithHasher(testHasher),
+		app.WithMailer(mailer),
+		app.WithAccountPolicy(app.AccountPolicy{SessionTTL: time.Hour, ResetTTL: time.Hour, MaxFailedLogins: 3, Lockout: time.Hour}),
+	}, opts...)...)
+}
+
+func getAccountsTestClient(mailer app.Mailer, opts ...app.Option) *testClient {
+	server := httpgin.NewHTTPServer(":18080", newAccountsApp(mailer, opts...))
+	testServer := httptest.NewServer(server.Handler)
+
+	return &testClient{
+		client:  testServer.Client(),
+		BaseURL: testServer.URL,
+	}
+}
+
+func (tc *testClient) send(method string, path string, token string, body map[string]any, out any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("unable to marshal: %w", err)
+	}
+
+	req, err := http.NewRequest(method, tc.BaseURL+"/api/v1"+path, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("unable to create request: %w", err)
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+	withToken(req, token)
+
+	return tc.getResponse(req, out)
+}
+
+func (tc *testClient) login(email string, pass string) (sessionResponse, error) {
+	var response sessionResponse
+	err := tc.send(http.MethodPost, "/sessions", "", map[string]any{"email": email, "password": pass}, &response)
+	return response, err
+}
+
+// createAccount registers a user who can log in and returns the token of a
+// session of the user.
+func (tc *testClient) createAccount(name string, email string) (userResponse, string, error) {
+	var user userResponse
+	err := tc.send(http.MethodPost, "/accounts", "", map[string]any{"name": name, "email": email, "password": "correct horse"}, &user)
+	if err != nil {
+		return userResponse{}, "", err
+	}
+
+	session, err := tc.login(email, "correct horse")
+	return user, session.Data.Token, err
+}
+
+func TestAccounts(t *testing.T) {
+	client := getAccountsTestClient(&mailbox{mails: map[string][]string{}})
+
+	var user userResponse
+	err := client.send(http.MethodPost, "/accounts", "",
+		map[string]any{"name": "Oleg", "email": "oleg@testing.ru", "password": "correct horse"}, &user)
+	assert.NoError(t, err)
+	assert.Equal(t, "oleg@testing.ru", user.Data.Email)
+
+	err = client.send(http.MethodPost, "/accounts", "",
+		map[string]any{"name": "Ivan", "email": "OLEG@testing.ru", "password": "battery staple"}, &user)
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	err = client.send(http.MethodPost, "/accounts", "",
+		map[string]any{"name": "Ivan", "email": "ivan@testing.ru", "password": "short"}, &user)
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	err = client.send(http.MethodPost, "/accounts", "",
+		map[string]any{"name": "Ivan", "email": "ivan", "password": "battery staple"}, &user)
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	_, err = client.login("oleg@testing.ru", "wrong horse")
+	assert.ErrorIs(t, err, ErrUnauthorized)
+
+	_, err = client.login("ivan@testing.ru", "correct horse")
+	assert.ErrorIs(t, err, ErrUnauthorized)
+
+	session, err := client.login("oleg@testing.ru", "correct horse")
+	assert.NoError(t, err)
+	assert.Equal(t, user.Data.ID, session.Data.UserID)
+	assert.NotEmpty(t, session.Data.Token)
+	assert.True(t, session.Data.ExpirationTime.After(session.Data.CreatedAt))
+
+	path := fmt.Sprintf("/users/%d", user.Data.ID)
+
+	var owner userResponse
+	err = client.send(http.MethodGet, path, session.Data.Token, nil, &owner)
+	assert.NoError(t, err)
+	assert.Equal(t, "oleg@testing.ru", owner.Data.Email)
+
+	err = client.send(http.MethodGet, path, "garbage", nil, &owner)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+
+	err = client.send(http.MethodGet, path, fmt.Sprintf("%d.%s", user.Data.ID, "not-a-secret"), nil, &owner)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+
+	var empty any
+	err = client.send(http.MethodPut, path+"/password", "",
+		map[string]any{"old_password": "wrong horse", "new_password": "battery staple"}, &empty)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+
+	err = client.send(http.MethodPut, path+"/password", "",
+		map[string]any{"old_password": "correct horse", "new_password": "battery staple"}, &empty)
+	assert.NoError(t, err)
+
+	// a new password ends the sessions
+	err = client.send(http.MethodDelete, "/sessions", session.Data.Token, nil, &empty)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+
+	_, err = client.login("oleg@testing.ru", "correct horse")
+	assert.ErrorIs(t, err, ErrUnauthorized)
+
+	session, err = client.login("oleg@testing.ru", "battery staple")
+	assert.NoError(t, err)
+
+	err = client.send(http.MethodDelete, "/sessions", session.Data.Token, nil, &empty)
+	assert.NoError(t, err)
+
+	err = client.send(http.MethodDelete, "/sessions", session.Data.Token, nil, &empty)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+
+	err = client.send(http.MethodDelete, "/sessions", "", nil, &empty)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+}
+
+func TestAccountLockout(t *testing.T) {
+	client := getAccountsTestClient(&mailbox{mails: map[string][]string{}})
+
+	var user userResponse
+	err := client.send(http.MethodPost, "/accounts", "",
+		map[string]any{"name": "Oleg", "email": "oleg@testing.ru", "password": "correct horse"}, &user)
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, err = client.login("oleg@testing.ru", "wrong horse")
+		assert.ErrorIs(t, err, ErrUnauthorized, i)
+	}
+
+	// even the right password is refused while the account is locked
+	_, err = client.login("oleg@testing.ru", "correct horse")
+	assert.ErrorIs(t, err, ErrTooManyRequests)
+
+	var empty any
+	err = client.send(http.MethodPut, fmt.Sprintf("/users/%d/password", user.Data.ID), "",
+		map[string]any{"old_password": "correct horse", "new_password": "battery staple"}, &empty)
+	assert.ErrorIs(t, err, ErrTooManyRequests)
+}
+
+// slowHasher takes its time to hash and verify a password, so that the
+// registrations and the logins overlap.
+type slowHasher struct {
+	password.Hasher
+}
+
+func (h slowHasher) Hash(pass string) (string, error) {
+	time.Sleep(20 * time.Millisecond)
+	return h.Hasher.Hash(pass)
+}
+
+func (h slowHasher) Verify(hash string, pass string) error {
+	time.Sleep(20 * time.Millisecond)
+	return h.Hasher.Verify(hash, pass)
+}
+
+func TestAccountLockout_Concurrent(t *testing.T) {
+	client := getAccountsTestClient(&mailbox{mails: map[string][]string{}}, app.WithHasher(slowHasher{testHasher}))
+
+	for i := 0; i < 10; i++ {
+		email := fmt.Sprintf("oleg%d@testing.ru", i)
+
+		var user userResponse
+		err := client.send(http.MethodPost, "/accounts", "",
+			map[string]any{"name": "Oleg", "email": email, "password": "correct horse"}, &user)
+		assert.NoError(t, err)
+
+		// every failed login counts, however many run at the same time
+		var wg sync.WaitGroup
+		for j := 0; j < 3; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := client.login(email, "wrong horse")
+				assert.ErrorIs(t, err, ErrUnauthorized, email)
+			}()
+		}
+		wg.Wait()
+
+		_, err = client.login(email, "correct horse")
+		assert.ErrorIs(t, err, ErrTooManyRequests, email)
+	}
+}
+
+func TestAccountEmail(t *testing.T) {
+	mails := &mailbox{mails: map[string][]string{}}
+	client := getAccountsTestClient(mails)
+
+	var user, other userResponse
+	err := client.send(http.MethodPost, "/accounts", "",
+		map[string]any{"name": "Oleg", "email": "oleg@testing.ru", "password": "correct horse"}, &user)
+	assert.NoError(t, err)
+	err = client.send(http.MethodPost, "/accounts", "",
+		map[string]any{"name": "Ivan", "email": "ivan@testing.ru", "password": "battery staple"}, &other)
+	assert.NoError(t, err)
+
+	session, err := client.login("oleg@testing.ru", "correct horse")
+	assert.NoError(t, err)
+	stranger, err := client.login("ivan@testing.ru", "battery staple")
+	assert.NoError(t, err)
+
+	path := fmt.Sprintf("/users/%d", user.Data.ID)
+	var updated userResponse
+
+	// else anyone could take the account over by a password reset
+	_, err = client.updateUser(user.Data.ID, "Oleg", "thief@testing.ru")
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	err = client.patch(path, `{"email":"thief@testing.ru"}`, &updated)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	err = client.patchAs(path, stranger.Data.Token, `{"email":"thief@testing.ru"}`, &updated)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	var empty any
+	err = client.send(http.MethodPost, "/password-resets", "", map[string]any{"email": "thief@testing.ru"}, &empty)
+	assert.NoError(t, err)
+	_, found := mails.last("thief@testing.ru")
+	assert.False(t, found)
+
+	// nor any other field
+	_, err = client.updateUser(user.Data.ID, "Oleg P.", "oleg@testing.ru")
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	err = client.patch(path, `{"name":"Oleg P."}`, &updated)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	err = client.patchAs(path, session.Data.Token, `{"name":"Oleg P."}`, &updated)
+	assert.NoError(t, err)
+	assert.Equal(t, "Oleg P.", updated.Data.Name)
+
+	err = client.patchAs(path, session.Data.Token, `{"email":"IVAN@testing.ru"}`, &updated)
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	err = client.patchAs(path, session.Data.Token, `{"email":"oleg"}`, &updated)
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	err = client.patchAs(path, session.Data.Token, `{"email":"oleg@example.com"}`, &updated)
+	assert.NoError(t, err)
+	assert.Equal(t, "oleg@example.com", updated.Data.Email)
+
+	_, err = client.login("oleg@example.com", "correct horse")
+	assert.NoError(t, err)
+}
+
+func TestAccountDelete(t *testing.T) {
+	client := getAccountsTestClient(&mailbox{mails: map[string][]string{}})
+
+	user, token, err := client.createAccount("Oleg", "oleg@testing.ru")
+	assert.NoError(t, err)
+
+	_, stranger, err := client.createAccount("Ivan", "ivan@testing.ru")
+	assert.NoError(t, err)
+
+	path := fmt.Sprintf("/users/%d", user.Data.ID)
+
+	var deleted userResponse
+	err = client.send(http.MethodDelete, path, "", nil, &deleted)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	err = client.send(http.MethodDelete, path, stranger, nil, &deleted)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	err = client.send(http.MethodDelete, path, token, nil, &deleted)
+	assert.NoError(t, err)
+
+	_, err = client.getUser(user.Data.ID, "")
+	assert.ErrorIs(t, err, ErrBadRequest)
+}
+
+func TestRegister_Concurrent(t *testing.T) {
+	client := getAccountsTestClient(&mailbox{mails: map[string][]string{}}, app.WithHasher(slowHasher{testHasher}))
+
+	// an email is taken by one account only, however many register at once
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	registered := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var user userResponse
+			err := client.send(http.MethodPost, "/accounts", "",
+				map[string]any{"name": "Oleg", "email": "oleg@testing.ru", "password": "correct horse"}, &user)
+			if err != nil {
+				assert.ErrorIs(t, err, ErrBadRequest)
+				return
+			}
+			mu.Lock()
+			registered++
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, registered)
+}
+
+func TestPasswordReset(t *testing.T) {
+	mails := &mailbox{mails: map[string][]string{}}
+	client := getAccountsTestClient(mails)
+
+	var user userResponse
+	err := client.send(http.MethodPost, "/accounts", "",
+		map[string]any{"name": "Oleg", "email": "oleg@testing.ru", "password": "correct horse"}, &user)
+	assert.NoError(t, err)
+
+	// lock the account, a reset unlocks it
+	for i := 0; i < 3; i++ {
+		_, _ = client.login("oleg@testing.ru", "wrong horse")
+	}
+
+	var empty any
+	err = client.send(http.MethodPost, "/password-resets", "", map[string]any{"email": "ivan@testing.ru"}, &empty)
+	assert.NoError(t, err)
+	_, found := mails.last("ivan@testing.ru")
+	assert.False(t, found)
+
+	err = client.send(http.MethodPost, "/password-resets", "", map[string]any{"email": "oleg@testing.ru"}, &empty)
+	assert.NoError(t, err)
+	mail, found := mails.last("oleg@testing.ru")
+	assert.True(t, found)
+
+	token := resetToken(mail)
+	assert.True(t, strings.HasPrefix(token, fmt.Sprintf("%d.", user.Data.ID)), mail)
+
+	err = client.send(http.MethodPost, "/password-resets/confirm", "",
+		map[string]any{"token": token + "x", "new_password": "battery staple"}, &empty)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+
+	err = client.send(http.MethodPost, "/password-resets/confirm", "",
+		map[string]any{"token": token, "new_password": "short"}, &empty)
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	err = client.send(http.MethodPost, "/password-resets/confirm", "",
+		map[string]any{"token": token, "new_password": "battery staple"}, &empty)
+	assert.NoError(t, err)
+
+	err = client.send(http.MethodPost, "/password-resets/confirm", "",
+		map[string]any{"token": token, "new_password": "another staple"}, &empty)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+
+	_, err = client.login("oleg@testing.ru", "correct horse")
+	assert.ErrorIs(t, err, ErrUnauthorized)
+
+	_, err = client.login("oleg@testing.ru", "battery staple")
+	assert.NoError(t, err)
+}
+
+func TestGRPCAccounts(t *testing.T) {
+	lis := bufconn.Listen(1024 * 1024)
+	t.Cleanup(func() {
+		lis.Close()
+	})
+
+	srv := grpc.NewServer()
+	t.Cleanup(func() {
+		srv.Stop()
+	})
+
+	grpcPort.RegisterAdServiceServer(srv, grpcPort.NewService(newAccountsApp(&mailbox{mails: map[string][]string{}})))
+
+	go func() {
+		assert.NoError(t, srv.Serve(lis), "srv.Serve")
+	}()
+
+	dialer := func(context.Context, string) (net.Conn, error) {
+		return lis.Dial()
+	}
+
+	conn, err := grpc.NewClient("passthrough:///bufnet", grpc.WithContextDialer(dialer), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(t, err, "grpc.NewClient")
+	t.Cleanup(func() {
+		conn.Close()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	t.Cleanup(cancel)
+
+	client := grpcPort.NewAdServiceClient(conn)
+
+	user, err := client.Register(ctx, &grpcPort.RegisterRequest{Name: "Oleg", Email: "oleg@testing.ru", Password: "correct horse"})
+	assert.NoError(t, err)
+
+	_, err = client.Login(ctx, &grpcPort.LoginRequest{Email: "oleg@testing.ru", Password: "wrong horse"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	session, err := client.Login(ctx, &grpcPort.LoginRequest{Email: "oleg@testing.ru", Password: "correct horse"})
+	assert.NoError(t, err)
+	assert.Equal(t, user.Id, session.UserId)
+
+	authorized := metadata.AppendToOutgoingContext(ctx, grpcPort.AuthorizationMetadata, "Bearer "+session.Token)
+
+	owner, err := client.GetUser(authorized, &grpcPort.GetUserRequest{Id: user.Id})
+	assert.NoError(t, err)
+	assert.Equal(t, "oleg@testing.ru", owner.Email)
+
+	public, err := client.GetUser(ctx, &grpcPort.GetUserRequest{Id: user.Id})
+	assert.NoError(t, err)
+	assert.Empty(t, public.Email)
+
+	_, err = client.UpdateUser(ctx, &grpcPort.UpdateUserRequest{Id: user.Id, Name: "Oleg", Email: "thief@testing.ru"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = client.UpdateUser(authorized, &grpcPort.UpdateUserRequest{Id: user.Id, Name: "Oleg", Email: "oleg"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	updated, err := client.UpdateUser(authorized, &grpcPort.UpdateUserRequest{Id: user.Id, Name: "Oleg", Email: "oleg@example.com"})
+	assert.NoError(t, err)
+	assert.Equal(t, "oleg@example.com", updated.Email)
+
+	_, err = client.UpdateUser(ctx, &grpcPort.UpdateUserRequest{Id: user.Id, Name: "Ivan",
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"name"}}})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = client.DeleteUser(ctx, &grpcPort.DeleteUserRequest{Id: user.Id})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = client.Logout(authorized, &grpcPort.LogoutRequest{})
+	assert.NoError(t, err)
+
+	_, err = client.Logout(authorized, &grpcPort.LogoutRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = client.GetUser(authorized, &grpcPort.GetUserRequest{Id: user.Id})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
@@ -1,7 +1,9 @@
 package tests
 
 import (
+	"fmt"
 	"github.com/stretchr/testify/assert"
+	"homework10/internal/app"
 	"testing"
 )
 
@@ -178,6 +180,78 @@ func TestDeleteAd(t *testing.T) {
 	assert.ErrorIs(t, err, ErrBadRequest)
 }
 
+func TestListAdRevisions(t *testing.T) {
+	client := GetTestClient()
+
+	createdUser, err := client.CreateUser("Test User", "test@testing.ru")
+	assert.NoError(t, err)
+
+	response, err := client.CreateAd(createdUser.Data.ID, "hello", "world")
+	assert.NoError(t, err)
+
+	_, err = client.updateAd(createdUser.Data.ID, response.Data.ID, "привет", "мир")
+	assert.NoError(t, err)
+
+	revisions, err := client.listAdRevisions(response.Data.ID)
+	assert.NoError(t, err)
+	assert.Len(t, revisions.Data, 2)
+	assert.Equal(t, revisions.Data[0].Title, "hello")
+	assert.Equal(t, revisions.Data[0].Text, "world")
+	assert.Equal(t, revisions.Data[1].Title, "привет")
+	assert.Equal(t, revisions.Data[1].Text, "мир")
+	assert.True(t, revisions.Data[0].ID < revisions.Data[1].ID)
+	assert.False(t, revisions.Data[1].UpdatedAt.IsZero())
+}
+
+func TestListAdRevisions_Limit(t *testing.T) {
+	client := GetTestClient()
+
+	createdUser, err := client.CreateUser("Test User", "test@testing.ru")
+	assert.NoError(t, err)
+
+	response, err := client.CreateAd(createdUser.Data.ID, "hello", "world")
+	assert.NoError(t, err)
+
+	for i := 0; i < app.DefaultRevisionLimit; i++ {
+		_, err = client.updateAd(createdUser.Data.ID, response.Data.ID, fmt.Sprintf("title %d", i), "text")
+		assert.NoError(t, err)
+	}
+
+	revisions, err := client.listAdRevisions(response.Data.ID)
+	assert.NoError(t, err)
+	assert.Len(t, revisions.Data, app.DefaultRevisionLimit)
+	assert.Equal(t, revisions.Data[0].Title, "title 0")
+}
+
+func TestRollbackAd(t *testing.T) {
+	client := GetTestClient()
+
+	createdUser, err := client.CreateUser("Test User", "test@testing.ru")
+	assert.NoError(t, err)
+
+	response, err := client.CreateAd(createdUser.Data.ID, "hello", "world")
+	assert.NoError(t, err)
+
+	_, err = client.updateAd(createdUser.Data.ID, response.Data.ID, "привет", "мир")
+	assert.NoError(t, err)
+
+	revisions, err := client.listAdRevisions(response.Data.ID)
+	assert.NoError(t, err)
+
+	response, err = client.rollbackAd(createdUser.Data.ID, response.Data.ID, revisions.Data[0].ID)
+	assert.NoError(t, err)
+	assert.Equal(t, response.Data.Title, "hello")
+	assert.Equal(t, response.Data.Text, "world")
+
+	revisions, err = client.listAdRevisions(response.Data.ID)
+	assert.NoError(t, err)
+	assert.Len(t, revisions.Data, 3)
+	assert.Equal(t, revisions.Data[2].Title, "hello")
+
+	_, err = client.rollbackAd(createdUser.Data.ID, response.Data.ID, 100)
+	assert.ErrorIs(t, err, ErrBadRequest)
+}
+
 func TestCreateUser(t *testing.T) {
 	client := GetTestClient()
 
@@ -198,7 +272,8 @@ func TestUpdateUser(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Zero(t, response.Data.ID)
 	assert.Equal(t, response.Data.Name, "Test User 2")
-	assert.Equal(t, response.Data.Email, "test2@testing.ru")
+	// only the owner signed in sees the email
+	assert.Empty(t, response.Data.Email)
 }
 
 func TestGetUser(t *testing.T) {
@@ -210,11 +285,11 @@ func TestGetUser(t *testing.T) {
 	response, err := client.updateUser(createdUser.Data.ID, "Test User 2", "test2@testing.ru")
 	assert.NoError(t, err)
 
-	user, err := client.getUser(response.Data.ID)
+	user, err := client.getUser(response.Data.ID, "")
 	assert.NoError(t, err)
 	assert.Equal(t, user.Data.ID, response.Data.ID)
 	assert.Equal(t, user.Data.Name, response.Data.Name)
-	assert.Equal(t, user.Data.Email, response.Data.Email)
+	assert.Empty(t, user.Data.Email)
 }
 
 func TestDeleteUser(t *testing.T) {
@@ -229,6 +304,6 @@ func TestDeleteUser(t *testing.T) {
 	err = client.deleteUser(response.Data.ID)
 	assert.NoError(t, err)
 
-	_, err = client.getUser(response.Data.ID)
+	_, err = client.getUser(response.Data.ID, "")
 	assert.ErrorIs(t, err, ErrBadRequest)
 }
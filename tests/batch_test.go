@@ -0,0 +1,141 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type batchAdResult struct {
+	Status int     `json:"status"`
+	Data   *adData `json:"data"`
+	Error  *string `json:"error"`
+}
+
+type batchAdsResponse struct {
+	Data struct {
+		Create       []batchAdResult `json:"create"`
+		ChangeStatus []batchAdResult `json:"change_status"`
+		Get          []batchAdResult `json:"get"`
+	} `json:"data"`
+}
+
+func (tc *testClient) batchAds(body map[string]any) (batchAdsResponse, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return batchAdsResponse{}, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, tc.BaseURL+"/api/v1/ads:batch", bytes.NewReader(data))
+	if err != nil {
+		return batchAdsResponse{}, err
+	}
+	req.Header.Add("Content-Type", "application/json")
+
+	var response batchAdsResponse
+	err = tc.getResponse(req, &response)
+
+	return response, err
+}
+
+func TestBatchAds(t *testing.T) {
+	client := GetTestClient()
+
+	author, err := client.CreateUser("Oleg", "oleg@testing.ru")
+	assert.NoError(t, err)
+	stranger, err := client.CreateUser("Ivan", "ivan@testing.ru")
+	assert.NoError(t, err)
+
+	existing, err := client.CreateAd(author.Data.ID, "hello", "world")
+	assert.NoError(t, err)
+
+	resp, err := client.batchAds(map[string]any{
+		"create": []map[string]any{
+			{"user_id": author.Data.ID, "title": "first", "text": "text"},
+			{"user_id": author.Data.ID, "title": "", "text": "text"},
+			{"user_id": 7, "title": "second", "text": "text"},
+		},
+		"change_status": []map[string]any{
+			{"ad_id": existing.Data.ID, "user_id": author.Data.ID, "published": true},
+			{"ad_id": existing.Data.ID, "user_id": stranger.Data.ID, "published": false},
+		},
+		"get": []int64{existing.Data.ID, 7},
+	})
+	assert.NoError(t, err)
+
+	assert.Len(t, resp.Data.Create, 3)
+	assert.Equal(t, http.StatusOK, resp.Data.Create[0].Status)
+	assert.Equal(t, "first", resp.Data.Create[0].Data.Title)
+	assert.Equal(t, http.StatusBadRequest, resp.Data.Create[1].Status)
+	assert.Nil(t, resp.Data.Create[1].Data)
+	assert.NotNil(t, resp.Data.Create[1].Error)
+	assert.Equal(t, http.StatusBadRequest, resp.Data.Create[2].Status)
+
+	assert.Equal(t, http.StatusOK, resp.Data.ChangeStatus[0].Status)
+	assert.Equal(t, http.StatusForbidden, resp.Data.ChangeStatus[1].Status)
+
+	assert.True(t, resp.Data.Get[0].Data.Published)
+	assert.Equal(t, http.StatusBadRequest, resp.Data.Get[1].Status)
+
+	ad, err := client.getAd(resp.Data.Create[0].Data.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "first", ad.Data.Title)
+
+	resp, err = client.batchAds(map[string]any{})
+	assert.NoError(t, err)
+	assert.Empty(t, resp.Data.Create)
+
+	_, err = client.batchAds(map[string]any{"get": make([]int64, 101)})
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	req, err := http.NewRequest(http.MethodPost, client.BaseURL+"/api/v1/ads:other", bytes.NewReader([]byte("{}")))
+	assert.NoError(t, err)
+	err = client.getResponse(req, &resp)
+	assert.EqualError(t, err, "unexpected status code: 404 Not Found")
+}
+
+func TestBatchAds_Concurrent(t *testing.T) {
+	client := GetTestClient()
+
+	author, err := client.CreateUser("Oleg", "oleg@testing.ru")
+	assert.NoError(t, err)
+
+	// every created ad gets an ID of its own and is stored under it
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	created := map[int64]string{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			drafts := make([]map[string]any, 5)
+			for j := range drafts {
+				drafts[j] = map[string]any{"user_id": author.Data.ID, "title": fmt.Sprintf("ad %d-%d", i, j), "text": "text"}
+			}
+
+			resp, err := client.batchAds(map[string]any{"create": drafts})
+			assert.NoError(t, err)
+
+			mu.Lock()
+			defer mu.Unlock()
+			for _, result := range resp.Data.Create {
+				assert.NotContains(t, created, result.Data.ID)
+				created[result.Data.ID] = result.Data.Title
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, created, 50)
+	for id, title := range created {
+		ad, err := client.getAd(id)
+		assert.NoError(t, err)
+		assert.Equal(t, id, ad.Data.ID)
+		assert.Equal(t, title, ad.Data.Title)
+	}
+}
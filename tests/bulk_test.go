@@ -0,0 +1,129 @@
+package tests
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"homework10/internal/ports/httpgin"
+)
+
+// adminToken is sent by importRecords and exportRecords, see adminClient.
+const adminToken = "0123456789abcdef0123456789abcdef"
+
+func adminClient() *testClient {
+	return GetTestClient(httpgin.AdminMW(httpgin.AdminAuth{Token: adminToken}))
+}
+
+type reportData struct {
+	DryRun   bool `json:"dry_run"`
+	Total    int  `json:"total"`
+	Imported int  `json:"imported"`
+	Failed   int  `json:"failed"`
+	Errors   []struct {
+		Line  int    `json:"line"`
+		Error string `json:"error"`
+	} `json:"errors"`
+}
+
+type reportResponse struct {
+	Data reportData `json:"data"`
+}
+
+func (tc *testClient) importRecords(kind string, query string, body string) (reportResponse, error) {
+	req, err := http.NewRequest(http.MethodPost, tc.BaseURL+"/api/v1/admin/import/"+kind+query, strings.NewReader(body))
+	if err != nil {
+		return reportResponse{}, err
+	}
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+
+	var response reportResponse
+	err = tc.getResponse(req, &response)
+
+	return response, err
+}
+
+func (tc *testClient) exportRecords(kind string, query string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, tc.BaseURL+"/api/v1/admin/export/"+kind+query, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+
+	return tc.client.Do(req)
+}
+
+func TestBulkImportExport(t *testing.T) {
+	client := adminClient()
+
+	report, err := client.importRecords("users", "?format=csv", "name,email\nOleg,oleg@testing.ru\n")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Data.Imported)
+
+	report, err = client.importRecords("ads", "?dry_run=true", `{"title":"hello","text":"world","author_id":0,"published":true}`+"\n"+`{"title":"hello","text":"world","author_id":1}`)
+	assert.NoError(t, err)
+	assert.True(t, report.Data.DryRun)
+	assert.Equal(t, 2, report.Data.Total)
+	assert.Equal(t, 1, report.Data.Failed)
+	assert.Equal(t, 2, report.Data.Errors[0].Line)
+
+	ads, err := client.filterListAds("?published=true")
+	assert.NoError(t, err)
+	assert.Len(t, ads.Data, 0)
+
+	report, err = client.importRecords("ads", "", `{"title":"hello","text":"world","author_id":0,"published":true}`)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Data.Imported)
+
+	ads, err = client.filterListAds("?published=true")
+	assert.NoError(t, err)
+	assert.Len(t, ads.Data, 1)
+
+	resp, err := client.exportRecords("ads", "?format=csv")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "text/csv; charset=utf-8", resp.Header.Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="ads.csv"`, resp.Header.Get("Content-Disposition"))
+	assert.True(t, strings.HasPrefix(string(body), "id,title,text,author_id,published,"), string(body))
+	assert.Contains(t, string(body), "\n0,hello,world,0,true,")
+}
+
+func TestBulkErrors(t *testing.T) {
+	client := adminClient()
+
+	_, err := client.importRecords("messages", "", "")
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	_, err = client.importRecords("ads", "?format=xml", "")
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	_, err = client.importRecords("ads", "?format=csv", "title,author_id\nhello,0\n")
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	resp, err := client.exportRecords("ads", "?format=xml")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestBulkAdminOnly(t *testing.T) {
+	for _, client := range []*testClient{
+		GetTestClient(),
+		GetTestClient(httpgin.AdminMW(httpgin.AdminAuth{})),
+		GetTestClient(httpgin.AdminMW(httpgin.AdminAuth{Token: "fedcba9876543210fedcba9876543210"})),
+	} {
+		_, err := client.importRecords("users", "?format=csv", "name,email\nOleg,oleg@testing.ru\n")
+		assert.ErrorIs(t, err, ErrForbidden)
+
+		resp, err := client.exportRecords("users", "")
+		assert.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	}
+}
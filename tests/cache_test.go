@@ -0,0 +1,60 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (tc *testClient) getWithETag(t *testing.T, path string, etag string) *http.Response {
+	req, err := http.NewRequest(http.MethodGet, tc.BaseURL+"/api/v1"+path, nil)
+	assert.NoError(t, err)
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	resp, err := tc.client.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	return resp
+}
+
+func TestConditionalGet(t *testing.T) {
+	client := GetTestClient()
+
+	user, err := client.CreateUser("Oleg", "oleg@testing.ru")
+	assert.NoError(t, err)
+
+	ad, err := client.CreateAd(user.Data.ID, "hello", "world")
+	assert.NoError(t, err)
+
+	path := fmt.Sprintf("/ads/%d", ad.Data.ID)
+
+	first := client.getWithETag(t, path, "")
+	assert.Equal(t, http.StatusOK, first.StatusCode)
+	assert.Equal(t, "no-cache", first.Header.Get("Cache-Control"))
+	etag := first.Header.Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	notModified := client.getWithETag(t, path, `"other", W/`+etag)
+	assert.Equal(t, http.StatusNotModified, notModified.StatusCode)
+	assert.Equal(t, etag, notModified.Header.Get("ETag"))
+
+	_, err = client.updateAd(user.Data.ID, ad.Data.ID, "bye", "world")
+	assert.NoError(t, err)
+
+	modified := client.getWithETag(t, path, etag)
+	assert.Equal(t, http.StatusOK, modified.StatusCode)
+	assert.NotEqual(t, etag, modified.Header.Get("ETag"))
+
+	list := client.getWithETag(t, "/ads?published=false", "")
+	assert.Equal(t, http.StatusOK, list.StatusCode)
+	assert.Equal(t, http.StatusNotModified, client.getWithETag(t, "/ads?published=false", list.Header.Get("ETag")).StatusCode)
+
+	missing := client.getWithETag(t, "/ads/100", "*")
+	assert.Equal(t, http.StatusBadRequest, missing.StatusCode)
+	assert.Empty(t, missing.Header.Get("ETag"))
+}
@@ -0,0 +1,184 @@
+package tests
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenConversation(t *testing.T) {
+	client := GetTestClient()
+
+	seller, err := client.CreateUser("Seller", "seller@testing.ru")
+	assert.NoError(t, err)
+
+	buyer, err := client.CreateUser("Buyer", "buyer@testing.ru")
+	assert.NoError(t, err)
+
+	ad, err := client.CreateAd(seller.Data.ID, "hello", "world")
+	assert.NoError(t, err)
+
+	conversation, err := client.openConversation(buyer.Data.ID, ad.Data.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, conversation.Data.AdID, ad.Data.ID)
+	assert.Equal(t, conversation.Data.BuyerID, buyer.Data.ID)
+	assert.Equal(t, conversation.Data.SellerID, seller.Data.ID)
+	assert.False(t, conversation.Data.Closed)
+
+	reopened, err := client.openConversation(buyer.Data.ID, ad.Data.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, reopened.Data.ID, conversation.Data.ID)
+
+	_, err = client.openConversation(seller.Data.ID, ad.Data.ID)
+	assert.ErrorIs(t, err, ErrBadRequest)
+}
+
+func TestSendMessage(t *testing.T) {
+	client := GetTestClient()
+
+	seller, sellerToken, err := client.createAccount("Seller", "seller@testing.ru")
+	assert.NoError(t, err)
+
+	buyer, buyerToken, err := client.createAccount("Buyer", "buyer@testing.ru")
+	assert.NoError(t, err)
+
+	ad, err := client.CreateAd(seller.Data.ID, "hello", "world")
+	assert.NoError(t, err)
+
+	conversation, err := client.openConversation(buyer.Data.ID, ad.Data.ID)
+	assert.NoError(t, err)
+
+	message, err := client.sendMessage(buyer.Data.ID, conversation.Data.ID, "is it still available?")
+	assert.NoError(t, err)
+	assert.Equal(t, message.Data.AuthorID, buyer.Data.ID)
+	assert.Equal(t, message.Data.Text, "is it still available?")
+
+	_, err = client.sendMessage(buyer.Data.ID, conversation.Data.ID, "")
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	conversations, err := client.listConversations(seller.Data.ID, sellerToken)
+	assert.NoError(t, err)
+	assert.Len(t, conversations.Data, 1)
+	assert.Equal(t, conversations.Data[0].Unread, int64(1))
+
+	conversations, err = client.listConversations(buyer.Data.ID, buyerToken)
+	assert.NoError(t, err)
+	assert.Len(t, conversations.Data, 1)
+	assert.Zero(t, conversations.Data[0].Unread)
+
+	messages, err := client.listMessages(conversation.Data.ID, sellerToken)
+	assert.NoError(t, err)
+	assert.Len(t, messages.Data, 1)
+
+	conversations, err = client.listConversations(seller.Data.ID, sellerToken)
+	assert.NoError(t, err)
+	assert.Zero(t, conversations.Data[0].Unread)
+
+	// the conversations of a user are listed in a session of the user only
+	_, err = client.listConversations(seller.Data.ID, "")
+	assert.ErrorIs(t, err, ErrUnauthorized)
+
+	_, err = client.listConversations(seller.Data.ID, buyerToken)
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestConversationOfAnotherUser(t *testing.T) {
+	client := GetTestClient()
+
+	seller, err := client.CreateUser("Seller", "seller@testing.ru")
+	assert.NoError(t, err)
+
+	buyer, err := client.CreateUser("Buyer", "buyer@testing.ru")
+	assert.NoError(t, err)
+
+	stranger, strangerToken, err := client.createAccount("Stranger", "stranger@testing.ru")
+	assert.NoError(t, err)
+
+	ad, err := client.CreateAd(seller.Data.ID, "hello", "world")
+	assert.NoError(t, err)
+
+	conversation, err := client.openConversation(buyer.Data.ID, ad.Data.ID)
+	assert.NoError(t, err)
+
+	_, err = client.sendMessage(stranger.Data.ID, conversation.Data.ID, "hi")
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	_, err = client.listMessages(conversation.Data.ID, strangerToken)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	_, err = client.listMessages(conversation.Data.ID, "")
+	assert.ErrorIs(t, err, ErrUnauthorized)
+
+	_, err = client.closeConversation(buyer.Data.ID, conversation.Data.ID)
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestCloseConversation(t *testing.T) {
+	client := GetTestClient()
+
+	seller, err := client.CreateUser("Seller", "seller@testing.ru")
+	assert.NoError(t, err)
+
+	buyer, err := client.CreateUser("Buyer", "buyer@testing.ru")
+	assert.NoError(t, err)
+
+	ad, err := client.CreateAd(seller.Data.ID, "hello", "world")
+	assert.NoError(t, err)
+
+	conversation, err := client.openConversation(buyer.Data.ID, ad.Data.ID)
+	assert.NoError(t, err)
+
+	conversation, err = client.closeConversation(seller.Data.ID, conversation.Data.ID)
+	assert.NoError(t, err)
+	assert.True(t, conversation.Data.Closed)
+
+	_, err = client.sendMessage(buyer.Data.ID, conversation.Data.ID, "hi")
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	reopened, err := client.openConversation(buyer.Data.ID, ad.Data.ID)
+	assert.NoError(t, err)
+	assert.NotEqual(t, reopened.Data.ID, conversation.Data.ID)
+}
+
+func TestSendMessage_Concurrent(t *testing.T) {
+	client := GetTestClient()
+
+	seller, sellerToken, err := client.createAccount("Seller", "seller@testing.ru")
+	assert.NoError(t, err)
+
+	buyer, err := client.CreateUser("Buyer", "buyer@testing.ru")
+	assert.NoError(t, err)
+
+	ad, err := client.CreateAd(seller.Data.ID, "hello", "world")
+	assert.NoError(t, err)
+
+	conversation, err := client.openConversation(buyer.Data.ID, ad.Data.ID)
+	assert.NoError(t, err)
+
+	const perUser = 25
+
+	var wg sync.WaitGroup
+	for _, userID := range []int64{seller.Data.ID, buyer.Data.ID} {
+		for i := 0; i < perUser; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := client.sendMessage(userID, conversation.Data.ID, fmt.Sprintf("message %d", i))
+				assert.NoError(t, err)
+			}()
+		}
+	}
+	wg.Wait()
+
+	messages, err := client.listMessages(conversation.Data.ID, sellerToken)
+	assert.NoError(t, err)
+	assert.Len(t, messages.Data, 2*perUser)
+
+	ids := make(map[int64]bool, len(messages.Data))
+	for _, message := range messages.Data {
+		ids[message.ID] = true
+	}
+	assert.Len(t, ids, 2*perUser, "message IDs must be unique")
+}
@@ -38,6 +38,25 @@ func TestUpdateAdOfAnotherUser(t *testing.T) {
 	assert.ErrorIs(t, err, ErrForbidden)
 }
 
+func TestRollbackAdOfAnotherUser(t *testing.T) {
+	client := GetTestClient()
+
+	createdUser1, err := client.CreateUser("Test User", "test@testing.ru")
+	assert.NoError(t, err)
+
+	resp, err := client.CreateAd(createdUser1.Data.ID, "hello", "world")
+	assert.NoError(t, err)
+
+	createdUser2, err := client.CreateUser("Test User", "test@testing.ru")
+	assert.NoError(t, err)
+
+	revisions, err := client.listAdRevisions(resp.Data.ID)
+	assert.NoError(t, err)
+
+	_, err = client.rollbackAd(createdUser2.Data.ID, resp.Data.ID, revisions.Data[0].ID)
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
 func TestCreateAd_ID(t *testing.T) {
 	client := GetTestClient()
 
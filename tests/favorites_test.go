@@ -0,0 +1,227 @@
+package tests
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddFavorite(t *testing.T) {
+	client := GetTestClient()
+
+	author, err := client.CreateUser("Author", "author@testing.ru")
+	assert.NoError(t, err)
+
+	reader, readerToken, err := client.createAccount("Reader", "reader@testing.ru")
+	assert.NoError(t, err)
+
+	response, err := client.CreateAd(author.Data.ID, "hello", "world")
+	assert.NoError(t, err)
+
+	response, err = client.addFavorite(reader.Data.ID, response.Data.ID, false)
+	assert.NoError(t, err)
+	assert.Equal(t, response.Data.Favorites, int64(1))
+
+	response, err = client.addFavorite(reader.Data.ID, response.Data.ID, true)
+	assert.NoError(t, err)
+	assert.Equal(t, response.Data.Favorites, int64(1))
+
+	favorites, err := client.listFavorites(reader.Data.ID, readerToken)
+	assert.NoError(t, err)
+	assert.Len(t, favorites.Data, 1)
+	assert.Equal(t, favorites.Data[0].ID, response.Data.ID)
+
+	ad, err := client.getAd(response.Data.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, ad.Data.Favorites, int64(1))
+}
+
+func TestRemoveFavorite(t *testing.T) {
+	client := GetTestClient()
+
+	author, authorToken, err := client.createAccount("Author", "author@testing.ru")
+	assert.NoError(t, err)
+
+	response, err := client.CreateAd(author.Data.ID, "hello", "world")
+	assert.NoError(t, err)
+
+	_, err = client.addFavorite(author.Data.ID, response.Data.ID, false)
+	assert.NoError(t, err)
+
+	response, err = client.removeFavorite(author.Data.ID, response.Data.ID)
+	assert.NoError(t, err)
+	assert.Zero(t, response.Data.Favorites)
+
+	favorites, err := client.listFavorites(author.Data.ID, authorToken)
+	assert.NoError(t, err)
+	assert.Empty(t, favorites.Data)
+
+	_, err = client.removeFavorite(author.Data.ID, response.Data.ID)
+	assert.ErrorIs(t, err, ErrBadRequest)
+}
+
+func TestFavoriteNotifications(t *testing.T) {
+	client := GetTestClient()
+
+	author, err := client.CreateUser("Author", "author@testing.ru")
+	assert.NoError(t, err)
+
+	follower, followerToken, err := client.createAccount("Follower", "follower@testing.ru")
+	assert.NoError(t, err)
+
+	reader, readerToken, err := client.createAccount("Reader", "reader@testing.ru")
+	assert.NoError(t, err)
+
+	response, err := client.CreateAd(author.Data.ID, "hello", "world")
+	assert.NoError(t, err)
+
+	_, err = client.addFavorite(follower.Data.ID, response.Data.ID, true)
+	assert.NoError(t, err)
+
+	_, err = client.addFavorite(reader.Data.ID, response.Data.ID, false)
+	assert.NoError(t, err)
+
+	_, err = client.updateAd(author.Data.ID, response.Data.ID, "привет", "мир")
+	assert.NoError(t, err)
+
+	notifications, err := client.listNotifications(follower.Data.ID, followerToken)
+	assert.NoError(t, err)
+	assert.Len(t, notifications.Data, 1)
+	assert.Equal(t, notifications.Data[0].AdID, response.Data.ID)
+
+	notifications, err = client.listNotifications(reader.Data.ID, readerToken)
+	assert.NoError(t, err)
+	assert.Empty(t, notifications.Data)
+
+	// the status does not change, nobody is notified
+	_, err = client.ChangeAdStatus(author.Data.ID, response.Data.ID, false)
+	assert.NoError(t, err)
+
+	notifications, err = client.listNotifications(follower.Data.ID, followerToken)
+	assert.NoError(t, err)
+	assert.Len(t, notifications.Data, 1)
+
+	err = client.deleteAd(response.Data.ID, author.Data.ID)
+	assert.NoError(t, err)
+
+	notifications, err = client.listNotifications(follower.Data.ID, followerToken)
+	assert.NoError(t, err)
+	assert.Len(t, notifications.Data, 2)
+
+	favorites, err := client.listFavorites(follower.Data.ID, followerToken)
+	assert.NoError(t, err)
+	assert.Empty(t, favorites.Data)
+}
+
+func TestDeleteUser_Favorites(t *testing.T) {
+	client := GetTestClient()
+
+	author, err := client.CreateUser("Author", "author@testing.ru")
+	assert.NoError(t, err)
+
+	reader, err := client.CreateUser("Reader", "reader@testing.ru")
+	assert.NoError(t, err)
+
+	response, err := client.CreateAd(author.Data.ID, "hello", "world")
+	assert.NoError(t, err)
+
+	_, err = client.addFavorite(reader.Data.ID, response.Data.ID, false)
+	assert.NoError(t, err)
+
+	err = client.deleteUser(reader.Data.ID)
+	assert.NoError(t, err)
+
+	ad, err := client.getAd(response.Data.ID)
+	assert.NoError(t, err)
+	assert.Zero(t, ad.Data.Favorites)
+}
+
+func TestAddFavorite_Concurrent(t *testing.T) {
+	client := GetTestClient()
+
+	author, err := client.CreateUser("Author", "author@testing.ru")
+	assert.NoError(t, err)
+
+	ad, err := client.CreateAd(author.Data.ID, "hello", "world")
+	assert.NoError(t, err)
+
+	readers := make([]int64, 20)
+	for i := range readers {
+		reader, err := client.CreateUser("Reader", "reader@testing.ru")
+		assert.NoError(t, err)
+		readers[i] = reader.Data.ID
+	}
+
+	// the counter and the edits of the author must survive each other
+	var wg sync.WaitGroup
+	for i, reader := range readers {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, err := client.addFavorite(reader, ad.Data.ID, false)
+			assert.NoError(t, err)
+		}()
+		go func() {
+			defer wg.Done()
+			_, err := client.updateAd(author.Data.ID, ad.Data.ID, "hello", fmt.Sprintf("world %d", i))
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	_, err = client.ChangeAdStatus(author.Data.ID, ad.Data.ID, true)
+	assert.NoError(t, err)
+
+	got, err := client.getAd(ad.Data.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(readers)), got.Data.Favorites)
+	assert.True(t, got.Data.Published)
+}
+
+func TestAddFavorite_DeletedAd(t *testing.T) {
+	client := GetTestClient()
+
+	author, err := client.CreateUser("Author", "author@testing.ru")
+	assert.NoError(t, err)
+
+	readers := make([]int64, 10)
+	tokens := make([]string, len(readers))
+	for i := range readers {
+		reader, token, err := client.createAccount("Reader", fmt.Sprintf("reader%d@testing.ru", i))
+		assert.NoError(t, err)
+		readers[i] = reader.Data.ID
+		tokens[i] = token
+	}
+
+	for i := 0; i < 10; i++ {
+		ad, err := client.CreateAd(author.Data.ID, "hello", "world")
+		assert.NoError(t, err)
+
+		// a favorite is either dropped with the ad or not added at all
+		var wg sync.WaitGroup
+		for _, reader := range readers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := client.addFavorite(reader, ad.Data.ID, false)
+				if err != nil {
+					assert.ErrorIs(t, err, ErrBadRequest)
+				}
+			}()
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, client.deleteAd(ad.Data.ID, author.Data.ID))
+		}()
+		wg.Wait()
+	}
+
+	for i, reader := range readers {
+		favorites, err := client.listFavorites(reader, tokens[i])
+		assert.NoError(t, err)
+		assert.Empty(t, favorites.Data)
+	}
+}
@@ -0,0 +1,173 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/reflection"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/test/bufconn"
+
+	"homework10/internal/adapters/repo"
+	"homework10/internal/app"
+	"homework10/internal/ports/gateway"
+	grpcPort "homework10/internal/ports/grpc"
+	"homework10/internal/ports/httpgin"
+)
+
+func newGatewayConn(t *testing.T) *grpc.ClientConn {
+	lis := bufconn.Listen(1024 * 1024)
+	t.Cleanup(func() {
+		lis.Close()
+	})
+
+	srv := grpc.NewServer()
+	t.Cleanup(func() {
+		srv.Stop()
+	})
+
+	grpcPort.RegisterAdServiceServer(srv, grpcPort.NewService(app.NewApp(repo.New(), repo.New(), repo.New(), app.WithHasher(testHasher))))
+	reflection.Register(srv)
+
+	go func() {
+		assert.NoError(t, srv.Serve(lis), "srv.Serve")
+	}()
+
+	dialer := func(context.Context, string) (net.Conn, error) {
+		return lis.Dial()
+	}
+
+	conn, err := grpc.NewClient("passthrough:///bufnet", grpc.WithContextDialer(dialer), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(t, err, "grpc.NewClient")
+	t.Cleanup(func() {
+		conn.Close()
+	})
+
+	return conn
+}
+
+func getGatewayTestClient(t *testing.T) *testClient {
+	gw, err := gateway.NewHandler(context.Background(), newGatewayConn(t))
+	assert.NoError(t, err)
+
+	server := httpgin.NewGatewayServer(":18080", gw)
+	testServer := httptest.NewServer(server.Handler)
+	t.Cleanup(testServer.Close)
+
+	return &testClient{
+		client:  testServer.Client(),
+		BaseURL: testServer.URL,
+	}
+}
+
+func rawJSON(t *testing.T, url string) map[string]any {
+	resp, err := http.Get(url)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, "application/json; charset=utf-8", resp.Header.Get("Content-Type"))
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+
+	var out map[string]any
+	assert.NoError(t, json.Unmarshal(body, &out))
+
+	return out
+}
+
+func TestGatewayCompatibleWithGin(t *testing.T) {
+	clients := map[string]*testClient{"gin": GetTestClient(), "gateway": getGatewayTestClient(t)}
+
+	responses := make(map[string]map[string]any)
+	for name, client := range clients {
+		user, err := client.CreateUser("Oleg", "oleg@testing.ru")
+		assert.NoError(t, err, name)
+
+		ad, err := client.CreateAd(user.Data.ID, "hello", "world")
+		assert.NoError(t, err, name)
+
+		ad, err = client.ChangeAdStatus(user.Data.ID, ad.Data.ID, true)
+		assert.NoError(t, err, name)
+		assert.True(t, ad.Data.Published, name)
+
+		ads, err := client.filterListAds(fmt.Sprintf("?published=true&user_id=%d", user.Data.ID))
+		assert.NoError(t, err, name)
+		assert.Len(t, ads.Data, 1, name)
+		assert.Equal(t, ad.Data.ID, ads.Data[0].ID, name)
+		assert.False(t, ads.Data[0].CreatedAt.IsZero(), name)
+
+		stranger, err := client.CreateUser("Ivan", "ivan@testing.ru")
+		assert.NoError(t, err, name)
+
+		_, err = client.updateAd(stranger.Data.ID, ad.Data.ID, "bye", "world")
+		assert.ErrorIs(t, err, ErrForbidden, name)
+
+		_, err = client.getAd(ad.Data.ID + 1)
+		assert.ErrorIs(t, err, ErrBadRequest, name)
+
+		conversation, err := client.openConversation(stranger.Data.ID, ad.Data.ID)
+		assert.NoError(t, err, name)
+		assert.Equal(t, user.Data.ID, conversation.Data.SellerID, name)
+
+		responses[name] = rawJSON(t, client.BaseURL+"/api/v1/ads/0")
+	}
+
+	for _, response := range responses {
+		data := response["data"].(map[string]any)
+		assert.IsType(t, "", data["creation_time"])
+		assert.IsType(t, "", data["update_time"])
+		delete(data, "creation_time")
+		delete(data, "update_time")
+	}
+
+	assert.Equal(t, responses["gin"], responses["gateway"])
+}
+
+func TestGatewayErrorEnvelope(t *testing.T) {
+	client := getGatewayTestClient(t)
+
+	resp, err := http.Get(client.BaseURL + "/api/v1/ads/first")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	var body struct {
+		Data  any     `json:"data"`
+		Error *string `json:"error"`
+	}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Nil(t, body.Data)
+	assert.NotNil(t, body.Error)
+}
+
+func TestGRPCReflection(t *testing.T) {
+	client := reflectionpb.NewServerReflectionClient(newGatewayConn(t))
+
+	stream, err := client.ServerReflectionInfo(context.Background())
+	assert.NoError(t, err)
+
+	err = stream.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	})
+	assert.NoError(t, err)
+
+	resp, err := stream.Recv()
+	assert.NoError(t, err)
+
+	var services []string
+	for _, service := range resp.GetListServicesResponse().GetService() {
+		services = append(services, service.GetName())
+	}
+	assert.Contains(t, services, grpcPort.AdService_ServiceDesc.ServiceName)
+}
@@ -4,13 +4,16 @@ import (
 	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/recovery"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
 	"testing"
 )
 
 import (
 	"context"
+	"log/slog"
 	"net"
 	"time"
 
@@ -23,6 +26,18 @@ import (
 	grpcPort "homework10/internal/ports/grpc"
 )
 
+// createGRPCAccount registers a user with a password and returns it with
+// a context carrying a session of the user.
+func createGRPCAccount(ctx context.Context, t *testing.T, client grpcPort.AdServiceClient, name, email string) (*grpcPort.UserResponse, context.Context) {
+	user, err := client.Register(ctx, &grpcPort.RegisterRequest{Name: name, Email: email, Password: "correct horse"})
+	assert.NoError(t, err, "client.Register")
+
+	session, err := client.Login(ctx, &grpcPort.LoginRequest{Email: email, Password: "correct horse"})
+	assert.NoError(t, err, "client.Login")
+
+	return user, metadata.AppendToOutgoingContext(ctx, grpcPort.AuthorizationMetadata, "Bearer "+session.Token)
+}
+
 func TestGRPCCreateAd(t *testing.T) {
 	lis := bufconn.Listen(1024 * 1024)
 	t.Cleanup(func() {
@@ -34,7 +49,7 @@ func TestGRPCCreateAd(t *testing.T) {
 		srv.Stop()
 	})
 
-	svc := grpcPort.NewService(app.NewApp(repo.New(), repo.New()))
+	svc := grpcPort.NewService(app.NewApp(repo.New(), repo.New(), repo.New()))
 	grpcPort.RegisterAdServiceServer(srv, svc)
 
 	go func() {
@@ -89,7 +104,7 @@ func TestGRPCChangeAdStatus(t *testing.T) {
 		srv.Stop()
 	})
 
-	svc := grpcPort.NewService(app.NewApp(repo.New(), repo.New()))
+	svc := grpcPort.NewService(app.NewApp(repo.New(), repo.New(), repo.New()))
 	grpcPort.RegisterAdServiceServer(srv, svc)
 
 	go func() {
@@ -143,7 +158,7 @@ func TestGRPCUpdateAd(t *testing.T) {
 		srv.Stop()
 	})
 
-	svc := grpcPort.NewService(app.NewApp(repo.New(), repo.New()))
+	svc := grpcPort.NewService(app.NewApp(repo.New(), repo.New(), repo.New()))
 	grpcPort.RegisterAdServiceServer(srv, svc)
 
 	go func() {
@@ -198,7 +213,7 @@ func TestGRPCGetAd(t *testing.T) {
 		srv.Stop()
 	})
 
-	svc := grpcPort.NewService(app.NewApp(repo.New(), repo.New()))
+	svc := grpcPort.NewService(app.NewApp(repo.New(), repo.New(), repo.New()))
 	grpcPort.RegisterAdServiceServer(srv, svc)
 
 	go func() {
@@ -255,7 +270,7 @@ func TestGRPCDeleteAd(t *testing.T) {
 		srv.Stop()
 	})
 
-	svc := grpcPort.NewService(app.NewApp(repo.New(), repo.New()))
+	svc := grpcPort.NewService(app.NewApp(repo.New(), repo.New(), repo.New()))
 	grpcPort.RegisterAdServiceServer(srv, svc)
 
 	go func() {
@@ -308,7 +323,7 @@ func TestGRPCListAds(t *testing.T) {
 		srv.Stop()
 	})
 
-	svc := grpcPort.NewService(app.NewApp(repo.New(), repo.New()))
+	svc := grpcPort.NewService(app.NewApp(repo.New(), repo.New(), repo.New()))
 	grpcPort.RegisterAdServiceServer(srv, svc)
 
 	go func() {
@@ -374,7 +389,7 @@ func TestGRPCSearchAds(t *testing.T) {
 		srv.Stop()
 	})
 
-	svc := grpcPort.NewService(app.NewApp(repo.New(), repo.New()))
+	svc := grpcPort.NewService(app.NewApp(repo.New(), repo.New(), repo.New()))
 	grpcPort.RegisterAdServiceServer(srv, svc)
 
 	go func() {
@@ -440,7 +455,7 @@ func TestGRPCCreateUser(t *testing.T) {
 		srv.Stop()
 	})
 
-	svc := grpcPort.NewService(app.NewApp(repo.New(), repo.New()))
+	svc := grpcPort.NewService(app.NewApp(repo.New(), repo.New(), repo.New()))
 	grpcPort.RegisterAdServiceServer(srv, svc)
 
 	go func() {
@@ -481,7 +496,7 @@ func TestGRPCUpdateUser(t *testing.T) {
 		srv.Stop()
 	})
 
-	svc := grpcPort.NewService(app.NewApp(repo.New(), repo.New()))
+	svc := grpcPort.NewService(app.NewApp(repo.New(), repo.New(), repo.New()))
 	grpcPort.RegisterAdServiceServer(srv, svc)
 
 	go func() {
@@ -512,7 +527,8 @@ func TestGRPCUpdateUser(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Zero(t, response.Id)
 	assert.Equal(t, response.Name, "Test User 2")
-	assert.Equal(t, response.Email, "test2@testing.ru")
+	// only the owner signed in sees the email
+	assert.Empty(t, response.Email)
 }
 
 func TestGRPCGetUser(t *testing.T) {
@@ -526,7 +542,7 @@ func TestGRPCGetUser(t *testing.T) {
 		srv.Stop()
 	})
 
-	svc := grpcPort.NewService(app.NewApp(repo.New(), repo.New()))
+	svc := grpcPort.NewService(app.NewApp(repo.New(), repo.New(), repo.New()))
 	grpcPort.RegisterAdServiceServer(srv, svc)
 
 	go func() {
@@ -571,7 +587,7 @@ func TestGRPCDeleteUser(t *testing.T) {
 		srv.Stop()
 	})
 
-	svc := grpcPort.NewService(app.NewApp(repo.New(), repo.New()))
+	svc := grpcPort.NewService(app.NewApp(repo.New(), repo.New(), repo.New()))
 	grpcPort.RegisterAdServiceServer(srv, svc)
 
 	go func() {
@@ -612,7 +628,7 @@ func TestGRPCServerInterceptor(t *testing.T) {
 	})
 
 	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(
-		logging.UnaryServerInterceptor(grpcPort.InterceptorLogger()),
+		logging.UnaryServerInterceptor(grpcPort.InterceptorLogger(slog.Default())),
 		recovery.UnaryServerInterceptor([]recovery.Option{
 			recovery.WithRecoveryHandler(grpcPort.PanicInterceptor),
 		}...),
@@ -621,7 +637,7 @@ func TestGRPCServerInterceptor(t *testing.T) {
 		srv.Stop()
 	})
 
-	svc := grpcPort.NewService(app.NewApp(repo.New(), repo.New()))
+	svc := grpcPort.NewService(app.NewApp(repo.New(), repo.New(), repo.New()))
 	grpcPort.RegisterAdServiceServer(srv, svc)
 
 	go func() {
@@ -674,3 +690,422 @@ func TestGRPCServerInterceptor(t *testing.T) {
 	_, err = client.GetUser(ctx, &grpcPort.GetUserRequest{Id: user.Id})
 	assert.Equal(t, status.Code(err), codes.InvalidArgument)
 }
+
+func TestGRPCAdRevisions(t *testing.T) {
+	lis := bufconn.Listen(1024 * 1024)
+	t.Cleanup(func() {
+		lis.Close()
+	})
+
+	srv := grpc.NewServer()
+	t.Cleanup(func() {
+		srv.Stop()
+	})
+
+	svc := grpcPort.NewService(app.NewApp(repo.New(), repo.New(), repo.New()))
+	grpcPort.RegisterAdServiceServer(srv, svc)
+
+	go func() {
+		assert.NoError(t, srv.Serve(lis), "srv.Serve")
+	}()
+
+	dialer := func(context.Context, string) (net.Conn, error) {
+		return lis.Dial()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	t.Cleanup(func() {
+		cancel()
+	})
+
+	conn, err := grpc.DialContext(ctx, "", grpc.WithContextDialer(dialer), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(t, err, "grpc.DialContext")
+
+	t.Cleanup(func() {
+		conn.Close()
+	})
+
+	client := grpcPort.NewAdServiceClient(conn)
+	user, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Name: "Oleg"})
+	assert.NoError(t, err, "client.CreateUser")
+
+	ad, err := client.CreateAd(ctx, &grpcPort.CreateAdRequest{
+		Title:  "hello",
+		Text:   "world",
+		UserId: user.Id,
+	})
+	assert.NoError(t, err)
+
+	_, err = client.UpdateAd(ctx, &grpcPort.UpdateAdRequest{
+		UserId: user.Id, AdId: ad.Id, Title: "привет", Text: "мир"})
+	assert.NoError(t, err)
+
+	revisions, err := client.ListAdRevisions(ctx, &grpcPort.ListAdRevisionsRequest{AdId: ad.Id})
+	assert.NoError(t, err)
+	assert.Len(t, revisions.List, 2)
+	assert.Equal(t, revisions.List[0].Title, "hello")
+	assert.Equal(t, revisions.List[1].Title, "привет")
+
+	response, err := client.RollbackAd(ctx, &grpcPort.RollbackAdRequest{
+		UserId: user.Id, AdId: ad.Id, RevisionId: revisions.List[0].Id})
+	assert.NoError(t, err)
+	assert.Equal(t, response.Title, "hello")
+	assert.Equal(t, response.Text, "world")
+
+	_, err = client.RollbackAd(ctx, &grpcPort.RollbackAdRequest{
+		UserId: user.Id, AdId: ad.Id, RevisionId: 100})
+	assert.Equal(t, status.Code(err), codes.InvalidArgument)
+}
+
+func TestGRPCFavorites(t *testing.T) {
+	lis := bufconn.Listen(1024 * 1024)
+	t.Cleanup(func() {
+		lis.Close()
+	})
+
+	srv := grpc.NewServer()
+	t.Cleanup(func() {
+		srv.Stop()
+	})
+
+	svc := grpcPort.NewService(app.NewApp(repo.New(), repo.New(), repo.New(), app.WithHasher(testHasher)))
+	grpcPort.RegisterAdServiceServer(srv, svc)
+
+	go func() {
+		assert.NoError(t, srv.Serve(lis), "srv.Serve")
+	}()
+
+	dialer := func(context.Context, string) (net.Conn, error) {
+		return lis.Dial()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	t.Cleanup(func() {
+		cancel()
+	})
+
+	conn, err := grpc.DialContext(ctx, "", grpc.WithContextDialer(dialer), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(t, err, "grpc.DialContext")
+
+	t.Cleanup(func() {
+		conn.Close()
+	})
+
+	client := grpcPort.NewAdServiceClient(conn)
+	author, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Name: "Oleg"})
+	assert.NoError(t, err, "client.CreateUser")
+
+	follower, followerCtx := createGRPCAccount(ctx, t, client, "Ivan", "ivan@testing.ru")
+
+	ad, err := client.CreateAd(ctx, &grpcPort.CreateAdRequest{
+		Title:  "hello",
+		Text:   "world",
+		UserId: author.Id,
+	})
+	assert.NoError(t, err)
+
+	response, err := client.AddFavorite(ctx, &grpcPort.AddFavoriteRequest{UserId: follower.Id, AdId: ad.Id, Notify: true})
+	assert.NoError(t, err)
+	assert.Equal(t, response.Favorites, int64(1))
+
+	_, err = client.ListFavorites(ctx, &grpcPort.ListFavoritesRequest{UserId: follower.Id})
+	assert.Equal(t, status.Code(err), codes.Unauthenticated)
+
+	favorites, err := client.ListFavorites(followerCtx, &grpcPort.ListFavoritesRequest{UserId: follower.Id})
+	assert.NoError(t, err)
+	assert.Len(t, favorites.List, 1)
+
+	_, err = client.ChangeAdStatus(ctx, &grpcPort.ChangeAdStatusRequest{UserId: author.Id, AdId: ad.Id, Published: true})
+	assert.NoError(t, err)
+
+	notifications, err := client.ListNotifications(followerCtx, &grpcPort.ListNotificationsRequest{UserId: follower.Id})
+	assert.NoError(t, err)
+	assert.Len(t, notifications.List, 1)
+	assert.Equal(t, notifications.List[0].AdId, ad.Id)
+
+	response, err = client.RemoveFavorite(ctx, &grpcPort.RemoveFavoriteRequest{UserId: follower.Id, AdId: ad.Id})
+	assert.NoError(t, err)
+	assert.Zero(t, response.Favorites)
+
+	_, err = client.RemoveFavorite(ctx, &grpcPort.RemoveFavoriteRequest{UserId: follower.Id, AdId: ad.Id})
+	assert.Equal(t, status.Code(err), codes.InvalidArgument)
+}
+
+func TestGRPCConversations(t *testing.T) {
+	lis := bufconn.Listen(1024 * 1024)
+	t.Cleanup(func() {
+		lis.Close()
+	})
+
+	srv := grpc.NewServer()
+	t.Cleanup(func() {
+		srv.Stop()
+	})
+
+	svc := grpcPort.NewService(app.NewApp(repo.New(), repo.New(), repo.New(), app.WithHasher(testHasher)))
+	grpcPort.RegisterAdServiceServer(srv, svc)
+
+	go func() {
+		assert.NoError(t, srv.Serve(lis), "srv.Serve")
+	}()
+
+	dialer := func(context.Context, string) (net.Conn, error) {
+		return lis.Dial()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	t.Cleanup(func() {
+		cancel()
+	})
+
+	conn, err := grpc.DialContext(ctx, "", grpc.WithContextDialer(dialer), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(t, err, "grpc.DialContext")
+
+	t.Cleanup(func() {
+		conn.Close()
+	})
+
+	client := grpcPort.NewAdServiceClient(conn)
+	seller, sellerCtx := createGRPCAccount(ctx, t, client, "Oleg", "oleg@testing.ru")
+	buyer, buyerCtx := createGRPCAccount(ctx, t, client, "Ivan", "ivan@testing.ru")
+
+	ad, err := client.CreateAd(ctx, &grpcPort.CreateAdRequest{
+		Title:  "hello",
+		Text:   "world",
+		UserId: seller.Id,
+	})
+	assert.NoError(t, err)
+
+	conversation, err := client.OpenConversation(ctx, &grpcPort.OpenConversationRequest{AdId: ad.Id, UserId: buyer.Id})
+	assert.NoError(t, err)
+	assert.Equal(t, conversation.SellerId, seller.Id)
+
+	message, err := client.SendMessage(ctx, &grpcPort.SendMessageRequest{
+		ConversationId: conversation.Id, UserId: buyer.Id, Text: "is it still available?"})
+	assert.NoError(t, err)
+	assert.Equal(t, message.AuthorId, buyer.Id)
+
+	_, err = client.ListConversations(buyerCtx, &grpcPort.ListConversationsRequest{UserId: seller.Id})
+	assert.Equal(t, status.Code(err), codes.PermissionDenied)
+
+	conversations, err := client.ListConversations(sellerCtx, &grpcPort.ListConversationsRequest{UserId: seller.Id})
+	assert.NoError(t, err)
+	assert.Len(t, conversations.List, 1)
+	assert.Equal(t, conversations.List[0].Unread, int64(1))
+
+	_, err = client.ListMessages(ctx, &grpcPort.ListMessagesRequest{ConversationId: conversation.Id})
+	assert.Equal(t, status.Code(err), codes.Unauthenticated)
+
+	messages, err := client.ListMessages(sellerCtx, &grpcPort.ListMessagesRequest{ConversationId: conversation.Id})
+	assert.NoError(t, err)
+	assert.Len(t, messages.List, 1)
+
+	_, err = client.CloseConversation(ctx, &grpcPort.CloseConversationRequest{ConversationId: conversation.Id, UserId: buyer.Id})
+	assert.Equal(t, status.Code(err), codes.PermissionDenied)
+
+	conversation, err = client.CloseConversation(ctx, &grpcPort.CloseConversationRequest{ConversationId: conversation.Id, UserId: seller.Id})
+	assert.NoError(t, err)
+	assert.True(t, conversation.Closed)
+}
+
+func TestGRPCSavedSearches(t *testing.T) {
+	lis := bufconn.Listen(1024 * 1024)
+	t.Cleanup(func() {
+		lis.Close()
+	})
+
+	srv := grpc.NewServer()
+	t.Cleanup(func() {
+		srv.Stop()
+	})
+
+	svc := grpcPort.NewService(app.NewApp(repo.New(), repo.New(), repo.New(), app.WithHasher(testHasher)))
+	grpcPort.RegisterAdServiceServer(srv, svc)
+
+	go func() {
+		assert.NoError(t, srv.Serve(lis), "srv.Serve")
+	}()
+
+	dialer := func(context.Context, string) (net.Conn, error) {
+		return lis.Dial()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	t.Cleanup(func() {
+		cancel()
+	})
+
+	conn, err := grpc.DialContext(ctx, "", grpc.WithContextDialer(dialer), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(t, err, "grpc.DialContext")
+
+	t.Cleanup(func() {
+		conn.Close()
+	})
+
+	client := grpcPort.NewAdServiceClient(conn)
+	author, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Name: "Oleg"})
+	assert.NoError(t, err, "client.CreateUser")
+
+	subscriber, subscriberCtx := createGRPCAccount(ctx, t, client, "Ivan", "ivan@testing.ru")
+
+	search, err := client.SaveSearch(ctx, &grpcPort.SaveSearchRequest{UserId: subscriber.Id, Pattern: "cat", AuthorId: -1})
+	assert.NoError(t, err)
+	assert.Equal(t, search.Pattern, "cat")
+
+	ad, err := client.CreateAd(ctx, &grpcPort.CreateAdRequest{
+		Title:  "best cat",
+		Text:   "not for sale",
+		UserId: author.Id,
+	})
+	assert.NoError(t, err)
+
+	_, err = client.ChangeAdStatus(ctx, &grpcPort.ChangeAdStatusRequest{UserId: author.Id, AdId: ad.Id, Published: true})
+	assert.NoError(t, err)
+
+	notifications, err := client.ListNotifications(subscriberCtx, &grpcPort.ListNotificationsRequest{UserId: subscriber.Id})
+	assert.NoError(t, err)
+	assert.Len(t, notifications.List, 1)
+
+	searches, err := client.ListSavedSearches(subscriberCtx, &grpcPort.ListSavedSearchesRequest{UserId: subscriber.Id})
+	assert.NoError(t, err)
+	assert.Len(t, searches.List, 1)
+
+	_, err = client.DeleteSavedSearch(ctx, &grpcPort.DeleteSavedSearchRequest{UserId: subscriber.Id, SearchId: search.Id})
+	assert.NoError(t, err)
+
+	_, err = client.DeleteSavedSearch(ctx, &grpcPort.DeleteSavedSearchRequest{UserId: subscriber.Id, SearchId: search.Id})
+	assert.Equal(t, status.Code(err), codes.InvalidArgument)
+}
+
+func TestGRPCBatch(t *testing.T) {
+	lis := bufconn.Listen(1024 * 1024)
+	t.Cleanup(func() {
+		lis.Close()
+	})
+
+	srv := grpc.NewServer()
+	t.Cleanup(func() {
+		srv.Stop()
+	})
+
+	svc := grpcPort.NewService(app.NewApp(repo.New(), repo.New(), repo.New()))
+	grpcPort.RegisterAdServiceServer(srv, svc)
+
+	go func() {
+		assert.NoError(t, srv.Serve(lis), "srv.Serve")
+	}()
+
+	dialer := func(context.Context, string) (net.Conn, error) {
+		return lis.Dial()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	t.Cleanup(func() {
+		cancel()
+	})
+
+	conn, err := grpc.DialContext(ctx, "", grpc.WithContextDialer(dialer), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(t, err, "grpc.DialContext")
+
+	t.Cleanup(func() {
+		conn.Close()
+	})
+
+	client := grpcPort.NewAdServiceClient(conn)
+	author, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Name: "Oleg"})
+	assert.NoError(t, err, "client.CreateUser")
+
+	stranger, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Name: "Ivan"})
+	assert.NoError(t, err, "client.CreateUser")
+
+	created, err := client.BatchCreateAds(ctx, &grpcPort.BatchCreateAdsRequest{Requests: []*grpcPort.CreateAdRequest{
+		{Title: "first", Text: "text", UserId: author.Id},
+		{Title: "", Text: "text", UserId: author.Id},
+		{Title: "second", Text: "text", UserId: author.Id},
+	}})
+	assert.NoError(t, err)
+	assert.Len(t, created.Results, 3)
+	assert.Equal(t, "first", created.Results[0].Ad.Title)
+	assert.Equal(t, int32(codes.InvalidArgument), created.Results[1].Error.Code)
+	assert.Nil(t, created.Results[1].Ad)
+	assert.Equal(t, created.Results[0].Ad.Id+1, created.Results[2].Ad.Id)
+
+	first, second := created.Results[0].Ad.Id, created.Results[2].Ad.Id
+
+	changed, err := client.BatchChangeAdStatus(ctx, &grpcPort.BatchChangeAdStatusRequest{Requests: []*grpcPort.ChangeAdStatusRequest{
+		{AdId: first, UserId: author.Id, Published: true},
+		{AdId: second, UserId: stranger.Id, Published: true},
+		{AdId: second + 1, UserId: author.Id, Published: true},
+	}})
+	assert.NoError(t, err)
+	assert.True(t, changed.Results[0].Ad.Published)
+	assert.Equal(t, int32(codes.PermissionDenied), changed.Results[1].Error.Code)
+	assert.Equal(t, int32(codes.InvalidArgument), changed.Results[2].Error.Code)
+
+	got, err := client.BatchGetAds(ctx, &grpcPort.BatchGetAdsRequest{Ids: []int64{second, first, second + 1}})
+	assert.NoError(t, err)
+	assert.False(t, got.Results[0].Ad.Published)
+	assert.True(t, got.Results[1].Ad.Published)
+	assert.Equal(t, int32(codes.InvalidArgument), got.Results[2].Error.Code)
+
+	_, err = client.BatchGetAds(ctx, &grpcPort.BatchGetAdsRequest{Ids: make([]int64, app.MaxBatchSize+1)})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGRPCUpdateMask(t *testing.T) {
+	lis := bufconn.Listen(1024 * 1024)
+	t.Cleanup(func() {
+		lis.Close()
+	})
+
+	srv := grpc.NewServer()
+	t.Cleanup(func() {
+		srv.Stop()
+	})
+
+	svc := grpcPort.NewService(app.NewApp(repo.New(), repo.New(), repo.New()))
+	grpcPort.RegisterAdServiceServer(srv, svc)
+
+	go func() {
+		assert.NoError(t, srv.Serve(lis), "srv.Serve")
+	}()
+
+	dialer := func(context.Context, string) (net.Conn, error) {
+		return lis.Dial()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	t.Cleanup(func() {
+		cancel()
+	})
+
+	conn, err := grpc.DialContext(ctx, "", grpc.WithContextDialer(dialer), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(t, err, "grpc.DialContext")
+
+	t.Cleanup(func() {
+		conn.Close()
+	})
+
+	client := grpcPort.NewAdServiceClient(conn)
+	user, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Name: "Oleg", Email: "oleg@testing.ru"})
+	assert.NoError(t, err, "client.CreateUser")
+
+	ad, err := client.CreateAd(ctx, &grpcPort.CreateAdRequest{Title: "hello", Text: "world", UserId: user.Id})
+	assert.NoError(t, err, "client.CreateAd")
+
+	ad, err = client.UpdateAd(ctx, &grpcPort.UpdateAdRequest{AdId: ad.Id, UserId: user.Id, Title: "bye",
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"title"}}})
+	assert.NoError(t, err, "client.UpdateAd")
+	assert.Equal(t, "bye", ad.Title)
+	assert.Equal(t, "world", ad.Text)
+
+	_, err = client.UpdateAd(ctx, &grpcPort.UpdateAdRequest{AdId: ad.Id, UserId: user.Id,
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"published"}}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	updated, err := client.UpdateUser(ctx, &grpcPort.UpdateUserRequest{Id: user.Id, Name: "Ivan",
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"name"}}})
+	assert.NoError(t, err, "client.UpdateUser")
+	assert.Equal(t, "Ivan", updated.Name)
+	assert.Empty(t, updated.Email)
+}
@@ -0,0 +1,183 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"homework10/internal/adapters/repo"
+	"homework10/internal/app"
+	"homework10/internal/idempotency"
+	grpcPort "homework10/internal/ports/grpc"
+	"homework10/internal/ports/httpgin"
+)
+
+func (tc *testClient) postWithKey(t *testing.T, path string, key string, body map[string]any, headers ...string) (*http.Response, []byte) {
+	data, err := json.Marshal(body)
+	assert.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodPost, tc.BaseURL+"/api/v1"+path, bytes.NewReader(data))
+	assert.NoError(t, err)
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add(httpgin.IdempotencyKeyHeader, key)
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Add(headers[i], headers[i+1])
+	}
+
+	resp, err := tc.client.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+
+	return resp, respBody
+}
+
+func TestIdempotencyKey(t *testing.T) {
+	client := GetTestClient(httpgin.IdempotencyMW(idempotency.New(time.Hour, 100)))
+
+	user, err := client.CreateUser("Oleg", "oleg@testing.ru")
+	assert.NoError(t, err)
+
+	draft := map[string]any{"user_id": user.Data.ID, "title": "hello", "text": "world"}
+
+	first, firstBody := client.postWithKey(t, "/ads", "retry-1", draft)
+	assert.Equal(t, http.StatusOK, first.StatusCode)
+	assert.Empty(t, first.Header.Get(httpgin.IdempotentReplayedHeader))
+
+	retry, retryBody := client.postWithKey(t, "/ads", "retry-1", draft)
+	assert.Equal(t, http.StatusOK, retry.StatusCode)
+	assert.Equal(t, "true", retry.Header.Get(httpgin.IdempotentReplayedHeader))
+	assert.Equal(t, firstBody, retryBody)
+
+	ads, err := client.listAds()
+	assert.NoError(t, err)
+	assert.Len(t, ads.Data, 0)
+
+	all, err := client.filterListAds("?published=false")
+	assert.NoError(t, err)
+	assert.Len(t, all.Data, 1)
+
+	draft["title"] = "bye"
+	reused, _ := client.postWithKey(t, "/ads", "retry-1", draft)
+	assert.Equal(t, http.StatusUnprocessableEntity, reused.StatusCode)
+
+	other, _ := client.postWithKey(t, "/ads", "retry-2", draft)
+	assert.Equal(t, http.StatusOK, other.StatusCode)
+
+	all, err = client.filterListAds("?published=false")
+	assert.NoError(t, err)
+	assert.Len(t, all.Data, 2)
+
+	// the same key sent by another caller is another request
+	another, _ := client.postWithKey(t, "/ads", "retry-2", draft, "Authorization", "Bearer another-session")
+	assert.Equal(t, http.StatusOK, another.StatusCode)
+	assert.Empty(t, another.Header.Get(httpgin.IdempotentReplayedHeader))
+
+	all, err = client.filterListAds("?published=false")
+	assert.NoError(t, err)
+	assert.Len(t, all.Data, 3)
+
+	draft["text"] = strings.Repeat("x", httpgin.MaxIdempotentBody)
+	large, _ := client.postWithKey(t, "/ads", "retry-3", draft)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, large.StatusCode)
+}
+
+func TestGRPCIdempotencyKey(t *testing.T) {
+	lis := bufconn.Listen(1024 * 1024)
+	t.Cleanup(func() {
+		lis.Close()
+	})
+
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(grpcPort.IdempotencyInterceptor(idempotency.New(time.Hour, 100))))
+	t.Cleanup(func() {
+		srv.Stop()
+	})
+
+	svc := grpcPort.NewService(app.NewApp(repo.New(), repo.New(), repo.New()))
+	grpcPort.RegisterAdServiceServer(srv, svc)
+
+	go func() {
+		assert.NoError(t, srv.Serve(lis), "srv.Serve")
+	}()
+
+	dialer := func(context.Context, string) (net.Conn, error) {
+		return lis.Dial()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	t.Cleanup(func() {
+		cancel()
+	})
+
+	conn, err := grpc.DialContext(ctx, "", grpc.WithContextDialer(dialer), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(t, err, "grpc.DialContext")
+
+	t.Cleanup(func() {
+		conn.Close()
+	})
+
+	client := grpcPort.NewAdServiceClient(conn)
+	user, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Name: "Oleg"})
+	assert.NoError(t, err, "client.CreateUser")
+
+	keyCtx := metadata.AppendToOutgoingContext(ctx, grpcPort.IdempotencyKeyMetadata, "retry-1")
+	request := &grpcPort.CreateAdRequest{Title: "hello", Text: "world", UserId: user.Id}
+
+	first, err := client.CreateAd(keyCtx, request)
+	assert.NoError(t, err, "client.CreateAd")
+
+	var header metadata.MD
+	retry, err := client.CreateAd(keyCtx, request, grpc.Header(&header))
+	assert.NoError(t, err, "client.CreateAd")
+	assert.Equal(t, first.Id, retry.Id)
+	assert.Equal(t, []string{"true"}, header.Get(grpcPort.IdempotentReplayedMetadata))
+
+	_, err = client.CreateAd(keyCtx, &grpcPort.CreateAdRequest{Title: "bye", Text: "world", UserId: user.Id})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	list, err := client.ListAds(ctx, &grpcPort.ListAdsRequest{UserId: user.Id})
+	assert.NoError(t, err, "client.ListAds")
+	assert.Len(t, list.List, 1)
+
+	anotherCtx := metadata.AppendToOutgoingContext(keyCtx, grpcPort.AuthorizationMetadata, "Bearer another-session")
+	another, err := client.CreateAd(anotherCtx, request)
+	assert.NoError(t, err, "client.CreateAd")
+	assert.NotEqual(t, first.Id, another.Id)
+}
+
+func TestGRPCIdempotencyKey_Panic(t *testing.T) {
+	interceptor := grpcPort.IdempotencyInterceptor(idempotency.New(time.Hour, 100))
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(grpcPort.IdempotencyKeyMetadata, "retry-1"))
+	info := &grpc.UnaryServerInfo{FullMethod: grpcPort.AdService_CreateAd_FullMethodName}
+	request := &grpcPort.CreateAdRequest{Title: "hello", Text: "world"}
+
+	assert.Panics(t, func() {
+		_, _ = interceptor(ctx, request, info, func(context.Context, any) (any, error) {
+			panic("broken handler")
+		})
+	})
+
+	// the retry is not taken for a call in progress
+	response, err := interceptor(ctx, request, info, func(context.Context, any) (any, error) {
+		return &grpcPort.AdResponse{Id: 1}, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), response.(*grpcPort.AdResponse).Id)
+}
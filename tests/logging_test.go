@@ -0,0 +1,123 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+
+	"homework10/internal/adapters/repo"
+	"homework10/internal/app"
+	"homework10/internal/logger"
+	grpcPort "homework10/internal/ports/grpc"
+	"homework10/internal/ports/httpgin"
+)
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
+
+func TestHTTPRequestID(t *testing.T) {
+	var logs syncBuffer
+	a := app.NewApp(repo.New(), repo.New(), repo.New(), app.WithLogger(logger.New(&logs, "info")))
+	server := httptest.NewServer(httpgin.NewHTTPServer(":18080", a).Handler)
+	t.Cleanup(server.Close)
+
+	req, err := http.NewRequest(http.MethodPost, server.URL+"/api/v1/users",
+		strings.NewReader(`{"name": "Oleg", "email": "oleg@testing.ru"}`))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(logger.RequestIDHeader, "req-42")
+
+	resp, err := server.Client().Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, "req-42", resp.Header.Get(logger.RequestIDHeader))
+	assert.Contains(t, logs.String(), `"msg":"user created"`)
+	assert.Contains(t, logs.String(), `"request_id":"req-42"`)
+
+	resp, err = server.Client().Get(server.URL + "/api/v1/ads")
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Len(t, resp.Header.Get(logger.RequestIDHeader), 32)
+}
+
+func TestGRPCRequestID(t *testing.T) {
+	var logs syncBuffer
+
+	lis := bufconn.Listen(1024 * 1024)
+	t.Cleanup(func() {
+		lis.Close()
+	})
+
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(grpcPort.RequestIDInterceptor))
+	t.Cleanup(func() {
+		srv.Stop()
+	})
+
+	svc := grpcPort.NewService(app.NewApp(repo.New(), repo.New(), repo.New(), app.WithLogger(logger.New(&logs, "info"))))
+	grpcPort.RegisterAdServiceServer(srv, svc)
+
+	go func() {
+		assert.NoError(t, srv.Serve(lis), "srv.Serve")
+	}()
+
+	dialer := func(context.Context, string) (net.Conn, error) {
+		return lis.Dial()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	t.Cleanup(func() {
+		cancel()
+	})
+
+	conn, err := grpc.DialContext(ctx, "", grpc.WithContextDialer(dialer), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(t, err, "grpc.DialContext")
+
+	t.Cleanup(func() {
+		conn.Close()
+	})
+
+	client := grpcPort.NewAdServiceClient(conn)
+
+	var header metadata.MD
+	_, err = client.CreateUser(metadata.AppendToOutgoingContext(ctx, logger.RequestIDKey, "req-7"),
+		&grpcPort.CreateUserRequest{Name: "Oleg", Email: "oleg@testing.ru"}, grpc.Header(&header))
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"req-7"}, header.Get(logger.RequestIDKey))
+	assert.Contains(t, logs.String(), `"request_id":"req-7"`)
+
+	_, err = client.ListAds(ctx, &grpcPort.ListAdsRequest{}, grpc.Header(&header))
+	assert.NoError(t, err)
+	assert.Len(t, header.Get(logger.RequestIDKey), 1)
+	assert.Len(t, header.Get(logger.RequestIDKey)[0], 32)
+}
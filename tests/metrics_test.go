@@ -0,0 +1,61 @@
+package tests
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"homework10/internal/adapters/repo"
+	"homework10/internal/app"
+	"homework10/internal/metrics"
+	"homework10/internal/ports/httpgin"
+)
+
+func TestHTTPMetrics(t *testing.T) {
+	m := metrics.New()
+	adRepo, userRepo := repo.New(), repo.New()
+	m.RegisterDomain(adRepo, userRepo)
+
+	a := app.NewApp(m.Repository("ads", adRepo), m.Repository("users", userRepo), repo.New())
+	server := httpgin.NewHTTPServer(":18080", a, httpgin.MetricsMW(m))
+	testServer := httptest.NewServer(server.Handler)
+	t.Cleanup(testServer.Close)
+	client := &testClient{client: testServer.Client(), BaseURL: testServer.URL}
+
+	user, err := client.CreateUser("Oleg", "oleg@testing.ru")
+	assert.NoError(t, err)
+
+	ad, err := client.CreateAd(user.Data.ID, "hello", "world")
+	assert.NoError(t, err)
+
+	_, err = client.ChangeAdStatus(user.Data.ID, ad.Data.ID, true)
+	assert.NoError(t, err)
+
+	_, err = client.getAd(ad.Data.ID + 1)
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	metricsServer := httptest.NewServer(m.Handler())
+	t.Cleanup(metricsServer.Close)
+
+	resp, err := metricsServer.Client().Get(metricsServer.URL)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+
+	for _, line := range []string{
+		`http_requests_total{code="200",method="POST",route="/api/v1/ads"} 1`,
+		`http_requests_total{code="400",method="GET",route="/api/v1/ads/:ad_id"} 1`,
+		`http_request_duration_seconds_count{method="PUT",route="/api/v1/ads/:ad_id/status"} 1`,
+		"ads_total 1",
+		"ads_published 1",
+		"users_total 1",
+		`repository_operation_duration_seconds_count{operation="add",repository="ads"} 1`,
+	} {
+		assert.True(t, strings.Contains(string(body), line), line)
+	}
+}
@@ -0,0 +1,130 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"homework10/internal/adapters/repo"
+	"homework10/internal/app"
+	"homework10/internal/ports/httpgin"
+)
+
+func newDocsServer(t *testing.T) (*gin.Engine, *httptest.Server) {
+	server := httpgin.NewHTTPServer(":18080", app.NewApp(repo.New(), repo.New(), repo.New()))
+	testServer := httptest.NewServer(server.Handler)
+	t.Cleanup(testServer.Close)
+
+	return server.Handler.(*gin.Engine), testServer
+}
+
+func loadSpec(t *testing.T, baseURL string) *openapi3.T {
+	resp, err := http.Get(baseURL + "/api/docs/openapi.json")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	data, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+
+	doc, err := openapi3.NewLoader().LoadFromData(data)
+	assert.NoError(t, err)
+	assert.NoError(t, doc.Validate(context.Background()))
+
+	return doc
+}
+
+func TestOpenAPIMatchesRoutes(t *testing.T) {
+	engine, testServer := newDocsServer(t)
+	doc := loadSpec(t, testServer.URL)
+
+	var routes []string
+	for _, route := range engine.Routes() {
+		if path, ok := strings.CutPrefix(route.Path, "/api/v1"); ok {
+			routes = append(routes, route.Method+" "+httpgin.OpenAPIPath(path))
+		}
+	}
+
+	var operations []string
+	for path, item := range doc.Paths.Map() {
+		for method := range item.Operations() {
+			operations = append(operations, method+" "+path)
+		}
+	}
+
+	sort.Strings(routes)
+	sort.Strings(operations)
+	assert.Equal(t, routes, operations)
+}
+
+func TestOpenAPIResponseSchemas(t *testing.T) {
+	_, testServer := newDocsServer(t)
+	doc := loadSpec(t, testServer.URL)
+	client := &testClient{client: testServer.Client(), BaseURL: testServer.URL}
+
+	user, err := client.CreateUser("Oleg", "oleg@testing.ru")
+	assert.NoError(t, err)
+	_, err = client.CreateAd(user.Data.ID, "hello", "world")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		path string
+		url  string
+	}{
+		{path: "/ads/{ad_id}", url: "/api/v1/ads/0"},
+		{path: "/ads/search/{pattern}", url: "/api/v1/ads/search/hel"},
+		{path: "/users/{user_id}", url: "/api/v1/users/0"},
+		{path: "/ads/{ad_id}", url: "/api/v1/ads/1"},
+	}
+
+	for _, test := range tests {
+		resp, err := http.Get(testServer.URL + test.url)
+		assert.NoError(t, err)
+
+		var body any
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		resp.Body.Close()
+
+		response := doc.Paths.Find(test.path).Get.Responses.Status(resp.StatusCode)
+		if response == nil {
+			t.Fatalf(`test %q: status %d is not documented`, test.url, resp.StatusCode)
+		}
+
+		schema := response.Value.Content.Get("application/json").Schema.Value
+		if err := schema.VisitJSON(body); err != nil {
+			t.Fatalf(`test %q: expect nil got %v`, test.url, err)
+		}
+	}
+}
+
+func TestSwaggerUI(t *testing.T) {
+	_, testServer := newDocsServer(t)
+
+	resp, err := http.Get(testServer.URL + "/api/docs")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	page, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Contains(t, string(page), "swagger-ui")
+
+	resp, err = http.Get(testServer.URL + "/api/docs/swagger-initializer.js")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	script, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Contains(t, string(script), `url: "openapi.json"`)
+}
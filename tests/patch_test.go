@@ -0,0 +1,76 @@
+package tests
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (tc *testClient) patch(path string, body string, out any) error {
+	return tc.patchAs(path, "", body, out)
+}
+
+func (tc *testClient) patchAs(path string, token string, body string, out any) error {
+	req, err := http.NewRequest(http.MethodPatch, tc.BaseURL+"/api/v1"+path, bytes.NewReader([]byte(body)))
+	if err != nil {
+		return fmt.Errorf("unable to create request: %w", err)
+	}
+
+	req.Header.Add("Content-Type", "application/merge-patch+json")
+	withToken(req, token)
+
+	return tc.getResponse(req, out)
+}
+
+func TestMergePatch(t *testing.T) {
+	clients := map[string]*testClient{"gin": GetTestClient(), "gateway": getGatewayTestClient(t)}
+
+	for name, client := range clients {
+		user, err := client.CreateUser("Oleg", "oleg@testing.ru")
+		assert.NoError(t, err, name)
+
+		stranger, err := client.CreateUser("Ivan", "ivan@testing.ru")
+		assert.NoError(t, err, name)
+
+		ad, err := client.CreateAd(user.Data.ID, "hello", "world")
+		assert.NoError(t, err, name)
+
+		path := fmt.Sprintf("/ads/%d", ad.Data.ID)
+
+		var patched adResponse
+		err = client.patch(path, fmt.Sprintf(`{"user_id":%d,"title":"bye"}`, user.Data.ID), &patched)
+		assert.NoError(t, err, name)
+		assert.Equal(t, "bye", patched.Data.Title, name)
+		assert.Equal(t, "world", patched.Data.Text, name)
+
+		err = client.patch(path, fmt.Sprintf(`{"user_id":%d,"text":"everyone"}`, user.Data.ID), &patched)
+		assert.NoError(t, err, name)
+		assert.Equal(t, "bye", patched.Data.Title, name)
+		assert.Equal(t, "everyone", patched.Data.Text, name)
+
+		err = client.patch(path, fmt.Sprintf(`{"user_id":%d,"title":""}`, user.Data.ID), &patched)
+		assert.ErrorIs(t, err, ErrBadRequest, name)
+
+		err = client.patch(path, fmt.Sprintf(`{"user_id":%d,"title":null}`, user.Data.ID), &patched)
+		assert.ErrorIs(t, err, ErrBadRequest, name)
+
+		err = client.patch(path, fmt.Sprintf(`{"user_id":%d,"published":true}`, user.Data.ID), &patched)
+		assert.ErrorIs(t, err, ErrBadRequest, name)
+
+		err = client.patch(path, fmt.Sprintf(`{"user_id":%d,"title":"mine"}`, stranger.Data.ID), &patched)
+		assert.ErrorIs(t, err, ErrForbidden, name)
+
+		got, err := client.getAd(ad.Data.ID)
+		assert.NoError(t, err, name)
+		assert.Equal(t, "bye", got.Data.Title, name)
+
+		var patchedUser userResponse
+		err = client.patch(fmt.Sprintf("/users/%d", user.Data.ID), `{"email":"oleg@example.com"}`, &patchedUser)
+		assert.NoError(t, err, name)
+		assert.Equal(t, "Oleg", patchedUser.Data.Name, name)
+		assert.Empty(t, patchedUser.Data.Email, name)
+	}
+}
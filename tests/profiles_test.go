@@ -0,0 +1,59 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserProfile(t *testing.T) {
+	clients := map[string]*testClient{"gin": GetTestClient(), "gateway": getGatewayTestClient(t)}
+
+	for name, client := range clients {
+		var user userResponse
+		err := client.send(http.MethodPost, "/accounts", "",
+			map[string]any{"name": "Oleg", "email": "oleg@testing.ru", "password": "correct horse"}, &user)
+		assert.NoError(t, err, name)
+		assert.False(t, user.Data.RegistrationTime.IsZero(), name)
+
+		session, err := client.login("oleg@testing.ru", "correct horse")
+		assert.NoError(t, err, name)
+
+		path := fmt.Sprintf("/users/%d", user.Data.ID)
+		profile := `{"display_name":"Oleg P.","avatar_url":"https://example.com/oleg.png",` +
+			`"phone":"+7 (900) 123-45-67","city":"Moscow","bio":"I sell cats"}`
+
+		var patched userResponse
+		err = client.patchAs(path, session.Data.Token, profile, &patched)
+		assert.NoError(t, err, name)
+		assert.Equal(t, "Oleg P.", patched.Data.DisplayName, name)
+		assert.Equal(t, "+7 (900) 123-45-67", patched.Data.Phone, name)
+
+		// nobody else changes the profile of an account
+		err = client.patch(path, `{"city":"Paris"}`, &patched)
+		assert.ErrorIs(t, err, ErrForbidden, name)
+
+		owner, err := client.getUser(user.Data.ID, session.Data.Token)
+		assert.NoError(t, err, name)
+		assert.Equal(t, "oleg@testing.ru", owner.Data.Email, name)
+		assert.Equal(t, "+7 (900) 123-45-67", owner.Data.Phone, name)
+		assert.Equal(t, "Moscow", owner.Data.City, name)
+
+		public, err := client.getUser(user.Data.ID, "")
+		assert.NoError(t, err, name)
+		assert.Empty(t, public.Data.Email, name)
+		assert.Empty(t, public.Data.Phone, name)
+		assert.Equal(t, "Oleg P.", public.Data.DisplayName, name)
+		assert.Equal(t, "https://example.com/oleg.png", public.Data.AvatarURL, name)
+		assert.Equal(t, "I sell cats", public.Data.Bio, name)
+		assert.True(t, owner.Data.RegistrationTime.Equal(public.Data.RegistrationTime), name)
+
+		err = client.patchAs(path, session.Data.Token, `{"avatar_url":"javascript:alert(1)"}`, &patched)
+		assert.ErrorIs(t, err, ErrBadRequest, name)
+
+		err = client.patchAs(path, session.Data.Token, `{"phone":"call me"}`, &patched)
+		assert.ErrorIs(t, err, ErrBadRequest, name)
+	}
+}
@@ -0,0 +1,256 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"homework10/internal/adapters/repo"
+	"homework10/internal/app"
+	"homework10/internal/ports/gateway"
+	grpcPort "homework10/internal/ports/grpc"
+	"homework10/internal/ports/httpgin"
+	"homework10/internal/ratelimit"
+)
+
+// getRateLimitTestClient serves an app limited by the limiter, which knows
+// the sessions of the app.
+func getRateLimitTestClient(limiter *ratelimit.Limiter) *testClient {
+	a := newAccountsApp(&mailbox{mails: map[string][]string{}})
+	server := httpgin.NewHTTPServer(":18080", a, httpgin.RateLimitMW(limiter, a))
+	testServer := httptest.NewServer(server.Handler)
+
+	return &testClient{
+		client:  testServer.Client(),
+		BaseURL: testServer.URL,
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	limiter := ratelimit.New(map[string]ratelimit.Rule{
+		"POST /api/v1/ads": {Rate: 0.001, Burst: 2},
+	})
+	client := getRateLimitTestClient(limiter)
+
+	user1, err := client.CreateUser("Test User", "test@testing.ru")
+	assert.NoError(t, err)
+
+	user2, err := client.CreateUser("Test User 2", "test2@testing.ru")
+	assert.NoError(t, err)
+
+	_, err = client.CreateAd(user1.Data.ID, "hello", "world")
+	assert.NoError(t, err)
+
+	_, err = client.CreateAd(user1.Data.ID, "hello", "world")
+	assert.NoError(t, err)
+
+	_, err = client.CreateAd(user1.Data.ID, "hello", "world")
+	assert.ErrorIs(t, err, ErrTooManyRequests)
+
+	// the bucket belongs to the client, not to the user it names
+	_, err = client.CreateAd(user2.Data.ID, "hello", "world")
+	assert.ErrorIs(t, err, ErrTooManyRequests)
+
+	// nor to the address it claims without a trusted proxy
+	req, err := http.NewRequest(http.MethodPost, client.BaseURL+"/api/v1/ads",
+		strings.NewReader(fmt.Sprintf(`{"user_id":%d,"title":"hello","text":"world"}`, user2.Data.ID)))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+
+	var response adResponse
+	err = client.getResponse(req, &response)
+	assert.ErrorIs(t, err, ErrTooManyRequests)
+
+	_, err = client.listAds()
+	assert.NoError(t, err)
+}
+
+func TestRateLimit_TrustedProxy(t *testing.T) {
+	limiter := ratelimit.New(map[string]ratelimit.Rule{
+		"POST /api/v1/users": {Rate: 0.001, Burst: 1},
+	})
+
+	a := app.NewApp(repo.New(), repo.New(), repo.New())
+	server := httpgin.NewHTTPServer(":18080", a, httpgin.RateLimitMW(limiter, a))
+	assert.NoError(t, httpgin.SetTrustedProxies(server, []string{"127.0.0.1", "::1"}))
+	testServer := httptest.NewServer(server.Handler)
+	t.Cleanup(testServer.Close)
+
+	client := &testClient{client: testServer.Client(), BaseURL: testServer.URL}
+
+	createUser := func(clientIP string) error {
+		req, err := http.NewRequest(http.MethodPost, client.BaseURL+"/api/v1/users",
+			strings.NewReader(`{"name":"Oleg","email":"oleg@testing.ru"}`))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Forwarded-For", clientIP)
+
+		var response userResponse
+		return client.getResponse(req, &response)
+	}
+
+	assert.NoError(t, createUser("203.0.113.7"))
+	assert.ErrorIs(t, createUser("203.0.113.7"), ErrTooManyRequests)
+	assert.NoError(t, createUser("203.0.113.8"))
+}
+
+func TestRateLimit_RetryAfter(t *testing.T) {
+	limiter := ratelimit.New(map[string]ratelimit.Rule{
+		"POST /api/v1/users": {Rate: 0.5, Burst: 1},
+	})
+	client := getRateLimitTestClient(limiter)
+
+	_, err := client.CreateUser("Test User", "test@testing.ru")
+	assert.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodPost, client.BaseURL+"/api/v1/users", nil)
+	assert.NoError(t, err)
+
+	resp, err := client.client.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, resp.StatusCode, http.StatusTooManyRequests)
+	assert.Equal(t, resp.Header.Get("Retry-After"), "2")
+}
+
+func TestGRPCRateLimit(t *testing.T) {
+	lis := bufconn.Listen(1024 * 1024)
+	t.Cleanup(func() {
+		lis.Close()
+	})
+
+	limiter := ratelimit.New(map[string]ratelimit.Rule{
+		grpcPort.AdService_CreateAd_FullMethodName: {Rate: 0.001, Burst: 1},
+	})
+
+	a := app.NewApp(repo.New(), repo.New(), repo.New(), app.WithHasher(testHasher))
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(grpcPort.RateLimitInterceptor(limiter, a)))
+	t.Cleanup(func() {
+		srv.Stop()
+	})
+
+	svc := grpcPort.NewService(a)
+	grpcPort.RegisterAdServiceServer(srv, svc)
+
+	go func() {
+		assert.NoError(t, srv.Serve(lis), "srv.Serve")
+	}()
+
+	dialer := func(context.Context, string) (net.Conn, error) {
+		return lis.Dial()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	t.Cleanup(func() {
+		cancel()
+	})
+
+	conn, err := grpc.DialContext(ctx, "", grpc.WithContextDialer(dialer), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(t, err, "grpc.DialContext")
+
+	t.Cleanup(func() {
+		conn.Close()
+	})
+
+	client := grpcPort.NewAdServiceClient(conn)
+	user, err := client.CreateUser(ctx, &grpcPort.CreateUserRequest{Name: "Oleg"})
+	assert.NoError(t, err, "client.CreateUser")
+
+	_, err = client.CreateAd(ctx, &grpcPort.CreateAdRequest{Title: "hello", Text: "world", UserId: user.Id})
+	assert.NoError(t, err)
+
+	_, err = client.CreateAd(ctx, &grpcPort.CreateAdRequest{Title: "hello", Text: "world", UserId: user.Id})
+	assert.Equal(t, status.Code(err), codes.ResourceExhausted)
+
+	details := status.Convert(err).Details()
+	assert.Len(t, details, 1)
+	retryInfo, ok := details[0].(*errdetails.RetryInfo)
+	assert.True(t, ok)
+	assert.True(t, retryInfo.RetryDelay.AsDuration() > 0)
+
+	// the user of a session has a bucket of its own
+	ivan, authCtx := createGRPCAccount(ctx, t, client, "Ivan", "ivan@testing.ru")
+
+	_, err = client.CreateAd(authCtx, &grpcPort.CreateAdRequest{Title: "hello", Text: "world", UserId: ivan.Id})
+	assert.NoError(t, err)
+
+	_, err = client.CreateAd(authCtx, &grpcPort.CreateAdRequest{Title: "hello", Text: "world", UserId: ivan.Id})
+	assert.Equal(t, status.Code(err), codes.ResourceExhausted)
+}
+
+func TestRateLimit_SessionUser(t *testing.T) {
+	limiter := ratelimit.New(map[string]ratelimit.Rule{
+		"POST /api/v1/ads": {Rate: 0.001, Burst: 1},
+	})
+	client := getRateLimitTestClient(limiter)
+
+	oleg, olegToken, err := client.createAccount("Oleg", "oleg@testing.ru")
+	assert.NoError(t, err)
+
+	ivan, ivanToken, err := client.createAccount("Ivan", "ivan@testing.ru")
+	assert.NoError(t, err)
+
+	createAd := func(userID int64, token string) error {
+		var response adResponse
+		return client.send(http.MethodPost, "/ads", token,
+			map[string]any{"user_id": userID, "title": "hello", "text": "world"}, &response)
+	}
+
+	assert.NoError(t, createAd(oleg.Data.ID, olegToken))
+	assert.ErrorIs(t, createAd(oleg.Data.ID, olegToken), ErrTooManyRequests)
+
+	// users behind the same address do not share a bucket
+	assert.NoError(t, createAd(ivan.Data.ID, ivanToken))
+
+	// nor do they with the requests of the address without a session
+	assert.NoError(t, createAd(oleg.Data.ID, ""))
+	assert.ErrorIs(t, createAd(oleg.Data.ID, ""), ErrTooManyRequests)
+}
+
+func TestRateLimit_Gateway(t *testing.T) {
+	limiter := ratelimit.New(map[string]ratelimit.Rule{
+		"POST /api/v1/users":     {Rate: 0.001, Burst: 1},
+		"GET /api/v1/ads/:ad_id": {Rate: 0.001, Burst: 1},
+	})
+
+	gw, err := gateway.NewHandler(context.Background(), newGatewayConn(t))
+	assert.NoError(t, err)
+
+	// no session is used, so the app only has to authenticate none
+	server := httpgin.NewGatewayServer(":18080", gw, httpgin.RateLimitMW(limiter, app.NewApp(repo.New(), repo.New(), repo.New())))
+	testServer := httptest.NewServer(server.Handler)
+	t.Cleanup(testServer.Close)
+
+	client := &testClient{client: testServer.Client(), BaseURL: testServer.URL}
+
+	user, err := client.CreateUser("Oleg", "oleg@testing.ru")
+	assert.NoError(t, err)
+
+	_, err = client.CreateUser("Ivan", "ivan@testing.ru")
+	assert.ErrorIs(t, err, ErrTooManyRequests)
+
+	_, err = client.CreateAd(user.Data.ID, "hello", "world")
+	assert.NoError(t, err)
+
+	_, err = client.CreateAd(user.Data.ID, "hello", "world")
+	assert.NoError(t, err)
+
+	// the paths of a route share its bucket
+	_, err = client.getAd(0)
+	assert.NoError(t, err)
+
+	_, err = client.getAd(1)
+	assert.ErrorIs(t, err, ErrTooManyRequests)
+}
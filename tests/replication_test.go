@@ -0,0 +1,233 @@
+package tests
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"homework10/internal/adapters/repo"
+	"homework10/internal/app"
+	grpcPort "homework10/internal/ports/grpc"
+	"homework10/internal/replication"
+	"homework10/internal/tlscert"
+	"homework10/internal/tlscert/tlscerttest"
+)
+
+// serveReplicationTest serves srv on an in-process listener and returns a
+// connection to it made with creds.
+func serveReplicationTest(t *testing.T, srv *grpc.Server, creds credentials.TransportCredentials) *grpc.ClientConn {
+	lis := bufconn.Listen(1024 * 1024)
+	t.Cleanup(func() {
+		lis.Close()
+	})
+	t.Cleanup(srv.Stop)
+
+	go func() {
+		assert.NoError(t, srv.Serve(lis), "srv.Serve")
+	}()
+
+	dialer := func(context.Context, string) (net.Conn, error) {
+		return lis.Dial()
+	}
+
+	conn, err := grpc.NewClient("passthrough:///bufnet", grpc.WithContextDialer(dialer), grpc.WithTransportCredentials(creds))
+	assert.NoError(t, err, "grpc.NewClient")
+	t.Cleanup(func() {
+		conn.Close()
+	})
+
+	return conn
+}
+
+// replicationCerts returns the certificates of a primary issued by ca.
+func replicationCerts(t *testing.T, ca *tlscerttest.CA) *tlscert.Reloader {
+	dir := t.TempDir()
+	certPEM, keyPEM := ca.Issue(t, "primary", "")
+
+	certs, err := tlscert.NewReloader(
+		tlscerttest.WriteFile(t, dir, "cert.pem", certPEM),
+		tlscerttest.WriteFile(t, dir, "key.pem", keyPEM),
+		tlscerttest.WriteFile(t, dir, "ca.pem", ca.PEM))
+	assert.NoError(t, err)
+
+	return certs
+}
+
+// clientCreds are the credentials of a client authenticated as identity.
+func clientCreds(t *testing.T, ca *tlscerttest.CA, identity string) credentials.TransportCredentials {
+	return credentials.NewTLS(&tls.Config{
+		RootCAs:      ca.Pool(),
+		ServerName:   "localhost",
+		Certificates: []tls.Certificate{ca.KeyPair(t, "client", identity)},
+	})
+}
+
+const replicaIdentity = "spiffe://ads.local/replica"
+
+func TestReplication(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	t.Cleanup(cancel)
+
+	ca := tlscerttest.NewCA(t)
+
+	names := []string{"ads", "users", "conversations"}
+
+	// the primary keeps only the last 4 changes for the replicas
+	sources := make(map[string]replication.Source)
+	primaryRepos := make(map[string]*repo.Repo)
+	for _, name := range names {
+		r := repo.New().(*repo.Repo)
+		r.Replicate(4, nil)
+		primaryRepos[name] = r
+		sources[name] = r
+	}
+
+	primarySrv := grpc.NewServer(
+		grpc.Creds(credentials.NewTLS(replicationCerts(t, ca).ServerConfig(true))),
+		grpc.ChainStreamInterceptor(grpcPort.PeerIdentityStreamInterceptor),
+	)
+	grpcPort.RegisterAdServiceServer(primarySrv, grpcPort.NewService(app.NewApp(primaryRepos["ads"], primaryRepos["users"], primaryRepos["conversations"])))
+	grpcPort.RegisterReplicationServer(primarySrv, grpcPort.NewReplicationService(sources, 20*time.Millisecond, []string{replicaIdentity}))
+	primaryConn := serveReplicationTest(t, primarySrv, clientCreds(t, ca, replicaIdentity))
+	primary := grpcPort.NewAdServiceClient(primaryConn)
+
+	user, err := primary.CreateUser(ctx, &grpcPort.CreateUserRequest{Name: "Oleg", Email: "oleg@example.com"})
+	assert.NoError(t, err)
+	for _, title := range []string{"bike", "car", "boat"} {
+		_, err := primary.CreateAd(ctx, &grpcPort.CreateAdRequest{Title: title, Text: "for sale", UserId: user.Id})
+		assert.NoError(t, err)
+	}
+
+	sinks := make(map[string]replication.Sink)
+	replicaRepos := make(map[string]*repo.Repo)
+	for _, name := range names {
+		r := repo.NewReplica()
+		replicaRepos[name] = r
+		sinks[name] = r
+	}
+
+	replicaSrv := grpc.NewServer(grpc.ChainUnaryInterceptor(grpcPort.ReadOnlyInterceptor(
+		grpcPort.AdService_GetAd_FullMethodName,
+		grpcPort.AdService_ListAds_FullMethodName,
+		grpcPort.AdService_SearchAds_FullMethodName,
+	)))
+	grpcPort.RegisterAdServiceServer(replicaSrv, grpcPort.NewService(app.NewApp(replicaRepos["ads"], replicaRepos["users"], replicaRepos["conversations"])))
+	replica := grpcPort.NewAdServiceClient(serveReplicationTest(t, replicaSrv, insecure.NewCredentials()))
+
+	follow := func() (*grpcPort.Replica, func()) {
+		followCtx, cancel := context.WithCancel(ctx)
+		follower := grpcPort.NewReplica(primaryConn, sinks, 10*time.Millisecond, slog.New(slog.NewTextHandler(io.Discard, nil)))
+		ready := follower.ReadyCheck(time.Second)
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			follower.Run(followCtx)
+		}()
+
+		// a new replica catches up with a dump of the primary
+		assert.Eventually(t, func() bool { return ready(ctx) == nil }, 5*time.Second, 5*time.Millisecond)
+
+		return follower, func() {
+			cancel()
+			<-done
+		}
+	}
+
+	titles := func(client grpcPort.AdServiceClient) []string {
+		res, err := client.SearchAds(ctx, &grpcPort.SearchAdsRequest{})
+		assert.NoError(t, err)
+
+		var titles []string
+		for _, ad := range res.List {
+			titles = append(titles, ad.Title)
+		}
+		return titles
+	}
+
+	_, stop := follow()
+	assert.ElementsMatch(t, []string{"bike", "car", "boat"}, titles(replica))
+
+	// the following changes are streamed
+	_, err = primary.UpdateAd(ctx, &grpcPort.UpdateAdRequest{AdId: 0, Title: "bicycle", Text: "for sale", UserId: user.Id})
+	assert.NoError(t, err)
+	_, err = primary.ChangeAdStatus(ctx, &grpcPort.ChangeAdStatusRequest{AdId: 1, UserId: user.Id, Published: true})
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		ad, err := replica.GetAd(ctx, &grpcPort.GetAdRequest{Id: 0})
+		return err == nil && ad.Title == "bicycle"
+	}, 5*time.Second, 5*time.Millisecond)
+
+	res, err := replica.ListAds(ctx, &grpcPort.ListAdsRequest{Published: true, UserId: -1})
+	assert.NoError(t, err)
+	if assert.Len(t, res.List, 1) {
+		assert.Equal(t, "car", res.List[0].Title)
+	}
+
+	// writes are left to the primary
+	_, err = replica.CreateUser(ctx, &grpcPort.CreateUserRequest{Name: "Ivan"})
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+
+	// a replica disconnected for more changes than the primary keeps catches
+	// up with a dump again
+	stop()
+	_, err = primary.DeleteAd(ctx, &grpcPort.DeleteAdRequest{AdId: 2, AuthorId: user.Id})
+	assert.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		_, err := primary.UpdateAd(ctx, &grpcPort.UpdateAdRequest{AdId: 1, Title: "truck", Text: "for sale", UserId: user.Id})
+		assert.NoError(t, err)
+	}
+
+	_, behind := replicaRepos["ads"].Position()
+	_, _, err = sources["ads"].Since(replicaRepos["ads"].Position())
+	assert.ErrorIs(t, err, replication.Behind, "the replica at change %d must need a dump", behind)
+
+	follower, stop := follow()
+	defer stop()
+	assert.ElementsMatch(t, []string{"bicycle", "truck"}, titles(replica))
+
+	_, primarySeq := primaryRepos["ads"].Position()
+	_, replicaSeq := replicaRepos["ads"].Position()
+	assert.Equal(t, primarySeq, replicaSeq)
+	assert.Zero(t, follower.Lag()["ads"].Changes)
+}
+
+func TestReplication_Unauthorized(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	t.Cleanup(cancel)
+
+	ca := tlscerttest.NewCA(t)
+	r := repo.New().(*repo.Repo)
+	r.Replicate(4, nil)
+
+	srv := grpc.NewServer(
+		grpc.Creds(credentials.NewTLS(replicationCerts(t, ca).ServerConfig(false))),
+		grpc.ChainStreamInterceptor(grpcPort.PeerIdentityStreamInterceptor),
+	)
+	grpcPort.RegisterReplicationServer(srv, grpcPort.NewReplicationService(map[string]replication.Source{"ads": r}, time.Second, []string{replicaIdentity}))
+
+	follow := func(creds credentials.TransportCredentials) codes.Code {
+		client := grpcPort.NewReplicationClient(serveReplicationTest(t, srv, creds))
+		stream, err := client.Follow(ctx, &grpcPort.FollowRequest{Repository: "ads"})
+		if err == nil {
+			_, err = stream.Recv()
+		}
+		return status.Code(err)
+	}
+
+	anonymous := credentials.NewTLS(&tls.Config{RootCAs: ca.Pool(), ServerName: "localhost"})
+	assert.Equal(t, codes.Unauthenticated, follow(anonymous))
+	assert.Equal(t, codes.PermissionDenied, follow(clientCreds(t, ca, "spiffe://ads.local/billing")))
+}
@@ -0,0 +1,204 @@
+package tests
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"homework10/internal/adapters/repo"
+	"homework10/internal/app"
+	"homework10/internal/users"
+)
+
+type recordingNotifier struct {
+	mu            sync.Mutex
+	notifications map[int64][]users.Notification
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, user users.User, notification users.Notification) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.notifications[user.ID] = append(n.notifications[user.ID], notification)
+	return nil
+}
+
+func (n *recordingNotifier) received(userID int64) []users.Notification {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.notifications[userID]
+}
+
+// blockingNotifier records the notifications it is given once it is
+// released.
+type blockingNotifier struct {
+	recordingNotifier
+	release chan struct{}
+}
+
+func (n *blockingNotifier) Notify(ctx context.Context, user users.User, notification users.Notification) error {
+	<-n.release
+	return n.recordingNotifier.Notify(ctx, user, notification)
+}
+
+func TestSaveSearch(t *testing.T) {
+	client := GetTestClient()
+
+	user, userToken, err := client.createAccount("Test User", "test@testing.ru")
+	assert.NoError(t, err)
+
+	search, err := client.saveSearch(user.Data.ID, map[string]any{"pattern": "cat"})
+	assert.NoError(t, err)
+	assert.Equal(t, search.Data.Pattern, "cat")
+	assert.Equal(t, search.Data.AuthorID, int64(-1))
+
+	_, err = client.saveSearch(user.Data.ID, map[string]any{"pattern": "dog", "author_id": 0})
+	assert.NoError(t, err)
+
+	_, err = client.listSavedSearches(user.Data.ID, "")
+	assert.ErrorIs(t, err, ErrUnauthorized)
+
+	_, other, err := client.createAccount("Other User", "other@testing.ru")
+	assert.NoError(t, err)
+
+	_, err = client.listSavedSearches(user.Data.ID, other)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	searches, err := client.listSavedSearches(user.Data.ID, userToken)
+	assert.NoError(t, err)
+	assert.Len(t, searches.Data, 2)
+	assert.Equal(t, searches.Data[1].AuthorID, int64(0))
+
+	err = client.deleteSavedSearch(user.Data.ID, search.Data.ID)
+	assert.NoError(t, err)
+
+	err = client.deleteSavedSearch(user.Data.ID, search.Data.ID)
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	searches, err = client.listSavedSearches(user.Data.ID, userToken)
+	assert.NoError(t, err)
+	assert.Len(t, searches.Data, 1)
+}
+
+func TestSavedSearchNotifications(t *testing.T) {
+	client := GetTestClient()
+
+	author, err := client.CreateUser("Author", "author@testing.ru")
+	assert.NoError(t, err)
+
+	subscriber, subscriberToken, err := client.createAccount("Subscriber", "subscriber@testing.ru")
+	assert.NoError(t, err)
+
+	_, err = client.saveSearch(subscriber.Data.ID, map[string]any{"pattern": "cat"})
+	assert.NoError(t, err)
+
+	cat, err := client.CreateAd(author.Data.ID, "best cat", "not for sale")
+	assert.NoError(t, err)
+
+	dog, err := client.CreateAd(author.Data.ID, "best dog", "not for sale")
+	assert.NoError(t, err)
+
+	notifications, err := client.listNotifications(subscriber.Data.ID, subscriberToken)
+	assert.NoError(t, err)
+	assert.Empty(t, notifications.Data)
+
+	_, err = client.ChangeAdStatus(author.Data.ID, cat.Data.ID, true)
+	assert.NoError(t, err)
+
+	_, err = client.ChangeAdStatus(author.Data.ID, dog.Data.ID, true)
+	assert.NoError(t, err)
+
+	_, err = client.ChangeAdStatus(author.Data.ID, cat.Data.ID, true)
+	assert.NoError(t, err)
+
+	notifications, err = client.listNotifications(subscriber.Data.ID, subscriberToken)
+	assert.NoError(t, err)
+	assert.Len(t, notifications.Data, 1)
+	assert.Equal(t, notifications.Data[0].AdID, cat.Data.ID)
+}
+
+func TestSavedSearchNotifier(t *testing.T) {
+	notifier := &recordingNotifier{notifications: make(map[int64][]users.Notification)}
+	a := app.NewApp(repo.New(), repo.New(), repo.New(), app.WithNotifier(notifier))
+
+	author, err := a.CreateUser(context.Background(), "Author", "author@testing.ru")
+	assert.NoError(t, err)
+
+	subscriber, err := a.CreateUser(context.Background(), "Subscriber", "subscriber@testing.ru")
+	assert.NoError(t, err)
+
+	_, err = a.SaveSearch(context.Background(), subscriber.ID, "", author.ID)
+	assert.NoError(t, err)
+
+	ad, err := a.CreateAd(context.Background(), "hello", "world", author.ID)
+	assert.NoError(t, err)
+
+	_, err = a.ChangeAdStatus(context.Background(), ad.ID, author.ID, true)
+	assert.NoError(t, err)
+
+	// the notifier is called in the background
+	assert.Eventually(t, func() bool { return len(notifier.received(subscriber.ID)) == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, notifier.received(subscriber.ID)[0].AdID, ad.ID)
+	assert.Empty(t, notifier.received(author.ID))
+}
+
+func TestSavedSearchNotifier_Slow(t *testing.T) {
+	notifier := &blockingNotifier{recordingNotifier: recordingNotifier{notifications: make(map[int64][]users.Notification)},
+		release: make(chan struct{})}
+	a := app.NewApp(repo.New(), repo.New(), repo.New(), app.WithNotifier(notifier), app.WithDeliveryQueue(1))
+
+	author, err := a.CreateUser(context.Background(), "Author", "author@testing.ru")
+	assert.NoError(t, err)
+
+	var subscribers []users.User
+	for i := 0; i < 3; i++ {
+		subscriber, err := a.CreateUser(context.Background(), "Subscriber", "subscriber@testing.ru")
+		assert.NoError(t, err)
+
+		_, err = a.SaveSearch(context.Background(), subscriber.ID, "", author.ID)
+		assert.NoError(t, err)
+
+		subscribers = append(subscribers, subscriber)
+	}
+
+	ad, err := a.CreateAd(context.Background(), "hello", "world", author.ID)
+	assert.NoError(t, err)
+
+	// the change does not wait for the notifier, nor for room in the queue
+	done := make(chan error)
+	go func() {
+		_, err := a.ChangeAdStatus(context.Background(), ad.ID, author.ID, true)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatalf(`test %q: expect %v got %v`, "Slow notifier", "the change done", "the change blocked")
+	}
+
+	// the inbox is written on the way
+	for _, subscriber := range subscribers {
+		notifications, err := a.ListNotifications(context.Background(), subscriber.ID)
+		assert.NoError(t, err)
+		assert.Len(t, notifications, 1)
+	}
+
+	// one notification is held by the notifier and one waits in the queue,
+	// the last one is dropped
+	received := func() int {
+		count := 0
+		for _, subscriber := range subscribers {
+			count += len(notifier.received(subscriber.ID))
+		}
+		return count
+	}
+
+	close(notifier.release)
+	assert.Eventually(t, func() bool { return received() > 0 }, time.Second, time.Millisecond)
+	assert.Never(t, func() bool { return received() == len(subscribers) }, 100*time.Millisecond, time.Millisecond)
+}
@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"homework10/internal/adapters/repo"
+	"homework10/internal/app"
+	grpcPort "homework10/internal/ports/grpc"
+	"homework10/internal/ratelimit"
+	"homework10/internal/tlscert"
+	"homework10/internal/tlscert/tlscerttest"
+)
+
+func TestGRPCMutualTLSIdentity(t *testing.T) {
+	ca := tlscerttest.NewCA(t)
+	dir := t.TempDir()
+	certPEM, keyPEM := ca.Issue(t, "ad-service", "")
+
+	certs, err := tlscert.NewReloader(
+		tlscerttest.WriteFile(t, dir, "cert.pem", certPEM),
+		tlscerttest.WriteFile(t, dir, "key.pem", keyPEM),
+		tlscerttest.WriteFile(t, dir, "ca.pem", ca.PEM))
+	assert.NoError(t, err)
+
+	limiter := ratelimit.New(map[string]ratelimit.Rule{
+		grpcPort.AdService_SearchAds_FullMethodName: {Rate: 0, Burst: 1},
+	})
+	a := app.NewApp(repo.New(), repo.New(), repo.New())
+
+	lis := bufconn.Listen(1024 * 1024)
+	t.Cleanup(func() {
+		lis.Close()
+	})
+
+	srv := grpc.NewServer(
+		grpc.Creds(credentials.NewTLS(certs.ServerConfig(true))),
+		grpc.ChainUnaryInterceptor(grpcPort.PeerIdentityInterceptor, grpcPort.RateLimitInterceptor(limiter, a)),
+	)
+	t.Cleanup(func() {
+		srv.Stop()
+	})
+
+	svc := grpcPort.NewService(a)
+	grpcPort.RegisterAdServiceServer(srv, svc)
+
+	go func() {
+		assert.NoError(t, srv.Serve(lis), "srv.Serve")
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	t.Cleanup(func() {
+		cancel()
+	})
+
+	dial := func(cert tls.Certificate) grpcPort.AdServiceClient {
+		creds := credentials.NewTLS(&tls.Config{
+			RootCAs:      ca.Pool(),
+			ServerName:   "localhost",
+			Certificates: []tls.Certificate{cert},
+		})
+
+		conn, err := grpc.DialContext(ctx, "", grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return lis.Dial()
+		}), grpc.WithTransportCredentials(creds))
+		assert.NoError(t, err, "grpc.DialContext")
+
+		t.Cleanup(func() {
+			conn.Close()
+		})
+
+		return grpcPort.NewAdServiceClient(conn)
+	}
+
+	billing := dial(ca.KeyPair(t, "billing", "spiffe://ads.local/billing"))
+	search := dial(ca.KeyPair(t, "search", "spiffe://ads.local/search"))
+
+	_, err = billing.SearchAds(ctx, &grpcPort.SearchAdsRequest{Pattern: "bike"})
+	assert.NoError(t, err)
+
+	_, err = billing.SearchAds(ctx, &grpcPort.SearchAdsRequest{Pattern: "bike"})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	_, err = search.SearchAds(ctx, &grpcPort.SearchAdsRequest{Pattern: "bike"})
+	assert.NoError(t, err)
+}
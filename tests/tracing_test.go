@@ -0,0 +1,156 @@
+package tests
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+
+	"homework10/internal/adapters/repo"
+	"homework10/internal/app"
+	grpcPort "homework10/internal/ports/grpc"
+	"homework10/internal/ports/httpgin"
+	"homework10/internal/tracing"
+)
+
+const (
+	testTraceID     = "4bf92f3577b34da6a3ce929d0e0e4736"
+	testTraceparent = "00-" + testTraceID + "-00f067aa0ba902b7-01"
+)
+
+func newTracedApp(tp *sdktrace.TracerProvider) app.App {
+	return tracing.App(app.NewApp(
+		tracing.Repository("ads", repo.New(), tp),
+		tracing.Repository("users", repo.New(), tp),
+		tracing.Repository("conversations", repo.New(), tp),
+	), tp)
+}
+
+func spanByName(spans tracetest.SpanStubs, name string) (tracetest.SpanStub, bool) {
+	for _, span := range spans {
+		if span.Name == name {
+			return span, true
+		}
+	}
+
+	return tracetest.SpanStub{}, false
+}
+
+func TestHTTPTracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	server := httpgin.NewHTTPServer(":18080", newTracedApp(tp),
+		otelgin.Middleware("test", otelgin.WithTracerProvider(tp), otelgin.WithPropagators(tracing.Propagator())))
+	testServer := httptest.NewServer(server.Handler)
+	t.Cleanup(testServer.Close)
+
+	req, err := http.NewRequest(http.MethodPost, testServer.URL+"/api/v1/users",
+		strings.NewReader(`{"name": "Oleg", "email": "oleg@testing.ru"}`))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("traceparent", testTraceparent)
+
+	resp, err := testServer.Client().Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	spans := exporter.GetSpans()
+	for _, span := range spans {
+		assert.Equal(t, testTraceID, span.SpanContext.TraceID().String(), span.Name)
+	}
+
+	handler, ok := spanByName(spans, "POST /api/v1/users")
+	assert.True(t, ok)
+	service, ok := spanByName(spans, "AdService.CreateUser")
+	assert.True(t, ok)
+	add, ok := spanByName(spans, "repository.users.Add")
+	assert.True(t, ok)
+
+	assert.Equal(t, handler.SpanContext.SpanID(), service.Parent.SpanID())
+	assert.Equal(t, service.SpanContext.SpanID(), add.Parent.SpanID())
+
+	exporter.Reset()
+
+	client := &testClient{client: testServer.Client(), BaseURL: testServer.URL}
+	_, err = client.getAd(42)
+	assert.ErrorIs(t, err, ErrBadRequest)
+
+	getAd, ok := spanByName(exporter.GetSpans(), "AdService.GetAd")
+	assert.True(t, ok)
+	assert.Equal(t, codes.Error, getAd.Status.Code)
+	assert.Contains(t, getAd.Attributes, attribute.Int64("ad.id", 42))
+}
+
+func TestGRPCTracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	lis := bufconn.Listen(1024 * 1024)
+	t.Cleanup(func() {
+		lis.Close()
+	})
+
+	srv := grpc.NewServer(grpc.StatsHandler(otelgrpc.NewServerHandler(
+		otelgrpc.WithTracerProvider(tp), otelgrpc.WithPropagators(tracing.Propagator()))))
+	t.Cleanup(func() {
+		srv.Stop()
+	})
+
+	svc := grpcPort.NewService(newTracedApp(tp))
+	grpcPort.RegisterAdServiceServer(srv, svc)
+
+	go func() {
+		assert.NoError(t, srv.Serve(lis), "srv.Serve")
+	}()
+
+	dialer := func(context.Context, string) (net.Conn, error) {
+		return lis.Dial()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	t.Cleanup(func() {
+		cancel()
+	})
+
+	conn, err := grpc.DialContext(ctx, "", grpc.WithContextDialer(dialer), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(t, err, "grpc.DialContext")
+
+	t.Cleanup(func() {
+		conn.Close()
+	})
+
+	client := grpcPort.NewAdServiceClient(conn)
+
+	user, err := client.CreateUser(metadata.AppendToOutgoingContext(ctx, "traceparent", testTraceparent),
+		&grpcPort.CreateUserRequest{Name: "Oleg", Email: "oleg@testing.ru"})
+	assert.NoError(t, err)
+
+	spans := exporter.GetSpans()
+	for _, span := range spans {
+		assert.Equal(t, testTraceID, span.SpanContext.TraceID().String(), span.Name)
+	}
+
+	rpc, ok := spanByName(spans, grpcPort.AdService_CreateUser_FullMethodName[1:])
+	assert.True(t, ok)
+	service, ok := spanByName(spans, "AdService.CreateUser")
+	assert.True(t, ok)
+
+	assert.Equal(t, rpc.SpanContext.SpanID(), service.Parent.SpanID())
+	assert.Contains(t, service.Attributes, attribute.Int64("user.id", user.Id))
+}
@@ -9,8 +9,11 @@ import (
 	"net/http/httptest"
 	"time"
 
+	"github.com/gin-gonic/gin"
+
 	"homework10/internal/adapters/repo"
 	"homework10/internal/app"
+	"homework10/internal/password"
 	"homework10/internal/ports/httpgin"
 )
 
@@ -22,6 +25,7 @@ type adData struct {
 	Published bool      `json:"published"`
 	CreatedAt time.Time `json:"creation_time"`
 	UpdatedAt time.Time `json:"update_time"`
+	Favorites int64     `json:"favorites"`
 }
 
 type adResponse struct {
@@ -32,10 +36,86 @@ type adsResponse struct {
 	Data []adData `json:"data"`
 }
 
+type revisionData struct {
+	ID        int64     `json:"id"`
+	Title     string    `json:"title"`
+	Text      string    `json:"text"`
+	UpdatedAt time.Time `json:"update_time"`
+}
+
+type revisionsResponse struct {
+	Data []revisionData `json:"data"`
+}
+
+type savedSearchData struct {
+	ID        int64     `json:"id"`
+	Pattern   string    `json:"pattern"`
+	AuthorID  int64     `json:"author_id"`
+	CreatedAt time.Time `json:"creation_time"`
+}
+
+type savedSearchResponse struct {
+	Data savedSearchData `json:"data"`
+}
+
+type savedSearchesResponse struct {
+	Data []savedSearchData `json:"data"`
+}
+
+type notificationData struct {
+	ID        int64     `json:"id"`
+	AdID      int64     `json:"ad_id"`
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"creation_time"`
+}
+
+type notificationsResponse struct {
+	Data []notificationData `json:"data"`
+}
+
+type conversationData struct {
+	ID        int64     `json:"id"`
+	AdID      int64     `json:"ad_id"`
+	BuyerID   int64     `json:"buyer_id"`
+	SellerID  int64     `json:"seller_id"`
+	Closed    bool      `json:"closed"`
+	Unread    int64     `json:"unread"`
+	CreatedAt time.Time `json:"creation_time"`
+}
+
+type conversationResponse struct {
+	Data conversationData `json:"data"`
+}
+
+type conversationsResponse struct {
+	Data []conversationData `json:"data"`
+}
+
+type messageData struct {
+	ID        int64     `json:"id"`
+	AuthorID  int64     `json:"author_id"`
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"creation_time"`
+}
+
+type messageResponse struct {
+	Data messageData `json:"data"`
+}
+
+type messagesResponse struct {
+	Data []messageData `json:"data"`
+}
+
 type userData struct {
-	ID    int64  `json:"id"`
-	Name  string `json:"name"`
-	Email string `json:"email"`
+	ID               int64     `json:"id"`
+	Name             string    `json:"name"`
+	Email            string    `json:"email"`
+	DisplayName      string    `json:"display_name"`
+	AvatarURL        string    `json:"avatar_url"`
+	Phone            string    `json:"phone"`
+	City             string    `json:"city"`
+	Bio              string    `json:"bio"`
+	RegistrationTime time.Time `json:"registration_time"`
 }
 
 type userResponse struct {
@@ -43,8 +123,10 @@ type userResponse struct {
 }
 
 var (
-	ErrBadRequest = fmt.Errorf("bad request")
-	ErrForbidden  = fmt.Errorf("forbidden")
+	ErrBadRequest      = fmt.Errorf("bad request")
+	ErrUnauthorized    = fmt.Errorf("unauthorized")
+	ErrForbidden       = fmt.Errorf("forbidden")
+	ErrTooManyRequests = fmt.Errorf("too many requests")
 )
 
 type testClient struct {
@@ -52,8 +134,11 @@ type testClient struct {
 	BaseURL string
 }
 
-func GetTestClient() *testClient {
-	server := httpgin.NewHTTPServer(":18080", app.NewApp(repo.New(), repo.New()))
+// testHasher is a cheap password hasher for the accounts of the tests.
+var testHasher = password.NewArgon2id(password.Argon2idParams{Memory: 64, Time: 1, Threads: 1, SaltLen: 16, KeyLen: 32})
+
+func GetTestClient(middlewares ...gin.HandlerFunc) *testClient {
+	server := httpgin.NewHTTPServer(":18080", app.NewApp(repo.New(), repo.New(), repo.New(), app.WithHasher(testHasher)), middlewares...)
 	testServer := httptest.NewServer(server.Handler)
 
 	return &testClient{
@@ -62,6 +147,13 @@ func GetTestClient() *testClient {
 	}
 }
 
+// withToken sends the session token as a bearer token, if there is one.
+func withToken(req *http.Request, token string) {
+	if token != "" {
+		req.Header.Add("Authorization", "Bearer "+token)
+	}
+}
+
 func (tc *testClient) getResponse(req *http.Request, out any) error {
 	resp, err := tc.client.Do(req)
 	if err != nil {
@@ -72,9 +164,15 @@ func (tc *testClient) getResponse(req *http.Request, out any) error {
 		if resp.StatusCode == http.StatusBadRequest {
 			return ErrBadRequest
 		}
+		if resp.StatusCode == http.StatusUnauthorized {
+			return ErrUnauthorized
+		}
 		if resp.StatusCode == http.StatusForbidden {
 			return ErrForbidden
 		}
+		if resp.StatusCode == http.StatusTooManyRequests {
+			return ErrTooManyRequests
+		}
 		return fmt.Errorf("unexpected status code: %s", resp.Status)
 	}
 
@@ -253,6 +351,48 @@ func (tc *testClient) searchAds(pattern string) (adsResponse, error) {
 	return response, nil
 }
 
+func (tc *testClient) listAdRevisions(adID int64) (revisionsResponse, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(tc.BaseURL+"/api/v1/ads/%d/revisions", adID), nil)
+	if err != nil {
+		return revisionsResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	var response revisionsResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return revisionsResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) rollbackAd(userID int64, adID int64, revisionID int64) (adResponse, error) {
+	body := map[string]any{
+		"user_id":     userID,
+		"revision_id": revisionID,
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return adResponse{}, fmt.Errorf("unable to marshal: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPut, fmt.Sprintf(tc.BaseURL+"/api/v1/ads/%d/rollback", adID), bytes.NewReader(data))
+	if err != nil {
+		return adResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+
+	var response adResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return adResponse{}, err
+	}
+
+	return response, nil
+}
+
 func (tc *testClient) CreateUser(name string, email string) (userResponse, error) {
 	body := map[string]any{
 		"name":  name,
@@ -307,12 +447,14 @@ func (tc *testClient) updateUser(userID int64, name string, email string) (userR
 	return response, nil
 }
 
-func (tc *testClient) getUser(userID int64) (userResponse, error) {
+func (tc *testClient) getUser(userID int64, token string) (userResponse, error) {
 	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(tc.BaseURL+"/api/v1/users/%d", userID), nil)
 	if err != nil {
 		return userResponse{}, fmt.Errorf("unable to create request: %w", err)
 	}
 
+	withToken(req, token)
+
 	var response userResponse
 	err = tc.getResponse(req, &response)
 	if err != nil {
@@ -331,3 +473,241 @@ func (tc *testClient) deleteUser(userID int64) error {
 	var response userResponse
 	return tc.getResponse(req, &response)
 }
+
+func (tc *testClient) addFavorite(userID int64, adID int64, notify bool) (adResponse, error) {
+	body := map[string]any{
+		"ad_id":  adID,
+		"notify": notify,
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return adResponse{}, fmt.Errorf("unable to marshal: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf(tc.BaseURL+"/api/v1/users/%d/favorites", userID), bytes.NewReader(data))
+	if err != nil {
+		return adResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+
+	var response adResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return adResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) removeFavorite(userID int64, adID int64) (adResponse, error) {
+	req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf(tc.BaseURL+"/api/v1/users/%d/favorites/%d", userID, adID), nil)
+	if err != nil {
+		return adResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	var response adResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return adResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) listFavorites(userID int64, token string) (adsResponse, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(tc.BaseURL+"/api/v1/users/%d/favorites", userID), nil)
+	if err != nil {
+		return adsResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	withToken(req, token)
+
+	var response adsResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return adsResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) listNotifications(userID int64, token string) (notificationsResponse, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(tc.BaseURL+"/api/v1/users/%d/notifications", userID), nil)
+	if err != nil {
+		return notificationsResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	withToken(req, token)
+
+	var response notificationsResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return notificationsResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) openConversation(userID int64, adID int64) (conversationResponse, error) {
+	body := map[string]any{
+		"user_id": userID,
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return conversationResponse{}, fmt.Errorf("unable to marshal: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf(tc.BaseURL+"/api/v1/ads/%d/conversations", adID), bytes.NewReader(data))
+	if err != nil {
+		return conversationResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+
+	var response conversationResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return conversationResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) listConversations(userID int64, token string) (conversationsResponse, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(tc.BaseURL+"/api/v1/users/%d/conversations", userID), nil)
+	if err != nil {
+		return conversationsResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	withToken(req, token)
+
+	var response conversationsResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return conversationsResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) sendMessage(userID int64, conversationID int64, text string) (messageResponse, error) {
+	body := map[string]any{
+		"user_id": userID,
+		"text":    text,
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return messageResponse{}, fmt.Errorf("unable to marshal: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf(tc.BaseURL+"/api/v1/conversations/%d/messages", conversationID), bytes.NewReader(data))
+	if err != nil {
+		return messageResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+
+	var response messageResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return messageResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) listMessages(conversationID int64, token string) (messagesResponse, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(tc.BaseURL+"/api/v1/conversations/%d/messages", conversationID), nil)
+	if err != nil {
+		return messagesResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	withToken(req, token)
+
+	var response messagesResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return messagesResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) closeConversation(userID int64, conversationID int64) (conversationResponse, error) {
+	body := map[string]any{
+		"user_id": userID,
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return conversationResponse{}, fmt.Errorf("unable to marshal: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPut, fmt.Sprintf(tc.BaseURL+"/api/v1/conversations/%d/close", conversationID), bytes.NewReader(data))
+	if err != nil {
+		return conversationResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+
+	var response conversationResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return conversationResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) saveSearch(userID int64, body map[string]any) (savedSearchResponse, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return savedSearchResponse{}, fmt.Errorf("unable to marshal: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf(tc.BaseURL+"/api/v1/users/%d/searches", userID), bytes.NewReader(data))
+	if err != nil {
+		return savedSearchResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	req.Header.Add("Content-Type", "application/json")
+
+	var response savedSearchResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return savedSearchResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) listSavedSearches(userID int64, token string) (savedSearchesResponse, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf(tc.BaseURL+"/api/v1/users/%d/searches", userID), nil)
+	if err != nil {
+		return savedSearchesResponse{}, fmt.Errorf("unable to create request: %w", err)
+	}
+
+	withToken(req, token)
+
+	var response savedSearchesResponse
+	err = tc.getResponse(req, &response)
+	if err != nil {
+		return savedSearchesResponse{}, err
+	}
+
+	return response, nil
+}
+
+func (tc *testClient) deleteSavedSearch(userID int64, searchID int64) error {
+	req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf(tc.BaseURL+"/api/v1/users/%d/searches/%d", userID, searchID), nil)
+	if err != nil {
+		return fmt.Errorf("unable to create request: %w", err)
+	}
+
+	var response savedSearchResponse
+	return tc.getResponse(req, &response)
+}
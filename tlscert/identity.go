@@ -0,0 +1,33 @@
+package tlscert
+
+import (
+	"context"
+	"crypto/tls"
+)
+
+type identityKey struct{}
+
+// Identity names the caller by its verified client certificate: the first
+// URI SAN, e.g. a SPIFFE ID, or else the common name. It is empty when the
+// client has not presented a verified certificate.
+func Identity(state *tls.ConnectionState) string {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return ""
+	}
+
+	cert := state.VerifiedChains[0][0]
+	if len(cert.URIs) > 0 {
+		return cert.URIs[0].String()
+	}
+
+	return cert.Subject.CommonName
+}
+
+func WithIdentity(ctx context.Context, identity string) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+func IdentityFromContext(ctx context.Context) string {
+	identity, _ := ctx.Value(identityKey{}).(string)
+	return identity
+}
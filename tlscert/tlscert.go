@@ -0,0 +1,215 @@
+package tlscert
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"github.com/pkg/errors"
+	"os"
+	"sync"
+	"time"
+)
+
+var (
+	InvalidCA   = errors.New("no certificates found in the CA file")
+	UnknownPeer = errors.New("the peer certificate is not the server certificate")
+)
+
+// Reloader serves the certificate and the client CA pool from files and
+// reloads them when the files change, so that rotated certificates are used
+// without a restart.
+type Reloader struct {
+	certFile string
+	keyFile  string
+	caFile   string
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	pool    *x509.CertPool
+	modTime time.Time
+}
+
+// NewReloader loads the key pair and, if caFile is set, the CA certificates
+// the client certificates are verified against.
+func NewReloader(certFile string, keyFile string, caFile string) (*Reloader, error) {
+	r := &Reloader{certFile: certFile, keyFile: keyFile, caFile: caFile}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *Reloader) Reload() error {
+	modTime, err := r.lastModified()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("can't load key pair: %w", err)
+	}
+
+	var pool *x509.CertPool
+	if r.caFile != "" {
+		data, err := os.ReadFile(r.caFile)
+		if err != nil {
+			return fmt.Errorf("can't read CA file: %w", err)
+		}
+
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return InvalidCA
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cert, r.pool, r.modTime = &cert, pool, modTime
+
+	return nil
+}
+
+func (r *Reloader) lastModified() (time.Time, error) {
+	var last time.Time
+	for _, file := range []string{r.certFile, r.keyFile, r.caFile} {
+		if file == "" {
+			continue
+		}
+
+		info, err := os.Stat(file)
+		if err != nil {
+			return last, err
+		}
+		if info.ModTime().After(last) {
+			last = info.ModTime()
+		}
+	}
+
+	return last, nil
+}
+
+// Run checks the files every interval until ctx is done and reloads them
+// when one has changed. A failed reload keeps the previous certificate and
+// is reported to onError, if set.
+func (r *Reloader) Run(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		modTime, err := r.lastModified()
+		if err == nil {
+			r.mu.RLock()
+			changed := !modTime.Equal(r.modTime)
+			r.mu.RUnlock()
+
+			if !changed {
+				continue
+			}
+
+			err = r.Reload()
+		}
+
+		if err != nil && onError != nil {
+			onError(err)
+		}
+	}
+}
+
+func (r *Reloader) Certificate() *tls.Certificate {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.cert
+}
+
+// ServerConfig returns a TLS configuration using the current certificate
+// and CA pool on every handshake. With a CA file, client certificates are
+// verified if given, or always required when requireClientCert is set.
+func (r *Reloader) ServerConfig(requireClientCert bool) *tls.Config {
+	base := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	base.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		r.mu.RLock()
+		defer r.mu.RUnlock()
+
+		cfg := base.Clone()
+		cfg.GetConfigForClient = nil
+		cfg.Certificates = []tls.Certificate{*r.cert}
+
+		if r.pool != nil {
+			cfg.ClientCAs = r.pool
+			cfg.ClientAuth = tls.VerifyClientCertIfGiven
+			if requireClientCert {
+				cfg.ClientAuth = tls.RequireAndVerifyClientCert
+			}
+		}
+
+		return cfg, nil
+	}
+
+	return base
+}
+
+// LoopbackConfig returns a TLS configuration for calling the server from
+// the same process: only the current server certificate is trusted, and it
+// is presented as the client certificate too, so with required client
+// certificates it must be issued by the client CA.
+func (r *Reloader) LoopbackConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		// the chain is checked by VerifyConnection against our own certificate
+		InsecureSkipVerify: true,
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			cert := r.Certificate()
+			if len(cs.PeerCertificates) == 0 || !bytes.Equal(cs.PeerCertificates[0].Raw, cert.Certificate[0]) {
+				return UnknownPeer
+			}
+			return nil
+		},
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return r.Certificate(), nil
+		},
+	}
+}
+
+// PeerConfig returns a TLS configuration for calling another instance of the
+// service, e.g. the primary from a read replica: its certificate is verified
+// against the current CA pool, which the instances share, and the current
+// certificate is presented as the client certificate.
+func (r *Reloader) PeerConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		// the chain is checked by VerifyConnection against the CA pool of
+		// the moment, which may be reloaded
+		InsecureSkipVerify: true,
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			if len(cs.PeerCertificates) == 0 {
+				return UnknownPeer
+			}
+
+			r.mu.RLock()
+			opts := x509.VerifyOptions{Roots: r.pool, DNSName: cs.ServerName, Intermediates: x509.NewCertPool()}
+			r.mu.RUnlock()
+
+			for _, cert := range cs.PeerCertificates[1:] {
+				opts.Intermediates.AddCert(cert)
+			}
+			_, err := cs.PeerCertificates[0].Verify(opts)
+			return err
+		},
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return r.Certificate(), nil
+		},
+	}
+}
@@ -0,0 +1,215 @@
+package tlscert
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"homework10/internal/tlscert/tlscerttest"
+)
+
+type files struct {
+	cert, key, ca string
+}
+
+func writeFiles(t *testing.T, ca *tlscerttest.CA, commonName string) files {
+	dir := t.TempDir()
+	certPEM, keyPEM := ca.Issue(t, commonName, "")
+
+	return files{
+		cert: tlscerttest.WriteFile(t, dir, "cert.pem", certPEM),
+		key:  tlscerttest.WriteFile(t, dir, "key.pem", keyPEM),
+		ca:   tlscerttest.WriteFile(t, dir, "ca.pem", ca.PEM),
+	}
+}
+
+func newServer(t *testing.T, r *Reloader, requireClientCert bool) *httptest.Server {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, _ = io.WriteString(w, Identity(req.TLS))
+	}))
+	server.TLS = r.ServerConfig(requireClientCert)
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func get(server *httptest.Server, ca *tlscerttest.CA, clientCert *tls.Certificate) (string, error) {
+	config := &tls.Config{RootCAs: ca.Pool()}
+	if clientCert != nil {
+		config.Certificates = []tls.Certificate{*clientCert}
+	}
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: config}}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	return string(body), err
+}
+
+func TestNewReloader(t *testing.T) {
+	ca := tlscerttest.NewCA(t)
+	f := writeFiles(t, ca, "server")
+
+	if _, err := NewReloader(f.cert, f.key+".missing", ""); err == nil {
+		t.Fatal("expect error for a missing key")
+	}
+
+	if _, err := NewReloader(f.cert, f.key, f.key); !errors.Is(err, InvalidCA) {
+		t.Fatalf("expect %v got %v", InvalidCA, err)
+	}
+}
+
+func TestMutualTLS(t *testing.T) {
+	ca := tlscerttest.NewCA(t)
+	f := writeFiles(t, ca, "server")
+
+	r, err := NewReloader(f.cert, f.key, f.ca)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	billing := ca.KeyPair(t, "billing", "spiffe://ads.local/billing")
+	search := ca.KeyPair(t, "search", "")
+	stranger := tlscerttest.NewCA(t).KeyPair(t, "stranger", "")
+
+	type Test struct {
+		Name      string
+		Require   bool
+		Cert      *tls.Certificate
+		Expect    string
+		ExpectErr bool
+	}
+
+	tests := [...]Test{
+		{"uri identity", true, &billing, "spiffe://ads.local/billing", false},
+		{"common name identity", true, &search, "search", false},
+		{"no certificate required", true, nil, "", true},
+		{"unknown issuer", false, &stranger, "", true},
+		{"optional certificate", false, nil, "", false},
+	}
+
+	for _, test := range tests {
+		got, err := get(newServer(t, r, test.Require), ca, test.Cert)
+		if (err != nil) != test.ExpectErr {
+			t.Fatalf(`test %q: expect error %v got %v`, test.Name, test.ExpectErr, err)
+		}
+		if got != test.Expect {
+			t.Fatalf(`test %q: expect %q got %q`, test.Name, test.Expect, got)
+		}
+	}
+}
+
+func TestReloader_Run(t *testing.T) {
+	ca := tlscerttest.NewCA(t)
+	f := writeFiles(t, ca, "old")
+
+	r, err := NewReloader(f.cert, f.key, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// the key and the certificate are not replaced at once, so a reload can
+	// fail in between and is retried on the next tick
+	go r.Run(ctx, 10*time.Millisecond, nil)
+
+	server := newServer(t, r, false)
+
+	certPEM, keyPEM := ca.Issue(t, "new", "")
+	if err := os.WriteFile(f.key, keyPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(f.cert, certPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Minute)
+	for _, file := range []string{f.cert, f.key} {
+		if err := os.Chtimes(file, future, future); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		conn, err := tls.Dial("tcp", server.Listener.Addr().String(), &tls.Config{RootCAs: ca.Pool(), ServerName: "localhost"})
+		if err == nil {
+			name := conn.ConnectionState().PeerCertificates[0].Subject.CommonName
+			conn.Close()
+			if name == "new" {
+				break
+			}
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatal("the new certificate was not picked up")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestReloader_PeerConfig(t *testing.T) {
+	ca := tlscerttest.NewCA(t)
+	f := writeFiles(t, ca, "primary")
+
+	server, err := NewReloader(f.cert, f.key, f.ca)
+	if err != nil {
+		t.Fatal(err)
+	}
+	primary := newServer(t, server, true)
+
+	replicaFiles := writeFiles(t, ca, "replica")
+	strangerFiles := writeFiles(t, tlscerttest.NewCA(t), "stranger")
+
+	type Test struct {
+		Name       string
+		Files      files
+		ServerName string
+		Expect     string
+		ExpectErr  bool
+	}
+
+	tests := [...]Test{
+		{"same CA", replicaFiles, "localhost", "replica", false},
+		{"other host name", replicaFiles, "primary.example.com", "", true},
+		{"other CA", strangerFiles, "localhost", "", true},
+	}
+
+	for _, test := range tests {
+		r, err := NewReloader(test.Files.cert, test.Files.key, test.Files.ca)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		config := r.PeerConfig()
+		config.ServerName = test.ServerName
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: config}}
+
+		var got string
+		resp, err := client.Get(primary.URL)
+		if err == nil {
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			got = string(body)
+		}
+
+		if (err != nil) != test.ExpectErr {
+			t.Fatalf(`test %q: expect error %v got %v`, test.Name, test.ExpectErr, err)
+		}
+		if got != test.Expect {
+			t.Fatalf(`test %q: expect %q got %q`, test.Name, test.Expect, got)
+		}
+	}
+}
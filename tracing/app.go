@@ -0,0 +1,340 @@
+package tracing
+
+import (
+	"context"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"homework10/internal/ads"
+	"homework10/internal/app"
+	"homework10/internal/conversations"
+	"homework10/internal/users"
+	"time"
+)
+
+// App wraps a so that every call gets a span, e.g. "AdService.CreateAd",
+// with the IDs of the request and the result as attributes.
+func App(a app.App, tp trace.TracerProvider) app.App {
+	return &tracedApp{app: a, tracer: tp.Tracer(instrumentationName)}
+}
+
+type tracedApp struct {
+	app    app.App
+	tracer trace.Tracer
+}
+
+func (t *tracedApp) start(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return t.tracer.Start(ctx, "AdService."+method, trace.WithAttributes(attrs...))
+}
+
+func (t *tracedApp) CreateAd(ctx context.Context, title string, text string, userId int64) (ads.Ad, error) {
+	ctx, span := t.start(ctx, "CreateAd", attribute.Int64("user.id", userId))
+	ad, err := t.app.CreateAd(ctx, title, text, userId)
+	end(span, err, attribute.Int64("ad.id", ad.ID))
+	return ad, err
+}
+
+func (t *tracedApp) ChangeAdStatus(ctx context.Context, adId int64, userId int64, published bool) (ads.Ad, error) {
+	ctx, span := t.start(ctx, "ChangeAdStatus", attribute.Int64("ad.id", adId), attribute.Int64("user.id", userId))
+	ad, err := t.app.ChangeAdStatus(ctx, adId, userId, published)
+	end(span, err, attribute.Int64("ad.id", ad.ID))
+	return ad, err
+}
+
+func (t *tracedApp) UpdateAd(ctx context.Context, adId int64, userId int64, title string, text string) (ads.Ad, error) {
+	ctx, span := t.start(ctx, "UpdateAd", attribute.Int64("ad.id", adId), attribute.Int64("user.id", userId))
+	ad, err := t.app.UpdateAd(ctx, adId, userId, title, text)
+	end(span, err, attribute.Int64("ad.id", ad.ID))
+	return ad, err
+}
+
+func (t *tracedApp) PatchAd(ctx context.Context, adId int64, userId int64, patch ads.Patch) (ads.Ad, error) {
+	ctx, span := t.start(ctx, "PatchAd", attribute.Int64("ad.id", adId), attribute.Int64("user.id", userId))
+	ad, err := t.app.PatchAd(ctx, adId, userId, patch)
+	end(span, err, attribute.Int64("ad.id", ad.ID))
+	return ad, err
+}
+
+func (t *tracedApp) GetAd(ctx context.Context, adId int64) (ads.Ad, error) {
+	ctx, span := t.start(ctx, "GetAd", attribute.Int64("ad.id", adId))
+	ad, err := t.app.GetAd(ctx, adId)
+	end(span, err, attribute.Int64("ad.id", ad.ID))
+	return ad, err
+}
+
+func (t *tracedApp) DeleteAd(ctx context.Context, adId int64, userId int64) error {
+	ctx, span := t.start(ctx, "DeleteAd", attribute.Int64("ad.id", adId), attribute.Int64("user.id", userId))
+	err := t.app.DeleteAd(ctx, adId, userId)
+	end(span, err)
+	return err
+}
+
+func (t *tracedApp) ListAds(ctx context.Context, pubFilter bool, userFilter int64, timeFilter time.Time) ([]ads.Ad, error) {
+	ctx, span := t.start(ctx, "ListAds")
+	list, err := t.app.ListAds(ctx, pubFilter, userFilter, timeFilter)
+	end(span, err, attribute.Int("result.count", len(list)))
+	return list, err
+}
+
+func (t *tracedApp) SearchAds(ctx context.Context, pattern string) ([]ads.Ad, error) {
+	ctx, span := t.start(ctx, "SearchAds")
+	list, err := t.app.SearchAds(ctx, pattern)
+	end(span, err, attribute.Int("result.count", len(list)))
+	return list, err
+}
+
+func (t *tracedApp) ListAdRevisions(ctx context.Context, adId int64) ([]ads.Revision, error) {
+	ctx, span := t.start(ctx, "ListAdRevisions", attribute.Int64("ad.id", adId))
+	revisions, err := t.app.ListAdRevisions(ctx, adId)
+	end(span, err, attribute.Int("result.count", len(revisions)))
+	return revisions, err
+}
+
+func (t *tracedApp) RollbackAd(ctx context.Context, adId int64, userId int64, revisionId int64) (ads.Ad, error) {
+	ctx, span := t.start(ctx, "RollbackAd", attribute.Int64("ad.id", adId), attribute.Int64("user.id", userId), attribute.Int64("revision.id", revisionId))
+	ad, err := t.app.RollbackAd(ctx, adId, userId, revisionId)
+	end(span, err, attribute.Int64("ad.id", ad.ID))
+	return ad, err
+}
+
+// failedItems counts the items of a batch call that returned an error.
+func failedItems(results []ads.Result) int {
+	failed := 0
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+		}
+	}
+	return failed
+}
+
+func (t *tracedApp) BatchCreateAds(ctx context.Context, drafts []ads.Ad) ([]ads.Result, error) {
+	ctx, span := t.start(ctx, "BatchCreateAds", attribute.Int("batch.size", len(drafts)))
+	results, err := t.app.BatchCreateAds(ctx, drafts)
+	end(span, err, attribute.Int("batch.failed", failedItems(results)))
+	return results, err
+}
+
+func (t *tracedApp) BatchGetAds(ctx context.Context, adIds []int64) ([]ads.Result, error) {
+	ctx, span := t.start(ctx, "BatchGetAds", attribute.Int("batch.size", len(adIds)))
+	results, err := t.app.BatchGetAds(ctx, adIds)
+	end(span, err, attribute.Int("batch.failed", failedItems(results)))
+	return results, err
+}
+
+func (t *tracedApp) BatchChangeAdStatus(ctx context.Context, changes []ads.StatusChange) ([]ads.Result, error) {
+	ctx, span := t.start(ctx, "BatchChangeAdStatus", attribute.Int("batch.size", len(changes)))
+	results, err := t.app.BatchChangeAdStatus(ctx, changes)
+	end(span, err, attribute.Int("batch.failed", failedItems(results)))
+	return results, err
+}
+
+func (t *tracedApp) CreateUser(ctx context.Context, name string, email string) (users.User, error) {
+	ctx, span := t.start(ctx, "CreateUser")
+	user, err := t.app.CreateUser(ctx, name, email)
+	end(span, err, attribute.Int64("user.id", user.ID))
+	return user, err
+}
+
+func (t *tracedApp) UpdateUser(ctx context.Context, userId int64, viewerId int64, name string, email string) (users.User, error) {
+	ctx, span := t.start(ctx, "UpdateUser", attribute.Int64("user.id", userId), attribute.Int64("viewer.id", viewerId))
+	user, err := t.app.UpdateUser(ctx, userId, viewerId, name, email)
+	end(span, err, attribute.Int64("user.id", user.ID))
+	return user, err
+}
+
+func (t *tracedApp) PatchUser(ctx context.Context, userId int64, viewerId int64, patch users.Patch) (users.User, error) {
+	ctx, span := t.start(ctx, "PatchUser", attribute.Int64("user.id", userId), attribute.Int64("viewer.id", viewerId))
+	user, err := t.app.PatchUser(ctx, userId, viewerId, patch)
+	end(span, err, attribute.Int64("user.id", user.ID))
+	return user, err
+}
+
+func (t *tracedApp) GetUser(ctx context.Context, userId int64) (users.User, error) {
+	ctx, span := t.start(ctx, "GetUser", attribute.Int64("user.id", userId))
+	user, err := t.app.GetUser(ctx, userId)
+	end(span, err, attribute.Int64("user.id", user.ID))
+	return user, err
+}
+
+func (t *tracedApp) ViewUser(ctx context.Context, userId int64, viewerId int64) (users.User, error) {
+	ctx, span := t.start(ctx, "ViewUser", attribute.Int64("user.id", userId), attribute.Int64("viewer.id", viewerId))
+	user, err := t.app.ViewUser(ctx, userId, viewerId)
+	end(span, err, attribute.Int64("user.id", user.ID))
+	return user, err
+}
+
+func (t *tracedApp) DeleteUser(ctx context.Context, userId int64, viewerId int64) error {
+	ctx, span := t.start(ctx, "DeleteUser", attribute.Int64("user.id", userId), attribute.Int64("viewer.id", viewerId))
+	err := t.app.DeleteUser(ctx, userId, viewerId)
+	end(span, err)
+	return err
+}
+
+func (t *tracedApp) AddFavorite(ctx context.Context, userId int64, adId int64, notify bool) (ads.Ad, error) {
+	ctx, span := t.start(ctx, "AddFavorite", attribute.Int64("user.id", userId), attribute.Int64("ad.id", adId))
+	ad, err := t.app.AddFavorite(ctx, userId, adId, notify)
+	end(span, err, attribute.Int64("ad.id", ad.ID))
+	return ad, err
+}
+
+func (t *tracedApp) RemoveFavorite(ctx context.Context, userId int64, adId int64) (ads.Ad, error) {
+	ctx, span := t.start(ctx, "RemoveFavorite", attribute.Int64("user.id", userId), attribute.Int64("ad.id", adId))
+	ad, err := t.app.RemoveFavorite(ctx, userId, adId)
+	end(span, err, attribute.Int64("ad.id", ad.ID))
+	return ad, err
+}
+
+func (t *tracedApp) ListFavorites(ctx context.Context, userId int64) ([]ads.Ad, error) {
+	ctx, span := t.start(ctx, "ListFavorites", attribute.Int64("user.id", userId))
+	list, err := t.app.ListFavorites(ctx, userId)
+	end(span, err, attribute.Int("result.count", len(list)))
+	return list, err
+}
+
+func (t *tracedApp) ListNotifications(ctx context.Context, userId int64) ([]users.Notification, error) {
+	ctx, span := t.start(ctx, "ListNotifications", attribute.Int64("user.id", userId))
+	notifications, err := t.app.ListNotifications(ctx, userId)
+	end(span, err, attribute.Int("result.count", len(notifications)))
+	return notifications, err
+}
+
+func (t *tracedApp) SaveSearch(ctx context.Context, userId int64, pattern string, authorFilter int64) (users.SavedSearch, error) {
+	ctx, span := t.start(ctx, "SaveSearch", attribute.Int64("user.id", userId), attribute.Int64("search.author_id", authorFilter))
+	search, err := t.app.SaveSearch(ctx, userId, pattern, authorFilter)
+	end(span, err, attribute.Int64("search.id", search.ID))
+	return search, err
+}
+
+func (t *tracedApp) ListSavedSearches(ctx context.Context, userId int64) ([]users.SavedSearch, error) {
+	ctx, span := t.start(ctx, "ListSavedSearches", attribute.Int64("user.id", userId))
+	searches, err := t.app.ListSavedSearches(ctx, userId)
+	end(span, err, attribute.Int("result.count", len(searches)))
+	return searches, err
+}
+
+func (t *tracedApp) DeleteSavedSearch(ctx context.Context, userId int64, searchId int64) error {
+	ctx, span := t.start(ctx, "DeleteSavedSearch", attribute.Int64("user.id", userId), attribute.Int64("search.id", searchId))
+	err := t.app.DeleteSavedSearch(ctx, userId, searchId)
+	end(span, err)
+	return err
+}
+
+func (t *tracedApp) OpenConversation(ctx context.Context, adId int64, userId int64) (conversations.Conversation, error) {
+	ctx, span := t.start(ctx, "OpenConversation", attribute.Int64("ad.id", adId), attribute.Int64("user.id", userId))
+	conversation, err := t.app.OpenConversation(ctx, adId, userId)
+	end(span, err, attribute.Int64("conversation.id", conversation.ID))
+	return conversation, err
+}
+
+func (t *tracedApp) ListConversations(ctx context.Context, userId int64) ([]conversations.Conversation, error) {
+	ctx, span := t.start(ctx, "ListConversations", attribute.Int64("user.id", userId))
+	list, err := t.app.ListConversations(ctx, userId)
+	end(span, err, attribute.Int("result.count", len(list)))
+	return list, err
+}
+
+func (t *tracedApp) SendMessage(ctx context.Context, conversationId int64, userId int64, text string) (conversations.Message, error) {
+	ctx, span := t.start(ctx, "SendMessage", attribute.Int64("conversation.id", conversationId), attribute.Int64("user.id", userId))
+	message, err := t.app.SendMessage(ctx, conversationId, userId, text)
+	end(span, err, attribute.Int64("message.id", message.ID))
+	return message, err
+}
+
+func (t *tracedApp) ListMessages(ctx context.Context, conversationId int64, userId int64) ([]conversations.Message, error) {
+	ctx, span := t.start(ctx, "ListMessages", attribute.Int64("conversation.id", conversationId), attribute.Int64("user.id", userId))
+	messages, err := t.app.ListMessages(ctx, conversationId, userId)
+	end(span, err, attribute.Int("result.count", len(messages)))
+	return messages, err
+}
+
+func (t *tracedApp) CloseConversation(ctx context.Context, conversationId int64, userId int64) (conversations.Conversation, error) {
+	ctx, span := t.start(ctx, "CloseConversation", attribute.Int64("conversation.id", conversationId), attribute.Int64("user.id", userId))
+	conversation, err := t.app.CloseConversation(ctx, conversationId, userId)
+	end(span, err, attribute.Int64("conversation.id", conversation.ID))
+	return conversation, err
+}
+
+func (t *tracedApp) ImportAd(ctx context.Context, ad ads.Ad, dryRun bool) (ads.Ad, error) {
+	ctx, span := t.start(ctx, "ImportAd", attribute.Int64("user.id", ad.AuthorID), attribute.Bool("dry_run", dryRun))
+	ad, err := t.app.ImportAd(ctx, ad, dryRun)
+	end(span, err, attribute.Int64("ad.id", ad.ID))
+	return ad, err
+}
+
+func (t *tracedApp) ImportUser(ctx context.Context, user users.User, dryRun bool) (users.User, error) {
+	ctx, span := t.start(ctx, "ImportUser", attribute.Bool("dry_run", dryRun))
+	user, err := t.app.ImportUser(ctx, user, dryRun)
+	end(span, err, attribute.Int64("user.id", user.ID))
+	return user, err
+}
+
+func (t *tracedApp) ExportAds(ctx context.Context, emit func(ads.Ad) error) error {
+	ctx, span := t.start(ctx, "ExportAds")
+	count := 0
+	err := t.app.ExportAds(ctx, func(ad ads.Ad) error {
+		count++
+		return emit(ad)
+	})
+	end(span, err, attribute.Int("result.count", count))
+	return err
+}
+
+func (t *tracedApp) ExportUsers(ctx context.Context, emit func(users.User) error) error {
+	ctx, span := t.start(ctx, "ExportUsers")
+	count := 0
+	err := t.app.ExportUsers(ctx, func(user users.User) error {
+		count++
+		return emit(user)
+	})
+	end(span, err, attribute.Int("result.count", count))
+	return err
+}
+
+func (t *tracedApp) Register(ctx context.Context, name string, email string, password string) (users.User, error) {
+	ctx, span := t.start(ctx, "Register")
+	user, err := t.app.Register(ctx, name, email, password)
+	end(span, err, attribute.Int64("user.id", user.ID))
+	return user, err
+}
+
+func (t *tracedApp) Login(ctx context.Context, email string, password string) (users.Session, error) {
+	ctx, span := t.start(ctx, "Login")
+	session, err := t.app.Login(ctx, email, password)
+	end(span, err, attribute.Int64("user.id", session.UserID))
+	return session, err
+}
+
+func (t *tracedApp) Authenticate(ctx context.Context, token string) (users.User, error) {
+	ctx, span := t.start(ctx, "Authenticate")
+	user, err := t.app.Authenticate(ctx, token)
+	end(span, err, attribute.Int64("user.id", user.ID))
+	return user, err
+}
+
+func (t *tracedApp) Logout(ctx context.Context, token string) error {
+	ctx, span := t.start(ctx, "Logout")
+	err := t.app.Logout(ctx, token)
+	end(span, err)
+	return err
+}
+
+func (t *tracedApp) ChangePassword(ctx context.Context, userId int64, oldPassword string, newPassword string) error {
+	ctx, span := t.start(ctx, "ChangePassword", attribute.Int64("user.id", userId))
+	err := t.app.ChangePassword(ctx, userId, oldPassword, newPassword)
+	end(span, err)
+	return err
+}
+
+func (t *tracedApp) RequestPasswordReset(ctx context.Context, email string) error {
+	ctx, span := t.start(ctx, "RequestPasswordReset")
+	err := t.app.RequestPasswordReset(ctx, email)
+	end(span, err)
+	return err
+}
+
+func (t *tracedApp) ResetPassword(ctx context.Context, token string, newPassword string) error {
+	ctx, span := t.start(ctx, "ResetPassword")
+	err := t.app.ResetPassword(ctx, token, newPassword)
+	end(span, err)
+	return err
+}
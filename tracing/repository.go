@@ -0,0 +1,118 @@
+package tracing
+
+import (
+	"context"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"homework10/internal/app"
+	"homework10/internal/query"
+)
+
+// Repository wraps r so that every operation gets a span named after the
+// repository, e.g. "repository.ads.Get".
+func Repository(name string, r app.Repository, tp trace.TracerProvider) app.Repository {
+	return &repository{name: name, repo: r, tracer: tp.Tracer(instrumentationName)}
+}
+
+type repository struct {
+	name   string
+	repo   app.Repository
+	tracer trace.Tracer
+}
+
+func (r *repository) start(ctx context.Context, operation string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append(attrs, attribute.String("repository.name", r.name))
+	return r.tracer.Start(ctx, "repository."+r.name+"."+operation,
+		trace.WithSpanKind(trace.SpanKindInternal), trace.WithAttributes(attrs...))
+}
+
+func (r *repository) Add(ctx context.Context, e interface{}) error {
+	ctx, span := r.start(ctx, "Add")
+	err := r.repo.Add(ctx, e)
+	end(span, err)
+	return err
+}
+
+func (r *repository) Insert(ctx context.Context, id int64, e interface{}) error {
+	ctx, span := r.start(ctx, "Insert", attribute.Int64("repository.id", id))
+	err := r.repo.Insert(ctx, id, e)
+	end(span, err)
+	return err
+}
+
+func (r *repository) Update(ctx context.Context, id int64, e interface{}) error {
+	ctx, span := r.start(ctx, "Update", attribute.Int64("repository.id", id))
+	err := r.repo.Update(ctx, id, e)
+	end(span, err)
+	return err
+}
+
+func (r *repository) Modify(ctx context.Context, id int64, change func(e interface{}) (interface{}, error)) (interface{}, error) {
+	ctx, span := r.start(ctx, "Modify", attribute.Int64("repository.id", id))
+	e, err := r.repo.Modify(ctx, id, change)
+	end(span, err)
+	return e, err
+}
+
+func (r *repository) Get(ctx context.Context, id int64) (interface{}, error) {
+	ctx, span := r.start(ctx, "Get", attribute.Int64("repository.id", id))
+	e, err := r.repo.Get(ctx, id)
+	end(span, err)
+	return e, err
+}
+
+func (r *repository) Delete(ctx context.Context, id int64) error {
+	ctx, span := r.start(ctx, "Delete", attribute.Int64("repository.id", id))
+	err := r.repo.Delete(ctx, id)
+	end(span, err)
+	return err
+}
+
+func (r *repository) CheckIdExist(ctx context.Context, id int64) bool {
+	ctx, span := r.start(ctx, "CheckIdExist", attribute.Int64("repository.id", id))
+	exists := r.repo.CheckIdExist(ctx, id)
+	end(span, nil, attribute.Bool("repository.exists", exists))
+	return exists
+}
+
+func (r *repository) GetNextId(ctx context.Context) int64 {
+	ctx, span := r.start(ctx, "GetNextId")
+	id := r.repo.GetNextId(ctx)
+	end(span, nil, attribute.Int64("repository.id", id))
+	return id
+}
+
+func (r *repository) GetArray(ctx context.Context) []interface{} {
+	ctx, span := r.start(ctx, "GetArray")
+	arr := r.repo.GetArray(ctx)
+	end(span, nil, attribute.Int("repository.count", len(arr)))
+	return arr
+}
+
+func (r *repository) AddBatch(ctx context.Context, es []interface{}, withId func(e interface{}, id int64) interface{}) ([]int64, error) {
+	ctx, span := r.start(ctx, "AddBatch", attribute.Int("repository.count", len(es)))
+	ids, err := r.repo.AddBatch(ctx, es, withId)
+	end(span, err)
+	return ids, err
+}
+
+func (r *repository) GetBatch(ctx context.Context, ids []int64) ([]interface{}, error) {
+	ctx, span := r.start(ctx, "GetBatch", attribute.Int("repository.count", len(ids)))
+	arr, err := r.repo.GetBatch(ctx, ids)
+	end(span, err)
+	return arr, err
+}
+
+func (r *repository) UpdateBatch(ctx context.Context, ids []int64, es []interface{}) error {
+	ctx, span := r.start(ctx, "UpdateBatch", attribute.Int("repository.count", len(ids)))
+	err := r.repo.UpdateBatch(ctx, ids, es)
+	end(span, err)
+	return err
+}
+
+func (r *repository) Find(ctx context.Context, index query.Index, from int64, to int64) ([]interface{}, error) {
+	ctx, span := r.start(ctx, "Find", attribute.String("repository.index", index.Name))
+	arr, err := r.repo.Find(ctx, index, from, to)
+	end(span, err, attribute.Int("repository.count", len(arr)))
+	return arr, err
+}
@@ -0,0 +1,33 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNewProvider(t *testing.T) {
+	_, err := NewProvider(context.Background(), "test", "jaeger", "", nil)
+	if !errors.Is(err, UnknownExporter) {
+		t.Fatalf("expect %v got %v", UnknownExporter, err)
+	}
+
+	var buf bytes.Buffer
+	tp, err := NewProvider(context.Background(), "test", ExporterStdout, "", &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, span := tp.Tracer("test").Start(context.Background(), "operation")
+	span.End()
+
+	if err := tp.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(buf.String(), `"Name":"operation"`) {
+		t.Fatalf("expect the span in the output got %q", buf.String())
+	}
+}
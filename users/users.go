@@ -1,7 +1,91 @@
 package users
 
+import "time"
+
 type User struct {
-	ID    int64
-	Name  string
-	Email string
+	ID            int64
+	Name          string
+	Email         string
+	DisplayName   string
+	AvatarURL     string
+	Phone         string
+	City          string
+	Bio           string
+	RegisteredAt  time.Time
+	Credentials   Credentials
+	Favorites     []Favorite
+	Notifications []Notification
+	SavedSearches []SavedSearch
+}
+
+// Credentials are the secrets of a user with an account, never shown to
+// anybody. Only the digests of the session and reset tokens are kept.
+type Credentials struct {
+	PasswordHash   string
+	FailedLogins   int
+	LockedUntil    time.Time
+	Sessions       []Session
+	ResetDigest    []byte
+	ResetExpiresAt time.Time
+}
+
+// HasPassword tells whether the user can log in.
+func (c Credentials) HasPassword() bool {
+	return c.PasswordHash != ""
+}
+
+// Session is a login of the user. Token is set only when it is created: it
+// is given to the client once and stored as Digest.
+type Session struct {
+	ID        int64
+	UserID    int64
+	Token     string
+	Digest    []byte
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+type Favorite struct {
+	AdID      int64
+	Notify    bool
+	CreatedAt time.Time
+}
+
+type Notification struct {
+	ID        int64
+	AdID      int64
+	Text      string
+	CreatedAt time.Time
+}
+
+type SavedSearch struct {
+	ID        int64
+	Pattern   string
+	AuthorID  int64
+	CreatedAt time.Time
+}
+
+// Patch holds the fields of a partial update, nil ones are left as they are.
+type Patch struct {
+	Name        *string
+	Email       *string
+	DisplayName *string
+	AvatarURL   *string
+	Phone       *string
+	City        *string
+	Bio         *string
+}
+
+// Public is the profile of the user as seen by the other users: without the
+// contact info and the lists only the user sees.
+func (u User) Public() User {
+	return User{
+		ID:           u.ID,
+		Name:         u.Name,
+		DisplayName:  u.DisplayName,
+		AvatarURL:    u.AvatarURL,
+		City:         u.City,
+		Bio:          u.Bio,
+		RegisteredAt: u.RegisteredAt,
+	}
 }